@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/htekdev/gh-hookflow/internal/trigger"
+	"github.com/spf13/cobra"
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark [workflow]",
+	Short: "Measure how long a workflow takes to run",
+	Long: `Runs a workflow repeatedly against a synthetic event and reports its
+min, max, p50 and p99 latency in milliseconds, so you can check that a hook
+does not slow the agent down. Each iteration runs the steps exactly as
+hookflow run does, including side effects of the steps.
+
+The synthetic event type defaults to the workflow's first trigger (file,
+commit, push, tool or schedule); use --event and --path to choose another.
+
+--output writes the results to a CSV file, and --compare reads one written
+earlier and shows the change from it.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.
+
+Examples:
+  hookflow benchmark --workflow lint --iterations 100
+  hookflow benchmark lint --output before.csv
+  hookflow benchmark lint --compare before.csv`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		name, _ := cmd.Flags().GetString("workflow")
+		iterations, _ := cmd.Flags().GetInt("iterations")
+		eventType, _ := cmd.Flags().GetString("event")
+		path, _ := cmd.Flags().GetString("path")
+		output, _ := cmd.Flags().GetString("output")
+		compare, _ := cmd.Flags().GetString("compare")
+
+		if len(args) == 1 {
+			if name != "" && name != args[0] {
+				return fmt.Errorf("workflow given both as argument %q and --workflow %q", args[0], name)
+			}
+			name = args[0]
+		}
+		if name == "" {
+			return fmt.Errorf("a workflow name is required")
+		}
+		if iterations < 1 {
+			return fmt.Errorf("invalid --iterations %d: must be at least 1", iterations)
+		}
+		if eventType != "" && !isBenchmarkEventType(eventType) {
+			return fmt.Errorf("invalid --event %q: must be one of file, commit, push, tool, schedule", eventType)
+		}
+
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+
+		wfPath, err := findWorkflowByName(dir, name)
+		if err != nil {
+			return err
+		}
+		wf, err := schema.LoadWorkflow(wfPath)
+		if err != nil {
+			return fmt.Errorf("failed to load workflow: %w", err)
+		}
+
+		// Read the baseline first, so --compare and --output can name the same file
+		var previous *benchmarkStats
+		if compare != "" {
+			results, err := readBenchmarkCSV(compare)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if previous, ok = results[wf.Name]; !ok {
+				return fmt.Errorf("no results for workflow '%s' in %s", wf.Name, compare)
+			}
+		}
+
+		if eventType == "" {
+			eventType = benchmarkEventType(wf)
+		}
+		evt := benchmarkEvent(wf, eventType, path, dir)
+		if !trigger.MatcherFor(wf).Match(evt) {
+			fmt.Fprintf(os.Stderr, "%s the synthetic %s event does not match the triggers of '%s'; running it anyway\n", symbol(symbolWarn), eventType, wf.Name)
+		}
+
+		stats := runBenchmark(wf, evt, dir, iterations)
+		printBenchmark(stats, previous)
+
+		if output != "" {
+			if err := writeBenchmarkCSV(output, stats); err != nil {
+				return err
+			}
+			fmt.Printf("\nResults written to %s\n", output)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+
+	benchmarkCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+	benchmarkCmd.Flags().StringP("workflow", "w", "", "Workflow to benchmark")
+	benchmarkCmd.Flags().IntP("iterations", "n", 100, "Number of times to run the workflow")
+	benchmarkCmd.Flags().StringP("event", "e", "", "Synthetic event type: file, commit, push, tool, schedule (default: from the workflow's triggers)")
+	benchmarkCmd.Flags().String("path", "", "File path for file, commit and tool events")
+	benchmarkCmd.Flags().StringP("output", "o", "", "Write the results to this CSV file")
+	benchmarkCmd.Flags().String("compare", "", "Show the change from results in this CSV file")
+	_ = benchmarkCmd.RegisterFlagCompletionFunc("workflow", completeWorkflowNames)
+}
+
+// benchmarkEventTypes are the synthetic events benchmark can send
+var benchmarkEventTypes = []string{"file", "commit", "push", "tool", "schedule"}
+
+func isBenchmarkEventType(t string) bool {
+	for _, known := range benchmarkEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// benchmarkEventType returns the event type of the workflow's first trigger
+func benchmarkEventType(wf *schema.Workflow) string {
+	on := wf.On
+	switch {
+	case on.File != nil:
+		return "file"
+	case on.Commit != nil:
+		return "commit"
+	case on.Push != nil:
+		return "push"
+	case on.Tool != nil || len(on.Tools) > 0 || on.Hooks != nil:
+		return "tool"
+	case on.Schedule != nil:
+		return "schedule"
+	}
+	return "file"
+}
+
+// benchmarkEvent builds the synthetic event of the given type. Tool events
+// call the workflow's tool when it names one, and schedule events fire its cron.
+func benchmarkEvent(wf *schema.Workflow, eventType, path, dir string) *schema.Event {
+	var evt *schema.Event
+	switch eventType {
+	case "schedule":
+		cron := ""
+		if wf.On.Schedule != nil {
+			cron = wf.On.Schedule.Cron
+		}
+		evt = scheduleEvent(dir, cron)
+	case "tool":
+		evt = buildMockEvent("tool", testEventOptions{Path: path})
+		if wf.On.Tool != nil && wf.On.Tool.Name != "" {
+			evt.Hook.Tool.Name = wf.On.Tool.Name
+		} else if len(wf.On.Tools) > 0 && wf.On.Tools[0].Name != "" {
+			evt.Hook.Tool.Name = wf.On.Tools[0].Name
+		}
+		evt.Tool = &schema.ToolEvent{Name: evt.Hook.Tool.Name, Args: evt.Hook.Tool.Args, HookType: evt.Hook.Type}
+	default:
+		evt = buildMockEvent(eventType, testEventOptions{Branch: "main", Path: path, Action: "edit", Message: "benchmark commit"})
+	}
+	evt.Cwd = dir
+	if evt.Timestamp == "" {
+		evt.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	return evt
+}
+
+// benchmarkStats summarizes the run times of one workflow
+type benchmarkStats struct {
+	Workflow   string
+	Iterations int
+	Denied     int // Runs the workflow denied
+	Min        time.Duration
+	Max        time.Duration
+	P50        time.Duration
+	P99        time.Duration
+}
+
+// runBenchmark runs wf n times through the same runner as hookflow run
+func runBenchmark(wf *schema.Workflow, evt *schema.Event, dir string, n int) *benchmarkStats {
+	durations := make([]time.Duration, n)
+	denied := 0
+	for i := range durations {
+		start := time.Now()
+		result := newRunner(wf, evt, dir).RunWithBlocking(context.Background())
+		durations[i] = time.Since(start)
+		if result.PermissionDecision == "deny" {
+			denied++
+		}
+	}
+	stats := summarizeDurations(durations)
+	stats.Workflow = wf.Name
+	stats.Denied = denied
+	return stats
+}
+
+// summarizeDurations returns the min, max and percentiles of durations,
+// which must not be empty
+func summarizeDurations(durations []time.Duration) *benchmarkStats {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &benchmarkStats{
+		Iterations: len(sorted),
+		Min:        sorted[0],
+		Max:        sorted[len(sorted)-1],
+		P50:        percentile(sorted, 50),
+		P99:        percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// metrics returns the reported latencies in table and CSV order
+func (s *benchmarkStats) metrics() []time.Duration {
+	return []time.Duration{s.Min, s.Max, s.P50, s.P99}
+}
+
+// benchmarkMetrics names the values returned by metrics
+var benchmarkMetrics = []string{"min", "max", "p50", "p99"}
+
+// milliseconds returns d in milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// printBenchmark prints stats as a table, with the change from previous
+// when it is set
+func printBenchmark(stats, previous *benchmarkStats) {
+	fmt.Printf("Benchmarked '%s': %d iteration(s)", stats.Workflow, stats.Iterations)
+	if stats.Denied > 0 {
+		fmt.Printf(", %d denied", stats.Denied)
+	}
+	fmt.Print("\n\n")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if previous == nil {
+		_, _ = fmt.Fprintln(w, "METRIC\tMS")
+	} else {
+		_, _ = fmt.Fprintln(w, "METRIC\tMS\tPREVIOUS MS\tDELTA")
+	}
+	current := stats.metrics()
+	for i, metric := range benchmarkMetrics {
+		ms := milliseconds(current[i])
+		if previous == nil {
+			_, _ = fmt.Fprintf(w, "%s\t%.3f\n", metric, ms)
+			continue
+		}
+		prev := milliseconds(previous.metrics()[i])
+		_, _ = fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%s\n", metric, ms, prev, formatDelta(ms, prev))
+	}
+	_ = w.Flush()
+}
+
+// formatDelta formats the change from prev to ms, as in "+1.250 (+12.5%)"
+func formatDelta(ms, prev float64) string {
+	delta := fmt.Sprintf("%+.3f", ms-prev)
+	if prev == 0 {
+		return delta
+	}
+	return fmt.Sprintf("%s (%+.1f%%)", delta, (ms-prev)/prev*100)
+}
+
+// benchmarkCSVHeader is the first row of a benchmark CSV file
+var benchmarkCSVHeader = []string{"workflow", "iterations", "min_ms", "max_ms", "p50_ms", "p99_ms"}
+
+// writeBenchmarkCSV writes stats to path as a header and one row
+func writeBenchmarkCSV(path string, stats *benchmarkStats) error {
+	row := []string{stats.Workflow, strconv.Itoa(stats.Iterations)}
+	for _, d := range stats.metrics() {
+		row = append(row, strconv.FormatFloat(milliseconds(d), 'f', 3, 64))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write(benchmarkCSVHeader)
+	_ = w.Write(row)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// readBenchmarkCSV reads results written by writeBenchmarkCSV, keyed by
+// workflow name
+func readBenchmarkCSV(path string) (map[string]*benchmarkStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(benchmarkCSVHeader)
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid benchmark results in %s: %w", path, err)
+	}
+	if len(rows) == 0 || rows[0][0] != benchmarkCSVHeader[0] {
+		return nil, fmt.Errorf("invalid benchmark results in %s: missing header", path)
+	}
+
+	results := make(map[string]*benchmarkStats)
+	for i, row := range rows[1:] {
+		stats := &benchmarkStats{Workflow: row[0]}
+		if stats.Iterations, err = strconv.Atoi(row[1]); err != nil {
+			return nil, fmt.Errorf("invalid benchmark results in %s, line %d: %w", path, i+2, err)
+		}
+		values := make([]time.Duration, len(benchmarkMetrics))
+		for j := range values {
+			ms, err := strconv.ParseFloat(row[2+j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark results in %s, line %d: %w", path, i+2, err)
+			}
+			values[j] = time.Duration(ms * float64(time.Millisecond))
+		}
+		stats.Min, stats.Max, stats.P50, stats.P99 = values[0], values[1], values[2], values[3]
+		results[stats.Workflow] = stats
+	}
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/htekdev/gh-hookflow/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the hookflow cache",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete cached workflow discovery results and remote actions",
+	Long: `Deletes the workflow discovery results and remote uses: actions stored by
+hookflow run. Other files in the cache directory are left alone.
+
+The cache directory is --cache-dir, then $HOOKFLOW_CACHE_DIR, then ~/.hookflow/cache.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("cache-dir")
+		c := cache.NewFileCache(dir)
+		if err := c.Clear(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		fmt.Printf("Cleared cache: %s\n", c.Dir())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheClearCmd.Flags().String("cache-dir", "", "Cache directory to clear (default $HOOKFLOW_CACHE_DIR or ~/.hookflow/cache)")
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,9 +12,14 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	eventpkg "github.com/htekdev/gh-hookflow/internal/event"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+	"github.com/htekdev/gh-hookflow/internal/runner"
 	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/htekdev/gh-hookflow/internal/trigger"
+	"github.com/spf13/cobra"
 )
 
 // TestVersionCommand tests the version command execution
@@ -421,7 +428,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runWorkflow(tmpDir, "test")
+	err = runWorkflow(newRunTarget(tmpDir), "test")
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -472,7 +479,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON, "pre")
+	err = runMatchingWorkflows(newRunTarget(tmpDir), eventJSON, "pre")
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -523,7 +530,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON, "pre")
+	err = runMatchingWorkflows(newRunTarget(tmpDir), eventJSON, "pre")
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -561,7 +568,7 @@ func TestRunMatchingWorkflowsEmptyDir(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON, "pre")
+	err = runMatchingWorkflows(newRunTarget(tmpDir), eventJSON, "pre")
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -601,6 +608,56 @@ func TestParseEventDataPartialHookData(t *testing.T) {
 	}
 }
 
+func TestParseEventDataHookSessionID(t *testing.T) {
+	data := map[string]interface{}{
+		"hook": map[string]interface{}{
+			"type":       "preToolUse",
+			"session_id": "session-42",
+		},
+	}
+
+	event := parseEventData(data)
+	if event.Hook == nil || event.Hook.SessionID != "session-42" {
+		t.Errorf("Expected session ID session-42, got: %+v", event.Hook)
+	}
+}
+
+func TestParseEventDataCreateFile(t *testing.T) {
+	// A create tool call without a file event gets one from its args
+	event := parseEventData(map[string]interface{}{
+		"tool": map[string]interface{}{
+			"name": "create",
+			"args": map[string]interface{}{"path": "config.env", "file_text": "API_KEY=secret"},
+		},
+	})
+	if event.File == nil || event.File.Path != "config.env" || event.File.Action != "create" || event.File.Content != "API_KEY=secret" {
+		t.Errorf("Expected a create file event from the tool args, got: %+v", event.File)
+	}
+
+	// An explicit file event wins, but missing content is filled in
+	event = parseEventData(map[string]interface{}{
+		"tool": map[string]interface{}{
+			"name": "create",
+			"args": map[string]interface{}{"path": "a.txt", "file_text": "from args"},
+		},
+		"file": map[string]interface{}{"path": "b.txt", "action": "create"},
+	})
+	if event.File.Path != "b.txt" || event.File.Content != "from args" {
+		t.Errorf("Expected path b.txt with content from args, got: %+v", event.File)
+	}
+
+	// Other tools are left alone
+	event = parseEventData(map[string]interface{}{
+		"tool": map[string]interface{}{
+			"name": "edit",
+			"args": map[string]interface{}{"path": "a.txt", "file_text": "x"},
+		},
+	})
+	if event.File != nil {
+		t.Errorf("Expected no file event for edit, got: %+v", event.File)
+	}
+}
+
 // TestParseEventDataPartialCommit tests parsing commit with partial data
 func TestParseEventDataPartialCommit(t *testing.T) {
 	// Commit with only sha
@@ -652,6 +709,30 @@ func TestParseEventDataInvalidFileInCommit(t *testing.T) {
 	}
 }
 
+// TestParseEventDataPushFiles tests that push events keep their changed files
+func TestParseEventDataPushFiles(t *testing.T) {
+	data := map[string]interface{}{
+		"push": map[string]interface{}{
+			"ref":    "refs/heads/main",
+			"before": "aaa",
+			"after":  "bbb",
+			"files": []interface{}{
+				map[string]interface{}{"path": "src/app.go", "status": "modified"},
+				42, // Invalid - should be skipped
+			},
+		},
+	}
+
+	event := parseEventData(data)
+
+	if event.Push == nil {
+		t.Fatal("Expected Push to be set")
+	}
+	if len(event.Push.Files) != 1 || event.Push.Files[0].Path != "src/app.go" || event.Push.Files[0].Status != "modified" {
+		t.Errorf("Expected src/app.go modified, got: %v", event.Push.Files)
+	}
+}
+
 // TestRootCmdInit tests that root command is initialized properly
 func TestRootCmdInit(t *testing.T) {
 	// Verify commands are registered
@@ -845,7 +926,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -901,7 +982,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -964,7 +1045,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1026,7 +1107,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1084,7 +1165,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1153,7 +1234,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1188,7 +1269,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1259,7 +1340,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1295,7 +1376,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1359,7 +1440,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1431,7 +1512,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1490,7 +1571,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1549,7 +1630,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1608,7 +1689,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -1666,7 +1747,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1694,7 +1775,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1722,7 +1803,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1797,7 +1878,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1826,7 +1907,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1884,7 +1965,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1911,7 +1992,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -1989,7 +2070,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -2022,7 +2103,7 @@ steps:
 		stdoutR, stdoutW, _ := os.Pipe()
 		os.Stdout = stdoutW
 
-		_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 		_ = stdoutW.Close()
 		os.Stdout = oldStdout
@@ -2054,13 +2135,17 @@ func TestEventTypeToLifecycle(t *testing.T) {
 		// Short forms
 		{"pre", "pre"},
 		{"post", "post"},
-		// Unknown defaults to pre
+		// Empty defaults to pre
 		{"", "pre"},
-		{"unknown", "pre"},
-		{"something", "pre"},
-		// Future hook types can be added here
+		// Mapped through HOOKFLOW_LIFECYCLE_MAP
+		{"notification", "post"},
+		{"background", "pre"},
+		// Unmapped types are used as-is
+		{"unknown", "unknown"},
+		{"something", "something"},
 	}
 
+	t.Setenv(lifecycleMapEnv, "notification=post, background=pre,bogus,=post")
 	for _, tt := range tests {
 		t.Run(tt.eventType, func(t *testing.T) {
 			result := eventTypeToLifecycle(tt.eventType)
@@ -2071,6 +2156,51 @@ func TestEventTypeToLifecycle(t *testing.T) {
 	}
 }
 
+func TestCustomEventTypeLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "any.yml", `name: Any Lifecycle
+on:
+  file:
+    lifecycle: "*"
+    paths: ['**/*.md']
+blocking: true
+steps:
+  - name: Report
+    shell: bash
+    run: echo "lifecycle=${{ event.lifecycle }}" && exit 1
+`)
+	writeTestWorkflow(t, tmpDir, "background.yml", `name: Background Only
+on:
+  file:
+    lifecycle: background
+    paths: ['**/*.md']
+steps:
+  - name: Note
+    shell: bash
+    run: echo background
+`)
+
+	input := `{"toolName":"create","toolArgs":{"path":"notes.md","file_text":"hi"},"cwd":"` + filepath.ToSlash(tmpDir) + `"}`
+	for _, lifecycle := range []string{"pre", "post", "background"} {
+		t.Run(lifecycle, func(t *testing.T) {
+			var err error
+			output := captureStdout(t, func() {
+				err = runWithRawInput(newRunTarget(tmpDir), input, lifecycle, lifecycle)
+			})
+			if err != nil {
+				t.Fatalf("runWithRawInput() error: %v", err)
+			}
+			if !strings.Contains(output, "deny") {
+				t.Errorf("Expected '*' lifecycle workflow to run for %s, got: %s", lifecycle, output)
+			}
+		})
+	}
+}
+
 // TestNormalizeFilePath tests file path normalization for workflow matching
 func TestNormalizeFilePath(t *testing.T) {
 	tests := []struct {
@@ -2146,6 +2276,75 @@ func TestNormalizeFilePath(t *testing.T) {
 	}
 }
 
+func TestNormalizeEventPaths(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "repo")
+	abs := filepath.Join(dir, "src", "app.go")
+
+	evt := &schema.Event{
+		Tool: &schema.ToolEvent{
+			Name: "edit",
+			Args: map[string]interface{}{"path": abs, "old_str": "a"},
+		},
+		File: &schema.FileEvent{Path: abs, Action: "edit"},
+	}
+	normalizeEventPaths(evt, dir)
+
+	if evt.File.Path != "src/app.go" {
+		t.Errorf("file path = %q, want src/app.go", evt.File.Path)
+	}
+	if evt.Tool.Args["path"] != "src/app.go" {
+		t.Errorf("tool args path = %v, want src/app.go", evt.Tool.Args["path"])
+	}
+	if evt.Tool.Args["old_str"] != "a" {
+		t.Errorf("other args changed: %v", evt.Tool.Args)
+	}
+
+	// Non-string and missing paths are left alone
+	evt = &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": 42}}}
+	normalizeEventPaths(evt, dir)
+	if evt.Tool.Args["path"] != 42 {
+		t.Errorf("non-string path changed: %v", evt.Tool.Args["path"])
+	}
+	normalizeEventPaths(&schema.Event{Tool: &schema.ToolEvent{Name: "bash"}}, dir)
+}
+
+func TestToolArgsPathMatchesFilePath(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "args-path.yml", `name: Args Path
+on:
+  file:
+    paths: ['src/**']
+blocking: true
+steps:
+  - name: Same path
+    shell: bash
+    if: ${{ event.tool.args.path == event.file.path && event.tool.args.path == 'src/app.go' }}
+    run: exit 1
+`)
+
+	abs := filepath.Join(tmpDir, "src", "app.go")
+	evt := &schema.Event{
+		Tool: &schema.ToolEvent{
+			Name: "edit",
+			Args: map[string]interface{}{"path": abs},
+		},
+		File:      &schema.FileEvent{Path: abs, Action: "edit"},
+		Cwd:       tmpDir,
+		Lifecycle: "pre",
+	}
+
+	output := captureStdout(t, func() {
+		_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
+	})
+	if !strings.Contains(output, "deny") {
+		t.Errorf("expected event.tool.args.path to equal normalized event.file.path, got: %s", output)
+	}
+}
+
 // TestWorkflowMatchesAbsolutePath tests that workflow path patterns match even when event has absolute path
 func TestWorkflowMatchesAbsolutePath(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "hookflow-abspath-*")
@@ -2193,7 +2392,7 @@ steps:
 	stdoutR, stdoutW, _ := os.Pipe()
 	os.Stdout = stdoutW
 
-	_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+	_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 	_ = stdoutW.Close()
 	os.Stdout = oldStdout
@@ -2386,7 +2585,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -2511,7 +2710,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -2616,7 +2815,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -2727,7 +2926,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3062,7 +3261,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3211,7 +3410,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3360,7 +3559,7 @@ steps:
 					stdoutR, stdoutW, _ := os.Pipe()
 					os.Stdout = stdoutW
 
-					_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+					_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 					_ = stdoutW.Close()
 					os.Stdout = oldStdout
@@ -3519,7 +3718,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3649,7 +3848,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3772,7 +3971,7 @@ steps:
 			stdoutR, stdoutW, _ := os.Pipe()
 			os.Stdout = stdoutW
 
-			_ = runMatchingWorkflowsWithEvent(tmpDir, evt)
+			_ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt)
 
 			_ = stdoutW.Close()
 			os.Stdout = oldStdout
@@ -3793,3 +3992,2849 @@ steps:
 		})
 	}
 }
+
+// captureStdout runs fn and returns everything it wrote to stdout
+func TestTailLogWorkflowFilter(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "hookflow.log")
+	content := strings.Join([]string{
+		`[2024-01-01 00:00:00.000] [INFO] [1-2] [exec-id:a] [runner] {workflow_name="lint"} running workflow lint`,
+		`[2024-01-01 00:00:00.001] [INFO] [1-2] [exec-id:b] [runner] {workflow_name="test"} running workflow test`,
+		`[2024-01-01 00:00:00.002] [INFO] [1-2] [matcher] found 2 workflows`,
+		`[2024-01-01 00:00:00.003] [INFO] [1-2] [exec-id:a] [runner] {step_name="eslint" workflow_name="lint"} step eslint finished`,
+		`[2024-01-01 00:00:00.004] [INFO] [1-2] [exec-id:c] [runner] {workflow_name="lint-extra"} running workflow lint-extra`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureStdout(t, func() {
+		if err := tailLog(logPath, 50, workflowLineFilter("lint")); err != nil {
+			t.Errorf("tailLog() error: %v", err)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "running workflow lint") || !strings.Contains(lines[1], "step eslint finished") {
+		t.Errorf("Expected only the two lint lines, got:\n%s", output)
+	}
+
+	// n counts filtered lines, not raw lines
+	output = captureStdout(t, func() {
+		_ = tailLog(logPath, 1, workflowLineFilter("lint"))
+	})
+	if strings.TrimSpace(output) != strings.Split(content, "\n")[3] {
+		t.Errorf("Expected last lint line only, got:\n%s", output)
+	}
+
+	// Runs are logged at info level, so an empty result names the setting
+	output = captureStdout(t, func() {
+		_ = tailLog(logPath, 50, workflowLineFilter("deploy"))
+	})
+	if !strings.Contains(output, "HOOKFLOW_LOG_LEVEL=info") {
+		t.Errorf("Expected a hint about the log level, got:\n%s", output)
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := "on:\n  file:\n    paths: ['**/*.md']\nsteps:\n  - run: echo hi\n    colour: red\n"
+	writeTestWorkflow(t, tmpDir, "docs.yml", content)
+	path := filepath.Join(tmpDir, ".github", "hookflows", "docs.yml")
+
+	output := captureStdout(t, func() {
+		if n := fixValidation(tmpDir, "", true); n != 1 {
+			t.Errorf("fixValidation(dry run) = %d, want 1", n)
+		}
+	})
+	if !strings.Contains(output, "+ name: docs") || !strings.Contains(output, "-     colour: red") {
+		t.Errorf("Expected diff in dry run output, got:\n%s", output)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Error("Dry run modified the file")
+	}
+
+	output = captureStdout(t, func() {
+		fixValidation(tmpDir, "", false)
+	})
+	if !strings.Contains(output, "applied 2 fix(es)") {
+		t.Errorf("Expected fix summary, got:\n%s", output)
+	}
+	if !schema.ValidateWorkflow(path).Valid {
+		data, _ := os.ReadFile(path)
+		t.Errorf("Expected fixed workflow to be valid, got:\n%s", data)
+	}
+}
+
+func TestPrintValidationLintStrict(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "style.yml", "name: Style\non:\n  file:\n    paths: ['**/*.md']\nsteps:\n  - run: exit 1\n")
+
+	var valid bool
+	output := captureStdout(t, func() { valid = printValidation(tmpDir, "", false, true) })
+	if !valid {
+		t.Errorf("Expected validate --strict to pass without warnings, got:\n%s", output)
+	}
+
+	output = captureStdout(t, func() { valid = printValidation(tmpDir, "", true, false) })
+	if !valid {
+		t.Errorf("Expected lint warnings not to fail without --strict, got:\n%s", output)
+	}
+	for _, code := range []string{schema.LintUnnamedStep, schema.LintImplicitLifecycle} {
+		if !strings.Contains(output, "Warning ["+code+"]") {
+			t.Errorf("Expected %s warning, got:\n%s", code, output)
+		}
+	}
+
+	output = captureStdout(t, func() { valid = printValidation(tmpDir, "", true, true) })
+	if valid {
+		t.Errorf("Expected lint --strict to fail on warnings, got:\n%s", output)
+	}
+	if !strings.Contains(output, "2 warning(s) found in strict mode") {
+		t.Errorf("Expected strict mode summary, got:\n%s", output)
+	}
+}
+
+func TestPrintValidationJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "glob.yml", "name: Glob\non:\n  file:\n    paths: ['src/[abc']\nsteps:\n  - run: echo hi\n")
+
+	var valid bool
+	output := captureStdout(t, func() { valid = printValidationJSON(tmpDir, "", false, false) })
+	if valid {
+		t.Errorf("Expected an invalid result, got:\n%s", output)
+	}
+	var report struct {
+		Valid  bool `json:"valid"`
+		Errors []struct {
+			File    string `json:"file"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+		Warnings []json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected JSON output, got %v:\n%s", err, output)
+	}
+	if report.Valid || len(report.Errors) != 1 || report.Errors[0].Code != schema.ErrInvalidGlob || !strings.HasSuffix(report.Errors[0].File, "glob.yml") {
+		t.Errorf("Expected one %s error for glob.yml, got:\n%s", schema.ErrInvalidGlob, output)
+	}
+	if report.Warnings == nil {
+		t.Errorf("Expected an empty warnings list rather than null, got:\n%s", output)
+	}
+
+	// Lint warnings carry their codes too, and fail only with strict
+	lintDir := t.TempDir()
+	writeTestWorkflow(t, lintDir, "style.yml", "name: Style\non:\n  file:\n    paths: ['**/*.md']\nsteps:\n  - run: exit 1\n")
+	output = captureStdout(t, func() { valid = printValidationJSON(lintDir, "", true, false) })
+	if !valid || !strings.Contains(output, `"code": "`+schema.LintUnnamedStep+`"`) || !strings.Contains(output, `"errors": []`) {
+		t.Errorf("Expected a valid result with lint warnings, got:\n%s", output)
+	}
+	output = captureStdout(t, func() { valid = printValidationJSON(lintDir, "", true, true) })
+	if valid || !strings.Contains(output, `"valid": false`) {
+		t.Errorf("Expected strict mode to fail on warnings, got:\n%s", output)
+	}
+}
+
+// TestValidateWorkflowsAllowUnknownShells tests that --allow-unknown-shells reaches validate and lint
+func TestValidateWorkflowsAllowUnknownShells(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "fish.yml", `name: fish
+on:
+  tool:
+    name: edit
+steps:
+  - name: Run
+    shell: fish
+    run: echo hi
+`)
+	file := filepath.Join(tmpDir, ".github", "hookflows", "fish.yml")
+	defer func() { allowUnknownShells = false }()
+
+	for _, allow := range []bool{false, true} {
+		allowUnknownShells = allow
+		for _, lint := range []bool{false, true} {
+			if result := validateWorkflows(tmpDir, "", lint); result.Valid != allow {
+				t.Errorf("allow=%v lint=%v: dir Valid = %v, errors %v", allow, lint, result.Valid, result.Errors)
+			}
+			if result := validateWorkflows("", file, lint); result.Valid != allow {
+				t.Errorf("allow=%v lint=%v: file Valid = %v, errors %v", allow, lint, result.Valid, result.Errors)
+			}
+		}
+	}
+}
+
+func TestValidateFormatFlag(t *testing.T) {
+	defer func() {
+		_ = validateCmd.Flags().Set("format", validateFormatText)
+		_ = validateCmd.Flags().Set("watch", "false")
+	}()
+
+	_ = validateCmd.Flags().Set("format", "yaml")
+	if err := validateCmd.RunE(validateCmd, nil); err == nil || !strings.Contains(err.Error(), `invalid --format "yaml"`) {
+		t.Errorf("Expected an invalid --format error, got %v", err)
+	}
+
+	_ = validateCmd.Flags().Set("format", validateFormatJSON)
+	_ = validateCmd.Flags().Set("watch", "true")
+	if err := validateCmd.RunE(validateCmd, nil); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("Expected --format json with --watch to be rejected, got %v", err)
+	}
+}
+
+func TestDoctorChecks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("Skipping - sh not available")
+	}
+	t.Setenv(runner.ShellEnv, "sh")
+	t.Setenv("HOME", t.TempDir())
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "check.yml", "name: check\non:\n  file:\n    paths: ['**/*.go']\nsteps:\n  - name: Check\n    shell: sh\n    run: exit 0\n")
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".github", "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".github", "hooks", "hooks.json"), []byte(generateHooksJSON()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var passed bool
+	output := captureStdout(t, func() { passed = printDoctorChecks(runDoctorChecks(tmpDir)) })
+	if !passed {
+		t.Errorf("Expected all checks to pass, got:\n%s", output)
+	}
+	for _, want := range []string{"shell sh is available", "1 valid workflow(s) found", "event detector parses a sample event", "All 7 checks passed"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+
+	// An uninitialized repository that needs a missing shell fails with fixes
+	t.Setenv(runner.ShellEnv, "hookflow-missing-shell")
+	output = captureStdout(t, func() { passed = printDoctorChecks(runDoctorChecks(t.TempDir())) })
+	if passed {
+		t.Errorf("Expected checks to fail, got:\n%s", output)
+	}
+	for _, want := range []string{
+		"hookflow-missing-shell not found in PATH",
+		"no workflows found",
+		"Fix: run 'hookflow init' to create it",
+		"4 of 7 checks failed",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestSimulateToolEvent(t *testing.T) {
+	evt, err := simulateToolEvent("/repo", "edit", `{"path": "src/app.ts"}`, "post")
+	if err != nil {
+		t.Fatalf("simulateToolEvent: %v", err)
+	}
+	if evt.Tool == nil || evt.Tool.Name != "edit" || evt.Tool.Args["path"] != "src/app.ts" || evt.Tool.HookType != "postToolUse" {
+		t.Errorf("Unexpected tool event: %+v", evt.Tool)
+	}
+	if evt.Hook == nil || evt.Hook.Type != "postToolUse" || evt.Hook.Tool != evt.Tool {
+		t.Errorf("Unexpected hook event: %+v", evt.Hook)
+	}
+	if evt.Lifecycle != "post" || evt.Cwd != "/repo" {
+		t.Errorf("Expected post lifecycle in /repo, got %s in %s", evt.Lifecycle, evt.Cwd)
+	}
+	// Detection is bypassed: an edit does not become a file event
+	if evt.File != nil {
+		t.Errorf("Expected no file event, got %+v", evt.File)
+	}
+
+	for _, bad := range []string{"", "[]", "null", "{"} {
+		if _, err := simulateToolEvent("/repo", "edit", bad, "pre"); err == nil {
+			t.Errorf("Expected error for --simulate-args %q", bad)
+		}
+	}
+}
+
+func TestRunSimulateTool(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "no-env.yml", `name: no-env
+on:
+  tool:
+    name: edit
+    args:
+      path: '**/*.env'
+steps:
+  - name: Block
+    shell: bash
+    run: echo "editing ${{ event.tool.args.path }}" && exit 1
+`)
+
+	defer func() {
+		for name, value := range map[string]string{"dir": "", "simulate-tool": "", "simulate-args": "{}", "simulate-lifecycle": "pre"} {
+			_ = runCmd.Flags().Set(name, value)
+		}
+		runOpts = runOptions{}
+	}()
+	_ = runCmd.Flags().Set("dir", tmpDir)
+	_ = runCmd.Flags().Set("simulate-tool", "edit")
+	_ = runCmd.Flags().Set("simulate-args", `{"path": "config/.env"}`)
+
+	var err error
+	output := captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	if err != nil {
+		t.Fatalf("run --simulate-tool failed: %v", err)
+	}
+	if !strings.Contains(output, `"deny"`) || !strings.Contains(output, "editing config/.env") {
+		t.Errorf("Expected simulated edit to be denied, got: %s", output)
+	}
+
+	_ = runCmd.Flags().Set("simulate-args", `{"path": "README.md"}`)
+	output = captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	if err != nil || !strings.Contains(output, `"allow"`) {
+		t.Errorf("Expected non-matching simulated edit to be allowed, got: %s (err %v)", output, err)
+	}
+
+	_ = runCmd.Flags().Set("simulate-lifecycle", "during")
+	if err := runCmd.RunE(runCmd, []string{}); err == nil || !strings.Contains(err.Error(), "invalid --simulate-lifecycle") {
+		t.Errorf("Expected invalid --simulate-lifecycle error, got %v", err)
+	}
+
+	_ = runCmd.Flags().Set("simulate-lifecycle", "pre")
+	_ = runCmd.Flags().Set("simulate-tool", "")
+	if err := runCmd.RunE(runCmd, []string{}); err == nil || !strings.Contains(err.Error(), "require --simulate-tool") {
+		t.Errorf("Expected --simulate-args without --simulate-tool to fail, got %v", err)
+	}
+}
+
+func TestRunMatchAll(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "commits.yml", `name: commits
+on:
+  commit:
+    paths: ['**']
+steps:
+  - name: Commit check
+    shell: bash
+    run: touch commit-check-ran
+`)
+	writeTestWorkflow(t, tmpDir, "env-files.yml", `name: env-files
+on:
+  file:
+    paths: ['**/*.env']
+steps:
+  - name: Env only
+    shell: bash
+    if: ${{ event.tool.name == 'edit' }}
+    run: echo "env check ran"
+  - name: Always
+    shell: bash
+    run: echo "env workflow ran"
+`)
+
+	defer func() {
+		for name, value := range map[string]string{"dir": "", "simulate-tool": "", "match-all": "false", "dry-run": "false", "verbose": "false", "workflow": ""} {
+			_ = runCmd.Flags().Set(name, value)
+		}
+		runOpts = runOptions{}
+	}()
+	_ = runCmd.Flags().Set("dir", tmpDir)
+	_ = runCmd.Flags().Set("simulate-tool", "view")
+	_ = runCmd.Flags().Set("verbose", "true")
+
+	// Without --match-all a view call triggers neither workflow
+	var err error
+	output := captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.Contains(output, `"allow"`) || strings.Contains(output, "workflow ran") {
+		t.Errorf("Expected no workflow to run, got: %s", output)
+	}
+
+	_ = runCmd.Flags().Set("match-all", "true")
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	})
+	if err != nil {
+		t.Fatalf("run --match-all failed: %v", err)
+	}
+	if !strings.Contains(stderr, "--match-all ignores workflow triggers") {
+		t.Errorf("Expected the debugging warning on stderr, got: %q", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "commit-check-ran")); err != nil {
+		t.Errorf("Expected the commit workflow to run: %v", err)
+	}
+	if !strings.Contains(output, "env workflow ran") {
+		t.Errorf("Expected the file workflow to run, got: %s", output)
+	}
+	// Step conditions still apply
+	if strings.Contains(output, "env check ran") {
+		t.Errorf("Expected the if: condition to skip the env-only step, got: %s", output)
+	}
+
+	// With --dry-run every workflow is evaluated but no step runs
+	if err := os.Remove(filepath.Join(tmpDir, "commit-check-ran")); err != nil {
+		t.Fatal(err)
+	}
+	_ = runCmd.Flags().Set("dry-run", "true")
+	_ = captureStderr(t, func() {
+		output = captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	})
+	if err != nil {
+		t.Fatalf("run --match-all --dry-run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "commit-check-ran")); !os.IsNotExist(err) {
+		t.Errorf("Expected --dry-run not to run the commit workflow's step, got %v", err)
+	}
+	if strings.Contains(output, "workflow ran") || !strings.Contains(output, "Skipped (dry run)") {
+		t.Errorf("Expected every step to be skipped as a dry run, got: %s", output)
+	}
+	_ = runCmd.Flags().Set("dry-run", "false")
+
+	_ = runCmd.Flags().Set("simulate-tool", "")
+	_ = runCmd.Flags().Set("workflow", "commits")
+	if err := runCmd.RunE(runCmd, []string{}); err == nil || !strings.Contains(err.Error(), "--match-all") {
+		t.Errorf("Expected --match-all with --workflow to fail, got %v", err)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\n"
+	after := "a\nb\nC\nd\ne\nf\ng\n"
+	got := strings.Join(lineDiff(before, after), "|")
+	want := "  b|- c|+ C|  d|  f|+ g"
+	if got != want {
+		t.Errorf("lineDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	t.Setenv(extensionsEnv, "")
+	if err := rootCmd.PersistentPreRunE(rootCmd, nil); err != nil {
+		t.Fatalf("Expected no extensions to load, got %v", err)
+	}
+
+	notPlugin := filepath.Join(t.TempDir(), "functions.so")
+	if err := os.WriteFile(notPlugin, []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(extensionsEnv, string(filepath.ListSeparator)+notPlugin)
+	// Other commands warn and skip a broken plugin
+	var err error
+	stderr := captureStderr(t, func() { err = rootCmd.PersistentPreRunE(rootCmd, nil) })
+	if err != nil {
+		t.Errorf("Expected the broken extension to be skipped, got %v", err)
+	}
+	if !strings.Contains(stderr, extensionsEnv+": skipping: failed to load extension "+notPlugin) {
+		t.Errorf("Expected a warning for the broken extension, got %q", stderr)
+	}
+	// hookflow extension reports it as an error
+	err = rootCmd.PersistentPreRunE(extensionCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), extensionsEnv+": failed to load extension "+notPlugin) {
+		t.Errorf("Expected the broken extension to fail hookflow extension, got %v", err)
+	}
+
+	err = extensionCmd.RunE(extensionCmd, []string{filepath.Join(t.TempDir(), "missing.so")})
+	if err == nil || !strings.Contains(err.Error(), "failed to load extension") {
+		t.Errorf("Expected an error for a missing plugin, got %v", err)
+	}
+}
+
+func TestShellFlag(t *testing.T) {
+	defer func() {
+		shellFlag = ""
+		runner.SetDefaultShell("")
+	}()
+
+	shellFlag = "fish"
+	if err := rootCmd.PersistentPreRunE(rootCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid --shell") {
+		t.Errorf("Expected invalid --shell error, got %v", err)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("Skipping - sh not available")
+	}
+	shellFlag = "sh"
+	t.Setenv(runner.ShellEnv, "pwsh")
+	if err := rootCmd.PersistentPreRunE(rootCmd, nil); err != nil {
+		t.Fatalf("PersistentPreRunE() error: %v", err)
+	}
+
+	wf := &schema.Workflow{Name: "shell", Steps: []schema.Step{{Name: "Which", Run: "echo $0"}}}
+	result := newRunner(wf, nil, t.TempDir()).RunWithBlocking(context.Background())
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected --shell sh to win over HOOKFLOW_SHELL=pwsh, got: %s", result.PermissionDecisionReason)
+	}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+
+	fn()
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
+// captureStderr returns what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = w
+
+	fn()
+
+	_ = w.Close()
+	os.Stderr = oldStderr
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
+// TestOutputWorkflowResultVerbose tests that step results are only emitted with --verbose
+func TestOutputWorkflowResultVerbose(t *testing.T) {
+	result := &schema.WorkflowResult{
+		PermissionDecision: "allow",
+		StepResults: []schema.StepResult{
+			{Name: "lint", Success: true, DurationMs: 12, ExitCode: 0, OutputPreview: "ok"},
+		},
+	}
+
+	defer func() { runOpts = runOptions{} }()
+
+	runOpts = runOptions{}
+	output := captureStdout(t, func() { _ = outputWorkflowResult(result) })
+	if strings.Contains(output, "stepResults") {
+		t.Errorf("Expected step results to be omitted without --verbose, got: %s", output)
+	}
+	if len(result.StepResults) != 1 {
+		t.Error("outputWorkflowResult should not modify the caller's result")
+	}
+
+	runOpts = runOptions{Verbose: true}
+	output = captureStdout(t, func() { _ = outputWorkflowResult(result) })
+
+	var parsed schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if len(parsed.StepResults) != 1 || parsed.StepResults[0].Name != "lint" {
+		t.Errorf("Expected step results in verbose output, got: %s", output)
+	}
+}
+
+// writeTestWorkflow writes a workflow file into root/.github/hookflows
+func writeTestWorkflow(t *testing.T, root, fileName, content string) {
+	t.Helper()
+	workflowDir := filepath.Join(root, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workflowDir, fileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunWithSeparateWorkflowDir tests that --workflow-dir locates workflows while --dir stays the step cwd
+func TestRunWithSeparateWorkflowDir(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	projectDir := t.TempDir()
+	toolsDir := t.TempDir()
+
+	writeTestWorkflow(t, toolsDir, "marker.yml", `name: marker
+on:
+  tool:
+    name: edit
+steps:
+  - name: Write marker
+    shell: bash
+    run: touch marker.txt
+`)
+
+	defer func() { runOpts = runOptions{} }()
+
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	// Without --workflow-dir, the project directory has no workflows
+	runOpts = runOptions{}
+	output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(projectDir), evt) })
+	if !strings.Contains(output, `"allow"`) {
+		t.Errorf("Expected allow, got: %s", output)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "marker.txt")); err == nil {
+		t.Fatal("Workflow should not run without --workflow-dir")
+	}
+
+	runOpts = runOptions{WorkflowDir: toolsDir}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(projectDir), evt) })
+	if !strings.Contains(output, `"allow"`) {
+		t.Errorf("Expected allow, got: %s", output)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "marker.txt")); err != nil {
+		t.Errorf("Expected step to run in --dir, marker missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(toolsDir, "marker.txt")); err == nil {
+		t.Error("Step should not run in --workflow-dir")
+	}
+}
+
+func TestSymbolNoColor(t *testing.T) {
+	defer func() { noColor = false }()
+
+	noColor = false
+	if got := symbol(symbolOK); got != "✓" {
+		t.Errorf("symbol(symbolOK) = %q, want ✓", got)
+	}
+
+	noColor = true
+	tests := map[string]string{symbolOK: "OK", symbolFail: "FAIL", symbolWarn: "WARN"}
+	for sym, want := range tests {
+		if got := symbol(sym); got != want {
+			t.Errorf("symbol(%q) with --no-color = %q, want %q", sym, got, want)
+		}
+	}
+}
+
+func TestOutputWorkflowResultNoColor(t *testing.T) {
+	defer func() { noColor = false }()
+	noColor = true
+
+	result := schema.NewDenyResult("\x1b[31mlint failed\x1b[0m")
+	output := captureStdout(t, func() { _ = outputWorkflowResult(result) })
+
+	if strings.Contains(output, "\\u001b") || strings.Contains(output, "\x1b") {
+		t.Errorf("Expected ANSI escapes to be stripped, got: %s", output)
+	}
+	if !strings.Contains(output, "lint failed") {
+		t.Errorf("Expected reason text to be preserved, got: %s", output)
+	}
+}
+
+func TestWorkflowToolNames(t *testing.T) {
+	wf := &schema.Workflow{
+		On: schema.OnConfig{
+			Tool: &schema.ToolTrigger{Names: []string{"edit", "create"}},
+			Tools: []schema.ToolTrigger{
+				{Name: "powershell"},
+			},
+		},
+	}
+
+	got := workflowToolNames(wf)
+	want := []string{"edit", "create", "powershell"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("workflowToolNames() = %v, want %v", got, want)
+	}
+}
+
+func TestOutputWorkflowResultTable(t *testing.T) {
+	defer func() { runOpts = runOptions{} }()
+	runOpts = runOptions{OutputFormat: outputFormatTable}
+
+	allow := &schema.WorkflowResult{
+		PermissionDecision: "allow",
+		StepResults: []schema.StepResult{
+			{Name: "lint", Success: true, DurationMs: 1500, OutputPreview: "all good\nno issues"},
+			{Name: "docs", Success: true, Skipped: true, OutputPreview: "Skipped (condition not met)"},
+		},
+	}
+	var err error
+	output := captureStdout(t, func() { err = outputWorkflowResult(allow) })
+	if err != nil {
+		t.Errorf("Expected no error for allow, got %v", err)
+	}
+	for _, want := range []string{"STEP NAME", "STATUS", "DURATION", "OUTPUT PREVIEW", "lint", "passed", "1.5s", "all good no issues", "docs", "skipped", "Decision: allow"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected table output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	deny := &schema.WorkflowResult{
+		PermissionDecision:       "deny",
+		PermissionDecisionReason: "lint failed",
+		StepResults: []schema.StepResult{
+			{Name: "lint", Success: false, DurationMs: 20, ExitCode: 1, OutputPreview: "error"},
+		},
+	}
+	output = captureStdout(t, func() { err = outputWorkflowResult(deny) })
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code == 0 {
+		t.Errorf("Expected non-zero exitError for deny, got %v", err)
+	}
+	if !strings.Contains(output, "failed") || !strings.Contains(output, "Reason: lint failed") {
+		t.Errorf("Expected failed step and reason in table output, got:\n%s", output)
+	}
+}
+
+func TestOutputWorkflowResultProfile(t *testing.T) {
+	defer func() { runOpts = runOptions{} }()
+
+	result := &schema.WorkflowResult{
+		PermissionDecision: "allow",
+		StepResults: []schema.StepResult{
+			{Name: "lint", Success: true, DurationMs: 30},
+			{Name: "test", Success: true, DurationMs: 90},
+		},
+	}
+
+	// Profile is reported even when step results are trimmed
+	runOpts = runOptions{Profile: true}
+	output := captureStdout(t, func() { _ = outputWorkflowResult(result) })
+
+	var parsed schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if parsed.Profile == nil {
+		t.Fatalf("Expected profile in output, got: %s", output)
+	}
+	if parsed.Profile.TotalMs != 120 {
+		t.Errorf("TotalMs = %d, want 120", parsed.Profile.TotalMs)
+	}
+	if len(parsed.Profile.Steps) != 2 || parsed.Profile.Steps[1].Name != "test" || parsed.Profile.Steps[1].DurationMs != 90 {
+		t.Errorf("Unexpected profile steps: %+v", parsed.Profile.Steps)
+	}
+	if parsed.StepResults != nil {
+		t.Errorf("Expected step results to stay omitted without --verbose, got: %s", output)
+	}
+	if result.Profile != nil {
+		t.Error("outputWorkflowResult should not modify the caller's result")
+	}
+
+	runOpts = runOptions{}
+	output = captureStdout(t, func() { _ = outputWorkflowResult(result) })
+	if strings.Contains(output, "profile") {
+		t.Errorf("Expected no profile without --profile, got: %s", output)
+	}
+
+	runOpts = runOptions{Profile: true, OutputFormat: outputFormatTable}
+	output = captureStdout(t, func() { _ = outputWorkflowResult(result) })
+	for _, want := range []string{"TIMING", "25%", "75%", "Total: 120ms"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected table output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestDiscoverCommandWorkflowDirEnv(t *testing.T) {
+	envDir := t.TempDir()
+	writeTestWorkflow(t, envDir, "from-env.yml", `name: From Env
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - run: echo env
+`)
+	t.Setenv(workflowDirEnv, envDir)
+	defer func() { _ = discoverCmd.Flags().Set("dir", "") }()
+
+	// Environment variable is used when --dir is not provided
+	_ = discoverCmd.Flags().Set("dir", "")
+	output := captureStdout(t, func() { _ = discoverCmd.RunE(discoverCmd, []string{}) })
+	if !strings.Contains(output, "from-env") {
+		t.Errorf("Expected workflow from %s, got: %s", workflowDirEnv, output)
+	}
+
+	// --dir takes precedence over the environment variable
+	flagDir := t.TempDir()
+	_ = discoverCmd.Flags().Set("dir", flagDir)
+	output = captureStdout(t, func() { _ = discoverCmd.RunE(discoverCmd, []string{}) })
+	if strings.Contains(output, "from-env") || !strings.Contains(output, flagDir) {
+		t.Errorf("Expected --dir to override %s, got: %s", workflowDirEnv, output)
+	}
+}
+
+func TestDiscoverCommandDescription(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "secrets.yml", `name: secrets
+description: |
+  Blocks edits that add API keys, passwords or other credentials to tracked files
+on:
+  file:
+    paths: ['**/*.env']
+steps:
+  - run: echo check
+`)
+	writeTestWorkflow(t, tmpDir, "plain.yml", `name: plain
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - run: echo check
+`)
+	defer func() { _ = discoverCmd.Flags().Set("dir", "") }()
+	_ = discoverCmd.Flags().Set("dir", tmpDir)
+
+	output := captureStdout(t, func() { _ = discoverCmd.RunE(discoverCmd, []string{}) })
+	want := "secrets (" + filepath.Join(".github", "hookflows", "secrets.yml") + ") - Blocks edits that add API keys, passwords or other creden..."
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected %q in output, got:\n%s", want, output)
+	}
+	if !strings.Contains(output, "plain.yml)\n") {
+		t.Errorf("Expected no description for plain, got:\n%s", output)
+	}
+}
+
+func TestDiscoverCommandJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "lint.yml", `name: lint
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - run: echo lint
+`)
+	path := filepath.Join(tmpDir, ".github", "hookflows", "lint.yml")
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = discoverCmd.Flags().Set("dir", "")
+		_ = discoverCmd.Flags().Set("json", "false")
+	}()
+	_ = discoverCmd.Flags().Set("dir", tmpDir)
+	_ = discoverCmd.Flags().Set("json", "true")
+
+	output := captureStdout(t, func() {
+		if err := discoverCmd.RunE(discoverCmd, []string{}); err != nil {
+			t.Errorf("discover --json: %v", err)
+		}
+	})
+	var files []discoveredWorkflow
+	if err := json.Unmarshal([]byte(output), &files); err != nil {
+		t.Fatalf("Expected a JSON array, got %v:\n%s", err, output)
+	}
+	if len(files) != 1 || files[0].Name != "lint" || files[0].Path != path || files[0].RelPath != filepath.Join(".github", "hookflows", "lint.yml") {
+		t.Fatalf("Unexpected files %+v", files)
+	}
+	if got, err := time.Parse(time.RFC3339, files[0].ModTime); err != nil || !got.Equal(modTime) {
+		t.Errorf("modTime = %q, want %s in RFC3339", files[0].ModTime, modTime)
+	}
+	if _, err := time.Parse(time.RFC3339, files[0].LoadedAt); err != nil {
+		t.Errorf("loadedAt = %q is not RFC3339: %v", files[0].LoadedAt, err)
+	}
+
+	// An empty directory is an empty array
+	_ = discoverCmd.Flags().Set("dir", t.TempDir())
+	output = captureStdout(t, func() { _ = discoverCmd.RunE(discoverCmd, []string{}) })
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("Expected [], got %q", output)
+	}
+}
+
+func TestPreviewDescription(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Short one", "Short one"},
+		{"Spans\n  two lines\n", "Spans two lines"},
+		{strings.Repeat("a", 60), strings.Repeat("a", 60)},
+		{strings.Repeat("é", 61), strings.Repeat("é", 57) + "..."},
+	}
+	for _, tt := range tests {
+		if got := previewDescription(tt.in); got != tt.want {
+			t.Errorf("previewDescription(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRunContinueOnWorkflowError(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a-deny", "b-deny"} {
+		writeTestWorkflow(t, tmpDir, name+".yml", `name: `+name+`
+on:
+  tool:
+    name: edit
+steps:
+  - name: Check
+    shell: bash
+    run: echo "`+name+` failed" && echo "::suggest::make fix" && echo "::suggest::fix-`+name+`" && exit 1
+`)
+	}
+	writeTestWorkflow(t, tmpDir, "c-allow.yml", `name: c-allow
+on:
+  tool:
+    name: edit
+steps:
+  - name: Check
+    shell: bash
+    run: echo ok
+`)
+
+	defer func() { runOpts = runOptions{} }()
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	// Default is fail-fast: only the first deny is reported
+	runOpts = runOptions{}
+	output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	var result schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if result.PermissionDecision != "deny" || len(result.WorkflowResults) != 0 {
+		t.Errorf("Expected fail-fast deny without workflowResults, got: %s", output)
+	}
+	if result.WorkflowName != "a-deny" || len(result.Workflows) != 0 {
+		t.Errorf("Expected a-deny as the workflow and no workflow list without --verbose, got: %s", output)
+	}
+
+	runOpts = runOptions{ContinueOnWorkflowError: true}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	result = schema.WorkflowResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if result.PermissionDecision != "deny" {
+		t.Errorf("Expected aggregate deny, got %s", result.PermissionDecision)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "[a-deny]") || !strings.Contains(result.PermissionDecisionReason, "[b-deny]") {
+		t.Errorf("Expected both deny reasons, got: %s", result.PermissionDecisionReason)
+	}
+	if len(result.WorkflowResults) != 3 {
+		t.Fatalf("Expected 3 workflow results, got %d", len(result.WorkflowResults))
+	}
+	if result.WorkflowResults[2].WorkflowName != "c-allow" || result.WorkflowResults[2].PermissionDecision != "allow" {
+		t.Errorf("Expected c-allow to run and allow, got %+v", result.WorkflowResults[2])
+	}
+	if result.WorkflowName != "a-deny" {
+		t.Errorf("Expected aggregate to name the first denying workflow, got %q", result.WorkflowName)
+	}
+	var commands []string
+	for _, action := range result.Actions {
+		commands = append(commands, action.Command)
+	}
+	if got := strings.Join(commands, ", "); got != "make fix, fix-a-deny, fix-b-deny" {
+		t.Errorf("Expected the suggestions of both denials without repeats, got %q", got)
+	}
+
+	runOpts = runOptions{ContinueOnWorkflowError: true, Verbose: true}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	result = schema.WorkflowResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if got := strings.Join(result.Workflows, ","); got != "a-deny,b-deny,c-allow" {
+		t.Errorf("Expected --verbose to list every executed workflow, got %q", got)
+	}
+
+	// Fail-fast verbose output lists the workflows run before the deny
+	runOpts = runOptions{Verbose: true}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	result = schema.WorkflowResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if got := strings.Join(result.Workflows, ","); got != "a-deny" || result.WorkflowName != "a-deny" {
+		t.Errorf("Expected fail-fast --verbose to list only a-deny, got workflows %q, workflow %q", got, result.WorkflowName)
+	}
+}
+
+func TestRunMaxWorkflows(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	// Written out of order: the limit keeps the alphabetically first files
+	for _, name := range []string{"c", "a", "b"} {
+		writeTestWorkflow(t, tmpDir, name+".yml", `name: `+name+`
+on:
+  tool:
+    name: edit
+steps:
+  - name: Check
+    shell: bash
+    run: echo ok
+`)
+	}
+
+	defer func() { runOpts = runOptions{} }()
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	runOpts = runOptions{MaxWorkflows: 2, Verbose: true}
+	output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	var result schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if !result.Truncated {
+		t.Errorf("Expected truncated result, got: %s", output)
+	}
+	if got := strings.Join(result.Workflows, ","); got != "a,b" {
+		t.Errorf("Expected the first two workflows to run, got %q", got)
+	}
+
+	for _, limit := range []int{0, 3} {
+		runOpts = runOptions{MaxWorkflows: limit, Verbose: true}
+		output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+		result = schema.WorkflowResult{}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		if result.Truncated || len(result.Workflows) != 3 {
+			t.Errorf("--max-workflows %d: expected all 3 workflows untruncated, got: %s", limit, output)
+		}
+		if strings.Contains(output, "truncated") {
+			t.Errorf("--max-workflows %d: expected no truncated key, got: %s", limit, output)
+		}
+	}
+
+	// The limit follows sorted paths, not the directory walk, which visits
+	// sub/ before sub-a.yml
+	nested := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(nested, ".github", "hookflows", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sub/x", "sub-a"} {
+		writeTestWorkflow(t, nested, name+".yml", "name: "+filepath.Base(name)+"\non:\n  tool:\n    name: edit\nsteps:\n  - shell: bash\n    run: echo ok\n")
+	}
+	runOpts = runOptions{MaxWorkflows: 1, Verbose: true, NoCache: true}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(nested), evt) })
+	result = schema.WorkflowResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if got := strings.Join(result.Workflows, ","); got != "sub-a" {
+		t.Errorf("Expected sub-a.yml to sort before sub/x.yml, got %q", got)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a-deny", "b-allow", "c-deny", "d-allow"} {
+		exit := "0"
+		if strings.HasSuffix(name, "deny") {
+			exit = "1"
+		}
+		writeTestWorkflow(t, tmpDir, name+".yml", `name: `+name+`
+on:
+  tool:
+    name: edit
+steps:
+  - name: Check
+    shell: bash
+    run: sleep 0.3; echo "`+name+`"; exit `+exit+`
+`)
+	}
+
+	defer func() { runOpts = runOptions{} }()
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	run := func(limit int) (schema.WorkflowResult, time.Duration) {
+		t.Helper()
+		runOpts = runOptions{Parallel: true, ParallelLimit: limit}
+		start := time.Now()
+		output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+		elapsed := time.Since(start)
+		var result schema.WorkflowResult
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		return result, elapsed
+	}
+
+	result, elapsed := run(4)
+	if result.PermissionDecision != "deny" {
+		t.Errorf("Expected deny when any workflow denies, got %s", result.PermissionDecision)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "[a-deny]") || !strings.Contains(result.PermissionDecisionReason, "[c-deny]") {
+		t.Errorf("Expected both deny reasons, got: %s", result.PermissionDecisionReason)
+	}
+	if len(result.WorkflowResults) != 4 {
+		t.Fatalf("Expected 4 workflow results, got %d", len(result.WorkflowResults))
+	}
+	for i, name := range []string{"a-deny", "b-allow", "c-deny", "d-allow"} {
+		if result.WorkflowResults[i].WorkflowName != name {
+			t.Errorf("WorkflowResults[%d] = %s, want %s in discovery order", i, result.WorkflowResults[i].WorkflowName, name)
+		}
+	}
+	if elapsed >= 1200*time.Millisecond {
+		t.Errorf("Expected four 0.3s workflows to overlap, took %s", elapsed)
+	}
+	// The aggregate timestamps span every workflow's run
+	if span := result.FinishedAt.Sub(result.StartedAt); span < 300*time.Millisecond || span > elapsed {
+		t.Errorf("Expected aggregate timestamps to span the runs, got %s of %s", span, elapsed)
+	}
+	for _, wfResult := range result.WorkflowResults {
+		if wfResult.StartedAt.Before(result.StartedAt) || wfResult.FinishedAt.After(result.FinishedAt) {
+			t.Errorf("%s ran %v - %v outside the aggregate %v - %v", wfResult.WorkflowName, wfResult.StartedAt, wfResult.FinishedAt, result.StartedAt, result.FinishedAt)
+		}
+	}
+
+	// A limit of 1 runs them one at a time
+	if _, elapsed := run(1); elapsed < 1200*time.Millisecond {
+		t.Errorf("Expected --parallel-limit 1 to serialize workflows, took %s", elapsed)
+	}
+}
+
+// benchmarkSleepWorkflows runs four workflows that each sleep for a second
+func benchmarkSleepWorkflows(b *testing.B, run func(context.Context, []*schema.Workflow, *schema.Event, string) *schema.WorkflowResult) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		b.Skip("Skipping - bash not available")
+	}
+
+	var workflows []*schema.Workflow
+	for i := 0; i < 4; i++ {
+		workflows = append(workflows, &schema.Workflow{
+			Name:  fmt.Sprintf("sleep-%d", i),
+			Steps: []schema.Step{{Name: "Sleep", Shell: "bash", Run: "sleep 1"}},
+		})
+	}
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit"}}
+	dir := b.TempDir()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := run(context.Background(), workflows, evt, dir); result.PermissionDecision != "allow" {
+			b.Fatalf("Expected allow, got %s", result.PermissionDecisionReason)
+		}
+	}
+}
+
+func BenchmarkRunWorkflowsSequential(b *testing.B) {
+	benchmarkSleepWorkflows(b, runAllWorkflows)
+}
+
+func BenchmarkRunWorkflowsParallel(b *testing.B) {
+	benchmarkSleepWorkflows(b, func(ctx context.Context, workflows []*schema.Workflow, evt *schema.Event, dir string) *schema.WorkflowResult {
+		return runParallelWorkflows(ctx, workflows, evt, dir, defaultParallelLimit)
+	})
+}
+
+func TestScheduleNowRunsScheduledWorkflows(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "nightly.yml", `name: nightly
+on:
+  schedule:
+    cron: '0 2 * * *'
+steps:
+  - name: Scan
+    shell: bash
+    run: echo "${{ event.schedule.cron }}" > scheduled.txt
+`)
+	writeTestWorkflow(t, tmpDir, "edit.yml", `name: edit
+on:
+  tool:
+    name: edit
+steps:
+  - name: Marker
+    shell: bash
+    run: touch edit.txt
+`)
+
+	schedules, err := loadSchedules(tmpDir)
+	if err != nil {
+		t.Fatalf("loadSchedules failed: %v", err)
+	}
+	if len(schedules) != 1 || schedules[0].String() != "0 2 * * *" {
+		t.Fatalf("Expected one schedule '0 2 * * *', got %v", schedules)
+	}
+
+	output := captureStdout(t, func() {
+		if err := fireSchedule(newRunTarget(tmpDir), ""); err != nil {
+			t.Errorf("fireSchedule failed: %v", err)
+		}
+	})
+	if !strings.Contains(output, `"allow"`) {
+		t.Errorf("Expected allow, got: %s", output)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "scheduled.txt")); err != nil {
+		t.Errorf("Expected scheduled workflow to run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "edit.txt")); err == nil {
+		t.Error("Non-scheduled workflow should not run")
+	}
+}
+
+func TestServeSchedulesStopsOnCancel(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "nightly.yml", `name: nightly
+on:
+  schedule:
+    cron: '0 2 * * *'
+steps:
+  - run: echo scan
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output := captureStdout(t, func() {
+		if err := serveSchedules(ctx, tmpDir); err != nil {
+			t.Errorf("serveSchedules failed: %v", err)
+		}
+	})
+	if !strings.Contains(output, "Serving 1 schedule(s)") {
+		t.Errorf("Expected serve banner, got: %s", output)
+	}
+}
+
+func TestServeReloadsChangedWorkflow(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	nightly := func(version, cron string) string {
+		return fmt.Sprintf(`name: nightly
+on:
+  schedule:
+    cron: '%s'
+steps:
+  - name: Scan
+    shell: bash
+    run: echo %s > scanned.txt
+`, cron, version)
+	}
+	writeTestWorkflow(t, tmpDir, "nightly.yml", nightly("v1", "0 2 * * *"))
+	scanned := func() string {
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "scanned.txt"))
+		return strings.TrimSpace(string(data))
+	}
+	// waitFor polls until cond holds, as the watcher reloads asynchronously
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	armed := func(s *scheduleServer, cron string) bool {
+		s.timersMu.Lock()
+		defer s.timersMu.Unlock()
+		_, ok := s.timers[cron]
+		return ok
+	}
+
+	s := newScheduleServer(tmpDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	output := captureStdout(t, func() {
+		go func() { done <- s.serve(ctx) }()
+		waitFor("the initial load", func() bool { return armed(s, "0 2 * * *") })
+
+		if err := s.fire("0 2 * * *"); err != nil {
+			t.Errorf("fire: %v", err)
+		}
+		if got := scanned(); got != "v1" {
+			t.Errorf("Expected v1 run, got %q", got)
+		}
+
+		// Edit the step and move the schedule without restarting
+		writeTestWorkflow(t, tmpDir, "nightly.yml", nightly("version-2", "30 3 * * *"))
+		waitFor("the reload", func() bool { return armed(s, "30 3 * * *") && !armed(s, "0 2 * * *") })
+
+		if err := s.fire("30 3 * * *"); err != nil {
+			t.Errorf("fire: %v", err)
+		}
+		if got := scanned(); got != "version-2" {
+			t.Errorf("Expected the reloaded workflow to run, got %q", got)
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+
+	if !strings.Contains(output, "Serving 1 schedule(s)") || !strings.Contains(output, "Reloaded "+filepath.Join(".github", "hookflows", "nightly.yml")+" (update), serving 1 schedule(s)") {
+		t.Errorf("Expected serve and reload messages, got: %s", output)
+	}
+	if armed(s, "30 3 * * *") {
+		t.Error("Expected timers to be stopped after serve returns")
+	}
+}
+
+func TestServeWatchesWithoutSchedulesAndReloadsBases(t *testing.T) {
+	tmpDir := t.TempDir()
+	armed := func(s *scheduleServer, cron string) bool {
+		s.timersMu.Lock()
+		defer s.timersMu.Unlock()
+		_, ok := s.timers[cron]
+		return ok
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	base := filepath.Join(tmpDir, "shared", "base.yml")
+	writeBase := func(cron string) {
+		t.Helper()
+		content := fmt.Sprintf("name: base\non:\n  schedule:\n    cron: '%s'\nsteps:\n  - run: echo base\n", cron)
+		if err := os.WriteFile(base, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeBase("0 2 * * *")
+
+	s := newScheduleServer(tmpDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	output := captureStdout(t, func() {
+		go func() { done <- s.serve(ctx) }()
+
+		// serve keeps running with no scheduled workflows
+		time.Sleep(50 * time.Millisecond)
+		select {
+		case err := <-done:
+			t.Fatalf("serve returned without schedules: %v", err)
+		default:
+		}
+
+		writeTestWorkflow(t, tmpDir, "nightly.yml", "name: nightly\nextends: ../../shared/base.yml\nsteps:\n  - run: echo nightly\n")
+		waitFor("the new schedule", func() bool { return armed(s, "0 2 * * *") })
+
+		// Editing the base reloads the workflow that extends it
+		writeBase("30 3 * * *")
+		waitFor("the base reload", func() bool { return armed(s, "30 3 * * *") && !armed(s, "0 2 * * *") })
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve: %v", err)
+		}
+	})
+
+	if !strings.Contains(output, "No scheduled workflows found in: "+tmpDir+", watching for changes") {
+		t.Errorf("Expected the no-schedule message, got: %s", output)
+	}
+}
+
+func TestListTriggersCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "secrets.yml", `name: secrets
+description: Keep credentials out of env files
+on:
+  file:
+    paths: ['**/*.env']
+    actions: [edit]
+  tool:
+    names: [edit, create]
+    args:
+      path: '**/*.env'
+steps:
+  - run: echo blocked
+`)
+	writeTestWorkflow(t, tmpDir, "audit.yml", `name: audit
+on:
+  commit:
+  hooks:
+    hook_type: postToolUse
+  schedule:
+    cron: '0 2 * * *'
+steps:
+  - run: echo audit
+`)
+
+	defer func() {
+		_ = listTriggersCmd.Flags().Set("dir", "")
+		_ = listTriggersCmd.Flags().Set("event-type", "")
+		_ = listTriggersCmd.Flags().Set("sort", sortByWorkflow)
+	}()
+	_ = listTriggersCmd.Flags().Set("dir", tmpDir)
+
+	var err error
+	output := captureStdout(t, func() { err = listTriggersCmd.RunE(listTriggersCmd, []string{}) })
+	if err != nil {
+		t.Fatalf("list-triggers failed: %v", err)
+	}
+	for _, want := range []string{
+		"WORKFLOW", "TRIGGER TYPE", "DETAILS", "DESCRIPTION",
+		"Keep credentials out of env files",
+		"lifecycle: pre; actions: edit; paths: **/*.env",
+		"name: edit, create; lifecycle: pre; args: path=**/*.env",
+		"lifecycle: pre",
+		"cron: 0 2 * * *",
+		"hook_type: postToolUse",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Index(output, "audit") > strings.Index(output, "secrets") {
+		t.Errorf("Expected rows sorted by workflow name, got:\n%s", output)
+	}
+
+	// Sorting by type puts tool rows before file rows
+	_ = listTriggersCmd.Flags().Set("sort", sortByType)
+	output = captureStdout(t, func() { _ = listTriggersCmd.RunE(listTriggersCmd, []string{}) })
+	if strings.Index(output, "name: edit") > strings.Index(output, "actions: edit") {
+		t.Errorf("Expected tool triggers before file triggers, got:\n%s", output)
+	}
+
+	_ = listTriggersCmd.Flags().Set("event-type", "schedule")
+	output = captureStdout(t, func() { _ = listTriggersCmd.RunE(listTriggersCmd, []string{}) })
+	if !strings.Contains(output, "cron:") || strings.Contains(output, "secrets") || strings.Contains(output, "commit") {
+		t.Errorf("Expected only schedule triggers, got:\n%s", output)
+	}
+
+	_ = listTriggersCmd.Flags().Set("event-type", "bogus")
+	if err := listTriggersCmd.RunE(listTriggersCmd, []string{}); err == nil {
+		t.Error("Expected error for invalid --event-type")
+	}
+	_ = listTriggersCmd.Flags().Set("event-type", "")
+	_ = listTriggersCmd.Flags().Set("sort", "name")
+	if err := listTriggersCmd.RunE(listTriggersCmd, []string{}); err == nil {
+		t.Error("Expected error for invalid --sort")
+	}
+}
+
+func TestParseContextFlags(t *testing.T) {
+	vars, err := parseContextFlags([]string{"environment=staging", "region=eu=west", "environment=production", "empty="})
+	if err != nil {
+		t.Fatalf("parseContextFlags failed: %v", err)
+	}
+	want := map[string]string{"environment": "production", "region": "eu=west", "empty": ""}
+	if len(vars) != len(want) {
+		t.Errorf("Expected %d vars, got %v", len(want), vars)
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("vars[%q] = %q, want %q", k, vars[k], v)
+		}
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		if _, err := parseContextFlags([]string{bad}); err == nil {
+			t.Errorf("Expected error for --context %q", bad)
+		}
+	}
+}
+
+func TestRunWithContextVars(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "deploy-guard.yml", `name: deploy-guard
+on:
+  tool:
+    name: edit
+steps:
+  - name: Block in production
+    if: ${{ ctx.environment == 'production' }}
+    shell: bash
+    run: echo "blocked in ${{ ctx.environment }}" && exit 1
+`)
+
+	defer func() { runOpts = runOptions{} }()
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	runOpts = runOptions{Context: map[string]string{"environment": "staging"}}
+	output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	if !strings.Contains(output, `"allow"`) {
+		t.Errorf("Expected allow in staging, got: %s", output)
+	}
+
+	runOpts = runOptions{Context: map[string]string{"environment": "production"}}
+	output = captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+	if !strings.Contains(output, `"deny"`) || !strings.Contains(output, "blocked in production") {
+		t.Errorf("Expected deny in production, got: %s", output)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".github", "hookflows")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchFiles(ctx, root, 10*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+
+	expectChange := func(action string) {
+		t.Helper()
+		select {
+		case <-changes:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("No change reported after %s", action)
+		}
+	}
+
+	// Give the watcher time to take its initial snapshot
+	time.Sleep(50 * time.Millisecond)
+	path := filepath.Join(root, "lint.yml")
+	if err := os.WriteFile(path, []byte("name: lint\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("create")
+
+	if err := os.WriteFile(path, []byte("name: lint-updated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("modify")
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	expectChange("delete")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watchFiles returned %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchFiles did not stop after cancel")
+	}
+}
+
+func TestRawInputHooksToolsFilter(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "post-edit.yml", `name: post-edit
+on:
+  hooks:
+    types: [postToolUse]
+    tools: [edit, create]
+steps:
+  - name: Deny
+    shell: bash
+    run: echo "hook ${{ event.hook.type }} for ${{ event.hook.tool.name }}" && exit 1
+`)
+
+	defer func() { runOpts = runOptions{} }()
+	runOpts = runOptions{}
+
+	tests := []struct {
+		name      string
+		tool      string
+		lifecycle string
+		wantDeny  bool
+	}{
+		{"listed tool after use", "edit", "post", true},
+		{"other listed tool", "create", "post", true},
+		{"unlisted tool", "view", "post", false},
+		{"wrong hook type", "edit", "pre", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := fmt.Sprintf(`{"toolName": %q, "toolArgs": {"path": "README.md"}, "cwd": %q}`, tt.tool, tmpDir)
+			output := captureStdout(t, func() { _ = runWithRawInput(newRunTarget(tmpDir), input, tt.lifecycle, tt.lifecycle) })
+
+			if gotDeny := strings.Contains(output, `"deny"`); gotDeny != tt.wantDeny {
+				t.Errorf("deny = %v, want %v; output: %s", gotDeny, tt.wantDeny, output)
+			}
+			if tt.wantDeny && !strings.Contains(output, "hook postToolUse for "+tt.tool) {
+				t.Errorf("Expected hook context in output, got: %s", output)
+			}
+		})
+	}
+}
+
+// TestRawInputHookTypeWithLifecycleMap tests that hook_type sees the raw
+// --event-type even when $HOOKFLOW_LIFECYCLE_MAP maps it to a lifecycle
+func TestRawInputHookTypeWithLifecycleMap(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "notify.yml", `name: notify
+on:
+  hooks:
+    hook_type: notification
+steps:
+  - name: Deny
+    shell: bash
+    run: echo "hook ${{ event.hook.type }}" && exit 1
+`)
+
+	defer func() { runOpts = runOptions{} }()
+	runOpts = runOptions{}
+	input := fmt.Sprintf(`{"toolName": "edit", "toolArgs": {"path": "README.md"}, "cwd": %q}`, tmpDir)
+
+	for _, lifecycleMap := range []string{"", "notification=post"} {
+		t.Setenv(lifecycleMapEnv, lifecycleMap)
+		lifecycle := eventTypeToLifecycle("notification")
+		output := captureStdout(t, func() { _ = runWithRawInput(newRunTarget(tmpDir), input, "notification", lifecycle) })
+		if !strings.Contains(output, `"deny"`) || !strings.Contains(output, "hook notification") {
+			t.Errorf("map %q: expected hook_type notification to match, got: %s", lifecycleMap, output)
+		}
+	}
+
+	for eventType, want := range map[string]string{"": "preToolUse", "pre": "preToolUse", "post": "postToolUse", "postToolUse": "postToolUse", "notification": "notification"} {
+		if got := eventHookType(eventType, eventTypeToLifecycle(eventType)); got != want {
+			t.Errorf("eventHookType(%q) = %q, want %q", eventType, got, want)
+		}
+	}
+}
+
+func TestParseEventVars(t *testing.T) {
+	vars, err := parseEventVars([]string{"cwd=/tmp", "file.action=create", "metadata.note=a=b"})
+	if err != nil {
+		t.Fatalf("parseEventVars: %v", err)
+	}
+	if len(vars) != 3 || vars[1].Path[0] != "file" || vars[1].Path[1] != "action" || vars[2].Value != "a=b" {
+		t.Errorf("Unexpected event vars: %+v", vars)
+	}
+
+	for _, bad := range []string{"cwd", "=x", "file.=create", ".cwd=x", "file..action=x"} {
+		if _, err := parseEventVars([]string{bad}); err == nil {
+			t.Errorf("Expected error for --event-vars %q", bad)
+		}
+	}
+}
+
+func TestApplyEventVars(t *testing.T) {
+	evt := &schema.Event{
+		Tool:      &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "a.go"}},
+		File:      &schema.FileEvent{Path: "a.go", Action: "edit", ChangedLines: 3},
+		Cwd:       "/repo",
+		Lifecycle: "pre",
+	}
+	vars, _ := parseEventVars([]string{
+		"cwd=/tmp",
+		"file.action=create",
+		"file.changed_lines=40",
+		"tool.args.path=b.go",
+		"metadata.source=debug",
+		"cwd=/override",
+	})
+
+	patched, err := applyEventVars(evt, vars)
+	if err != nil {
+		t.Fatalf("applyEventVars: %v", err)
+	}
+	if patched.Cwd != "/override" {
+		t.Errorf("Expected the last cwd override to win, got %q", patched.Cwd)
+	}
+	if patched.File.Action != "create" || patched.File.ChangedLines != 40 || patched.File.Path != "a.go" {
+		t.Errorf("Unexpected file event: %+v", patched.File)
+	}
+	if patched.Tool.Args["path"] != "b.go" || patched.Tool.Name != "edit" {
+		t.Errorf("Unexpected tool event: %+v", patched.Tool)
+	}
+	if patched.Metadata["source"] != "debug" || patched.Lifecycle != "pre" {
+		t.Errorf("Expected new metadata and untouched lifecycle, got %+v", patched)
+	}
+	if evt.Cwd != "/repo" || evt.File.Action != "edit" {
+		t.Errorf("Expected the original event to be unchanged, got %+v", evt)
+	}
+
+	errorCases := []string{
+		"file.changed_lines=many",
+		"cwd.path=x",
+		"tool.args=x",
+		"file=x",
+	}
+	for _, flag := range errorCases {
+		vars, _ := parseEventVars([]string{flag})
+		if _, err := applyEventVars(evt, vars); err == nil {
+			t.Errorf("Expected error for --event-vars %q", flag)
+		}
+	}
+}
+
+func TestRunRawEventVars(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "new-files.yml", `name: new-files
+on:
+  file:
+    types: [create]
+    paths: ['**/*.md']
+steps:
+  - name: Deny
+    shell: bash
+    run: echo "created ${{ event.file.path }}" && exit 1
+`)
+
+	defer func() {
+		_ = runCmd.Flags().Set("dir", "")
+		_ = runCmd.Flags().Set("event", "")
+		_ = runCmd.Flags().Set("raw", "false")
+		_ = runCmd.Flags().Lookup("event-vars").Value.(interface{ Replace([]string) error }).Replace(nil)
+		runOpts = runOptions{}
+	}()
+	input := fmt.Sprintf(`{"toolName": "edit", "toolArgs": {"path": "README.md", "old_str": "a", "new_str": "b"}, "cwd": %q}`, tmpDir)
+	_ = runCmd.Flags().Set("dir", tmpDir)
+	_ = runCmd.Flags().Set("event", input)
+	_ = runCmd.Flags().Set("event-vars", "file.action=create")
+
+	// Without --raw the flag is rejected
+	if err := runCmd.RunE(runCmd, []string{}); err == nil || !strings.Contains(err.Error(), "requires --raw") {
+		t.Errorf("Expected --event-vars without --raw to fail, got %v", err)
+	}
+
+	_ = runCmd.Flags().Set("raw", "true")
+	var err error
+	output := captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	if err != nil {
+		t.Fatalf("run --raw --event-vars failed: %v", err)
+	}
+	if !strings.Contains(output, `"deny"`) || !strings.Contains(output, "created README.md") {
+		t.Errorf("Expected the edit patched into a create to be denied, got: %s", output)
+	}
+}
+
+func TestRunEventSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemaFile := filepath.Join(tmpDir, "event.schema.json")
+	if err := os.WriteFile(schemaFile, schema.EventSchema(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { runOpts = runOptions{} }()
+	runOpts = runOptions{EventSchema: schemaFile}
+
+	// A matching event runs as usual
+	output := captureStdout(t, func() {
+		if err := runMatchingWorkflows(newRunTarget(tmpDir), `{"tool":{"name":"edit","args":{"path":"a.go"}}}`, "pre"); err != nil {
+			t.Errorf("valid event returned error: %v", err)
+		}
+	})
+	if !strings.Contains(output, "permissionDecision") {
+		t.Errorf("Expected permissionDecision in output, got: %s", output)
+	}
+
+	// Type mismatches parseEventData would skip are reported instead
+	err := runMatchingWorkflows(newRunTarget(tmpDir), `{"tool":{"name":5},"file":{"path":"a.go","action":"rename"}}`, "pre")
+	if err == nil {
+		t.Fatal("Expected error for event that does not match the schema")
+	}
+	for _, want := range []string{"event does not match", "tool.name", "file.action"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	runOpts.EventSchema = filepath.Join(tmpDir, "missing.json")
+	if err := runMatchingWorkflows(newRunTarget(tmpDir), `{}`, "pre"); err == nil || !strings.Contains(err.Error(), "failed to read event schema") {
+		t.Errorf("Expected read error for missing schema, got %v", err)
+	}
+}
+
+func TestEventSchemaCommand(t *testing.T) {
+	output := captureStdout(t, func() { eventSchemaCmd.Run(eventSchemaCmd, nil) })
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("event-schema output is not JSON: %v", err)
+	}
+	if doc["title"] != "Hookflow Event" {
+		t.Errorf("title = %v, want Hookflow Event", doc["title"])
+	}
+}
+
+func TestReplayDenialLog(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	tmpDir := t.TempDir()
+	// The file name differs from name: so replay has to search by name
+	writeTestWorkflow(t, tmpDir, "block-secrets.yml", `name: Block secrets
+on:
+  file:
+    paths: ['**/*.env']
+steps:
+  - name: Check
+    shell: bash
+    run: echo "blocked ${{ event.file.path }}"; exit 1
+`)
+
+	defer func() { runOpts = runOptions{} }()
+	runOpts = runOptions{}
+	evt := &schema.Event{File: &schema.FileEvent{Path: "config/prod.env", Action: "edit"}}
+	output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+
+	var denied schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &denied); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if denied.PermissionDecision != "deny" || denied.LogFile == "" {
+		t.Fatalf("Expected deny with a log file, got %+v", denied)
+	}
+
+	output = captureStdout(t, func() {
+		if err := replayDenialLog(newRunTarget(tmpDir), denied.LogFile); err != nil {
+			t.Errorf("replayDenialLog: %v", err)
+		}
+	})
+	var replayed schema.WorkflowResult
+	if err := json.Unmarshal([]byte(output), &replayed); err != nil {
+		t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+	}
+	if replayed.PermissionDecision != "deny" || !strings.Contains(replayed.PermissionDecisionReason, "blocked config/prod.env") {
+		t.Errorf("Expected replayed deny for the same file, got %+v", replayed)
+	}
+
+	if err := replayDenialLog(newRunTarget(t.TempDir()), denied.LogFile); err == nil || !strings.Contains(err.Error(), "workflow 'Block secrets' not found") {
+		t.Errorf("Expected not-found error, got %v", err)
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			completionCmd.SetOut(&buf)
+			defer completionCmd.SetOut(nil)
+			if err := completionCmd.RunE(completionCmd, []string{shell}); err != nil {
+				t.Fatalf("completion %s: %v", shell, err)
+			}
+			if !strings.Contains(buf.String(), "hookflow") {
+				t.Errorf("completion %s output does not mention hookflow:\n%.200s", shell, buf.String())
+			}
+		})
+	}
+
+	if err := completionCmd.Args(completionCmd, []string{"tcsh"}); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+func TestCompleteWorkflowNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, file := range []string{"lint.yml", "block-secrets.yaml", "audit.yml"} {
+		writeTestWorkflow(t, tmpDir, file, "name: "+file+"\non:\n  tool:\n    name: edit\nsteps:\n  - run: echo ok\n")
+	}
+
+	defer func() {
+		_ = runCmd.Flags().Set("dir", "")
+		_ = runCmd.Flags().Set("workflow-dir", "")
+	}()
+	_ = runCmd.Flags().Set("dir", tmpDir)
+
+	names, directive := completeWorkflowNames(runCmd, nil, "")
+	if want := []string{"audit", "block-secrets", "lint"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("directive = %v, want NoFileComp", directive)
+	}
+
+	for _, cmd := range []*cobra.Command{runCmd, testCmd} {
+		if _, ok := cmd.GetFlagCompletionFunc("workflow"); !ok {
+			t.Errorf("%s --workflow has no completion function", cmd.Name())
+		}
+	}
+
+	// --workflow-dir wins over --dir
+	_ = runCmd.Flags().Set("workflow-dir", t.TempDir())
+	if names, _ := completeWorkflowNames(runCmd, nil, ""); len(names) != 0 {
+		t.Errorf("Expected no names from empty --workflow-dir, got %v", names)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# CI secrets
+API_TOKEN=abc123
+
+export REGION = us-east-1
+GREETING="hello \"world\"\nbye"
+LITERAL='no $expansion \n here'
+TRAILING=value # comment
+HASH_IN_VALUE=a#b
+QUOTED_HASH="x # y" # comment
+WIN_PATH="C:\tools\bin\app.exe"
+BACKSLASH="a\\nb\\"
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := parseEnvFile(path)
+	if err != nil {
+		t.Fatalf("parseEnvFile: %v", err)
+	}
+	want := [][2]string{
+		{"API_TOKEN", "abc123"},
+		{"REGION", "us-east-1"},
+		{"GREETING", "hello \"world\"\nbye"},
+		{"LITERAL", `no $expansion \n here`},
+		{"TRAILING", "value"},
+		{"HASH_IN_VALUE", "a#b"},
+		{"QUOTED_HASH", "x # y"},
+		{"WIN_PATH", `C:\tools\bin\app.exe`},
+		{"BACKSLASH", `a\nb\`},
+		{"EMPTY", ""},
+	}
+	if len(vars) != len(want) {
+		t.Fatalf("vars = %q, want %q", vars, want)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("vars[%d] = %q, want %q", i, vars[i], want[i])
+		}
+	}
+
+	for name, bad := range map[string]string{
+		"no equals":           "JUST_A_KEY\n",
+		"space in key":        "MY KEY=1\n",
+		"unterminated":        "A=\"open\n",
+		"unterminated single": "A='open\n",
+	} {
+		badPath := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(badPath, []byte("OK=1\n"+bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseEnvFile(badPath); err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("%s: expected error on line 2, got %v", name, err)
+		}
+	}
+
+	if _, err := parseEnvFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Expected error for missing env file")
+	}
+}
+
+func TestLoadRunEnv(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.env")
+	local := filepath.Join(dir, "local.env")
+	if err := os.WriteFile(base, []byte("HOOKFLOW_TEST_A=base\nHOOKFLOW_TEST_B=base\nHOOKFLOW_TEST_C=base\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte("HOOKFLOW_TEST_B=local\nHOOKFLOW_TEST_C=local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Register cleanup for every variable loadRunEnv sets
+	for _, key := range []string{"HOOKFLOW_TEST_A", "HOOKFLOW_TEST_B", "HOOKFLOW_TEST_C", "HOOKFLOW_TEST_D"} {
+		t.Setenv(key, "")
+	}
+
+	if err := loadRunEnv([]string{base, local}, []string{"HOOKFLOW_TEST_C=flag", "HOOKFLOW_TEST_D=a=b"}); err != nil {
+		t.Fatalf("loadRunEnv: %v", err)
+	}
+	want := map[string]string{
+		"HOOKFLOW_TEST_A": "base",  // only in the first file
+		"HOOKFLOW_TEST_B": "local", // later file wins
+		"HOOKFLOW_TEST_C": "flag",  // --env wins over every file
+		"HOOKFLOW_TEST_D": "a=b",
+	}
+	for key, value := range want {
+		if got := os.Getenv(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	if err := loadRunEnv(nil, []string{"=oops"}); err == nil || !strings.Contains(err.Error(), "invalid --env") {
+		t.Errorf("Expected invalid --env error, got %v", err)
+	}
+}
+
+func TestFindWorkflowFilesCacheDir(t *testing.T) {
+	defer func() { runOpts = runOptions{} }()
+
+	tmpDir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	writeTestWorkflow(t, tmpDir, "lint.yml", "name: lint\non:\n  tool:\n    name: edit\nsteps:\n  - run: echo lint\n")
+	// Directories changed within the last moments are not cached
+	past := time.Now().Add(-time.Minute)
+	for _, dir := range []string{filepath.Join(tmpDir, ".github"), filepath.Join(tmpDir, ".github", "hookflows")} {
+		if err := os.Chtimes(dir, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runOpts.CacheDir = cacheDir
+	files, err := findWorkflowFiles(tmpDir)
+	if err != nil {
+		t.Fatalf("findWorkflowFiles: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "lint.yml" {
+		t.Errorf("Expected lint.yml, got %v", files)
+	}
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "discovery", "*.json"))
+	if len(cached) != 1 {
+		t.Fatalf("Expected one discovery entry in --cache-dir, got %v", cached)
+	}
+
+	// A new workflow invalidates the entry
+	writeTestWorkflow(t, tmpDir, "secrets.yml", "name: secrets\non:\n  tool:\n    name: edit\nsteps:\n  - run: echo secrets\n")
+	if files, _ := findWorkflowFiles(tmpDir); len(files) != 2 {
+		t.Errorf("Expected 2 workflows after adding one, got %v", files)
+	}
+
+	// --no-cache scans without reading or writing the cache
+	if err := os.RemoveAll(cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	runOpts.NoCache = true
+	if files, _ := findWorkflowFiles(tmpDir); len(files) != 2 {
+		t.Errorf("Expected 2 workflows with --no-cache, got %v", files)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Expected --no-cache to leave the cache directory alone, got %v", err)
+	}
+}
+
+func TestRunWorkflowGlob(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	defer func() { runOpts = runOptions{} }()
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"security-secrets", "security-env", "style"} {
+		writeTestWorkflow(t, tmpDir, name+".yml", "name: "+name+"\non:\n  tool:\n    name: edit\nsteps:\n  - shell: bash\n    run: echo "+name+"\n")
+	}
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{}}}
+
+	tests := []struct {
+		globs []string
+		want  string
+	}{
+		{nil, "security-env, security-secrets, style"},
+		{[]string{"security-*.yml"}, "security-env, security-secrets"},
+		{[]string{"style.yml", "*-env.yml"}, "security-env, style"},
+		{[]string{".github/hookflows/style.yml"}, ""},
+	}
+	for _, tt := range tests {
+		runOpts = runOptions{NoCache: true, Verbose: true, WorkflowGlobs: tt.globs}
+		output := captureStdout(t, func() { _ = runMatchingWorkflowsWithEvent(newRunTarget(tmpDir), evt) })
+		var result schema.WorkflowResult
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Output is not valid JSON: %v\n%s", err, output)
+		}
+		// A single workflow reports its name rather than a workflow list
+		ran := result.Workflows
+		if len(ran) == 0 && result.WorkflowName != "" {
+			ran = []string{result.WorkflowName}
+		}
+		if got := strings.Join(ran, ", "); got != tt.want {
+			t.Errorf("--workflow-glob %v ran %q, want %q", tt.globs, got, tt.want)
+		}
+	}
+
+	if err := runCmd.Flags().Set("workflow-glob", "[bad"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = runCmd.Flags().Lookup("workflow-glob").Value.(interface{ Replace([]string) error }).Replace(nil)
+	}()
+	err := runCmd.RunE(runCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid --workflow-glob "[bad"`) {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestRunOutputFile(t *testing.T) {
+	defer func() { runOpts = runOptions{} }()
+	path := filepath.Join(t.TempDir(), "result.json")
+
+	allow := schema.NewAllowResult()
+	runOpts = runOptions{OutputFile: path}
+	if output := captureStdout(t, func() { _ = outputWorkflowResult(allow) }); output != "" {
+		t.Errorf("Expected no stdout with --output-file, got %q", output)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected the result file: %v", err)
+	}
+	var result schema.WorkflowResult
+	if err := json.Unmarshal(data, &result); err != nil || result.PermissionDecision != "allow" {
+		t.Fatalf("Expected an allow result in the file, got %v (err %v)\n%s", result.PermissionDecision, err, data)
+	}
+
+	// --append adds one JSON line per run, after an existing file's content
+	runOpts = runOptions{OutputFile: path, AppendOutput: true}
+	_ = outputWorkflowResult(schema.NewDenyResult("blocked"))
+	_ = outputWorkflowResult(allow)
+	data, _ = os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if !strings.HasPrefix(string(data), "{\n") || len(lines) < 3 {
+		t.Fatalf("Expected the indented result followed by appended lines, got:\n%s", data)
+	}
+	for i, want := range []string{"deny", "allow"} {
+		var appended schema.WorkflowResult
+		if err := json.Unmarshal([]byte(lines[len(lines)-2+i]), &appended); err != nil || appended.PermissionDecision != want {
+			t.Errorf("Appended line %d = %q (err %v), want decision %s", i, lines[len(lines)-2+i], err, want)
+		}
+	}
+
+	// Without --append the file is replaced
+	runOpts = runOptions{OutputFile: path}
+	_ = outputWorkflowResult(allow)
+	if data, _ = os.ReadFile(path); strings.Contains(string(data), "blocked") {
+		t.Errorf("Expected the file to be replaced, got:\n%s", data)
+	}
+
+	for _, tt := range []struct {
+		flags map[string]string
+		want  string
+	}{
+		{map[string]string{"append": "true"}, "--append requires --output-file"},
+		{map[string]string{"output-file": path, "output-format": "table"}, "cannot be used with --output-format table"},
+	} {
+		for name, value := range tt.flags {
+			if err := runCmd.Flags().Set(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := runCmd.RunE(runCmd, nil)
+		for name := range tt.flags {
+			flag := runCmd.Flags().Lookup(name)
+			_ = flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Flags %v: expected error %q, got %v", tt.flags, tt.want, err)
+		}
+	}
+}
+
+// withStdin runs fn with input as stdin
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = oldStdin
+		_ = r.Close()
+	}()
+	fn()
+}
+
+func TestRunStdinFormat(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	defer func() { runOpts = runOptions{} }()
+
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "block-edit.yml", "name: Block edit\non:\n  tool:\n    name: edit\nsteps:\n  - shell: bash\n    run: exit 1\n")
+	copilotInput := `{"toolName": "edit", "toolArgs": {"path": "a.go"}, "cwd": "` + filepath.ToSlash(tmpDir) + `"}`
+	eventInput := `{"tool": {"name": "edit", "args": {"path": "a.go"}}, "cwd": "` + filepath.ToSlash(tmpDir) + `"}`
+
+	run := func(flags map[string]string, input string) (string, error) {
+		flags["dir"] = tmpDir
+		flags["no-cache"] = "true"
+		for name, value := range flags {
+			if err := runCmd.Flags().Set(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer func() {
+			for name := range flags {
+				flag := runCmd.Flags().Lookup(name)
+				_ = flag.Value.Set(flag.DefValue)
+				flag.Changed = false
+			}
+		}()
+		var err error
+		output := captureStdout(t, func() {
+			_ = captureStderr(t, func() {
+				withStdin(t, input, func() { err = runCmd.RunE(runCmd, nil) })
+			})
+		})
+		return output, err
+	}
+
+	for _, tt := range []struct {
+		format, input string
+	}{
+		{"copilot", copilotInput},
+		{"raw", copilotInput},
+		{"json", eventInput},
+	} {
+		output, err := run(map[string]string{"stdin-format": tt.format}, tt.input)
+		if err != nil {
+			t.Errorf("--stdin-format %s: %v", tt.format, err)
+			continue
+		}
+		var result schema.WorkflowResult
+		if err := json.Unmarshal([]byte(output), &result); err != nil || result.PermissionDecision != "deny" {
+			t.Errorf("--stdin-format %s: expected a deny result, got %q (err %v)", tt.format, output, err)
+		}
+	}
+
+	errorTests := []struct {
+		flags map[string]string
+		input string
+		want  string
+	}{
+		{map[string]string{"stdin-format": "copilot"}, eventInput, "toolName is missing"},
+		{map[string]string{"stdin-format": "yaml"}, "", `invalid --stdin-format "yaml"`},
+		{map[string]string{"stdin-format": "json", "raw": "true"}, "", "cannot be used with --raw or --event"},
+		{map[string]string{"stdin-format": "copilot", "event": "-"}, "", "cannot be used with --raw or --event"},
+	}
+	for _, tt := range errorTests {
+		if _, err := run(tt.flags, tt.input); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Flags %v: expected error %q, got %v", tt.flags, tt.want, err)
+		}
+	}
+}
+
+func TestRunWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	defer func() { runOpts = runOptions{} }()
+
+	root := t.TempDir()
+	t.Chdir(root)
+	api := filepath.Join(root, "packages", "api")
+	web := filepath.Join(root, "packages", "web")
+	// Each package requires a file in its own directory, so the step cwd shows
+	writeTestWorkflow(t, api, "needs-schema.yml", "name: Needs schema\non:\n  tool:\n    name: edit\nsteps:\n  - shell: bash\n    run: test -f schema.sql\n")
+	writeTestWorkflow(t, web, "needs-package.yml", "name: Needs package\non:\n  tool:\n    name: edit\nsteps:\n  - shell: bash\n    run: test -f package.json\n")
+	if err := os.WriteFile(filepath.Join(web, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "packages", "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	input := `{"toolName": "edit", "toolArgs": {"path": "README.md"}, "cwd": "` + filepath.ToSlash(root) + `"}`
+
+	run := func(workspaces []string, flags map[string]string) (schema.WorkflowResult, error) {
+		flags["no-cache"] = "true"
+		for name, value := range flags {
+			if err := runCmd.Flags().Set(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, workspace := range workspaces {
+			if err := runCmd.Flags().Set("workspace", workspace); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer func() {
+			for name := range flags {
+				flag := runCmd.Flags().Lookup(name)
+				_ = flag.Value.Set(flag.DefValue)
+				flag.Changed = false
+			}
+			flag := runCmd.Flags().Lookup("workspace")
+			_ = flag.Value.(interface{ Replace([]string) error }).Replace(nil)
+			flag.Changed = false
+		}()
+		var err error
+		output := captureStdout(t, func() {
+			_ = captureStderr(t, func() {
+				withStdin(t, input, func() { err = runCmd.RunE(runCmd, nil) })
+			})
+		})
+		var result schema.WorkflowResult
+		if err == nil {
+			if jsonErr := json.Unmarshal([]byte(output), &result); jsonErr != nil {
+				t.Fatalf("Expected a JSON result, got %q: %v", output, jsonErr)
+			}
+		}
+		return result, err
+	}
+
+	result, err := run([]string{"packages/web"}, map[string]string{"raw": "true"})
+	if err != nil || result.PermissionDecision != "allow" {
+		t.Errorf("Expected the web workspace to allow, got %+v (err %v)", result, err)
+	}
+	result, err = run([]string{"packages/api"}, map[string]string{"raw": "true"})
+	if err != nil || result.PermissionDecision != "deny" {
+		t.Errorf("Expected the api workspace to deny, got %+v (err %v)", result, err)
+	}
+
+	// Several workspaces share the stdin input and deny if any of them does
+	result, err = run([]string{"packages/web", "packages/api", "packages/docs"}, map[string]string{"raw": "true", "verbose": "true"})
+	if err != nil || result.PermissionDecision != "deny" {
+		t.Fatalf("Expected a combined deny, got %+v (err %v)", result, err)
+	}
+	if !strings.HasPrefix(result.PermissionDecisionReason, "[packages/api] ") || strings.Contains(result.PermissionDecisionReason, "packages/web") {
+		t.Errorf("Expected only the api workspace in the reason, got %q", result.PermissionDecisionReason)
+	}
+	var steps []string
+	for _, step := range result.StepResults {
+		steps = append(steps, step.Name)
+	}
+	if len(steps) != 2 || !strings.HasPrefix(steps[0], "packages/web: ") || !strings.HasPrefix(steps[1], "packages/api: ") {
+		t.Errorf("Expected step names prefixed with their workspace, got %v", steps)
+	}
+	if runOpts.WorkflowDir != "" {
+		t.Errorf("Expected running several workspaces to leave --workflow-dir unset, got %q", runOpts.WorkflowDir)
+	}
+	result, err = run([]string{"packages/web", "packages/docs"}, map[string]string{"raw": "true"})
+	if err != nil || result.PermissionDecision != "allow" {
+		t.Errorf("Expected web and docs to allow, got %+v (err %v)", result, err)
+	}
+
+	errorTests := []struct {
+		workspaces []string
+		flags      map[string]string
+		want       string
+	}{
+		{[]string{"packages/web"}, map[string]string{"dir": root}, "cannot be used with them"},
+		{[]string{"packages/web"}, map[string]string{"workflow-dir": root}, "cannot be used with them"},
+		{[]string{"packages/missing"}, map[string]string{"raw": "true"}, `invalid --workspace "packages/missing"`},
+		{[]string{"packages/web", "packages/api"}, map[string]string{"workflow": "needs-package"}, "more than one --workspace"},
+	}
+	for _, tt := range errorTests {
+		if _, err := run(tt.workspaces, tt.flags); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("--workspace %v with %v: expected error %q, got %v", tt.workspaces, tt.flags, tt.want, err)
+		}
+	}
+}
+
+func TestCacheClearCommand(t *testing.T) {
+	cacheDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cacheDir, "actions", "abc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(cacheDir, "discovery"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacheClearCmd.Flags().Set("cache-dir", cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cacheClearCmd.Flags().Set("cache-dir", "") }()
+
+	output := captureStdout(t, func() {
+		if err := cacheClearCmd.RunE(cacheClearCmd, nil); err != nil {
+			t.Errorf("cache clear: %v", err)
+		}
+	})
+	if !strings.Contains(output, "Cleared cache: "+cacheDir) {
+		t.Errorf("Expected the cleared directory in the output, got %q", output)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the cache directory to be removed, got %v", err)
+	}
+}
+
+func TestConfigCommand(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(home, ".hookflow", "config.yml")
+
+	for _, kv := range [][2]string{{"default.shell", "bash"}, {"log.max-files", "20"}, {"cache.enabled", "false"}} {
+		if err := configSetCmd.RunE(configSetCmd, kv[:]); err != nil {
+			t.Fatalf("config set %s: %v", kv[0], err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "default.shell: bash\n") {
+		t.Errorf("Expected default.shell: bash in the config file, got:\n%s", data)
+	}
+
+	output := captureStdout(t, func() {
+		if err := configGetCmd.RunE(configGetCmd, []string{"default.shell"}); err != nil {
+			t.Errorf("config get: %v", err)
+		}
+	})
+	if output != "bash\n" {
+		t.Errorf("config get default.shell = %q, want bash", output)
+	}
+	var exitErr *exitError
+	if err := configGetCmd.RunE(configGetCmd, []string{"default.dir"}); !errors.As(err, &exitErr) || exitErr.code != 1 {
+		t.Errorf("Expected exit 1 for an unset key, got %v", err)
+	}
+
+	if err := configUnsetCmd.RunE(configUnsetCmd, []string{"log.max-files"}); err != nil {
+		t.Fatalf("config unset: %v", err)
+	}
+	output = captureStdout(t, func() {
+		if err := configListCmd.RunE(configListCmd, nil); err != nil {
+			t.Errorf("config list: %v", err)
+		}
+	})
+	if output != "cache.enabled: false\ndefault.shell: bash\n" {
+		t.Errorf("Unexpected config list output: %q", output)
+	}
+
+	errorTests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"default.shell", "fish"}, `invalid default.shell "fish": must be one of bash, sh, pwsh, powershell, cmd, python, node`},
+		{[]string{"log.level", "loud"}, "unknown log level"},
+		{[]string{"log.max-files", "0"}, "must be a positive number"},
+		{[]string{"cache.enabled", "maybe"}, "must be true or false"},
+		{[]string{"cache.dir", ""}, "must not be empty"},
+		{[]string{"default.color", "red"}, `unknown config key "default.color"`},
+	}
+	for _, tt := range errorTests {
+		if err := configSetCmd.RunE(configSetCmd, tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config set %v: expected error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+	if err := configGetCmd.RunE(configGetCmd, []string{"nope"}); err == nil || !strings.Contains(err.Error(), "unknown config key") {
+		t.Errorf("Expected an unknown key error from get, got %v", err)
+	}
+}
+
+func TestLoadUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	defer func() { userConfig = map[string]string{} }()
+	// t.Setenv restores the variables loadUserConfig sets
+	for _, env := range []string{runner.ShellEnv, workflowDirEnv, logging.LogLevelEnv} {
+		t.Setenv(env, "")
+		_ = os.Unsetenv(env)
+	}
+	t.Setenv(workflowDirEnv, "/from/env")
+
+	path := filepath.Join(home, ".hookflow", "config.yml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("default.shell: sh\ndefault.dir: /from/config\ncache.enabled: false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loadUserConfig()
+	if got := os.Getenv(runner.ShellEnv); got != "sh" {
+		t.Errorf("Expected default.shell to set $%s, got %q", runner.ShellEnv, got)
+	}
+	if got := os.Getenv(workflowDirEnv); got != "/from/env" {
+		t.Errorf("Expected the environment to win over default.dir, got %q", got)
+	}
+	if _, set := os.LookupEnv(logging.LogLevelEnv); set {
+		t.Errorf("Expected unset keys to leave $%s alone", logging.LogLevelEnv)
+	}
+	if !cacheDisabled() {
+		t.Error("Expected cache.enabled: false to disable the cache")
+	}
+
+	// A broken file is reported and ignored
+	userConfig = map[string]string{}
+	if err := os.WriteFile(path, []byte("log.level: loud\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stderr := captureStderr(t, loadUserConfig)
+	if !strings.Contains(stderr, "ignoring config") || os.Getenv(logging.LogLevelEnv) != "" {
+		t.Errorf("Expected the broken config to be ignored, got stderr %q", stderr)
+	}
+	if cacheDisabled() {
+		t.Error("Expected the cache to stay enabled without a config")
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "guard.yml", `name: env-guard
+on:
+  file:
+    paths: ['**/*.env']
+steps:
+  - name: Deny
+    if: event.file.action == 'edit'
+    run: echo "denied ${{ event.file.path }}" && exit 1
+`)
+	writeTestWorkflow(t, tmpDir, "action.yml", `name: action
+on:
+  file:
+    paths: ['**']
+steps:
+  - uses: ./actions/lint
+`)
+	if err := exportCmd.Flags().Set("dir", tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = exportCmd.Flags().Set("dir", "")
+		_ = exportCmd.Flags().Set("workflow", "")
+		_ = exportCmd.Flags().Set("shell", "bash")
+	}()
+
+	// By workflow name as an argument
+	output := captureStdout(t, func() {
+		if err := exportCmd.RunE(exportCmd, []string{"env-guard"}); err != nil {
+			t.Errorf("export: %v", err)
+		}
+	})
+	if !strings.HasPrefix(output, "#!/usr/bin/env bash\n") || !strings.Contains(output, `echo "denied ${HOOKFLOW_EVENT_FILE_PATH}" && exit 1`) {
+		t.Errorf("Expected a bash script reading the event from the environment, got:\n%s", output)
+	}
+
+	// By file name with --workflow
+	_ = exportCmd.Flags().Set("workflow", "guard")
+	output = captureStdout(t, func() {
+		if err := exportCmd.RunE(exportCmd, nil); err != nil {
+			t.Errorf("export --workflow: %v", err)
+		}
+	})
+	if !strings.Contains(output, `"${HOOKFLOW_EVENT_FILE_ACTION}" == 'edit'`) {
+		t.Errorf("Expected the if: condition as a bash test, got:\n%s", output)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		workflow string
+		shell    string
+		want     string
+	}{
+		{"conflicting names", []string{"other"}, "env-guard", "bash", "both as argument"},
+		{"no name", nil, "", "bash", "a workflow name is required"},
+		{"unknown shell", nil, "env-guard", "pwsh", "only bash is supported"},
+		{"unknown workflow", []string{"missing"}, "", "bash", "workflow 'missing' not found"},
+		{"unsupported step", []string{"action"}, "", "bash", "cannot export workflow 'action'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = exportCmd.Flags().Set("workflow", tt.workflow)
+			_ = exportCmd.Flags().Set("shell", tt.shell)
+			err := exportCmd.RunE(exportCmd, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBenchmarkCommand(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "guard.yml", `name: env-guard
+on:
+  file:
+    paths: ['**/*.env']
+steps:
+  - name: Check
+    shell: bash
+    run: echo "checking ${{ event.file.path }}" >> "$BENCH_LOG"
+`)
+	benchLog := filepath.Join(tmpDir, "bench.log")
+	t.Setenv("BENCH_LOG", benchLog)
+	csvPath := filepath.Join(tmpDir, "results.csv")
+
+	flags := map[string]string{"dir": tmpDir, "iterations": "5", "path": "config/.env", "output": csvPath}
+	for name, value := range flags {
+		if err := benchmarkCmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() {
+		for name, value := range map[string]string{"dir": "", "workflow": "", "iterations": "100", "event": "", "path": "", "output": "", "compare": ""} {
+			_ = benchmarkCmd.Flags().Set(name, value)
+		}
+	}()
+
+	output := captureStdout(t, func() {
+		if err := benchmarkCmd.RunE(benchmarkCmd, []string{"env-guard"}); err != nil {
+			t.Errorf("benchmark: %v", err)
+		}
+	})
+	for _, want := range []string{"Benchmarked 'env-guard': 5 iteration(s)", "METRIC", "p50", "p99", "Results written to " + csvPath} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	data, err := os.ReadFile(benchLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "checking config/.env"); got != 5 {
+		t.Errorf("Expected 5 runs against the synthetic file event, got %d:\n%s", got, data)
+	}
+
+	results, err := readBenchmarkCSV(csvPath)
+	if err != nil {
+		t.Fatalf("readBenchmarkCSV: %v", err)
+	}
+	if stats := results["env-guard"]; stats == nil || stats.Iterations != 5 || stats.Min > stats.P50 || stats.P50 > stats.P99 || stats.P99 > stats.Max {
+		t.Errorf("Unexpected results %+v", stats)
+	}
+
+	// --compare reads the same file that --output replaces
+	_ = benchmarkCmd.Flags().Set("compare", csvPath)
+	output = captureStdout(t, func() {
+		if err := benchmarkCmd.RunE(benchmarkCmd, []string{"env-guard"}); err != nil {
+			t.Errorf("benchmark --compare: %v", err)
+		}
+	})
+	if !strings.Contains(output, "PREVIOUS MS") || !strings.Contains(output, "DELTA") {
+		t.Errorf("Expected a comparison table, got:\n%s", output)
+	}
+	_ = benchmarkCmd.Flags().Set("compare", "")
+	_ = benchmarkCmd.Flags().Set("output", "")
+
+	otherCSV := filepath.Join(tmpDir, "other.csv")
+	if err := os.WriteFile(otherCSV, []byte("workflow,iterations,min_ms,max_ms,p50_ms,p99_ms\nlint,10,1,2,1.5,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		flag  string
+		value string
+		want  string
+	}{
+		{"no name", nil, "", "", "a workflow name is required"},
+		{"zero iterations", []string{"env-guard"}, "iterations", "0", "invalid --iterations 0"},
+		{"unknown event", []string{"env-guard"}, "event", "merge", `invalid --event "merge"`},
+		{"unknown workflow", []string{"missing"}, "", "", "workflow 'missing' not found"},
+		{"workflow missing from compare", []string{"env-guard"}, "compare", otherCSV, "no results for workflow 'env-guard'"},
+		{"missing compare file", []string{"env-guard"}, "compare", filepath.Join(tmpDir, "none.csv"), "failed to read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.flag != "" {
+				_ = benchmarkCmd.Flags().Set(tt.flag, tt.value)
+				defer func() { _ = benchmarkCmd.Flags().Set(tt.flag, map[string]string{"iterations": "5"}[tt.flag]) }()
+			}
+			err := benchmarkCmd.RunE(benchmarkCmd, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestBenchmarkStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	stats := summarizeDurations(durations)
+	if stats.Min != time.Millisecond || stats.Max != 100*time.Millisecond || stats.P50 != 50*time.Millisecond || stats.P99 != 99*time.Millisecond {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	single := summarizeDurations([]time.Duration{7 * time.Millisecond})
+	if single.Min != single.P50 || single.P99 != single.Max || single.P50 != 7*time.Millisecond {
+		t.Errorf("Expected every statistic to be the only sample, got %+v", single)
+	}
+
+	if got := formatDelta(11, 10); got != "+1.000 (+10.0%)" {
+		t.Errorf("formatDelta(11, 10) = %q", got)
+	}
+	if got := formatDelta(0.5, 0); got != "+0.500" {
+		t.Errorf("formatDelta(0.5, 0) = %q", got)
+	}
+}
+
+func TestBenchmarkEventType(t *testing.T) {
+	tests := []struct {
+		on   schema.OnConfig
+		want string
+	}{
+		{schema.OnConfig{File: &schema.FileTrigger{}}, "file"},
+		{schema.OnConfig{Commit: &schema.CommitTrigger{}}, "commit"},
+		{schema.OnConfig{Push: &schema.PushTrigger{}}, "push"},
+		{schema.OnConfig{Tool: &schema.ToolTrigger{Name: "bash"}}, "tool"},
+		{schema.OnConfig{Schedule: &schema.ScheduleTrigger{Cron: "0 9 * * *"}}, "schedule"},
+		{schema.OnConfig{}, "file"}, // No trigger to match
+	}
+	for _, tt := range tests {
+		wf := &schema.Workflow{Name: "wf", On: tt.on}
+		if got := benchmarkEventType(wf); got != tt.want {
+			t.Errorf("benchmarkEventType(%+v) = %q, want %q", tt.on, got, tt.want)
+		}
+		evt := benchmarkEvent(wf, tt.want, "", t.TempDir())
+		if !trigger.NewMatcher(wf).Match(evt) && (tt.want != "file" || tt.on.File != nil) {
+			t.Errorf("Expected the synthetic %s event to match %+v", tt.want, tt.on)
+		}
+	}
+}
+
+func TestOutputWorkflowResultAssert(t *testing.T) {
+	defer func() { runOpts = runOptions{} }()
+	allow := schema.NewAllowResult()
+	deny := schema.NewDenyResult("secrets found")
+
+	tests := []struct {
+		name     string
+		format   string
+		assert   string
+		result   *schema.WorkflowResult
+		wantCode int // 0 for no error
+	}{
+		{"deny as expected", outputFormatJSON, "deny", deny, 0},
+		{"allow when deny expected", outputFormatJSON, "deny", allow, 2},
+		{"allow as expected", outputFormatJSON, "allow", allow, 0},
+		{"deny when allow expected", outputFormatJSON, "allow", deny, 2},
+		{"table deny as expected", outputFormatTable, "deny", deny, 0},
+		{"table deny when allow expected", outputFormatTable, "allow", deny, 2},
+		{"no assertion", outputFormatJSON, "", deny, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runOpts = runOptions{OutputFormat: tt.format, AssertDecision: tt.assert}
+			var err error
+			var stdout string
+			stderr := captureStderr(t, func() {
+				stdout = captureStdout(t, func() { err = outputWorkflowResult(tt.result) })
+			})
+
+			var exitErr *exitError
+			switch {
+			case tt.wantCode == 0 && err != nil:
+				t.Errorf("Expected no error, got %v", err)
+			case tt.wantCode != 0 && (!errors.As(err, &exitErr) || exitErr.code != tt.wantCode):
+				t.Errorf("Expected exit code %d, got %v", tt.wantCode, err)
+			}
+			if !strings.Contains(stdout, tt.result.PermissionDecision) {
+				t.Errorf("Expected the result on stdout, got %q", stdout)
+			}
+			if tt.assert != "" && tt.result == deny && !strings.Contains(stderr, "Reason: secrets found") {
+				t.Errorf("Expected the reason on stderr, got %q", stderr)
+			}
+			if tt.wantCode == 2 && !strings.Contains(stderr, "expected "+tt.assert+", got "+tt.result.PermissionDecision) {
+				t.Errorf("Expected the failed assertion on stderr, got %q", stderr)
+			}
+			if tt.assert == "" && stderr != "" {
+				t.Errorf("Expected nothing on stderr without an assertion, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestRunAssertFlags(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	tmpDir := t.TempDir()
+	writeTestWorkflow(t, tmpDir, "no-rm.yml", `name: no-rm
+on:
+  tool:
+    name: bash
+steps:
+  - name: Deny
+    shell: bash
+    run: echo "rm is not allowed" && exit 1
+`)
+	defer func() {
+		for name, value := range map[string]string{"dir": "", "simulate-tool": "", "assert-deny": "false", "assert-allow": "false"} {
+			_ = runCmd.Flags().Set(name, value)
+		}
+		runOpts = runOptions{}
+	}()
+	_ = runCmd.Flags().Set("dir", tmpDir)
+	_ = runCmd.Flags().Set("simulate-tool", "bash")
+
+	var err error
+	_ = runCmd.Flags().Set("assert-deny", "true")
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	})
+	if err != nil {
+		t.Errorf("Expected --assert-deny to pass for a deny, got %v", err)
+	}
+	if !strings.Contains(stderr, "Workflow 'no-rm' blocked") {
+		t.Errorf("Expected the deny reason on stderr, got %q", stderr)
+	}
+
+	// A view call matches no workflow and is allowed
+	_ = runCmd.Flags().Set("simulate-tool", "view")
+	captureStderr(t, func() {
+		captureStdout(t, func() { err = runCmd.RunE(runCmd, []string{}) })
+	})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 2 {
+		t.Errorf("Expected exit code 2 for an unexpected allow, got %v", err)
+	}
+
+	_ = runCmd.Flags().Set("assert-allow", "true")
+	if err := runCmd.RunE(runCmd, []string{}); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("Expected --assert-deny with --assert-allow to fail, got %v", err)
+	}
+}
+
+// TestRunDirDeprecationWarning tests that using --dir for the workflow
+// directory is reported on stderr, and that --workflow-dir silences it
+func TestRunDirDeprecationWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := func(flags map[string]string) string {
+		for name, value := range flags {
+			if err := runCmd.Flags().Set(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer func() {
+			for name := range flags {
+				flag := runCmd.Flags().Lookup(name)
+				_ = flag.Value.Set(flag.DefValue)
+				flag.Changed = false
+			}
+		}()
+		var err error
+		stderr := captureStderr(t, func() {
+			_ = captureStdout(t, func() { err = runCmd.RunE(runCmd, nil) })
+		})
+		if err != nil {
+			t.Fatalf("Flags %v: runCmd.RunE returned error: %v", flags, err)
+		}
+		return stderr
+	}
+
+	stderr := run(map[string]string{"dir": tmpDir, "event": "{}"})
+	if !strings.Contains(stderr, "--dir also selects the workflow directory") {
+		t.Errorf("Expected --dir deprecation warning on stderr, got: %q", stderr)
+	}
+
+	stderr = run(map[string]string{"dir": tmpDir, "workflow-dir": tmpDir, "event": "{}"})
+	if strings.Contains(stderr, "--dir also selects") {
+		t.Errorf("Expected no warning with --workflow-dir, got: %q", stderr)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells hookflow completion can generate scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <shell>",
+	Short: "Generate a shell completion script",
+	Long: `Prints a completion script for bash, zsh, fish, or powershell. The script
+completes subcommands, flags, and workflow names for --workflow.
+
+Load completions for the current session:
+  bash:        source <(hookflow completion bash)
+  zsh:         source <(hookflow completion zsh)
+  fish:        hookflow completion fish | source
+  powershell:  hookflow completion powershell | Out-String | Invoke-Expression
+
+To load them in every session, add the line above to your shell profile.`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             completionShells,
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeWorkflowNames completes --workflow with the workflows found in the
+// directory the command would search
+func completeWorkflowNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir := completionDir(cmd)
+	workflows, err := discoverWorkflows(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, wf := range workflows {
+		if !seen[wf.Name] {
+			seen[wf.Name] = true
+			names = append(names, wf.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionDir returns the workflow root from --workflow-dir, --dir, or
+// $HOOKFLOW_WORKFLOW_DIR, falling back to the current directory
+func completionDir(cmd *cobra.Command) string {
+	for _, name := range []string{"workflow-dir", "dir"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Value.String() != "" {
+			return flag.Value.String()
+		}
+	}
+	if dir := os.Getenv(workflowDirEnv); dir != "" {
+		return dir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
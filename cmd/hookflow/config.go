@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/cache"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+	"github.com/htekdev/gh-hookflow/internal/runner"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configSetting is a key hookflow config accepts
+type configSetting struct {
+	env      string // Environment variable the setting is a default for, if any
+	validate func(value string) error
+}
+
+// configSettings are the supported hookflow config keys
+var configSettings = map[string]configSetting{
+	"default.shell": {env: runner.ShellEnv, validate: func(v string) error {
+		if !schema.IsKnownShell(v) {
+			return fmt.Errorf("must be one of %s", strings.Join(schema.KnownShells, ", "))
+		}
+		return nil
+	}},
+	"default.dir": {env: workflowDirEnv, validate: nonEmpty},
+	"log.level": {env: logging.LogLevelEnv, validate: func(v string) error {
+		_, err := logging.ParseLevel(v)
+		return err
+	}},
+	"log.max-files": {env: logging.MaxLogFilesEnv, validate: func(v string) error {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			return fmt.Errorf("must be a positive number")
+		}
+		return nil
+	}},
+	"cache.dir": {env: cache.DirEnv, validate: nonEmpty},
+	"cache.enabled": {validate: func(v string) error {
+		if _, err := strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		return nil
+	}},
+}
+
+// userConfig holds the settings loaded from the config file at startup
+var userConfig = map[string]string{}
+
+func nonEmpty(v string) error {
+	if v == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+// configPath returns ~/.hookflow/config.yml
+func configPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the home directory: %w", err)
+	}
+	return filepath.Join(home, ".hookflow", "config.yml"), nil
+}
+
+// readConfig reads the settings in path. A missing file has no settings;
+// unknown keys and invalid values are an error.
+func readConfig(path string) (map[string]string, error) {
+	settings := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if settings == nil {
+		settings = map[string]string{}
+	}
+	for _, key := range sortedKeys(settings) {
+		if err := validateSetting(key, settings[key]); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	return settings, nil
+}
+
+// writeConfig writes settings to path, creating its directory
+func writeConfig(path string, settings map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		data = nil
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// validateSetting checks that key is supported and value is valid for it
+func validateSetting(key, value string) error {
+	setting, ok := configSettings[key]
+	if !ok {
+		return fmt.Errorf("unknown config key %q: must be one of %s", key, joinKeys())
+	}
+	if err := setting.validate(value); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return nil
+}
+
+// loadUserConfig reads the config file and makes its settings defaults for
+// the environment variables they stand for; flags and variables that are
+// already set win. It runs before logging starts so log.level applies to
+// the whole run. A broken file is reported and ignored, so it cannot stop
+// hooks or the config command that fixes it.
+func loadUserConfig() {
+	path, err := configPath()
+	if err != nil {
+		return
+	}
+	settings, err := readConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hookflow: ignoring config: %v\n", err)
+		return
+	}
+	userConfig = settings
+	for key, value := range settings {
+		env := configSettings[key].env
+		if _, set := os.LookupEnv(env); env != "" && !set {
+			_ = os.Setenv(env, value)
+		}
+	}
+}
+
+// cacheDisabled reports whether cache.enabled is set to false
+func cacheDisabled() bool {
+	enabled, err := strconv.ParseBool(userConfig["cache.enabled"])
+	return err == nil && !enabled
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and write persistent settings",
+	Long: `Reads and writes settings in ~/.hookflow/config.yml. Settings are defaults:
+the matching flag or environment variable always wins.
+
+Supported keys:
+  default.shell   Shell for steps without shell: ($HOOKFLOW_SHELL, --shell)
+  default.dir     Workflow directory ($HOOKFLOW_WORKFLOW_DIR, --dir)
+  log.level       debug, info, warn or error ($HOOKFLOW_LOG_LEVEL)
+  log.max-files   Denial logs to keep ($HOOKFLOW_MAX_LOG_FILES)
+  cache.dir       Cache directory ($HOOKFLOW_CACHE_DIR, --cache-dir)
+  cache.enabled   false to always run with --no-cache`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting; exits 1 when it is not set",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := configSettings[args[0]]; !ok {
+			return validateSetting(args[0], "")
+		}
+		settings, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		value, ok := settings[args[0]]
+		if !ok {
+			// Like git config, an unset key is reported through the exit code alone
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return &exitError{code: 1}
+		}
+		fmt.Println(value)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Save a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateSetting(args[0], args[1]); err != nil {
+			return err
+		}
+		return updateConfig(func(settings map[string]string) {
+			settings[args[0]] = args[1]
+		})
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := configSettings[args[0]]; !ok {
+			return validateSetting(args[0], "")
+		}
+		return updateConfig(func(settings map[string]string) {
+			delete(settings, args[0])
+		})
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print every setting",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := readConfigFile()
+		if err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(settings) {
+			fmt.Printf("%s: %s\n", key, settings[key])
+		}
+		return nil
+	},
+}
+
+// readConfigFile reads the settings in the config file
+func readConfigFile() (map[string]string, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	return readConfig(path)
+}
+
+// updateConfig applies change to the config file's settings and saves them
+func updateConfig(change func(settings map[string]string)) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	settings, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	change(settings)
+	return writeConfig(path, settings)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinKeys() string {
+	keys := make([]string, 0, len(configSettings))
+	for k := range configSettings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd)
+}
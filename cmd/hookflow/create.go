@@ -74,7 +74,7 @@ func runCreate(dir, prompt, outputName string, dryRun bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println("✓ Workflow generated successfully!")
+	fmt.Printf("%s Workflow generated successfully!\n", symbol(symbolOK))
 	fmt.Println()
 	fmt.Println("---")
 	fmt.Println(result.YAML)
@@ -118,13 +118,13 @@ func runCreate(dir, prompt, outputName string, dryRun bool) error {
 
 	validation := schema.ValidateWorkflow(tempFile)
 	if !validation.Valid {
-		fmt.Println("⚠ Generated workflow has validation issues:")
+		fmt.Printf("%s Generated workflow has validation issues:\n", symbol(symbolWarn))
 		for _, verr := range validation.Errors {
 			fmt.Printf("  - %s\n", verr.Message)
 		}
 		fmt.Println("\nSaving anyway - you may need to fix these issues manually.")
 	} else {
-		fmt.Println("✓ Workflow is valid")
+		fmt.Printf("%s Workflow is valid\n", symbol(symbolOK))
 	}
 
 	// Save the workflow
@@ -132,7 +132,7 @@ func runCreate(dir, prompt, outputName string, dryRun bool) error {
 		return fmt.Errorf("failed to save workflow: %w", err)
 	}
 
-	fmt.Printf("\n✓ Saved to: %s\n", outputPath)
+	fmt.Printf("\n%s Saved to: %s\n", symbol(symbolOK), outputPath)
 	fmt.Println("\nNext steps:")
 	fmt.Printf("  1. Review the workflow: cat %s\n", outputPath)
 	fmt.Printf("  2. Test it: hookflow test --event file --workflow %s\n", outputName)
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/htekdev/gh-hookflow/internal/discover"
+	"github.com/htekdev/gh-hookflow/internal/event"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+	"github.com/htekdev/gh-hookflow/internal/runner"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the local hookflow installation and configuration",
+	Long: `Runs a series of self-checks and prints a suggested fix for each failure:
+
+  - the default shell and every shell used by workflow steps are in PATH
+  - the log directories are writable
+  - .github/hookflows exists and is readable
+  - .github/hooks/hooks.json exists
+  - at least one workflow is found and every workflow is valid
+  - the event detector can parse a sample hook event
+
+Exits non-zero when any check fails.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Checking hookflow in: %s\n", dir)
+		if !printDoctorChecks(runDoctorChecks(dir)) {
+			return &exitError{code: 1}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringP("dir", "d", "", "Directory to check (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+}
+
+// doctorCheck is the outcome of one doctor self-check
+type doctorCheck struct {
+	Name string
+	Err  error  // nil when the check passed
+	Fix  string // Suggested fix when the check failed
+}
+
+// runDoctorChecks runs every self-check against the repository in dir
+func runDoctorChecks(dir string) []doctorCheck {
+	workflowDir := filepath.Join(dir, discover.WorkflowDir)
+	workflows := loadDoctorWorkflows(dir)
+
+	checks := checkShells(workflows)
+	checks = append(checks,
+		checkWritableDir("log directory", logging.LogDir()),
+		checkWritableDir("denial log directory", logging.DenialLogDir()),
+		checkWorkflowDir(workflowDir),
+		checkHooksFile(filepath.Join(dir, ".github", "hooks", "hooks.json")),
+		checkWorkflowsValid(dir, len(workflows)),
+		checkEventDetector(dir),
+	)
+	return checks
+}
+
+// printDoctorChecks prints each check and reports whether all of them passed
+func printDoctorChecks(checks []doctorCheck) bool {
+	failed := 0
+	for _, check := range checks {
+		if check.Err == nil {
+			fmt.Printf("%s %s\n", symbol(symbolOK), check.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("%s %s: %v\n", symbol(symbolFail), check.Name, check.Err)
+		if check.Fix != "" {
+			fmt.Printf("  Fix: %s\n", check.Fix)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed\n", failed, len(checks))
+		return false
+	}
+	fmt.Printf("\nAll %d checks passed\n", len(checks))
+	return true
+}
+
+// loadDoctorWorkflows loads the workflows in dir, skipping any that fail to
+// load; checkWorkflowsValid reports those
+func loadDoctorWorkflows(dir string) []*schema.Workflow {
+	files, err := discoverWorkflows(dir)
+	if err != nil {
+		return nil
+	}
+	var workflows []*schema.Workflow
+	for _, file := range files {
+		if wf, err := schema.LoadWorkflow(file.Path); err == nil {
+			workflows = append(workflows, wf)
+		}
+	}
+	return workflows
+}
+
+// checkShells looks up the default shell and every shell named by a step
+func checkShells(workflows []*schema.Workflow) []doctorCheck {
+	shells := map[string]bool{runner.DefaultShell(): true}
+	for _, wf := range workflows {
+		for _, step := range wf.Steps {
+			if step.Shell != "" {
+				shells[step.Shell] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(shells))
+	for shell := range shells {
+		names = append(names, shell)
+	}
+	sort.Strings(names)
+
+	var checks []doctorCheck
+	for _, shell := range names {
+		check := doctorCheck{Name: "shell " + shell + " is available"}
+		executable := shellExecutable(shell)
+		if _, err := exec.LookPath(executable); err != nil {
+			check.Err = fmt.Errorf("%s not found in PATH", executable)
+			check.Fix = fmt.Sprintf("install %s, or set %s (or --shell) to a shell that is installed", executable, runner.ShellEnv)
+			if executable == "pwsh" {
+				check.Fix = "install PowerShell from https://github.com/PowerShell/PowerShell/releases, or set " + runner.ShellEnv + "=bash (or sh)"
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// shellExecutable returns the program the runner starts for a shell name
+func shellExecutable(shell string) string {
+	if shell == "powershell" {
+		return "pwsh"
+	}
+	return shell
+}
+
+// checkWritableDir creates dir if needed and writes a scratch file to it
+func checkWritableDir(name, dir string) doctorCheck {
+	check := doctorCheck{Name: name + " " + dir + " is writable"}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		check.Err = err
+		check.Fix = "check the permissions of " + filepath.Dir(dir)
+		return check
+	}
+	f, err := os.CreateTemp(dir, "doctor-*")
+	if err != nil {
+		check.Err = err
+		check.Fix = "check the permissions of " + dir
+		return check
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return check
+}
+
+// checkWorkflowDir checks that the workflow directory exists and can be listed
+func checkWorkflowDir(workflowDir string) doctorCheck {
+	check := doctorCheck{Name: "workflow directory " + workflowDir + " is readable"}
+	if _, err := os.ReadDir(workflowDir); err != nil {
+		check.Err = err
+		check.Fix = "run 'hookflow init' to create it"
+		if !os.IsNotExist(err) {
+			check.Fix = "check the permissions of " + workflowDir
+		}
+	}
+	return check
+}
+
+// checkHooksFile checks that the Copilot hooks configuration exists
+func checkHooksFile(hooksFile string) doctorCheck {
+	check := doctorCheck{Name: "hooks configuration " + hooksFile + " exists"}
+	if _, err := os.Stat(hooksFile); err != nil {
+		check.Err = err
+		check.Fix = "run 'hookflow init' to create it"
+	}
+	return check
+}
+
+// checkWorkflowsValid checks that at least one workflow loads and none are invalid
+func checkWorkflowsValid(dir string, loaded int) doctorCheck {
+	check := doctorCheck{Name: "workflows are valid"}
+	result := schema.ValidateWorkflowsInDirWithOptions(dir, validateOptions())
+	switch {
+	case !result.Valid:
+		invalid := make(map[string]bool)
+		for _, err := range result.Errors {
+			invalid[err.File] = true
+		}
+		check.Err = fmt.Errorf("%d invalid workflow file(s)", len(invalid))
+		check.Fix = "run 'hookflow validate' to see the errors"
+	case loaded == 0:
+		check.Err = fmt.Errorf("no workflows found")
+		check.Fix = "add a workflow to " + discover.WorkflowDir + ", or run 'hookflow create'"
+	default:
+		check.Name = fmt.Sprintf("%d valid workflow(s) found", loaded)
+	}
+	return check
+}
+
+// checkEventDetector parses a sample edit hook event
+func checkEventDetector(dir string) doctorCheck {
+	check := doctorCheck{Name: "event detector parses a sample event"}
+	sample, _ := json.Marshal(map[string]interface{}{
+		"toolName": "edit",
+		"toolArgs": map[string]string{"path": "README.md", "old_str": "a", "new_str": "b"},
+		"cwd":      dir,
+	})
+	evt, err := event.NewDetector(nil).DetectFromRawInput(sample)
+	switch {
+	case err != nil:
+		check.Err = err
+	case evt.File == nil || evt.File.Path != "README.md":
+		check.Err = fmt.Errorf("sample edit was not detected as a file event")
+	}
+	if check.Err != nil {
+		check.Fix = "reinstall hookflow; if the problem persists, report it with 'hookflow logs' output"
+	}
+	return check
+}
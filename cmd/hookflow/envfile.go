@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadRunEnv reads every --env-file in order, then the --env overrides, and
+// sets the result in the process environment so workflow steps inherit it.
+// Later files override earlier ones, and --env overrides every file.
+func loadRunEnv(envFiles, envFlags []string) error {
+	vars := make(map[string]string)
+	var order []string
+	set := func(key, value string) {
+		if _, ok := vars[key]; !ok {
+			order = append(order, key)
+		}
+		vars[key] = value
+	}
+
+	for _, path := range envFiles {
+		fileVars, err := parseEnvFile(path)
+		if err != nil {
+			return err
+		}
+		for _, kv := range fileVars {
+			set(kv[0], kv[1])
+		}
+	}
+	for _, flag := range envFlags {
+		key, value, ok := strings.Cut(flag, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --env %q: must be KEY=VALUE", flag)
+		}
+		set(key, value)
+	}
+
+	for _, key := range order {
+		if err := os.Setenv(key, vars[key]); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines from a .env file in file order.
+// Blank lines, # comments and a leading "export " are ignored. Double-quoted
+// values support the \n, \" and \\ escapes, single-quoted values are
+// literal, and unquoted values end at a " #" comment.
+func parseEnvFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var vars [][2]string
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNum)
+		}
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, lineNum, key, err)
+		}
+		vars = append(vars, [2]string{key, value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return vars, nil
+}
+
+// parseEnvValue unquotes a single .env value
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '"':
+		end := closingQuote(value, '"')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated double-quoted value")
+		}
+		return unescapeEnvValue(value[1:end]), nil
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return value[1 : end+1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// unescapeEnvValue expands the escapes dotenv supports in double-quoted
+// values; any other backslash is kept, so Windows paths such as "C:\tools"
+// are read as written
+func unescapeEnvValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '"', '\\':
+				b.WriteByte(s[i+1])
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// closingQuote returns the index of the quote that ends the string starting
+// at s[0], skipping backslash escapes, or -1 when there is none
+func closingQuote(s string, quote byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
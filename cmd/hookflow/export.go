@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/htekdev/gh-hookflow/internal/export"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [workflow]",
+	Short: "Write a workflow as a standalone shell script",
+	Long: `Writes a workflow to stdout as a bash script that runs its steps without
+hookflow, for machines where hookflow cannot be installed.
+
+The workflow is named by argument or --workflow, as a file name or workflow
+name. Expressions that do not depend on the event are evaluated now. Event
+data is read from ` + export.EventEnvPrefix + `* environment variables, e.g.
+${{ event.file.path }} becomes $` + export.EventVar("event.file.path") + `; the
+script header lists the variables it reads. if: conditions become bash tests
+and a failed step skips the rest, as in hookflow.
+
+Steps with uses: or matrix, non-bash shells, and expressions bash cannot
+express, such as regex matches or steps.* references, are reported as errors.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		name, _ := cmd.Flags().GetString("workflow")
+		shell, _ := cmd.Flags().GetString("shell")
+
+		if len(args) == 1 {
+			if name != "" && name != args[0] {
+				return fmt.Errorf("workflow given both as argument %q and --workflow %q", args[0], name)
+			}
+			name = args[0]
+		}
+		if name == "" {
+			return fmt.Errorf("a workflow name is required")
+		}
+		if shell != "bash" {
+			return fmt.Errorf("invalid --shell %q: only bash is supported", shell)
+		}
+
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+
+		path, err := findWorkflowByName(dir, name)
+		if err != nil {
+			return err
+		}
+		wf, err := schema.LoadWorkflow(path)
+		if err != nil {
+			return fmt.Errorf("failed to load workflow: %w", err)
+		}
+
+		script, err := export.Bash(wf)
+		if err != nil {
+			return fmt.Errorf("cannot export workflow '%s': %w", wf.Name, err)
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+	exportCmd.Flags().StringP("workflow", "w", "", "Workflow to export")
+	exportCmd.Flags().String("shell", "bash", "Shell to export to: bash")
+	_ = exportCmd.RegisterFlagCompletionFunc("workflow", completeWorkflowNames)
+}
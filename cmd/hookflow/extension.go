@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/expression"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// extensionsEnv lists Go plugins to load before any command runs, separated
+// like PATH
+const extensionsEnv = "HOOKFLOW_EXTENSIONS"
+
+// extensionSymbol is the function an extension plugin exports
+const extensionSymbol = "Register"
+
+// loadedExtensions maps the absolute path of each loaded plugin to the
+// functions it registered, since a plugin can only be opened once
+var loadedExtensions = make(map[string][]string)
+
+var extensionCmd = &cobra.Command{
+	Use:   "extension <path>...",
+	Short: "Load expression function plugins and list their functions",
+	Long: `Loads Go plugins that add functions to workflow expressions and lists the
+functions each one registers. Use it to check a plugin before adding it to
+$` + extensionsEnv + `, which every hookflow command loads on start, separated
+like PATH.
+
+A plugin is a main package built with go build -buildmode=plugin, using the
+same Go version as hookflow. It exports:
+
+    func Register(register func(name string, fn interface{}) error) error
+
+and calls register once per function. fn must return (interface{}, error)
+and take string, bool, int, float64 or interface{} parameters, optionally
+variadic. Built-in function names cannot be replaced.
+
+Go plugins are supported on Linux and macOS only, and need a hookflow built
+with cgo: the release binaries for macOS and linux/arm64 are cross-compiled
+without it and fail with "plugin: not implemented". Build hookflow from source
+with CGO_ENABLED=1 there. A plugin must be built with the same Go toolchain,
+build flags and versions of every package it shares with hookflow, or it
+fails to load.
+
+A plugin in $` + extensionsEnv + ` that fails to load is reported on stderr and
+skipped by other commands; this command fails instead.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, path := range args {
+			names, err := loadExtension(path)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: %s\n", path, strings.Join(names, ", "))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(extensionCmd)
+}
+
+// loadExtensionsFromEnv loads the plugins listed in $HOOKFLOW_EXTENSIONS. A
+// plugin that fails to load is reported and skipped, so one broken plugin
+// only fails the workflows that call its functions. With strict, as for
+// hookflow extension, the failure is returned instead.
+func loadExtensionsFromEnv(strict bool) error {
+	for _, path := range filepath.SplitList(os.Getenv(extensionsEnv)) {
+		if path == "" {
+			continue
+		}
+		if _, err := loadExtension(path); err != nil {
+			if strict {
+				return fmt.Errorf("%s: %w", extensionsEnv, err)
+			}
+			logging.Context("extension").Warn("%s: skipping %s: %v", extensionsEnv, path, err)
+			fmt.Fprintf(os.Stderr, "%s %s: skipping: %v\n", symbol(symbolWarn), extensionsEnv, err)
+		}
+	}
+	return nil
+}
+
+// loadExtension opens the plugin at path and calls its Register function,
+// returning the names of the functions it registered in sorted order
+func loadExtension(path string) ([]string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if names, ok := loadedExtensions[abs]; ok {
+		return names, nil
+	}
+
+	p, err := plugin.Open(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extension %s: %w", path, err)
+	}
+	sym, err := p.Lookup(extensionSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("extension %s: %w", path, err)
+	}
+	register, ok := sym.(func(func(string, interface{}) error) error)
+	if !ok {
+		return nil, fmt.Errorf("extension %s: %s has type %T, want func(func(string, interface{}) error) error", path, extensionSymbol, sym)
+	}
+
+	var names []string
+	err = register(func(name string, fn interface{}) error {
+		if err := expression.Register(name, fn); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("extension %s: %w", path, err)
+	}
+	sort.Strings(names)
+	loadedExtensions[abs] = names
+	return names, nil
+}
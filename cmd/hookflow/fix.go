@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// fixValidation validates a single file, or every workflow in dir, and
+// applies the mechanical fixes for any errors found. With dryRun the fixes
+// are shown as a diff and nothing is written. It returns how many files
+// were (or would be) changed.
+func fixValidation(dir, file string, dryRun bool) int {
+	var result *schema.ValidationResult
+	if file != "" {
+		result = schema.ValidateWorkflowWithOptions(file, validateOptions())
+	} else {
+		result = schema.ValidateWorkflowsInDirWithOptions(dir, validateOptions())
+	}
+
+	changed := 0
+	seen := make(map[string]bool)
+	for _, verr := range result.Errors {
+		path := verr.File
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		fixes := result.Fixes(path)
+		if len(fixes) == 0 {
+			continue
+		}
+
+		before, after, err := result.FixContent(path)
+		if err != nil {
+			fmt.Printf("%s %s\n  Fix failed: %v\n", symbol(symbolFail), path, err)
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("%s %s: would apply %d fix(es)\n", symbol(symbolWarn), path, len(fixes))
+			for _, line := range lineDiff(string(before), string(after)) {
+				fmt.Println("  " + line)
+			}
+			changed++
+			continue
+		}
+
+		if err := result.Fix(path); err != nil {
+			fmt.Printf("%s %s\n  Fix failed: %v\n", symbol(symbolFail), path, err)
+			continue
+		}
+		fmt.Printf("%s %s: applied %d fix(es)\n", symbol(symbolOK), path, len(fixes))
+		for _, fix := range fixes {
+			fmt.Printf("    - %s\n", fix)
+		}
+		changed++
+	}
+	return changed
+}
+
+// lineDiff compares two texts line by line and returns the changed lines
+// prefixed with - or +, with one unchanged line of context around each change
+func lineDiff(before, after string) []string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, "- "+a[i])
+			i++
+		default:
+			ops = append(ops, "+ "+b[j])
+			j++
+		}
+	}
+
+	// Keep changes plus one line of context on each side
+	changedAt := func(k int) bool {
+		return k >= 0 && k < len(ops) && !strings.HasPrefix(ops[k], "  ")
+	}
+	var lines []string
+	for k, op := range ops {
+		if changedAt(k) || changedAt(k-1) || changedAt(k+1) {
+			lines = append(lines, op)
+		}
+	}
+	return lines
+}
@@ -51,7 +51,7 @@ func runInit(dir string, force bool) error {
 	if err := os.MkdirAll(hookflowsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create hookflows directory: %w", err)
 	}
-	fmt.Printf("✓ Created %s\n", hookflowsDir)
+	fmt.Printf("%s Created %s\n", symbol(symbolOK), hookflowsDir)
 
 	// Create .github/hooks directory for Copilot CLI hooks.json
 	hooksDir := filepath.Join(dir, ".github", "hooks")
@@ -62,13 +62,13 @@ func runInit(dir string, force bool) error {
 	// Create hooks.json in .github/hooks/ (the standard Copilot CLI location)
 	hooksFile := filepath.Join(hooksDir, "hooks.json")
 	if _, err := os.Stat(hooksFile); err == nil && !force {
-		fmt.Printf("⚠ %s already exists (use --force to overwrite)\n", hooksFile)
+		fmt.Printf("%s %s already exists (use --force to overwrite)\n", symbol(symbolWarn), hooksFile)
 	} else {
 		hooksContent := generateHooksJSON()
 		if err := os.WriteFile(hooksFile, []byte(hooksContent), 0644); err != nil {
 			return fmt.Errorf("failed to create hooks.json: %w", err)
 		}
-		fmt.Printf("✓ Created %s\n", hooksFile)
+		fmt.Printf("%s Created %s\n", symbol(symbolOK), hooksFile)
 	}
 
 	// Create example workflow in .github/hookflows/
@@ -76,31 +76,31 @@ func runInit(dir string, force bool) error {
 	if _, err := os.Stat(exampleWorkflow); os.IsNotExist(err) {
 		exampleContent := generateExampleWorkflow()
 		if err := os.WriteFile(exampleWorkflow, []byte(exampleContent), 0644); err != nil {
-			fmt.Printf("⚠ Could not create example workflow: %v\n", err)
+			fmt.Printf("%s Could not create example workflow: %v\n", symbol(symbolWarn), err)
 		} else {
-			fmt.Printf("✓ Created %s\n", exampleWorkflow)
+			fmt.Printf("%s Created %s\n", symbol(symbolOK), exampleWorkflow)
 		}
 	}
 
 	// Create skill directory and SKILL.md
 	skillDir := filepath.Join(dir, ".github", "skills", "hookflow")
 	if err := os.MkdirAll(skillDir, 0755); err != nil {
-		fmt.Printf("⚠ Could not create skill directory: %v\n", err)
+		fmt.Printf("%s Could not create skill directory: %v\n", symbol(symbolWarn), err)
 	} else {
 		skillFile := filepath.Join(skillDir, "SKILL.md")
 		if _, err := os.Stat(skillFile); err == nil && !force {
-			fmt.Printf("⚠ %s already exists (use --force to overwrite)\n", skillFile)
+			fmt.Printf("%s %s already exists (use --force to overwrite)\n", symbol(symbolWarn), skillFile)
 		} else {
 			skillContent := generateSkillMD()
 			if err := os.WriteFile(skillFile, []byte(skillContent), 0644); err != nil {
-				fmt.Printf("⚠ Could not create SKILL.md: %v\n", err)
+				fmt.Printf("%s Could not create SKILL.md: %v\n", symbol(symbolWarn), err)
 			} else {
-				fmt.Printf("✓ Created %s\n", skillFile)
+				fmt.Printf("%s Created %s\n", symbol(symbolOK), skillFile)
 			}
 		}
 	}
 
-	fmt.Println("\n✓ hookflow initialized successfully!")
+	fmt.Printf("\n%s hookflow initialized successfully!\n", symbol(symbolOK))
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Create a workflow: hookflow create \"block edits to .env files\"")
 	fmt.Println("  2. Or edit the example workflow in .github/hookflows/example.yml")
@@ -153,7 +153,7 @@ on:
     paths:
       - '**/.env'
       - '**/.env.*'
-    types:
+    actions:
       - edit
       - create
 
@@ -221,14 +221,14 @@ on:
   file:
     lifecycle: pre
     paths: ['**/*.env']
-    types: [create]
+    actions: [create]
 
 # Lint after file is edited (post)
 on:
   file:
     lifecycle: post
     paths: ['**/*.ts']
-    types: [edit]
+    actions: [edit]
 ` + "```" + `
 
 ### File Trigger
@@ -244,7 +244,7 @@ on:
       - 'secrets/**'
     paths-ignore:       # Patterns to exclude
       - '**/*.md'
-    types:              # Event types: create, edit, delete
+    actions:            # File actions: create, edit, delete
       - edit
       - create
 ` + "```" + `
@@ -269,6 +269,7 @@ Matches git commit events.
 on:
   commit:
     lifecycle: pre        # pre (default) or post
+    branch: main          # Glob on the branch being committed to
     paths:              # Files that must be in the commit
       - 'src/**'
     paths-ignore:
@@ -347,7 +348,7 @@ on:
       - '**/secrets/**'
       - '**/*.pem'
       - '**/*.key'
-    types: [edit, create]
+    actions: [edit, create]
 blocking: true
 steps:
   - name: Deny sensitive file access
@@ -363,7 +364,7 @@ name: Validate JSON
 on:
   file:
     paths: ['**/*.json']
-    types: [edit, create]
+    actions: [edit, create]
 blocking: true
 steps:
   - name: Check JSON syntax
@@ -380,7 +381,7 @@ on:
   file:
     lifecycle: post        # Run AFTER the edit
     paths: ['**/*.ts', '**/*.tsx']
-    types: [edit]
+    actions: [edit]
 blocking: false            # Non-blocking - just report
 steps:
   - name: Run ESLint
@@ -397,7 +398,7 @@ on:
   file:
     lifecycle: pre
     paths: ['**/*.js', '**/*.ts', '**/*.py']
-    types: [edit, create]
+    actions: [edit, create]
 blocking: true
 steps:
   - name: Check for passwords
@@ -431,7 +432,7 @@ steps:
 
 1. Check trigger type matches event (file vs tool vs commit)
 2. Verify path patterns use correct glob syntax
-3. Ensure ` + "`types`" + ` field matches the action (edit/create/delete)
+3. Ensure ` + "`actions`" + ` field matches the action (edit/create/delete)
 4. Check ` + "`lifecycle`" + ` matches hook type (pre = preToolUse, post = postToolUse)
 
 ### Pre vs Post Confusion
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validate workflow files and check their style",
+	Long: `Runs everything validate does and also warns about style issues:
+
+  - steps without a name
+  - run scripts longer than 200 characters, which read better as script files
+  - blocking workflows where no step can fail
+  - workflow names used by more than one file
+  - file, commit and push triggers without a lifecycle, which default to pre
+
+Warnings do not affect the exit code unless --strict is given.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runValidate(cmd, true)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+	lintCmd.Flags().StringP("file", "f", "", "Specific file to lint")
+	lintCmd.Flags().Bool("watch", false, "Re-lint whenever workflow files change")
+	lintCmd.Flags().Bool("fix", false, "Repair unknown fields and missing names before linting")
+	lintCmd.Flags().Bool("fix-dry-run", false, "Show the changes --fix would make without writing them")
+	lintCmd.Flags().Bool("strict", false, "Exit non-zero when there are warnings")
+	lintCmd.Flags().String("format", validateFormatText, "Output format: text or json")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var listTriggersCmd = &cobra.Command{
+	Use:   "list-triggers",
+	Short: "Show which events each workflow listens to",
+	Long: `Discovers all workflows and lists their triggers as a table of
+workflow, trigger type, details (tool names, path patterns, lifecycle) and
+the workflow description.
+
+Use --event-type to show only one trigger type, and --sort to order rows by
+workflow name (default) or trigger type.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		eventType, _ := cmd.Flags().GetString("event-type")
+		sortBy, _ := cmd.Flags().GetString("sort")
+
+		if eventType != "" && !isTriggerType(eventType) {
+			return fmt.Errorf("invalid --event-type %q: must be one of %s", eventType, strings.Join(triggerTypes, ", "))
+		}
+		if sortBy != sortByWorkflow && sortBy != sortByType {
+			return fmt.Errorf("invalid --sort %q: must be %s or %s", sortBy, sortByWorkflow, sortByType)
+		}
+
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+
+		workflows, err := discoverWorkflows(dir)
+		if err != nil {
+			return fmt.Errorf("failed to discover workflows: %w", err)
+		}
+
+		var rows []triggerRow
+		for _, file := range workflows {
+			wf, err := schema.LoadWorkflow(file.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s %s: %v\n", symbol(symbolWarn), file.RelPath, err)
+				continue
+			}
+			for _, row := range workflowTriggers(wf) {
+				if eventType == "" || row.Type == eventType {
+					rows = append(rows, row)
+				}
+			}
+		}
+
+		if len(rows) == 0 {
+			fmt.Println("No triggers found")
+			return nil
+		}
+
+		sortTriggerRows(rows, sortBy)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "WORKFLOW\tTRIGGER TYPE\tDETAILS\tDESCRIPTION")
+		for _, row := range rows {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.Workflow, row.Type, row.Details, row.Description)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listTriggersCmd)
+
+	listTriggersCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+	listTriggersCmd.Flags().StringP("event-type", "t", "", "Only show this trigger type: "+strings.Join(triggerTypes, ", "))
+	listTriggersCmd.Flags().String("sort", sortByWorkflow, "Sort rows by: workflow or type")
+}
+
+// triggerTypes lists the trigger types in the order they appear in on:
+var triggerTypes = []string{"hooks", "tool", "file", "commit", "push", "schedule"}
+
+// Sort orders for list-triggers
+const (
+	sortByWorkflow = "workflow"
+	sortByType     = "type"
+)
+
+// triggerRow is one line of list-triggers output
+type triggerRow struct {
+	Workflow    string
+	Type        string
+	Details     string
+	Description string // Workflow description, shortened for the table
+}
+
+// isTriggerType reports whether t is a known trigger type
+func isTriggerType(t string) bool {
+	for _, known := range triggerTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTriggerRows orders rows by workflow name or trigger type, keeping
+// each workflow's triggers in declaration order
+func sortTriggerRows(rows []triggerRow, sortBy string) {
+	typeOrder := make(map[string]int, len(triggerTypes))
+	for i, t := range triggerTypes {
+		typeOrder[t] = i
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if sortBy == sortByType && a.Type != b.Type {
+			return typeOrder[a.Type] < typeOrder[b.Type]
+		}
+		return a.Workflow < b.Workflow
+	})
+}
+
+// workflowTriggers describes each trigger in a workflow's on: section
+func workflowTriggers(wf *schema.Workflow) []triggerRow {
+	var rows []triggerRow
+	add := func(triggerType string, details ...string) {
+		rows = append(rows, triggerRow{
+			Workflow:    wf.Name,
+			Type:        triggerType,
+			Details:     joinDetails(details),
+			Description: previewDescription(wf.Description),
+		})
+	}
+
+	on := wf.On
+	if on.Hooks != nil {
+		add("hooks",
+			listDetail("types", on.Hooks.Types),
+			listDetail("tools", on.Hooks.Tools),
+			valueDetail("hook_type", on.Hooks.HookType))
+	}
+	tools := on.Tools
+	if on.Tool != nil {
+		tools = append([]schema.ToolTrigger{*on.Tool}, tools...)
+	}
+	for _, tool := range tools {
+		add("tool",
+			listDetail("name", tool.ToolNames()),
+			valueDetail("lifecycle", tool.GetLifecycle()),
+			argsDetail(tool.Args),
+			valueDetail("if", tool.If))
+	}
+	if on.File != nil {
+		add("file",
+			valueDetail("lifecycle", on.File.GetLifecycle()),
+			listDetail("actions", on.File.GetActions()),
+			listDetail("paths", on.File.Paths),
+			listDetail("paths-ignore", on.File.PathsIgnore),
+			countDetail("min-changed-lines", int64(on.File.MinChangedLines)),
+			countDetail("min-bytes", on.File.MinBytes),
+			countDetail("max-bytes", on.File.MaxBytes))
+	}
+	if on.Commit != nil {
+		add("commit",
+			valueDetail("lifecycle", on.Commit.GetLifecycle()),
+			listDetail("paths", on.Commit.Paths),
+			listDetail("paths-ignore", on.Commit.PathsIgnore),
+			listDetail("branches", on.Commit.Branches),
+			listDetail("branches-ignore", on.Commit.BranchesIgnore),
+			valueDetail("author", on.Commit.Author),
+			valueDetail("branch", on.Commit.Branch))
+	}
+	if on.Push != nil {
+		add("push",
+			valueDetail("lifecycle", on.Push.GetLifecycle()),
+			listDetail("paths", on.Push.Paths),
+			listDetail("paths-ignore", on.Push.PathsIgnore),
+			listDetail("branches", on.Push.Branches),
+			listDetail("branches-ignore", on.Push.BranchesIgnore),
+			listDetail("tags", on.Push.Tags),
+			listDetail("tags-ignore", on.Push.TagsIgnore))
+	}
+	if on.Schedule != nil {
+		add("schedule", valueDetail("cron", on.Schedule.Cron))
+	}
+	return rows
+}
+
+// valueDetail formats a single trigger setting, or "" when unset
+func valueDetail(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	return key + ": " + value
+}
+
+// countDetail formats a numeric trigger setting, or "" when zero
+func countDetail(key string, n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return valueDetail(key, fmt.Sprint(n))
+}
+
+// listDetail formats a list trigger setting, or "" when empty
+func listDetail(key string, values []string) string {
+	return valueDetail(key, strings.Join(values, ", "))
+}
+
+// argsDetail formats tool argument patterns in key order
+func argsDetail(args map[string]string) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+args[k])
+	}
+	return listDetail("args", pairs)
+}
+
+// joinDetails joins the non-empty details, or returns "(any)" when the
+// trigger has no filters
+func joinDetails(details []string) string {
+	var parts []string
+	for _, d := range details {
+		if d != "" {
+			parts = append(parts, d)
+		}
+	}
+	if len(parts) == 0 {
+		return "(any)"
+	}
+	return strings.Join(parts, "; ")
+}
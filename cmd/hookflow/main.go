@@ -3,14 +3,22 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/htekdev/gh-hookflow/internal/cache"
 	"github.com/htekdev/gh-hookflow/internal/discover"
 	"github.com/htekdev/gh-hookflow/internal/event"
 	"github.com/htekdev/gh-hookflow/internal/logging"
@@ -23,6 +31,10 @@ import (
 var version = "0.1.0"
 
 func main() {
+	// Settings from ~/.hookflow/config.yml are defaults for flags and
+	// environment variables, and log.level must apply before logging starts
+	loadUserConfig()
+
 	// Initialize logging (errors are non-fatal)
 	_ = logging.Init()
 	defer logging.Close()
@@ -30,12 +42,26 @@ func main() {
 	logging.Info("hookflow started, version=%s, args=%v", version, os.Args)
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		logging.Error("command failed: %v", err)
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// exitError requests a non-zero exit status after output has already been
+// written, e.g. a deny decision in table output
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "hookflow",
 	Short: "Local workflow engine for agentic DevOps",
@@ -43,6 +69,59 @@ var rootCmd = &cobra.Command{
 Copilot agent hooks, file changes, commits, and pushes.
 
 Workflows are defined in .github/hookflows/*.yml using a GitHub Actions-like syntax.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if logging.NoColorEnv() {
+			noColor = true
+		}
+		if noColor {
+			logging.SetNoColor(true)
+		}
+		if shellFlag != "" {
+			if !schema.IsKnownShell(shellFlag) {
+				return fmt.Errorf("invalid --shell %q: must be one of %s", shellFlag, strings.Join(schema.KnownShells, ", "))
+			}
+			runner.SetDefaultShell(shellFlag)
+		}
+		return loadExtensionsFromEnv(cmd == extensionCmd)
+	},
+}
+
+// shellFlag is the --shell default for steps without a shell, overriding $HOOKFLOW_SHELL
+var shellFlag string
+
+// allowUnknownShells is --allow-unknown-shells: accept step shells outside schema.KnownShells
+var allowUnknownShells bool
+
+// validateOptions returns the validation options set by the global flags
+func validateOptions() schema.ValidateOptions {
+	return schema.ValidateOptions{AllowUnknownShells: allowUnknownShells}
+}
+
+// noColor replaces Unicode status symbols with plain text and strips ANSI
+// escapes from output. Set by --no-color or the NO_COLOR environment variable.
+var noColor bool
+
+// Status symbols used in CLI output
+const (
+	symbolOK   = "✓"
+	symbolFail = "✗"
+	symbolWarn = "⚠"
+)
+
+// symbol returns a status symbol, or its plain-text form when color is disabled
+func symbol(s string) string {
+	if !noColor {
+		return s
+	}
+	switch s {
+	case symbolOK:
+		return "OK"
+	case symbolFail:
+		return "FAIL"
+	case symbolWarn:
+		return "WARN"
+	}
+	return s
 }
 
 var versionCmd = &cobra.Command{
@@ -59,17 +138,39 @@ var logsCmd = &cobra.Command{
 	Long: `Display hookflow logs for debugging.
 
 Logs are stored in ~/.hookflow/logs/ with daily rotation.
-Enable debug logging by setting HOOKFLOW_DEBUG=1.
+Only warnings and errors are logged by default. Set HOOKFLOW_LOG_LEVEL to
+debug, info, warn or error to change that; HOOKFLOW_DEBUG=1 means debug.
 
 Examples:
-  hookflow logs              # Show last 50 lines of today's log
-  hookflow logs -n 100       # Show last 100 lines
-  hookflow logs --path       # Print log file path (for scripting)
-  hookflow logs -f           # Follow log output`,
+  hookflow logs               # Show last 50 lines of today's log
+  hookflow logs -n 100        # Show last 100 lines
+  hookflow logs --path        # Print log file path (for scripting)
+  hookflow logs -f            # Follow log output
+  hookflow logs --clean       # Delete old denial logs
+  hookflow logs --workflow ci # Only lines from runs of the "ci" workflow
+
+Workflow runs are logged at info level, so --workflow finds only their
+warnings and errors unless hooks run with HOOKFLOW_LOG_LEVEL=info.
+
+Denial logs written when a workflow blocks are kept in the system temp
+directory under hookflow-denials/. Only the newest 50 are kept; set
+HOOKFLOW_MAX_LOG_FILES to change the limit.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pathOnly, _ := cmd.Flags().GetBool("path")
 		tail, _ := cmd.Flags().GetInt("tail")
 		follow, _ := cmd.Flags().GetBool("follow")
+		clean, _ := cmd.Flags().GetBool("clean")
+		workflowName, _ := cmd.Flags().GetString("workflow")
+
+		if clean {
+			dir := logging.DenialLogDir()
+			removed, err := logging.PruneLogs(dir, logging.MaxLogFiles())
+			if err != nil {
+				return fmt.Errorf("failed to clean denial logs: %w", err)
+			}
+			fmt.Printf("Removed %d old denial log(s) from: %s\n", removed, dir)
+			return nil
+		}
 
 		logPath := logging.LogPath()
 		if logPath == "" {
@@ -86,7 +187,7 @@ Examples:
 		// Check if log file exists
 		if _, err := os.Stat(logPath); os.IsNotExist(err) {
 			fmt.Printf("No logs found at: %s\n", logPath)
-			fmt.Println("\nTo enable logging, run hookflow commands with HOOKFLOW_DEBUG=1")
+			fmt.Println("\nTo log more, run hookflow commands with HOOKFLOW_LOG_LEVEL=info or HOOKFLOW_DEBUG=1")
 			return nil
 		}
 
@@ -95,23 +196,50 @@ Examples:
 		fmt.Printf("Log dir:  %s\n", logging.LogDir())
 		fmt.Println(strings.Repeat("-", 60))
 
+		var filter func(string) bool
+		if workflowName != "" {
+			filter = workflowLineFilter(workflowName)
+		}
+
 		// Read and display log file
 		if follow {
-			return followLog(logPath)
+			return followLog(logPath, filter)
 		}
 
-		return tailLog(logPath, tail)
+		return tailLog(logPath, tail, filter)
 	},
 }
 
-// tailLog shows the last n lines of the log file
-func tailLog(path string, n int) error {
+// workflowLineFilter matches log lines tagged with the given workflow name
+func workflowLineFilter(name string) func(string) bool {
+	return func(line string) bool {
+		run, ok := logging.ParseWorkflowRunLog(line)
+		return ok && run.WorkflowName == name
+	}
+}
+
+// tailLog shows the last n lines of the log file that pass filter,
+// or the last n lines when filter is nil
+func tailLog(path string, n int, filter func(string) bool) error {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read log file: %w", err)
 	}
 
 	lines := strings.Split(string(content), "\n")
+	if filter != nil {
+		var kept []string
+		for _, line := range lines {
+			if filter(line) {
+				kept = append(kept, line)
+			}
+		}
+		lines = kept
+		if len(kept) == 0 {
+			fmt.Println("No matching lines. Workflow runs are logged at info level; set HOOKFLOW_LOG_LEVEL=info where hooks run to record them.")
+			return nil
+		}
+	}
 
 	// Get last n lines
 	start := len(lines) - n
@@ -127,8 +255,9 @@ func tailLog(path string, n int) error {
 	return nil
 }
 
-// followLog tails the log file continuously (like tail -f)
-func followLog(path string) error {
+// followLog tails the log file continuously (like tail -f), printing only
+// complete lines that pass filter when one is given
+func followLog(path string, filter func(string) bool) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
@@ -142,13 +271,26 @@ func followLog(path string) error {
 	fmt.Println()
 
 	buf := make([]byte, 1024)
+	var pending string
 	for {
 		n, err := file.Read(buf)
 		if err != nil && err != io.EOF {
 			return err
 		}
 		if n > 0 {
-			fmt.Print(string(buf[:n]))
+			if filter == nil {
+				fmt.Print(string(buf[:n]))
+			} else {
+				// Hold back a trailing partial line until it is complete
+				pending += string(buf[:n])
+				lines := strings.Split(pending, "\n")
+				pending = lines[len(lines)-1]
+				for _, line := range lines[:len(lines)-1] {
+					if filter(line) {
+						fmt.Println(line)
+					}
+				}
+			}
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
@@ -157,9 +299,19 @@ func followLog(path string) error {
 var discoverCmd = &cobra.Command{
 	Use:   "discover",
 	Short: "Discover workflow files in the current directory",
-	Long:  `Searches for .github/hookflows/*.yml files and lists them.`,
+	Long: `Searches for .yml and .yaml files in .github/hookflows and its
+subdirectories and lists them.
+
+With --json, the files are printed as a JSON array with each file's name,
+path, relative path, modification time and discovery time (RFC3339).
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir, _ := cmd.Flags().GetString("dir")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
 		if dir == "" {
 			var err error
 			dir, err = os.Getwd()
@@ -167,6 +319,13 @@ var discoverCmd = &cobra.Command{
 				return err
 			}
 		}
+		if jsonOutput {
+			workflows, err := discoverWorkflows(dir)
+			if err != nil {
+				return fmt.Errorf("failed to discover workflows: %w", err)
+			}
+			return printDiscoveredJSON(workflows)
+		}
 		fmt.Printf("Discovering workflows in: %s\n", dir)
 
 		// Import discover package and call Discover
@@ -182,61 +341,255 @@ var discoverCmd = &cobra.Command{
 
 		fmt.Printf("Found %d workflow(s):\n", len(workflows))
 		for _, wf := range workflows {
-			fmt.Printf("  - %s (%s)\n", wf.Name, wf.RelPath)
+			details := []string{wf.RelPath}
+			var description string
+			if loaded, err := schema.LoadWorkflow(wf.Path); err == nil {
+				description = previewDescription(loaded.Description)
+				if loaded.Extends != "" {
+					details = append(details, "extends "+loaded.Extends)
+				}
+				if tools := workflowToolNames(loaded); len(tools) > 0 {
+					details = append(details, "tools: "+strings.Join(tools, ", "))
+				}
+			}
+			line := fmt.Sprintf("  - %s (%s)", wf.Name, strings.Join(details, ", "))
+			if description != "" {
+				line += " - " + description
+			}
+			fmt.Println(line)
 		}
 		return nil
 	},
 }
 
+// discoveredWorkflow is one file in the output of discover --json
+type discoveredWorkflow struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	RelPath  string `json:"relPath"`
+	ModTime  string `json:"modTime"`  // RFC3339
+	LoadedAt string `json:"loadedAt"` // RFC3339
+}
+
+// printDiscoveredJSON prints discovered workflow files as a JSON array
+func printDiscoveredJSON(files []discover.WorkflowFile) error {
+	out := make([]discoveredWorkflow, 0, len(files))
+	for _, f := range files {
+		out = append(out, discoveredWorkflow{
+			Name:     f.Name,
+			Path:     f.Path,
+			RelPath:  f.RelPath,
+			ModTime:  f.ModTime.Format(time.RFC3339),
+			LoadedAt: f.LoadedAt.Format(time.RFC3339),
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// descriptionPreviewLength is the most characters of a workflow description shown in listings
+const descriptionPreviewLength = 60
+
+// previewDescription puts a workflow description on one line and shortens
+// it to descriptionPreviewLength characters for listings
+func previewDescription(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if runes := []rune(description); len(runes) > descriptionPreviewLength {
+		return string(runes[:descriptionPreviewLength-3]) + "..."
+	}
+	return description
+}
+
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate workflow files",
-	Long:  `Validates workflow YAML files against the schema.`,
+	Long: `Validates workflow YAML files against the schema.
+
+With --watch, validation re-runs whenever a workflow file is created, modified
+or deleted, until interrupted with Ctrl+C.
+
+With --fix, mechanical errors are repaired in place before validating: unknown
+fields are removed and a missing or empty name is set from the file name.
+Other errors are reported as usual. --fix-dry-run shows the changes as a diff
+without writing them.
+
+Warnings do not affect the exit code unless --strict is given.
+
+With --format json, the result is printed as one JSON object with "valid",
+"errors" and "warnings". Every error and warning has a "code", such as
+unknown-field or invalid-glob, for tools that classify them.
+
+The search directory is --dir, then $HOOKFLOW_WORKFLOW_DIR, then the current directory.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		dir, _ := cmd.Flags().GetString("dir")
-		file, _ := cmd.Flags().GetString("file")
+		return runValidate(cmd, false)
+	},
+}
 
-		if dir == "" {
-			var err error
-			dir, err = os.Getwd()
-			if err != nil {
-				return err
-			}
+// runValidate implements validate and, with lint, the lint command
+func runValidate(cmd *cobra.Command, lint bool) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	file, _ := cmd.Flags().GetString("file")
+	watch, _ := cmd.Flags().GetBool("watch")
+	fix, _ := cmd.Flags().GetBool("fix")
+	fixDryRun, _ := cmd.Flags().GetBool("fix-dry-run")
+	strict, _ := cmd.Flags().GetBool("strict")
+	format, _ := cmd.Flags().GetString("format")
+
+	switch format {
+	case validateFormatText:
+	case validateFormatJSON:
+		if watch || fix || fixDryRun {
+			return fmt.Errorf("--format %s cannot be combined with --watch, --fix or --fix-dry-run", validateFormatJSON)
 		}
+	default:
+		return fmt.Errorf("invalid --format %q: must be %s or %s", format, validateFormatText, validateFormatJSON)
+	}
 
-		// Validate specific file or directory
-		var result *schema.ValidationResult
-		if file != "" {
-			fmt.Printf("Validating file: %s\n", file)
-			result = schema.ValidateWorkflow(file)
-		} else {
-			fmt.Printf("Validating workflows in: %s\n", dir)
-			result = schema.ValidateWorkflowsInDir(dir)
+	if dir == "" {
+		dir = os.Getenv(workflowDirEnv)
+	}
+	if dir == "" {
+		var err error
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
 		}
+	}
 
-		// Print results
-		if result.Valid {
-			if file != "" {
-				fmt.Printf("✓ File is valid\n")
-			} else {
-				fmt.Printf("✓ All workflows are valid\n")
-			}
-			return nil
+	if fix || fixDryRun {
+		if fixValidation(dir, file, fixDryRun) > 0 {
+			fmt.Println()
+		}
+	}
+
+	var valid bool
+	if format == validateFormatJSON {
+		valid = printValidationJSON(dir, file, lint, strict)
+	} else {
+		valid = printValidation(dir, file, lint, strict)
+	}
+	if !watch {
+		if !valid {
+			os.Exit(1)
 		}
+		return nil
+	}
+
+	root := filepath.Join(dir, discover.WorkflowDir)
+	if file != "" {
+		root = file
+	}
+	fmt.Printf("\nWatching %s for changes (Ctrl+C to stop)\n", root)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return watchFiles(ctx, root, watchInterval, func() {
+		fmt.Printf("\n[%s] Change detected, re-validating\n", time.Now().Format("15:04:05"))
+		printValidation(dir, file, lint, strict)
+	})
+}
+
+// printValidation validates a single file, or every workflow in dir, and
+// prints the results. With lint, style warnings are added. It reports
+// whether everything was valid; with strict, any warning counts as invalid.
+func printValidation(dir, file string, lint, strict bool) bool {
+	if file != "" {
+		fmt.Printf("Validating file: %s\n", file)
+	} else {
+		fmt.Printf("Validating workflows in: %s\n", dir)
+	}
+	result := validateWorkflows(dir, file, lint)
+
+	// Print warnings - these only affect the exit code in strict mode
+	for _, warning := range result.Warnings {
+		fmt.Printf("%s %s\n", symbol(symbolWarn), warning.File)
+		fmt.Printf("  Warning [%s]: %s\n", warning.Code, warning.Message)
+	}
+	if strict && result.Valid && len(result.Warnings) > 0 {
+		fmt.Printf("%s %d warning(s) found in strict mode\n", symbol(symbolFail), len(result.Warnings))
+		return false
+	}
 
-		// Print errors
-		for _, err := range result.Errors {
-			fmt.Printf("✗ %s\n", err.File)
-			fmt.Printf("  Error: %s\n", err.Message)
-			for _, detail := range err.Details {
-				fmt.Printf("    - %s\n", detail)
+	// Print results
+	if result.Valid {
+		if file != "" {
+			fmt.Printf("%s File is valid\n", symbol(symbolOK))
+			if wf, err := schema.LoadWorkflow(file); err == nil {
+				if wf.Description != "" {
+					fmt.Printf("  Description: %s\n", strings.Join(strings.Fields(wf.Description), " "))
+				}
+				if tools := workflowToolNames(wf); len(tools) > 0 {
+					fmt.Printf("  Tools: %s\n", strings.Join(tools, ", "))
+				}
 			}
+		} else {
+			fmt.Printf("%s All workflows are valid\n", symbol(symbolOK))
 		}
+		return true
+	}
 
-		// Exit with error code
-		os.Exit(1)
-		return nil
-	},
+	// Print errors
+	for _, err := range result.Errors {
+		fmt.Printf("%s %s\n", symbol(symbolFail), err.File)
+		fmt.Printf("  Error [%s]: %s\n", err.Code, err.Message)
+		for _, detail := range err.Details {
+			fmt.Printf("    - %s\n", detail)
+		}
+	}
+
+	return false
+}
+
+// Output formats for validate and lint
+const (
+	validateFormatText = "text"
+	validateFormatJSON = "json"
+)
+
+// validationReport is the output of validate --format json
+type validationReport struct {
+	Valid    bool                       `json:"valid"`
+	Errors   []schema.ValidationError   `json:"errors"`
+	Warnings []schema.ValidationWarning `json:"warnings"`
+}
+
+// printValidationJSON validates like printValidation but prints the result
+// as one JSON object, for editors and CI tools. Each error and warning
+// carries a code to classify it by.
+func printValidationJSON(dir, file string, lint, strict bool) bool {
+	result := validateWorkflows(dir, file, lint)
+	report := validationReport{
+		Valid:    result.Valid && !(strict && len(result.Warnings) > 0),
+		Errors:   result.Errors,
+		Warnings: result.Warnings,
+	}
+	if report.Errors == nil {
+		report.Errors = []schema.ValidationError{}
+	}
+	if report.Warnings == nil {
+		report.Warnings = []schema.ValidationWarning{}
+	}
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+	return report.Valid
+}
+
+// validateWorkflows validates a single file, or every workflow in dir, adding
+// style warnings with lint
+func validateWorkflows(dir, file string, lint bool) *schema.ValidationResult {
+	validateFile, validateDir := schema.ValidateWorkflowWithOptions, schema.ValidateWorkflowsInDirWithOptions
+	if lint {
+		validateFile, validateDir = schema.LintWorkflowWithOptions, schema.LintWorkflowsInDirWithOptions
+	}
+	if file != "" {
+		return validateFile(file, validateOptions())
+	}
+	return validateDir(dir, validateOptions())
 }
 
 var runCmd = &cobra.Command{
@@ -247,13 +600,208 @@ var runCmd = &cobra.Command{
 Use --raw to pass raw Copilot hook input (toolName, toolArgs, cwd) and let the CLI
 detect the event type automatically. This is the preferred mode for hook scripts.
 
-Use --event to pass a pre-built event JSON (legacy mode).`,
+Use --event-vars path=value with --raw to override properties of the detected
+event while debugging, e.g. --event-vars cwd=/tmp or --event-vars file.action=create.
+
+Use --event to pass a pre-built event JSON (legacy mode).
+
+Use --stdin-format to name the format of the input on stdin instead: copilot
+for Copilot hook input, which must have a toolName; json for event JSON, like
+--event -; or raw, the same as --raw.
+
+Use --replay with the "Full logs" file from a denial to re-run that workflow
+with the same event.
+
+Use --simulate-tool (with --simulate-args JSON and --simulate-lifecycle) to run
+the workflows for a tool call without a hook: the tool event is built directly
+and no file, commit or push event is detected.
+
+Use --assert-deny or --assert-allow in test scripts: the command exits 0 when
+the decision is the expected one and 2 when it is not, and prints the deny
+reason to stderr, e.g. hookflow run --raw --assert-deny < input.json.
+
+Use --match-all to run every workflow against the event, ignoring their on:
+triggers; step if: conditions are still evaluated. This is for debugging
+workflows only and must not be used in hook scripts, where it would run every
+workflow on every tool call. Combine it with --dry-run to see which steps would
+run without running them: steps are reported as skipped (dry run).
+
+Use --workspace <path> in a monorepo to run a package's own workflows from the
+repository root: it is short for --dir <path> --workflow-dir <path>, with path
+relative to the current directory. Repeat it to run the event against each
+workspace's workflows independently; the result denies if any workspace denies
+and the reason names the workspace.
+
+Workflows are found under --workflow-dir, then --dir, then $HOOKFLOW_WORKFLOW_DIR,
+then the current directory.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		eventStr, _ := cmd.Flags().GetString("event")
 		workflow, _ := cmd.Flags().GetString("workflow")
 		dir, _ := cmd.Flags().GetString("dir")
 		raw, _ := cmd.Flags().GetBool("raw")
 		eventType, _ := cmd.Flags().GetString("event-type")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		workflowDir, _ := cmd.Flags().GetString("workflow-dir")
+		outputFormat, _ := cmd.Flags().GetString("output-format")
+		continueOnWorkflowError, _ := cmd.Flags().GetBool("continue-on-workflow-error")
+		scheduleNow, _ := cmd.Flags().GetBool("schedule-now")
+		profile, _ := cmd.Flags().GetBool("profile")
+		contextFlags, _ := cmd.Flags().GetStringArray("context")
+		noCache, _ := cmd.Flags().GetBool("no-cache")
+		parallel, _ := cmd.Flags().GetBool("parallel")
+		parallelLimit, _ := cmd.Flags().GetInt("parallel-limit")
+		eventSchema, _ := cmd.Flags().GetString("event-schema")
+		maxWorkflows, _ := cmd.Flags().GetInt("max-workflows")
+		replay, _ := cmd.Flags().GetString("replay")
+		envFiles, _ := cmd.Flags().GetStringArray("env-file")
+		envFlags, _ := cmd.Flags().GetStringArray("env")
+		simulateTool, _ := cmd.Flags().GetString("simulate-tool")
+		simulateArgs, _ := cmd.Flags().GetString("simulate-args")
+		simulateLifecycle, _ := cmd.Flags().GetString("simulate-lifecycle")
+		eventVarFlags, _ := cmd.Flags().GetStringArray("event-vars")
+		cacheDir, _ := cmd.Flags().GetString("cache-dir")
+		matchAll, _ := cmd.Flags().GetBool("match-all")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		assertDeny, _ := cmd.Flags().GetBool("assert-deny")
+		assertAllow, _ := cmd.Flags().GetBool("assert-allow")
+		workflowGlobs, _ := cmd.Flags().GetStringArray("workflow-glob")
+		outputFile, _ := cmd.Flags().GetString("output-file")
+		stdinFormat, _ := cmd.Flags().GetString("stdin-format")
+		appendOutput, _ := cmd.Flags().GetBool("append")
+		workspaces, _ := cmd.Flags().GetStringArray("workspace")
+
+		if !cmd.Flags().Changed("no-cache") && cacheDisabled() {
+			noCache = true
+		}
+
+		if outputFormat != outputFormatJSON && outputFormat != outputFormatTable {
+			return fmt.Errorf("invalid --output-format %q: must be %s or %s", outputFormat, outputFormatJSON, outputFormatTable)
+		}
+		if stdinFormat != "" {
+			if raw || eventStr != "" {
+				return fmt.Errorf("--stdin-format cannot be used with --raw or --event")
+			}
+			switch stdinFormat {
+			case stdinFormatCopilot, stdinFormatRaw:
+				raw = true
+			case stdinFormatJSON:
+				eventStr = "-"
+			default:
+				return fmt.Errorf("invalid --stdin-format %q: must be %s, %s or %s", stdinFormat, stdinFormatCopilot, stdinFormatJSON, stdinFormatRaw)
+			}
+		}
+		if outputFile != "" && outputFormat == outputFormatTable {
+			return fmt.Errorf("--output-file writes the JSON result and cannot be used with --output-format %s", outputFormatTable)
+		}
+		if appendOutput && outputFile == "" {
+			return fmt.Errorf("--append requires --output-file")
+		}
+		if parallelLimit < 1 {
+			return fmt.Errorf("invalid --parallel-limit %d: must be at least 1", parallelLimit)
+		}
+		if maxWorkflows < 0 {
+			return fmt.Errorf("invalid --max-workflows %d: must be 0 (no limit) or more", maxWorkflows)
+		}
+		for _, pattern := range workflowGlobs {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid --workflow-glob %q: %w", pattern, err)
+			}
+		}
+		if eventSchema != "" && raw {
+			return fmt.Errorf("--event-schema validates --event JSON and cannot be used with --raw")
+		}
+		if replay != "" && (raw || eventStr != "" || workflow != "") {
+			return fmt.Errorf("--replay reads the workflow and event from the log and cannot be used with --raw, --event or --workflow")
+		}
+		if simulateTool == "" && (simulateArgs != "{}" || simulateLifecycle != "pre") {
+			return fmt.Errorf("--simulate-args and --simulate-lifecycle require --simulate-tool")
+		}
+		if simulateTool != "" && (raw || eventStr != "" || workflow != "" || replay != "") {
+			return fmt.Errorf("--simulate-tool builds the event itself and cannot be used with --raw, --event, --workflow or --replay")
+		}
+		if simulateLifecycle != "pre" && simulateLifecycle != "post" {
+			return fmt.Errorf("invalid --simulate-lifecycle %q: must be pre or post", simulateLifecycle)
+		}
+		if len(eventVarFlags) > 0 && !raw {
+			return fmt.Errorf("--event-vars patches the detected hook event and requires --raw")
+		}
+		if matchAll && (workflow != "" || replay != "" || scheduleNow) {
+			return fmt.Errorf("--match-all selects workflows by ignoring triggers and cannot be used with --workflow, --replay or --schedule-now")
+		}
+		if len(workspaces) > 0 && (dir != "" || workflowDir != "") {
+			return fmt.Errorf("--workspace sets --dir and --workflow-dir and cannot be used with them")
+		}
+		if len(workspaces) > 1 && (workflow != "" || replay != "" || scheduleNow) {
+			return fmt.Errorf("more than one --workspace cannot be used with --workflow, --replay or --schedule-now")
+		}
+		if assertDeny && assertAllow {
+			return fmt.Errorf("--assert-deny and --assert-allow cannot be used together")
+		}
+		var assertDecision string
+		switch {
+		case assertDeny:
+			assertDecision = "deny"
+		case assertAllow:
+			assertDecision = "allow"
+		}
+		contextVars, err := parseContextFlags(contextFlags)
+		if err != nil {
+			return err
+		}
+		eventVars, err := parseEventVars(eventVarFlags)
+		if err != nil {
+			return err
+		}
+		if err := loadRunEnv(envFiles, envFlags); err != nil {
+			return err
+		}
+		workspaceDirs, err := resolveWorkspaces(workspaces)
+		if err != nil {
+			return err
+		}
+		if len(workspaceDirs) == 1 {
+			dir, workflowDir = workspaceDirs[0], workspaceDirs[0]
+		}
+		// A deny in table output is reported through the exit code alone
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if matchAll {
+			logging.Warn("--match-all ignores workflow triggers and runs every workflow; use it only for debugging")
+			fmt.Fprintf(os.Stderr, "%s --match-all ignores workflow triggers and runs every workflow; use it only for debugging\n", symbol(symbolWarn))
+		}
+		if cmd.Flags().Changed("dir") && workflowDir == "" {
+			logging.Warn("--dir also selects the workflow directory; this dual use is deprecated, set --workflow-dir explicitly")
+			fmt.Fprintf(os.Stderr, "%s --dir also selects the workflow directory; this dual use is deprecated, set --workflow-dir explicitly\n", symbol(symbolWarn))
+		}
+
+		// Flags take precedence over the environment
+		if workflowDir == "" && dir == "" {
+			workflowDir = os.Getenv(workflowDirEnv)
+		}
+
+		runOpts = runOptions{
+			Verbose:                 verbose,
+			WorkflowDir:             workflowDir,
+			OutputFormat:            outputFormat,
+			ContinueOnWorkflowError: continueOnWorkflowError,
+			Profile:                 profile,
+			Context:                 contextVars,
+			NoCache:                 noCache,
+			Parallel:                parallel,
+			ParallelLimit:           parallelLimit,
+			EventSchema:             eventSchema,
+			MaxWorkflows:            maxWorkflows,
+			EventVars:               eventVars,
+			CacheDir:                cacheDir,
+			MatchAll:                matchAll,
+			DryRun:                  dryRun,
+			AssertDecision:          assertDecision,
+			WorkflowGlobs:           workflowGlobs,
+			OutputFile:              outputFile,
+			AppendOutput:            appendOutput,
+			StdinFormat:             stdinFormat,
+		}
 
 		// Convert event type to lifecycle
 		lifecycle := eventTypeToLifecycle(eventType)
@@ -266,18 +814,68 @@ Use --event to pass a pre-built event JSON (legacy mode).`,
 			}
 		}
 
-		// If workflow is specified, load and run it
-		if workflow != "" {
-			return runWorkflow(dir, workflow)
+		run := func(target runTarget) error {
+			// Fire every scheduled workflow immediately
+			if scheduleNow {
+				return fireSchedule(target, "")
+			}
+
+			// Re-run the workflow and event recorded in a denial log
+			if replay != "" {
+				return replayDenialLog(target, replay)
+			}
+
+			// Run workflows for a synthetic tool call
+			if simulateTool != "" {
+				evt, err := simulateToolEvent(target.dir, simulateTool, simulateArgs, simulateLifecycle)
+				if err != nil {
+					return err
+				}
+				return runMatchingWorkflowsWithEvent(target, evt)
+			}
+
+			// If workflow is specified, load and run it
+			if workflow != "" {
+				return runWorkflow(target, workflow)
+			}
+
+			// If --raw flag is set, use the new event detection
+			if raw {
+				return runWithRawInput(target, eventStr, eventType, lifecycle)
+			}
+
+			// Legacy mode: pre-built event JSON
+			return runMatchingWorkflows(target, eventStr, lifecycle)
 		}
 
-		// If --raw flag is set, use the new event detection
-		if raw {
-			return runWithRawInput(dir, eventStr, lifecycle)
+		if len(workspaceDirs) > 1 {
+			// Every workspace sees the same input, so stdin is read once
+			if eventStr == "-" || (raw && eventStr == "") {
+				input, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return fmt.Errorf("failed to read stdin: %w", err)
+				}
+				eventStr = string(input)
+			}
+			return runWorkspaces(workspaces, workspaceDirs, run)
 		}
+		return run(newRunTarget(dir))
+	},
+}
+
+var eventSchemaCmd = &cobra.Command{
+	Use:   "event-schema",
+	Short: "Print the JSON Schema for run --event input",
+	Long: `Prints the canonical JSON Schema for the event JSON accepted by hookflow run --event.
+
+Save it and pass it back with --event-schema, or use it to check the events your
+hook scripts build:
 
-		// Legacy mode: pre-built event JSON
-		return runMatchingWorkflows(dir, eventStr, lifecycle)
+  hookflow event-schema > event.schema.json
+  hookflow run --event "$EVENT" --event-schema event.schema.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(string(schema.EventSchema()))
 	},
 }
 
@@ -291,6 +889,7 @@ var triggersCmd = &cobra.Command{
 		fmt.Println("  file     - File create/edit events")
 		fmt.Println("  commit   - Git commit events")
 		fmt.Println("  push     - Git push events")
+		fmt.Println("  schedule - Cron schedules (run by hookflow serve)")
 	},
 }
 
@@ -302,41 +901,321 @@ func init() {
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(triggersCmd)
 	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(eventSchemaCmd)
+
+	// global flags
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable Unicode symbols and ANSI colors in output (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&allowUnknownShells, "allow-unknown-shells", false, "Accept step shells other than "+strings.Join(schema.KnownShells, ", ")+", run as <shell> -c <command>")
+	rootCmd.PersistentFlags().StringVar(&shellFlag, "shell", "", "Default shell for steps without shell: ("+strings.Join(schema.KnownShells, ", ")+"); overrides $HOOKFLOW_SHELL")
 
 	// discover flags
-	discoverCmd.Flags().StringP("dir", "d", "", "Directory to search (default: current directory)")
+	discoverCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+	discoverCmd.Flags().Bool("json", false, "Print the workflow files as JSON")
 
 	// validate flags
-	validateCmd.Flags().StringP("dir", "d", "", "Directory to search (default: current directory)")
+	validateCmd.Flags().StringP("dir", "d", "", "Directory to search (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
 	validateCmd.Flags().StringP("file", "f", "", "Specific file to validate")
+	validateCmd.Flags().Bool("watch", false, "Re-validate whenever workflow files change")
+	validateCmd.Flags().Bool("fix", false, "Repair unknown fields and missing names before validating")
+	validateCmd.Flags().Bool("fix-dry-run", false, "Show the changes --fix would make without writing them")
+	validateCmd.Flags().Bool("strict", false, "Exit non-zero when there are warnings")
+	validateCmd.Flags().String("format", validateFormatText, "Output format: text or json")
 
 	// run flags
 	runCmd.Flags().StringP("event", "e", "", "Event JSON (use '-' for stdin)")
 	runCmd.Flags().StringP("workflow", "w", "", "Specific workflow to run")
-	runCmd.Flags().StringP("dir", "d", "", "Directory to search (default: current directory)")
+	runCmd.Flags().StringP("dir", "d", "", "Working directory for steps; also used to find workflows unless --workflow-dir is set (deprecated dual use)")
+	runCmd.Flags().String("workflow-dir", "", "Directory containing .github/hookflows (default: --dir, then $HOOKFLOW_WORKFLOW_DIR)")
+	runCmd.Flags().String("output-format", outputFormatJSON, "Output format: json or table")
+	runCmd.Flags().Bool("continue-on-workflow-error", false, "Run all matching workflows even after one denies and report every result")
+	runCmd.Flags().Bool("schedule-now", false, "Immediately run every workflow with an on.schedule trigger")
 	runCmd.Flags().BoolP("raw", "r", false, "Accept raw hook input and auto-detect event type")
-	runCmd.Flags().StringP("event-type", "t", "preToolUse", "Hook event type: preToolUse or postToolUse")
+	runCmd.Flags().StringP("event-type", "t", "preToolUse", "Hook event type: preToolUse, postToolUse, or a custom type mapped by $HOOKFLOW_LIFECYCLE_MAP")
+	runCmd.Flags().Bool("verbose", false, "Include per-step results in the JSON output")
+	runCmd.Flags().Bool("profile", false, "Include per-step timings in the output")
+	runCmd.Flags().StringArray("context", nil, "Expression variable as key=value, read as ${{ ctx.key }} (repeatable)")
+	runCmd.Flags().Bool("no-cache", false, "Rescan workflows and re-fetch remote uses: actions instead of using the cache")
+	runCmd.Flags().String("cache-dir", "", "Directory for cached workflow discovery and remote actions (default $HOOKFLOW_CACHE_DIR or ~/.hookflow/cache)")
+	runCmd.Flags().Bool("parallel", false, "Run all matching workflows concurrently; deny if any workflow denies")
+	runCmd.Flags().Int("parallel-limit", defaultParallelLimit, "Most workflows to run at once with --parallel")
+	runCmd.Flags().Int("max-workflows", defaultMaxWorkflows, "Most matching workflows to run, in sorted file path order; 0 disables the limit")
+	runCmd.Flags().String("event-schema", "", "JSON Schema file to validate the --event JSON against before running")
+	runCmd.Flags().String("replay", "", "Re-run the workflow and event recorded in a denial log file")
+	runCmd.Flags().StringArray("env-file", nil, "Load KEY=VALUE environment variables for steps from a .env file (repeatable; later files win)")
+	runCmd.Flags().StringArray("env", nil, "Environment variable for steps as KEY=VALUE, overriding --env-file (repeatable)")
+	runCmd.Flags().String("simulate-tool", "", "Run workflows for a call to this tool, without event detection")
+	runCmd.Flags().String("simulate-args", "{}", "Tool arguments for --simulate-tool as a JSON object")
+	runCmd.Flags().String("simulate-lifecycle", "pre", "Lifecycle for --simulate-tool: pre or post")
+	runCmd.Flags().Bool("assert-deny", false, "Exit 0 if the decision is deny and 2 if it is allow, for test scripts")
+	runCmd.Flags().Bool("assert-allow", false, "Exit 0 if the decision is allow and 2 if it is deny, for test scripts")
+	runCmd.Flags().StringArray("workflow-glob", nil, "Only consider workflow files whose name matches this pattern, e.g. 'security-*.yml' (repeatable; any match)")
+	runCmd.Flags().String("stdin-format", "", "Format of the input on stdin: copilot (hook input), json (event JSON) or raw (same as --raw)")
+	runCmd.Flags().String("output-file", "", "Write the JSON result to this file instead of stdout")
+	runCmd.Flags().Bool("append", false, "With --output-file, append the result as one JSON line instead of replacing the file")
+	runCmd.Flags().Bool("match-all", false, "Run every workflow, ignoring trigger conditions (debugging only; do not use in hook scripts)")
+	runCmd.Flags().Bool("dry-run", false, "Evaluate workflows and step if: conditions without running any steps")
+	runCmd.Flags().StringArray("workspace", nil, "Package directory, relative to the current directory, to use as --dir and --workflow-dir (repeatable; each runs independently)")
+	runCmd.Flags().StringArray("event-vars", nil, "Override an event property as path=value after --raw detection, e.g. cwd=/tmp or file.action=create (repeatable)")
+	_ = runCmd.RegisterFlagCompletionFunc("workflow", completeWorkflowNames)
 
 	// logs flags
 	logsCmd.Flags().IntP("tail", "n", 50, "Number of lines to show")
 	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output (like tail -f)")
 	logsCmd.Flags().Bool("path", false, "Only print log path (for scripting)")
+	logsCmd.Flags().String("workflow", "", "Only show lines from runs of this workflow (runs are logged with HOOKFLOW_LOG_LEVEL=info)")
+	logsCmd.Flags().Bool("clean", false, "Delete denial logs beyond $HOOKFLOW_MAX_LOG_FILES (default 50)")
+}
+
+// runOptions holds settings from run command flags that shape workflow output
+type runOptions struct {
+	Verbose                 bool              // Include per-step results in the output
+	WorkflowDir             string            // Root containing .github/hookflows, overrides the --dir default
+	OutputFormat            string            // json (default) or table
+	ContinueOnWorkflowError bool              // Run every matching workflow instead of stopping at the first deny
+	Profile                 bool              // Add step timings to the output
+	Context                 map[string]string // Values from --context, exposed to expressions as ctx
+	NoCache                 bool              // Re-fetch remote uses: actions instead of using the action cache
+	Parallel                bool              // Run matching workflows concurrently
+	ParallelLimit           int               // Most workflows running at once with Parallel
+	EventSchema             string            // JSON Schema file the --event JSON must satisfy
+	MaxWorkflows            int               // Most matching workflows to run; 0 means no limit
+	EventVars               []eventVar        // Overrides from --event-vars, applied to the detected raw event
+	CacheDir                string            // Directory for discovery results and remote actions; empty for the default
+	MatchAll                bool              // Run every workflow regardless of its triggers, for debugging
+	DryRun                  bool              // Report the steps that would run instead of running them
+	AssertDecision          string            // Decision required by --assert-deny or --assert-allow; empty for none
+	WorkflowGlobs           []string          // --workflow-glob patterns; a workflow file must match one by base name
+	OutputFile              string            // Write the JSON result to this file instead of stdout
+	AppendOutput            bool              // Append the result to OutputFile as one JSON line instead of replacing it
+	StdinFormat             string            // Format named by --stdin-format; empty when it is not set
+}
+
+// Output formats for hookflow run
+const (
+	outputFormatJSON  = "json"
+	outputFormatTable = "table"
+)
+
+// Input formats for hookflow run --stdin-format
+const (
+	stdinFormatCopilot = "copilot"
+	stdinFormatJSON    = "json"
+	stdinFormatRaw     = "raw"
+)
+
+// workflowDirEnv overrides the workflow discovery directory when no flag is given
+const workflowDirEnv = "HOOKFLOW_WORKFLOW_DIR"
+
+// runOpts holds the options for the current run invocation
+var runOpts runOptions
+
+// parseContextFlags turns repeated --context key=value flags into a map.
+// A later flag overrides an earlier one with the same key.
+func parseContextFlags(flags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --context %q: must be key=value", flag)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// eventVar is one --event-vars override of an event property
+type eventVar struct {
+	Path  []string // Property path, e.g. [file action]
+	Value string
+}
+
+// parseEventVars parses --event-vars path=value flags, keeping their order
+// so a later override of the same path wins
+func parseEventVars(flags []string) ([]eventVar, error) {
+	vars := make([]eventVar, 0, len(flags))
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		path := strings.Split(key, ".")
+		if !ok || slices.Contains(path, "") {
+			return nil, fmt.Errorf("invalid --event-vars %q: must be path=value, e.g. file.action=create", flag)
+		}
+		vars = append(vars, eventVar{Path: path, Value: value})
+	}
+	return vars, nil
+}
+
+// applyEventVars returns evt with each override applied to its JSON form.
+// Missing objects along a path are created; the value keeps the type of the
+// property it replaces, so numbers and booleans are parsed.
+func applyEventVars(evt *schema.Event, vars []eventVar) (*schema.Event, error) {
+	encoded, err := json.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, err
+	}
+
+	for _, v := range vars {
+		name := strings.Join(v.Path, ".")
+		parent := data
+		for _, key := range v.Path[:len(v.Path)-1] {
+			switch child := parent[key].(type) {
+			case map[string]interface{}:
+				parent = child
+			case nil:
+				created := make(map[string]interface{})
+				parent[key] = created
+				parent = created
+			default:
+				return nil, fmt.Errorf("invalid --event-vars %s: %s is not an object", name, key)
+			}
+		}
+
+		leaf := v.Path[len(v.Path)-1]
+		switch parent[leaf].(type) {
+		case float64:
+			n, err := strconv.ParseFloat(v.Value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --event-vars %s: %q is not a number", name, v.Value)
+			}
+			parent[leaf] = n
+		case bool:
+			b, err := strconv.ParseBool(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --event-vars %s: %q is not a boolean", name, v.Value)
+			}
+			parent[leaf] = b
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("invalid --event-vars %s: cannot replace an object or array with a value", name)
+		default:
+			parent[leaf] = v.Value
+		}
+	}
+
+	encoded, err = json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	patched := &schema.Event{}
+	if err := json.Unmarshal(encoded, patched); err != nil {
+		return nil, fmt.Errorf("invalid --event-vars: %w", err)
+	}
+	return patched, nil
+}
+
+// newRunner creates a runner for wf with the current run's --context values
+func newRunner(wf *schema.Workflow, evt *schema.Event, dir string) *runner.Runner {
+	r := runner.NewRunnerWithOptions(wf, evt, dir, runner.RunnerOptions{DryRun: runOpts.DryRun})
+	r.SetContextVars(runOpts.Context)
+	r.SetNoActionCache(runOpts.NoCache)
+	r.SetActionCacheDir(cache.NewFileCache(runOpts.CacheDir).ActionsDir())
+	return r
+}
+
+// workflowRoot returns the directory that contains .github/hookflows for the current run
+func workflowRoot(dir string) string {
+	if runOpts.WorkflowDir != "" {
+		return runOpts.WorkflowDir
+	}
+	return dir
 }
 
-// eventTypeToLifecycle converts Copilot hook event type to workflow lifecycle
+// runTarget is where one run finds its workflows and sends its result
+type runTarget struct {
+	dir    string                             // Working directory for steps and path normalization
+	root   string                             // Directory containing .github/hookflows
+	output func(*schema.WorkflowResult) error // Receives the final result
+}
+
+// newRunTarget returns the target for dir, with workflows under
+// --workflow-dir or dir and the result output as usual
+func newRunTarget(dir string) runTarget {
+	return runTarget{dir: dir, root: workflowRoot(dir), output: outputWorkflowResult}
+}
+
+// lifecycleMapEnv maps custom hook event types to lifecycles, e.g. notification=post,background=post
+const lifecycleMapEnv = schema.LifecycleMapEnv
+
+// eventTypeToLifecycle converts a hook event type to a workflow lifecycle.
+// Copilot's preToolUse and postToolUse map to pre and post; other types are
+// looked up in $HOOKFLOW_LIFECYCLE_MAP and otherwise used as the lifecycle as-is.
 func eventTypeToLifecycle(eventType string) string {
 	switch eventType {
+	case "", "preToolUse", "pre":
+		return "pre"
 	case "postToolUse", "post":
 		return "post"
+	}
+	if lifecycle, ok := parseLifecycleMap(os.Getenv(lifecycleMapEnv))[eventType]; ok {
+		return lifecycle
+	}
+	return eventType
+}
+
+// parseLifecycleMap parses comma-separated type=lifecycle pairs, logging
+// and skipping malformed entries
+func parseLifecycleMap(value string) map[string]string {
+	mapping, invalid := schema.ParseLifecycleMap(value)
+	for _, entry := range invalid {
+		logging.Warn("ignoring invalid %s entry %q: expected type=lifecycle", lifecycleMapEnv, entry)
+	}
+	return mapping
+}
+
+// lifecycleToHookType converts a workflow lifecycle back to the Copilot hook
+// event type. Custom lifecycles are passed through unchanged.
+func lifecycleToHookType(lifecycle string) string {
+	switch lifecycle {
+	case "pre":
+		return "preToolUse"
+	case "post":
+		return "postToolUse"
 	default:
-		return "pre" // preToolUse, pre, or any unknown defaults to pre
+		return lifecycle
+	}
+}
+
+// eventHookType returns the hook type recorded on a --raw event: the
+// --event-type value as given, so hook_type can match custom types mapped by
+// $HOOKFLOW_LIFECYCLE_MAP, except that the built-in pre and post (or no
+// type) become preToolUse and postToolUse
+func eventHookType(eventType, lifecycle string) string {
+	switch eventType {
+	case "", "pre", "post":
+		return lifecycleToHookType(lifecycle)
 	}
+	return eventType
+}
+
+// simulateToolEvent builds the tool event for --simulate-tool. Unlike --raw,
+// no file, commit or push event is detected from the tool and its args.
+func simulateToolEvent(dir, toolName, argsJSON, lifecycle string) (*schema.Event, error) {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil || args == nil {
+		return nil, fmt.Errorf("invalid --simulate-args %q: must be a JSON object", argsJSON)
+	}
+
+	hookType := lifecycleToHookType(lifecycle)
+	tool := &schema.ToolEvent{
+		Name:     toolName,
+		Args:     args,
+		HookType: hookType,
+	}
+	return &schema.Event{
+		Hook:      &schema.HookEvent{Type: hookType, Tool: tool, Cwd: dir},
+		Tool:      tool,
+		Cwd:       dir,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Lifecycle: lifecycle,
+	}, nil
 }
 
 // runWorkflow loads and executes a specific workflow
-func runWorkflow(dir, workflowName string) error {
+func runWorkflow(target runTarget, workflowName string) error {
 	// Try to find the workflow file
-	path, found := findWorkflowFile(dir, workflowName)
+	path, found := findWorkflowFile(target.root, workflowName)
 	if !found {
 		return fmt.Errorf("workflow '%s' not found", workflowName)
 	}
@@ -349,17 +1228,61 @@ func runWorkflow(dir, workflowName string) error {
 
 	// Execute the workflow
 	ctx := context.Background()
-	r := runner.NewRunner(wf, nil, dir)
+	r := newRunner(wf, nil, target.dir)
 	result := r.RunWithBlocking(ctx)
 
 	// Output the result as JSON
-	return outputWorkflowResult(result)
+	return target.output(result)
+}
+
+// replayDenialLog re-runs the workflow recorded in a denial log with the
+// event it was denied for
+func replayDenialLog(target runTarget, logFile string) error {
+	denial, err := runner.ReadDenialLog(logFile)
+	if err != nil {
+		return err
+	}
+	if denial.Event == nil {
+		return fmt.Errorf("%s has no recorded event; it was written before --replay support", logFile)
+	}
+
+	path, err := findWorkflowByName(target.root, denial.Workflow)
+	if err != nil {
+		return err
+	}
+	wf, err := schema.LoadWorkflow(path)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow: %w", err)
+	}
+
+	logging.Context("run").Info("replaying workflow %s from %s (execution %s)", denial.Workflow, logFile, denial.ExecutionID)
+	result := newRunner(wf, denial.Event, target.dir).RunWithBlocking(context.Background())
+	return target.output(result)
+}
+
+// findWorkflowByName returns the workflow file whose name: is name, trying a
+// file named after it first
+func findWorkflowByName(dir, name string) (string, error) {
+	if path, found := findWorkflowFile(dir, name); found {
+		return path, nil
+	}
+	workflows, err := discoverWorkflows(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, wfFile := range workflows {
+		wf, err := schema.LoadWorkflow(wfFile.Path)
+		if err == nil && wf.Name == name {
+			return wfFile.Path, nil
+		}
+	}
+	return "", fmt.Errorf("workflow '%s' not found", name)
 }
 
 // runWithRawInput handles raw Copilot hook input and auto-detects event type
-func runWithRawInput(dir, inputStr, lifecycle string) error {
+func runWithRawInput(target runTarget, inputStr, eventType, lifecycle string) error {
 	log := logging.Context("run")
-	done := logging.StartOperation("runWithRawInput", "dir="+dir, "lifecycle="+lifecycle)
+	done := logging.StartOperation("runWithRawInput", "dir="+target.dir, "lifecycle="+lifecycle)
 
 	// Read from stdin if "-"
 	var input []byte
@@ -379,7 +1302,7 @@ func runWithRawInput(dir, inputStr, lifecycle string) error {
 		log.Debug("empty input, allowing by default")
 		result := schema.NewAllowResult()
 		done(nil)
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 
 	log.Debug("input length=%d", len(input))
@@ -391,61 +1314,68 @@ func runWithRawInput(dir, inputStr, lifecycle string) error {
 		done(err)
 		return fmt.Errorf("failed to detect event: %w", err)
 	}
+	if runOpts.StdinFormat == stdinFormatCopilot && evt.Tool.Name == "" {
+		err := fmt.Errorf("input is not Copilot hook input: toolName is missing")
+		done(err)
+		return err
+	}
 
 	// Override cwd if dir is specified
-	if dir != "" && evt.Cwd == "" {
-		evt.Cwd = dir
+	if target.dir != "" && evt.Cwd == "" {
+		evt.Cwd = target.dir
 	}
 	if evt.Cwd == "" {
-		evt.Cwd = dir
+		evt.Cwd = target.dir
 	}
 
-	// Set lifecycle from CLI flag
+	// Set lifecycle and hook type from CLI flag
 	evt.Lifecycle = lifecycle
+	hookType := eventHookType(eventType, lifecycle)
+	if evt.Hook != nil {
+		evt.Hook.Type = hookType
+	}
+	if evt.Tool != nil {
+		evt.Tool.HookType = hookType
+	}
+
+	if len(runOpts.EventVars) > 0 {
+		evt, err = applyEventVars(evt, runOpts.EventVars)
+		if err != nil {
+			done(err)
+			return err
+		}
+	}
 
-	log.Debug("detected event: file=%v, tool=%v, lifecycle=%s", evt.File != nil, evt.Tool != nil, lifecycle)
+	log.Debug("detected event: file=%v, tool=%v, lifecycle=%s", evt.File != nil, evt.Tool != nil, evt.Lifecycle)
 
 	// Discover and run matching workflows
-	err = runMatchingWorkflowsWithEvent(dir, evt)
+	err = runMatchingWorkflowsWithEvent(target, evt)
 	done(err)
 	return err
 }
 
 // runMatchingWorkflowsWithEvent runs workflows with a pre-built event
-func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
+func runMatchingWorkflowsWithEvent(target runTarget, evt *schema.Event) error {
 	log := logging.Context("matcher")
 
-	// Normalize file path to be relative to dir (for matching against workflow patterns)
+	// Normalize file paths to be relative to dir (for matching against workflow patterns)
+	normalizeEventPaths(evt, target.dir)
 	if evt.File != nil && evt.File.Path != "" {
-		originalPath := evt.File.Path
-		evt.File.Path = normalizeFilePath(evt.File.Path, dir)
-		log.Debug("normalized path: %s -> %s", originalPath, evt.File.Path)
+		log.Debug("normalized path: %s", evt.File.Path)
 	}
 
 	// Discover workflows
-	workflowDir := filepath.Join(dir, ".github", "hookflows")
+	root := target.root
+	workflowDir := filepath.Join(root, ".github", "hookflows")
 	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
 		// No workflows directory, allow by default
 		log.Debug("no workflow directory at %s, allowing", workflowDir)
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 
 	// Find all workflow files
-	var workflowFiles []string
-	err := filepath.Walk(workflowDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yml" || ext == ".yaml" {
-			workflowFiles = append(workflowFiles, path)
-		}
-		return nil
-	})
+	workflowFiles, err := findWorkflowFiles(root)
 	if err != nil {
 		log.Error("workflow scan failed: %v", err)
 		return fmt.Errorf("failed to scan workflows: %w", err)
@@ -456,17 +1386,17 @@ func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
 	if len(workflowFiles) == 0 {
 		// No workflows found, allow by default
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 
 	// Load and validate ALL workflows first - fail fast on invalid workflows
-	var matchingWorkflows []*schema.Workflow
+	var workflows []*schema.Workflow
 	var validationErrors []string
 	for _, path := range workflowFiles {
-		wf, err := schema.LoadAndValidateWorkflow(path)
+		wf, err := schema.LoadAndValidateWorkflowWithOptions(path, validateOptions())
 		if err != nil {
 			// Collect validation errors instead of silently skipping
-			relPath, _ := filepath.Rel(dir, path)
+			relPath, _ := filepath.Rel(root, path)
 			if relPath == "" {
 				relPath = path
 			}
@@ -474,11 +1404,23 @@ func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
 			validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", relPath, err))
 			continue
 		}
+		trigger.MatcherFor(wf)
+		workflows = append(workflows, wf)
+	}
+
+	return runLoadedWorkflows(target, evt, workflows, validationErrors)
+}
+
+// runLoadedWorkflows runs the loaded workflows that match evt.
+// validationErrors lists workflow files that failed to load; any deny the
+// event unless it is a self-repair of the workflows.
+func runLoadedWorkflows(target runTarget, evt *schema.Event, workflows []*schema.Workflow, validationErrors []string) error {
+	log := logging.Context("matcher")
 
+	var matchingWorkflows []*schema.Workflow
+	for _, wf := range workflows {
 		// Check if workflow matches the event
-		matcher := trigger.NewMatcher(wf)
-		matched := matcher.Match(evt)
-		if matched {
+		if workflowMatches(wf, evt) {
 			log.Info("workflow matched: %s", wf.Name)
 			matchingWorkflows = append(matchingWorkflows, wf)
 		} else {
@@ -489,59 +1431,44 @@ func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
 	// If any workflows are invalid, check if agent is trying to fix them
 	if len(validationErrors) > 0 {
 		// Allow edits/creates to .github/hookflows/ so agent can self-repair
-		if isHookflowSelfRepair(evt, dir) {
+		if isHookflowSelfRepair(evt, target.dir) {
 			log.Info("allowing self-repair for invalid workflows")
 			result := schema.NewAllowResult()
 			result.PermissionDecisionReason = "Allowing hookflow self-repair (workflows have errors)"
-			return outputWorkflowResult(result)
+			return target.output(result)
 		}
 
 		// Otherwise deny - workflows must be fixed first
-		result := &schema.WorkflowResult{
-			PermissionDecision:       "deny",
-			PermissionDecisionReason: fmt.Sprintf("Invalid workflow(s): %s. Fix workflows in .github/hookflows/ first.", strings.Join(validationErrors, "; ")),
-		}
-		return outputWorkflowResult(result)
+		result := schema.NewDenyResult(fmt.Sprintf("Invalid workflow(s): %s. Fix workflows in .github/hookflows/ first.", strings.Join(validationErrors, "; ")))
+		return target.output(result)
 	}
 
 	if len(matchingWorkflows) == 0 {
 		// No matching workflows, allow by default
 		log.Debug("no matching workflows, allowing")
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 
+	matchingWorkflows, truncated := limitWorkflows(matchingWorkflows, runOpts.MaxWorkflows)
 	log.Info("running %d matching workflows", len(matchingWorkflows))
 
-	// Run matching workflows
-	ctx := context.Background()
-	var finalResult *schema.WorkflowResult
-
-	for _, wf := range matchingWorkflows {
-		log.Debug("executing workflow: %s", wf.Name)
-		r := runner.NewRunner(wf, evt, dir)
-		result := r.RunWithBlocking(ctx)
-
-		// If any workflow denies, the final result is deny
-		if result.PermissionDecision == "deny" {
-			log.Warn("workflow %s denied: %s", wf.Name, result.PermissionDecisionReason)
-			return outputWorkflowResult(result)
-		}
-
-		log.Debug("workflow %s allowed", wf.Name)
-		// Keep the last allow result
-		finalResult = result
-	}
+	result := runWorkflows(context.Background(), matchingWorkflows, evt, target.dir)
+	result.Truncated = truncated
+	return target.output(result)
+}
 
-	if finalResult == nil {
-		finalResult = schema.NewAllowResult()
+// workflowMatches reports whether a workflow's triggers match the event, or
+// true for every workflow with --match-all
+func workflowMatches(wf *schema.Workflow, evt *schema.Event) bool {
+	if runOpts.MatchAll {
+		return true
 	}
-
-	return outputWorkflowResult(finalResult)
+	return trigger.MatcherFor(wf).Match(evt)
 }
 
 // runMatchingWorkflows discovers and runs all matching workflows
-func runMatchingWorkflows(dir, eventStr, lifecycle string) error {
+func runMatchingWorkflows(target runTarget, eventStr, lifecycle string) error {
 	// Parse the event
 	var eventData map[string]interface{}
 	
@@ -557,47 +1484,40 @@ func runMatchingWorkflows(dir, eventStr, lifecycle string) error {
 	if eventStr == "" {
 		// No event provided, allow by default
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 	
 	if err := json.Unmarshal([]byte(eventStr), &eventData); err != nil {
 		return fmt.Errorf("failed to parse event JSON: %w", err)
 	}
+
+	// parseEventData skips fields of the wrong type, so check the shape first
+	if runOpts.EventSchema != "" {
+		if err := validateEventSchema(runOpts.EventSchema, []byte(eventStr)); err != nil {
+			return err
+		}
+	}
 	
 	// Convert to Event struct
 	event := parseEventData(eventData)
 	
-	// Normalize file path to be relative to dir (for matching against workflow patterns)
-	if event.File != nil && event.File.Path != "" {
-		event.File.Path = normalizeFilePath(event.File.Path, dir)
-	}
+	// Normalize file paths to be relative to dir (for matching against workflow patterns)
+	normalizeEventPaths(event, target.dir)
 	
 	// Set lifecycle from CLI flag
 	event.Lifecycle = lifecycle
 	
 	// Discover workflows
-	workflowDir := filepath.Join(dir, ".github", "hookflows")
+	root := target.root
+	workflowDir := filepath.Join(root, ".github", "hookflows")
 	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
 		// No workflows directory, allow by default
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 	
 	// Find all workflow files
-	var workflowFiles []string
-	err := filepath.Walk(workflowDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yml" || ext == ".yaml" {
-			workflowFiles = append(workflowFiles, path)
-		}
-		return nil
-	})
+	workflowFiles, err := findWorkflowFiles(root)
 	if err != nil {
 		return fmt.Errorf("failed to scan workflows: %w", err)
 	}
@@ -605,7 +1525,7 @@ func runMatchingWorkflows(dir, eventStr, lifecycle string) error {
 	if len(workflowFiles) == 0 {
 		// No workflows found, allow by default
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 	
 	// Load and match workflows
@@ -618,8 +1538,7 @@ func runMatchingWorkflows(dir, eventStr, lifecycle string) error {
 		}
 		
 		// Check if workflow matches the event
-		matcher := trigger.NewMatcher(wf)
-		if matcher.Match(event) {
+		if workflowMatches(wf, event) {
 			matchingWorkflows = append(matchingWorkflows, wf)
 		}
 	}
@@ -627,31 +1546,306 @@ func runMatchingWorkflows(dir, eventStr, lifecycle string) error {
 	if len(matchingWorkflows) == 0 {
 		// No matching workflows, allow by default
 		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return target.output(result)
 	}
 	
 	// Run matching workflows
-	ctx := context.Background()
+	matchingWorkflows, truncated := limitWorkflows(matchingWorkflows, runOpts.MaxWorkflows)
+	result := runWorkflows(context.Background(), matchingWorkflows, event, target.dir)
+	result.Truncated = truncated
+	return target.output(result)
+}
+
+// findWorkflowFiles returns the paths of the workflow files under root in
+// path order, reusing cached discovery results unless --no-cache is set
+func findWorkflowFiles(root string) ([]string, error) {
+	var files []discover.WorkflowFile
+	var err error
+	if runOpts.NoCache {
+		files, err = discover.Discover(root)
+	} else {
+		files, err = cache.NewFileCache(runOpts.CacheDir).DiscoverWorkflows(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if !matchesWorkflowGlobs(file.Path, runOpts.WorkflowGlobs) {
+			logging.Context("matcher").Debug("workflow file %s excluded by --workflow-glob", file.RelPath)
+			continue
+		}
+		paths = append(paths, file.Path)
+	}
+	// Sorted here rather than relying on the walk or cache order, since
+	// --max-workflows keeps the first matches
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// matchesWorkflowGlobs reports whether the base name of path matches any
+// of patterns; no patterns match every file
+func matchesWorkflowGlobs(path string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMaxWorkflows is the default for --max-workflows
+const defaultMaxWorkflows = 50
+
+// limitWorkflows keeps the first limit workflows and reports whether any
+// were dropped; limit 0 means no limit. Workflows are matched in the path
+// order findWorkflowFiles sorts them into, so the same set runs every time.
+func limitWorkflows(workflows []*schema.Workflow, limit int) ([]*schema.Workflow, bool) {
+	if limit <= 0 || len(workflows) <= limit {
+		return workflows, false
+	}
+	logging.Context("run").Warn("%d workflows matched, running only the first %d (--max-workflows)", len(workflows), limit)
+	return workflows[:limit], true
+}
+
+// resolveWorkspaces returns the directory of each --workspace path,
+// relative to the current directory unless absolute
+func resolveWorkspaces(workspaces []string) ([]string, error) {
+	dirs := make([]string, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		dir, err := filepath.Abs(workspace)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("invalid --workspace %q: not a directory", workspace)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// runWorkspaces calls run for each workspace directory, with the directory
+// as the workflow root and a target that collects its result, and outputs
+// the combined result
+func runWorkspaces(names, dirs []string, run func(target runTarget) error) error {
+	results := make([]*schema.WorkflowResult, len(dirs))
+	for i, dir := range dirs {
+		collect := func(result *schema.WorkflowResult) error {
+			results[i] = result
+			return nil
+		}
+		if err := run(runTarget{dir: dir, root: dir, output: collect}); err != nil {
+			return fmt.Errorf("workspace %s: %w", names[i], err)
+		}
+		if results[i] == nil {
+			results[i] = schema.NewAllowResult()
+		}
+	}
+	return outputWorkflowResult(aggregateWorkspaceResults(names, results))
+}
+
+// aggregateWorkspaceResults combines per-workspace results into one like
+// aggregateWorkflowResults, prefixing workflow and step names with the
+// workspace
+func aggregateWorkspaceResults(names []string, results []*schema.WorkflowResult) *schema.WorkflowResult {
+	final := schema.NewAllowResult()
+	var reasons []string
+	for i, result := range results {
+		for _, wf := range result.Workflows {
+			final.Workflows = append(final.Workflows, names[i]+": "+wf)
+		}
+		for _, step := range result.StepResults {
+			step.Name = names[i] + ": " + step.Name
+			final.StepResults = append(final.StepResults, step)
+		}
+		final.Truncated = final.Truncated || result.Truncated
+
+		if result.PermissionDecision == "deny" {
+			final.PermissionDecision = "deny"
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", names[i], result.PermissionDecisionReason))
+			if final.WorkflowName == "" {
+				final.WorkflowName = result.WorkflowName
+			}
+			if final.LogFile == "" {
+				final.LogFile = result.LogFile
+			}
+			final.Actions = append(final.Actions, result.Actions...)
+		}
+
+		if i == 0 || result.StartedAt.Before(final.StartedAt) {
+			final.StartedAt = result.StartedAt
+		}
+		if i == 0 || result.FinishedAt.After(final.FinishedAt) {
+			final.FinishedAt = result.FinishedAt
+		}
+	}
+	final.PermissionDecisionReason = strings.Join(reasons, "\n")
+	return final
+}
+
+// runWorkflows runs the matching workflows concurrently with --parallel, all
+// of them with --continue-on-workflow-error, and otherwise in order until the
+// first deny
+func runWorkflows(ctx context.Context, workflows []*schema.Workflow, evt *schema.Event, dir string) *schema.WorkflowResult {
+	if runOpts.Parallel {
+		return runParallelWorkflows(ctx, workflows, evt, dir, runOpts.ParallelLimit)
+	}
+	if runOpts.ContinueOnWorkflowError {
+		return runAllWorkflows(ctx, workflows, evt, dir)
+	}
+
+	log := logging.Context("run")
 	var finalResult *schema.WorkflowResult
-	
-	for _, wf := range matchingWorkflows {
-		r := runner.NewRunner(wf, event, dir)
-		result := r.RunWithBlocking(ctx)
-		
+	var executed []string
+	startedAt := time.Now()
+
+	for _, wf := range workflows {
+		log.Debug("executing workflow: %s", wf.Name)
+		result := newRunner(wf, evt, dir).RunWithBlocking(ctx)
+		executed = append(executed, wf.Name)
+		// Timestamps cover every workflow run for this event
+		result.StartedAt = startedAt
+		result.Workflows = executed
+
 		// If any workflow denies, the final result is deny
 		if result.PermissionDecision == "deny" {
-			return outputWorkflowResult(result)
+			log.Warn("workflow %s denied: %s", wf.Name, result.PermissionDecisionReason)
+			return result
 		}
-		
+
+		log.Debug("workflow %s allowed", wf.Name)
 		// Keep the last allow result
 		finalResult = result
 	}
-	
+
 	if finalResult == nil {
 		finalResult = schema.NewAllowResult()
 	}
-	
-	return outputWorkflowResult(finalResult)
+	return finalResult
+}
+
+// runAllWorkflows runs every workflow without stopping at the first deny and
+// aggregates the decisions: deny if any workflow denied, with all deny reasons
+func runAllWorkflows(ctx context.Context, workflows []*schema.Workflow, evt *schema.Event, dir string) *schema.WorkflowResult {
+	log := logging.Context("run")
+	results := make([]*schema.WorkflowResult, len(workflows))
+	for i, wf := range workflows {
+		log.Debug("executing workflow: %s", wf.Name)
+		results[i] = newRunner(wf, evt, dir).RunWithBlocking(ctx)
+	}
+	return aggregateWorkflowResults(workflows, results)
+}
+
+// defaultParallelLimit is the default for --parallel-limit
+const defaultParallelLimit = 4
+
+// runParallelWorkflows runs every workflow concurrently, at most limit at a
+// time, and aggregates the results like runAllWorkflows
+func runParallelWorkflows(ctx context.Context, workflows []*schema.Workflow, evt *schema.Event, dir string, limit int) *schema.WorkflowResult {
+	log := logging.Context("run")
+	if limit < 1 {
+		limit = 1
+	}
+
+	results := make([]*schema.WorkflowResult, len(workflows))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, wf := range workflows {
+		wg.Add(1)
+		go func(i int, wf *schema.Workflow) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			log.Debug("executing workflow: %s", wf.Name)
+			results[i] = newRunner(wf, evt, dir).RunWithBlocking(ctx)
+		}(i, wf)
+	}
+	wg.Wait()
+
+	return aggregateWorkflowResults(workflows, results)
+}
+
+// aggregateWorkflowResults combines per-workflow results into one: deny wins
+// if any workflow denies, and the reason lists every deny reason
+func aggregateWorkflowResults(workflows []*schema.Workflow, results []*schema.WorkflowResult) *schema.WorkflowResult {
+	log := logging.Context("run")
+	final := schema.NewAllowResult()
+	var reasons []string
+	suggested := make(map[string]bool)
+
+	for i, wf := range workflows {
+		result := results[i]
+		final.Workflows = append(final.Workflows, wf.Name)
+
+		for _, step := range result.StepResults {
+			step.Name = wf.Name + " / " + step.Name
+			final.StepResults = append(final.StepResults, step)
+		}
+
+		if result.PermissionDecision == "deny" {
+			// Denials name the run, which may be a templated run-name
+			runName := result.WorkflowName
+			if runName == "" {
+				runName = wf.Name
+			}
+			log.Warn("workflow %s denied: %s", runName, result.PermissionDecisionReason)
+			final.PermissionDecision = "deny"
+			reasons = append(reasons, fmt.Sprintf("[%s] %s", runName, result.PermissionDecisionReason))
+			if final.WorkflowName == "" {
+				final.WorkflowName = runName
+			}
+			if final.LogFile == "" {
+				final.LogFile = result.LogFile
+			}
+			for _, action := range result.Actions {
+				if !suggested[action.Command] {
+					suggested[action.Command] = true
+					final.Actions = append(final.Actions, action)
+				}
+			}
+		} else {
+			log.Debug("workflow %s allowed", wf.Name)
+		}
+
+		// Step details are reported once, on the aggregate result
+		result.StepResults = nil
+		final.WorkflowResults = append(final.WorkflowResults, *result)
+	}
+
+	final.PermissionDecisionReason = strings.Join(reasons, "\n")
+
+	// The combined run spans the earliest start to the latest finish
+	for i, result := range results {
+		if i == 0 || result.StartedAt.Before(final.StartedAt) {
+			final.StartedAt = result.StartedAt
+		}
+		if i == 0 || result.FinishedAt.After(final.FinishedAt) {
+			final.FinishedAt = result.FinishedAt
+		}
+	}
+	return final
+}
+
+// validateEventSchema checks event JSON against the JSON Schema in
+// schemaFile and lists every violation in the returned error
+func validateEventSchema(schemaFile string, eventJSON []byte) error {
+	schemaJSON, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read event schema: %w", err)
+	}
+	violations, err := schema.ValidateEventJSON(schemaJSON, eventJSON)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("event does not match %s:\n  - %s", schemaFile, strings.Join(violations, "\n  - "))
 }
 
 // parseEventData converts raw event data to a schema.Event
@@ -667,6 +1861,9 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if cwd, ok := hookData["cwd"].(string); ok {
 			event.Hook.Cwd = cwd
 		}
+		if sessionID, ok := hookData["session_id"].(string); ok {
+			event.Hook.SessionID = sessionID
+		}
 		if toolData, ok := hookData["tool"].(map[string]interface{}); ok {
 			event.Hook.Tool = &schema.ToolEvent{}
 			if name, ok := toolData["name"].(string); ok {
@@ -718,19 +1915,14 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if author, ok := commitData["author"].(string); ok {
 			event.Commit.Author = author
 		}
+		if branch, ok := commitData["branch"].(string); ok {
+			event.Commit.Branch = branch
+		}
+		if diff, ok := commitData["diff"].(string); ok {
+			event.Commit.Diff = diff
+		}
 		if files, ok := commitData["files"].([]interface{}); ok {
-			for _, f := range files {
-				if fm, ok := f.(map[string]interface{}); ok {
-					fs := schema.FileStatus{}
-					if p, ok := fm["path"].(string); ok {
-						fs.Path = p
-					}
-					if s, ok := fm["status"].(string); ok {
-						fs.Status = s
-					}
-					event.Commit.Files = append(event.Commit.Files, fs)
-				}
-			}
+			event.Commit.Files = parseFileStatuses(files)
 		}
 	}
 	
@@ -746,6 +1938,9 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if after, ok := pushData["after"].(string); ok {
 			event.Push.After = after
 		}
+		if files, ok := pushData["files"].([]interface{}); ok {
+			event.Push.Files = parseFileStatuses(files)
+		}
 	}
 	
 	// Parse top-level cwd and timestamp
@@ -755,15 +1950,51 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 	if ts, ok := data["timestamp"].(string); ok {
 		event.Timestamp = ts
 	}
-	
+
+	// Parse metadata, keeping only string values
+	if metadata, ok := data["metadata"].(map[string]interface{}); ok {
+		event.Metadata = schema.MetadataStrings(metadata)
+	}
+
+	event.FillCreateFile()
 	return event
 }
 
+// parseFileStatuses converts a raw files array from a commit or push event
+func parseFileStatuses(files []interface{}) []schema.FileStatus {
+	var statuses []schema.FileStatus
+	for _, f := range files {
+		if fm, ok := f.(map[string]interface{}); ok {
+			fs := schema.FileStatus{}
+			if p, ok := fm["path"].(string); ok {
+				fs.Path = p
+			}
+			if s, ok := fm["status"].(string); ok {
+				fs.Status = s
+			}
+			statuses = append(statuses, fs)
+		}
+	}
+	return statuses
+}
+
 // discoverWorkflows finds all workflow files in a directory
 func discoverWorkflows(dir string) ([]discover.WorkflowFile, error) {
 	return discover.Discover(dir)
 }
 
+// workflowToolNames lists the tool names matched by a workflow's tool triggers
+func workflowToolNames(wf *schema.Workflow) []string {
+	var names []string
+	if wf.On.Tool != nil {
+		names = append(names, wf.On.Tool.ToolNames()...)
+	}
+	for i := range wf.On.Tools {
+		names = append(names, wf.On.Tools[i].ToolNames()...)
+	}
+	return names
+}
+
 // findWorkflowFile finds a workflow file by name
 func findWorkflowFile(dir, workflowName string) (string, bool) {
 	for _, ext := range []string{".yml", ".yaml"} {
@@ -775,16 +2006,172 @@ func findWorkflowFile(dir, workflowName string) (string, bool) {
 	return "", false
 }
 
-// outputWorkflowResult outputs the workflow result as JSON
+// outputWorkflowResult outputs the workflow result as JSON, or as a table with --output-format table
+// Per-step results are only included in JSON when --verbose is set to keep hook output small
 func outputWorkflowResult(result *schema.WorkflowResult) error {
+	tableOutput := runOpts.OutputFormat == outputFormatTable
+	if runOpts.Profile {
+		profiled := *result
+		profiled.Profile = schema.NewProfile(result.StepResults)
+		result = &profiled
+	}
+	if !runOpts.Verbose && !tableOutput && (len(result.StepResults) > 0 || len(result.Workflows) > 0) {
+		trimmed := *result
+		trimmed.StepResults = nil
+		trimmed.Workflows = nil
+		result = &trimmed
+	}
+
+	// Step output may carry terminal colors through to the reason text
+	if noColor {
+		plain := *result
+		plain.PermissionDecisionReason = logging.StripANSI(plain.PermissionDecisionReason)
+		plain.StepResults = make([]schema.StepResult, len(result.StepResults))
+		for i, step := range result.StepResults {
+			step.OutputPreview = logging.StripANSI(step.OutputPreview)
+			plain.StepResults[i] = step
+		}
+		plain.WorkflowResults = make([]schema.WorkflowResult, len(result.WorkflowResults))
+		for i, wfResult := range result.WorkflowResults {
+			wfResult.PermissionDecisionReason = logging.StripANSI(wfResult.PermissionDecisionReason)
+			plain.WorkflowResults[i] = wfResult
+		}
+		result = &plain
+	}
+
+	if tableOutput {
+		err := outputWorkflowTable(result)
+		var exitErr *exitError
+		if runOpts.AssertDecision != "" && errors.As(err, &exitErr) {
+			// The assertion decides the exit code instead of the deny
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		return checkAssertedDecision(result)
+	}
+
+	if runOpts.OutputFile != "" {
+		if err := writeResultFile(result, runOpts.OutputFile, runOpts.AppendOutput); err != nil {
+			return err
+		}
+		return checkAssertedDecision(result)
+	}
+
 	jsonBytes, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal result: %w", err)
 	}
 	fmt.Println(string(jsonBytes))
+	return checkAssertedDecision(result)
+}
+
+// writeResultFile writes result to path as indented JSON, or with appendLine
+// adds it to the end of path as a single JSON line, for audit logs
+func writeResultFile(result *schema.WorkflowResult, path string, appendLine bool) error {
+	var data []byte
+	var err error
+	if appendLine {
+		data, err = json.Marshal(result)
+	} else {
+		data, err = json.MarshalIndent(result, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	data = append(data, '\n')
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendLine {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// checkAssertedDecision compares the decision with --assert-deny or
+// --assert-allow. The reason is printed to stderr for diagnostics, and an
+// unexpected decision exits 2 so it is not mistaken for a command error.
+func checkAssertedDecision(result *schema.WorkflowResult) error {
+	if runOpts.AssertDecision == "" {
+		return nil
+	}
+	if result.PermissionDecisionReason != "" {
+		fmt.Fprintf(os.Stderr, "Reason: %s\n", result.PermissionDecisionReason)
+	}
+	if result.PermissionDecision != runOpts.AssertDecision {
+		fmt.Fprintf(os.Stderr, "%s expected %s, got %s\n", symbol(symbolFail), runOpts.AssertDecision, result.PermissionDecision)
+		return &exitError{code: 2}
+	}
+	return nil
+}
+
+// outputWorkflowTable prints step results as an aligned table for humans.
+// A deny decision is returned as an exitError so scripts can check the exit code.
+func outputWorkflowTable(result *schema.WorkflowResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if result.Profile != nil {
+		_, _ = fmt.Fprintln(w, "STEP NAME\tSTATUS\tDURATION\tTIMING\tOUTPUT PREVIEW")
+	} else {
+		_, _ = fmt.Fprintln(w, "STEP NAME\tSTATUS\tDURATION\tOUTPUT PREVIEW")
+	}
+	for _, step := range result.StepResults {
+		status := "passed"
+		switch {
+		case step.Skipped:
+			status = "skipped"
+		case !step.Success:
+			status = "failed"
+		}
+		duration := time.Duration(step.DurationMs) * time.Millisecond
+		if result.Profile != nil {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", step.Name, status, duration, timingShare(step.DurationMs, result.Profile.TotalMs), tableCell(step.OutputPreview))
+		} else {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", step.Name, status, duration, tableCell(step.OutputPreview))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write table: %w", err)
+	}
+
+	if result.Profile != nil {
+		fmt.Printf("\nTotal: %s\n", time.Duration(result.Profile.TotalMs)*time.Millisecond)
+	}
+
+	fmt.Printf("\nDecision: %s\n", result.PermissionDecision)
+	if result.PermissionDecision == "deny" {
+		if result.PermissionDecisionReason != "" {
+			fmt.Printf("Reason: %s\n", result.PermissionDecisionReason)
+		}
+		return &exitError{code: 1}
+	}
 	return nil
 }
 
+// timingShare formats a step's share of the total profiled time
+func timingShare(durationMs, totalMs int64) string {
+	if totalMs == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", durationMs*100/totalMs)
+}
+
+// tableCell flattens output to a single line so it cannot break table alignment
+func tableCell(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
 // Git command detection helpers
 //
 // These patterns are designed to match git commands at the start of a command line
@@ -860,6 +2247,19 @@ func isHookflowSelfRepair(evt *schema.Event, dir string) bool {
 	return false
 }
 
+// normalizeEventPaths makes event.file.path and event.tool.args.path relative
+// to dir, so triggers and expressions see the same path whichever they read
+func normalizeEventPaths(evt *schema.Event, dir string) {
+	if evt.File != nil && evt.File.Path != "" {
+		evt.File.Path = normalizeFilePath(evt.File.Path, dir)
+	}
+	if evt.Tool != nil {
+		if path, ok := evt.Tool.Args["path"].(string); ok && path != "" {
+			evt.Tool.Args["path"] = normalizeFilePath(path, dir)
+		}
+	}
+}
+
 // normalizeFilePath converts an absolute file path to a relative path from dir
 // This ensures workflow path patterns (like 'plugin.json') match correctly
 func normalizeFilePath(filePath, dir string) string {
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
+
+	"github.com/htekdev/gh-hookflow/internal/runner"
+	"github.com/htekdev/gh-hookflow/internal/schema"
 )
 
 func TestParseEventData_HookEvent(t *testing.T) {
@@ -296,3 +302,51 @@ steps:
 	}
 }
 
+func TestParseEventData_MetadataRoundTrip(t *testing.T) {
+	input := `{"file": {"path": "src/app.ts", "action": "edit"}, "metadata": {"ticket": "JIRA-123", "priority": 2}}`
+
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(input), &data); err != nil {
+		t.Fatalf("Failed to parse input: %v", err)
+	}
+	event := parseEventData(data)
+
+	if event.Metadata["ticket"] != "JIRA-123" {
+		t.Errorf("Expected Metadata[ticket] = 'JIRA-123', got '%s'", event.Metadata["ticket"])
+	}
+	if _, ok := event.Metadata["priority"]; ok {
+		t.Error("Expected non-string metadata values to be dropped")
+	}
+
+	// Metadata survives a JSON round trip of the parsed event
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	var roundTrip map[string]interface{}
+	if err := json.Unmarshal(encoded, &roundTrip); err != nil {
+		t.Fatalf("Failed to unmarshal event: %v", err)
+	}
+	event = parseEventData(roundTrip)
+	if event.Metadata["ticket"] != "JIRA-123" {
+		t.Fatalf("Expected metadata to survive round trip, got %v", event.Metadata)
+	}
+
+	// Metadata is visible to expressions as event.metadata
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	wf := &schema.Workflow{
+		Name: "metadata",
+		Steps: []schema.Step{
+			{Name: "ticket", If: "${{ event.metadata.ticket == 'JIRA-123' }}", Run: "echo ticket", Shell: "bash"},
+		},
+	}
+	results, err := runner.NewRunner(wf, event, t.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if results[0].Skipped {
+		t.Errorf("Expected step to run when event.metadata.ticket matches, got: %s", results[0].Output)
+	}
+}
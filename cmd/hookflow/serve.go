@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/htekdev/gh-hookflow/internal/cache"
+	"github.com/htekdev/gh-hookflow/internal/discover"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+	"github.com/htekdev/gh-hookflow/internal/schedule"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run scheduled workflows as a daemon",
+	Long: `Loads workflows with an on.schedule trigger and runs them at the times given
+by their cron expressions until interrupted.
+
+Each firing sends a schedule event to the matching workflows, exactly as
+hookflow run does for hook events. Results are printed as JSON and logged to
+the hookflow log directory. Workflows are kept in memory and reloaded when a
+file under .github/hookflows, or a file one of them extends, is added, changed
+or removed, so edits and new schedules take effect without a restart. With no
+scheduled workflows yet, serve keeps watching until one is added.
+
+Use 'hookflow run --schedule-now' to trigger scheduled workflows once for testing.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			dir = os.Getenv(workflowDirEnv)
+		}
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return serveSchedules(ctx, dir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringP("dir", "d", "", "Directory containing .github/hookflows (default: $HOOKFLOW_WORKFLOW_DIR or current directory)")
+}
+
+// loadSchedules returns the distinct cron schedules used by workflows in dir
+func loadSchedules(dir string) ([]*schedule.Cron, error) {
+	files, err := discover.Discover(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover workflows: %w", err)
+	}
+
+	var workflows []*schema.Workflow
+	for _, file := range files {
+		wf, err := schema.LoadWorkflow(file.Path)
+		if err != nil {
+			continue
+		}
+		workflows = append(workflows, wf)
+	}
+	return workflowSchedules(workflows)
+}
+
+// workflowSchedules returns the distinct cron schedules used by workflows
+func workflowSchedules(workflows []*schema.Workflow) ([]*schedule.Cron, error) {
+	seen := make(map[string]bool)
+	var schedules []*schedule.Cron
+	for _, wf := range workflows {
+		if wf.On.Schedule == nil {
+			continue
+		}
+		cron, err := schedule.Parse(wf.On.Schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", wf.Name, err)
+		}
+		if seen[cron.String()] {
+			continue
+		}
+		seen[cron.String()] = true
+		schedules = append(schedules, cron)
+	}
+	return schedules, nil
+}
+
+// serveSchedules fires scheduled workflows until ctx is cancelled
+func serveSchedules(ctx context.Context, dir string) error {
+	return newScheduleServer(dir).serve(ctx)
+}
+
+// scheduleServer fires scheduled workflows from an in-memory workflow cache
+// that is reloaded whenever a workflow file changes
+type scheduleServer struct {
+	dir       string
+	workflows *cache.WorkflowCache
+	log       *logging.ContextLogger
+
+	// Runs are serialized so workflow output never interleaves
+	runMu sync.Mutex
+
+	timersMu sync.Mutex
+	timers   map[string]*time.Timer // Armed timer for each active cron expression
+	stopped  bool
+}
+
+// newScheduleServer creates a server for the workflows in dir
+func newScheduleServer(dir string) *scheduleServer {
+	return &scheduleServer{
+		dir:       dir,
+		workflows: cache.NewWorkflowCacheWithOptions(validateOptions()),
+		log:       logging.Context("serve"),
+		timers:    make(map[string]*time.Timer),
+	}
+}
+
+// serve loads the workflows, arms their schedules and reloads them when a
+// workflow or a file it extends changes, until ctx is cancelled
+func (s *scheduleServer) serve(ctx context.Context) error {
+	// Start watching before loading so no change is missed in between
+	watcher, err := discover.NewWatcher(s.dir)
+	if err != nil {
+		return err
+	}
+	if err := s.workflows.Load(s.dir); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	for _, invalid := range s.workflows.Invalid() {
+		s.log.Warn("invalid workflow: %s", invalid)
+	}
+	watcher.Track(s.workflows.Dependencies())
+
+	schedules, err := workflowSchedules(s.workflows.Workflows())
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	if len(schedules) == 0 {
+		fmt.Printf("No scheduled workflows found in: %s, watching for changes\n", s.dir)
+	} else {
+		fmt.Printf("Serving %d schedule(s) from: %s\n", len(schedules), s.dir)
+		for _, cron := range schedules {
+			fmt.Printf("  - %s\n", cron)
+		}
+	}
+	s.arm(schedules)
+
+	// Events is closed when ctx is cancelled
+	go watcher.Run(ctx)
+	for evt := range watcher.Events() {
+		s.reload(evt)
+		// Bases may have been added or dropped by the reload
+		watcher.Track(s.workflows.Dependencies())
+	}
+
+	s.timersMu.Lock()
+	s.stopped = true
+	for expr, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, expr)
+	}
+	s.timersMu.Unlock()
+
+	s.log.Info("serve stopped")
+	return nil
+}
+
+// reload applies a workflow file change to the cache, reloads the workflows
+// that extend the changed file and re-arms the schedules. The next firing
+// uses the new workflows without a restart.
+func (s *scheduleServer) reload(evt discover.WatchEvent) {
+	if !evt.Dependency {
+		if err := s.workflows.Apply(evt); err != nil {
+			s.log.Warn("reloaded workflow %s is invalid: %v", evt.File.RelPath, err)
+		}
+	}
+	for _, file := range s.workflows.Dependents(evt.File.Path) {
+		if err := s.workflows.Apply(discover.WatchEvent{Op: discover.WatchUpdate, File: file}); err != nil {
+			s.log.Warn("reloaded workflow %s is invalid: %v", file.RelPath, err)
+		}
+	}
+
+	schedules, err := workflowSchedules(s.workflows.Workflows())
+	if err != nil {
+		s.log.Error("keeping current schedules: %v", err)
+		return
+	}
+	s.arm(schedules)
+	fmt.Printf("Reloaded %s (%s), serving %d schedule(s)\n", evt.File.RelPath, evt.Op, len(schedules))
+}
+
+// arm makes schedules the active set: timers for schedules no longer used
+// are stopped and new schedules are armed
+func (s *scheduleServer) arm(schedules []*schedule.Cron) {
+	s.timersMu.Lock()
+	defer s.timersMu.Unlock()
+
+	active := make(map[string]*schedule.Cron, len(schedules))
+	for _, cron := range schedules {
+		active[cron.String()] = cron
+	}
+	for expr, timer := range s.timers {
+		if active[expr] == nil {
+			timer.Stop()
+			delete(s.timers, expr)
+			s.log.Info("schedule %q removed", expr)
+		}
+	}
+	for expr, cron := range active {
+		if _, armed := s.timers[expr]; !armed {
+			s.armLocked(cron)
+		}
+	}
+}
+
+// armLocked starts the timer for the next run of cron; the caller holds timersMu
+func (s *scheduleServer) armLocked(cron *schedule.Cron) {
+	if s.stopped {
+		return
+	}
+	next := cron.Next(time.Now())
+	if next.IsZero() {
+		s.log.Warn("schedule %q never fires, skipping", cron)
+		return
+	}
+	s.log.Info("next run for %q at %s", cron, next.Format(time.RFC3339))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(next), func() {
+		s.runMu.Lock()
+		if err := s.fire(cron.String()); err != nil {
+			s.log.Error("scheduled run failed: %v", err)
+		}
+		s.runMu.Unlock()
+
+		s.timersMu.Lock()
+		defer s.timersMu.Unlock()
+		// Re-arm unless the schedule was removed or replaced meanwhile
+		if s.timers[cron.String()] == timer {
+			s.armLocked(cron)
+		}
+	})
+	s.timers[cron.String()] = timer
+}
+
+// fire runs the cached workflows whose schedule trigger uses cron
+func (s *scheduleServer) fire(cron string) error {
+	s.log.Info("schedule fired: %q", cron)
+	return runLoadedWorkflows(newRunTarget(s.dir), scheduleEvent(s.dir, cron), s.workflows.Workflows(), s.workflows.Invalid())
+}
+
+// fireSchedule runs the workflows whose schedule trigger uses cron.
+// An empty cron fires every scheduled workflow.
+func fireSchedule(target runTarget, cron string) error {
+	logging.Context("serve").Info("schedule fired: %q", cron)
+	return runMatchingWorkflowsWithEvent(target, scheduleEvent(target.dir, cron))
+}
+
+// scheduleEvent builds the event sent when cron fires
+func scheduleEvent(dir, cron string) *schema.Event {
+	return &schema.Event{
+		Schedule:  &schema.ScheduleEvent{Cron: cron},
+		Cwd:       dir,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
@@ -155,7 +155,7 @@ func runShiftLeft(dir string, dryRun bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println("✓ Analysis complete!")
+	fmt.Printf("%s Analysis complete!\n", symbol(symbolOK))
 	fmt.Println()
 	fmt.Println("Suggested agent-workflow(s):")
 	fmt.Println("---")
@@ -179,7 +179,7 @@ func runShiftLeft(dir string, dryRun bool) error {
 		return fmt.Errorf("failed to save workflow: %w", err)
 	}
 
-	fmt.Printf("✓ Saved to: %s\n", outputPath)
+	fmt.Printf("%s Saved to: %s\n", symbol(symbolOK), outputPath)
 	return nil
 }
 
@@ -246,7 +246,7 @@ func runShiftRight(dir string, dryRun bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println("✓ Generation complete!")
+	fmt.Printf("%s Generation complete!\n", symbol(symbolOK))
 	fmt.Println()
 	fmt.Println("Generated GitHub Action:")
 	fmt.Println("---")
@@ -270,7 +270,7 @@ func runShiftRight(dir string, dryRun bool) error {
 		return fmt.Errorf("failed to save workflow: %w", err)
 	}
 
-	fmt.Printf("✓ Saved to: %s\n", outputPath)
+	fmt.Printf("%s Saved to: %s\n", symbol(symbolOK), outputPath)
 	return nil
 }
 
@@ -301,7 +301,7 @@ on:
   file:
     paths:
       - '**/*.ts'
-    types:
+    actions:
       - edit
 
 For commit-based checks, use commit triggers:
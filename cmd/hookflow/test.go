@@ -68,6 +68,7 @@ func init() {
 	testCmd.Flags().StringP("dir", "d", "", "Directory to search (default: current directory)")
 	testCmd.Flags().StringP("event", "e", "", "Event type to simulate (commit, push, file)")
 	testCmd.Flags().StringP("workflow", "w", "", "Specific workflow to test (optional)")
+	_ = testCmd.RegisterFlagCompletionFunc("workflow", completeWorkflowNames)
 
 	// Event-specific flags
 	testCmd.Flags().String("branch", "main", "Branch name for commit/push events")
@@ -130,18 +131,17 @@ func runTest(dir, eventType, workflow string, opts testEventOptions) error {
 	for _, path := range workflowFiles {
 		wf, err := schema.LoadWorkflow(path)
 		if err != nil {
-			fmt.Printf("✗ %s\n", filepath.Base(path))
+			fmt.Printf("%s %s\n", symbol(symbolFail), filepath.Base(path))
 			fmt.Printf("  Error loading: %v\n\n", err)
 			continue
 		}
 
-		matcher := trigger.NewMatcher(wf)
-		matches := matcher.Match(evt)
+		matches := trigger.MatcherFor(wf).Match(evt)
 
 		relPath, _ := filepath.Rel(dir, path)
 		if matches {
 			matchCount++
-			fmt.Printf("✓ %s (%s)\n", wf.Name, relPath)
+			fmt.Printf("%s %s (%s)\n", symbol(symbolOK), wf.Name, relPath)
 			fmt.Printf("  Would execute %d step(s):\n", len(wf.Steps))
 			for i, step := range wf.Steps {
 				stepName := step.Name
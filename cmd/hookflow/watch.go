@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"time"
+)
+
+// watchInterval is how often validate --watch polls for workflow changes
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp identifies one version of a watched file
+type fileStamp struct {
+	modTime int64
+	size    int64
+}
+
+// snapshotFiles records every file under root, which may be a directory or a
+// single file. A missing root yields an empty snapshot.
+func snapshotFiles(root string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		return nil
+	})
+	return stamps
+}
+
+// watchFiles polls root every interval and calls onChange when a file is
+// created, modified or deleted. It returns when ctx is cancelled.
+// Polling keeps hookflow free of platform-specific file notification code.
+func watchFiles(ctx context.Context, root string, interval time.Duration, onChange func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := snapshotFiles(root)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			current := snapshotFiles(root)
+			if !maps.Equal(current, last) {
+				last = current
+				onChange()
+			}
+		}
+	}
+}
@@ -3,16 +3,18 @@ module github.com/htekdev/gh-hookflow
 go 1.24
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/github/copilot-sdk/go v0.1.28
 	github.com/spf13/cobra v1.10.2
 	github.com/xeipuuv/gojsonschema v1.2.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/github/copilot-sdk/go v0.1.28 // indirect
 	github.com/google/jsonschema-go v0.4.2 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
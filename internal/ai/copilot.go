@@ -149,7 +149,7 @@ The workflow must follow this structure:
 - on: (required) Trigger configuration - can be:
   - hooks: Match hook type (preToolUse, postToolUse)
   - tool: Match specific tool with args patterns
-  - file: Match file events with paths and types
+  - file: Match file events with paths and actions
   - commit: Match git commit events with paths/message patterns
   - push: Match git push events with branches/tags
 - blocking: (optional, default true) Whether to block on failure
@@ -162,12 +162,12 @@ The workflow must follow this structure:
 
 ## Trigger Examples
 
-File trigger (use 'types' for actions like edit/create/delete):
+File trigger (use 'actions' for edit/create/delete):
 on:
   file:
     paths:
       - '**/*.env*'
-    types:
+    actions:
       - edit
       - create
 
@@ -199,7 +199,7 @@ Use ${{ }} for expressions:
 2. Start with --- (YAML document separator)
 3. Include descriptive name and description
 4. Use appropriate triggers for the requirement
-5. For file triggers, use 'types' field (not 'actions')
+5. For file triggers, use the 'actions' field ('types' is deprecated)
 6. Include clear step names
 7. Add exit 1 to block/deny the action when needed
 
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/htekdev/gh-hookflow/internal/discover"
+)
+
+// Subdirectories of the cache directory. Clear removes only these, so a
+// mistyped --cache-dir cannot delete unrelated files.
+const (
+	actionsSubdir   = "actions"
+	discoverySubdir = "discovery"
+)
+
+// racyWindow is how long after a directory changes its workflow list is not
+// cached. File systems with coarse timestamps could otherwise give a later
+// change the same modification time as the cached scan.
+const racyWindow = 2 * time.Second
+
+// DirEnv overrides the default cache directory
+const DirEnv = "HOOKFLOW_CACHE_DIR"
+
+// DefaultDir returns $HOOKFLOW_CACHE_DIR or ~/.hookflow/cache, or a hookflow
+// directory under the system temp directory when there is no home directory
+func DefaultDir() string {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "hookflow", "cache")
+	}
+	return filepath.Join(home, ".hookflow", "cache")
+}
+
+// FileCache stores work that is repeated on every hookflow invocation, such
+// as workflow discovery and remote actions, under one directory
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates a cache rooted at dir, or at DefaultDir when dir is empty
+func NewFileCache(dir string) *FileCache {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &FileCache{dir: dir}
+}
+
+// Dir returns the cache directory
+func (c *FileCache) Dir() string {
+	return c.dir
+}
+
+// ActionsDir returns the directory remote actions are fetched into
+func (c *FileCache) ActionsDir() string {
+	return filepath.Join(c.dir, actionsSubdir)
+}
+
+// discoveryEntry is the cached workflow list of one root directory
+type discoveryEntry struct {
+	Root      string                  `json:"root"`
+	Dirs      map[string]int64        `json:"dirs"` // Modification time of every scanned directory, in nanoseconds
+	Workflows []discover.WorkflowFile `json:"workflows"`
+}
+
+// DiscoverWorkflows returns the workflow files under rootDir like
+// discover.Discover. The list is reused while no directory under
+// .github/hookflows has changed: adding, removing or renaming a file updates
+// its directory's modification time. Edits to existing files do not, so the
+// ModTime of reused files is read again.
+func (c *FileCache) DiscoverWorkflows(rootDir string) ([]discover.WorkflowFile, error) {
+	cacheFile := c.discoveryFile(rootDir)
+	if entry, err := readDiscoveryEntry(cacheFile); err == nil && entry.Root == rootDir && dirsUnchanged(entry.Dirs) {
+		now := time.Now()
+		for i := range entry.Workflows {
+			if info, err := os.Stat(entry.Workflows[i].Path); err == nil {
+				entry.Workflows[i].ModTime = info.ModTime()
+			}
+			entry.Workflows[i].LoadedAt = now
+		}
+		return entry.Workflows, nil
+	}
+
+	// Record directory times before scanning so a change during the scan
+	// invalidates the entry rather than being missed
+	dirs := scanDirs(filepath.Join(rootDir, discover.WorkflowDir))
+	workflows, err := discover.Discover(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) > 0 && settled(dirs, time.Now()) {
+		// Failing to cache only costs a rescan next time
+		_ = writeDiscoveryEntry(cacheFile, discoveryEntry{Root: rootDir, Dirs: dirs, Workflows: workflows})
+	}
+	return workflows, nil
+}
+
+// Clear deletes the cached discovery results and remote actions
+func (c *FileCache) Clear() error {
+	for _, subdir := range []string{actionsSubdir, discoverySubdir} {
+		if err := os.RemoveAll(filepath.Join(c.dir, subdir)); err != nil {
+			return err
+		}
+	}
+	// Remove the cache directory itself when nothing else is in it
+	_ = os.Remove(c.dir)
+	return nil
+}
+
+// discoveryFile returns the cache file for a root directory
+func (c *FileCache) discoveryFile(rootDir string) string {
+	sum := sha256.Sum256([]byte(rootDir))
+	return filepath.Join(c.dir, discoverySubdir, hex.EncodeToString(sum[:])+".json")
+}
+
+// scanDirs returns the modification time of dir and every directory below it
+func scanDirs(dir string) map[string]int64 {
+	dirs := make(map[string]int64)
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			dirs[path] = info.ModTime().UnixNano()
+		}
+		return nil
+	})
+	return dirs
+}
+
+// dirsUnchanged reports whether every recorded directory still has its
+// recorded modification time. A new subdirectory changes its parent's time.
+func dirsUnchanged(dirs map[string]int64) bool {
+	if len(dirs) == 0 {
+		return false
+	}
+	for dir, modTime := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || info.ModTime().UnixNano() != modTime {
+			return false
+		}
+	}
+	return true
+}
+
+// settled reports whether every directory changed at least racyWindow before now
+func settled(dirs map[string]int64, now time.Time) bool {
+	for _, modTime := range dirs {
+		if now.Sub(time.Unix(0, modTime)) < racyWindow {
+			return false
+		}
+	}
+	return true
+}
+
+func readDiscoveryEntry(path string) (*discoveryEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry discoveryEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// writeDiscoveryEntry writes the entry through a temporary file so
+// concurrent hookflow processes never read a partial file
+func writeDiscoveryEntry(path string, entry discoveryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".discovery-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
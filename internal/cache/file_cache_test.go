@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// age moves the modification time of every directory under root into the
+// past, so the discovery cache treats them as settled
+func age(t *testing.T, root string) {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, past, past)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func discoveredNames(t *testing.T, c *FileCache, root string) []string {
+	t.Helper()
+	files, err := c.DiscoverWorkflows(root)
+	if err != nil {
+		t.Fatalf("DiscoverWorkflows: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestFileCacheDiscoverWorkflows(t *testing.T) {
+	root := t.TempDir()
+	c := NewFileCache(t.TempDir())
+	writeWorkflow(t, root, "lint", workflowYAML("lint", "echo lint"))
+	age(t, root)
+
+	if got := discoveredNames(t, c, root); len(got) != 1 || got[0] != "lint" {
+		t.Fatalf("Expected [lint], got %v", got)
+	}
+	entry, err := readDiscoveryEntry(c.discoveryFile(root))
+	if err != nil {
+		t.Fatalf("Expected a cache entry: %v", err)
+	}
+
+	// A cached entry is returned as is while the directories are unchanged
+	entry.Workflows[0].Name = "cached"
+	if err := writeDiscoveryEntry(c.discoveryFile(root), *entry); err != nil {
+		t.Fatal(err)
+	}
+	if got := discoveredNames(t, c, root); len(got) != 1 || got[0] != "cached" {
+		t.Errorf("Expected the cached list, got %v", got)
+	}
+
+	// Editing a workflow keeps the entry, but its ModTime is read again
+	writeWorkflow(t, root, "lint", workflowYAML("lint", "echo v2"))
+	edited := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(entry.Workflows[0].Path, edited, edited); err != nil {
+		t.Fatal(err)
+	}
+	files, err := c.DiscoverWorkflows(root)
+	if err != nil {
+		t.Fatalf("DiscoverWorkflows: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "cached" {
+		t.Errorf("Expected the cached list after an edit, got %v", files)
+	} else if !files[0].ModTime.Equal(edited) || files[0].LoadedAt.Before(edited) {
+		t.Errorf("Expected ModTime %v and a fresh LoadedAt, got %v and %v", edited, files[0].ModTime, files[0].LoadedAt)
+	}
+
+	// Adding a file changes its directory and invalidates the entry
+	writeWorkflow(t, root, "secrets", workflowYAML("secrets", "echo secrets"))
+	if got := discoveredNames(t, c, root); len(got) != 2 || got[0] != "lint" || got[1] != "secrets" {
+		t.Errorf("Expected [lint secrets] after adding a file, got %v", got)
+	}
+
+	// So does a file in a new subdirectory
+	age(t, root)
+	discoveredNames(t, c, root)
+	sub := filepath.Join(root, ".github", "hookflows", "team")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "review.yml"), []byte(workflowYAML("review", "echo review")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := discoveredNames(t, c, root); len(got) != 3 {
+		t.Errorf("Expected 3 workflows after adding a subdirectory, got %v", got)
+	}
+}
+
+func TestFileCacheSkipsRecentChanges(t *testing.T) {
+	root := t.TempDir()
+	c := NewFileCache(t.TempDir())
+	writeWorkflow(t, root, "lint", workflowYAML("lint", "echo lint"))
+
+	// A directory changed moments ago might change again within the same
+	// timestamp, so its list is not cached yet
+	discoveredNames(t, c, root)
+	if _, err := os.Stat(c.discoveryFile(root)); !os.IsNotExist(err) {
+		t.Errorf("Expected no cache entry for a just-changed directory, got %v", err)
+	}
+}
+
+func TestFileCacheNoWorkflowDir(t *testing.T) {
+	c := NewFileCache(t.TempDir())
+	if got := discoveredNames(t, c, t.TempDir()); len(got) != 0 {
+		t.Errorf("Expected no workflows, got %v", got)
+	}
+}
+
+func TestFileCacheClear(t *testing.T) {
+	dir := t.TempDir()
+	c := NewFileCache(dir)
+	root := t.TempDir()
+	writeWorkflow(t, root, "lint", workflowYAML("lint", "echo lint"))
+	age(t, root)
+	discoveredNames(t, c, root)
+
+	if err := os.MkdirAll(filepath.Join(c.ActionsDir(), "abc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(unrelated, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	for _, sub := range []string{actionsSubdir, discoverySubdir} {
+		if _, err := os.Stat(filepath.Join(dir, sub)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", sub, err)
+		}
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("Expected unrelated files to be kept: %v", err)
+	}
+
+	// With nothing else in it, the cache directory itself goes too
+	if err := os.Remove(unrelated); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty cache directory to be removed, got %v", err)
+	}
+}
+
+func TestNewFileCacheDefaultDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(DirEnv, "")
+
+	c := NewFileCache("")
+	if want := filepath.Join(home, ".hookflow", "cache"); c.Dir() != want {
+		t.Errorf("Dir() = %s, want %s", c.Dir(), want)
+	}
+	if want := filepath.Join(home, ".hookflow", "cache", "actions"); c.ActionsDir() != want {
+		t.Errorf("ActionsDir() = %s, want %s", c.ActionsDir(), want)
+	}
+
+	t.Setenv(DirEnv, filepath.Join(home, "custom"))
+	if want := filepath.Join(home, "custom"); NewFileCache("").Dir() != want {
+		t.Errorf("Expected $%s to win, got %s", DirEnv, NewFileCache("").Dir())
+	}
+}
@@ -0,0 +1,180 @@
+// Package cache avoids repeating work across events. WorkflowCache keeps
+// loaded workflows in memory for long-running processes such as hookflow
+// serve; FileCache keeps discovery results and remote actions on disk
+// between hookflow invocations.
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/htekdev/gh-hookflow/internal/discover"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"github.com/htekdev/gh-hookflow/internal/trigger"
+)
+
+// entry is one cached workflow file: the loaded workflow, or why it failed to load
+type entry struct {
+	file     discover.WorkflowFile
+	workflow *schema.Workflow
+	err      error
+	bases    []string // Absolute paths of the files the workflow extends
+}
+
+// WorkflowCache holds the workflows of one directory, keyed by file path.
+// Reads take a shared lock so events can be processed while a reload waits.
+type WorkflowCache struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+	opts    schema.ValidateOptions
+}
+
+// NewWorkflowCache creates an empty workflow cache
+func NewWorkflowCache() *WorkflowCache {
+	return NewWorkflowCacheWithOptions(schema.ValidateOptions{})
+}
+
+// NewWorkflowCacheWithOptions creates an empty workflow cache that validates
+// workflows with the given options
+func NewWorkflowCacheWithOptions(opts schema.ValidateOptions) *WorkflowCache {
+	return &WorkflowCache{entries: make(map[string]entry), opts: opts}
+}
+
+// Load replaces the cache contents with every workflow under rootDir
+func (c *WorkflowCache) Load(rootDir string) error {
+	files, err := discover.Discover(rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to discover workflows: %w", err)
+	}
+
+	entries := make(map[string]entry, len(files))
+	for _, file := range files {
+		entries[file.Path] = loadEntry(file, c.opts)
+	}
+
+	c.mu.Lock()
+	c.entries = entries
+	c.mu.Unlock()
+	return nil
+}
+
+// Apply updates the cache for one watcher event. It returns the load error
+// of an added or updated file that is not a valid workflow; the file is then
+// reported by Invalid until it is fixed or removed.
+func (c *WorkflowCache) Apply(evt discover.WatchEvent) error {
+	if evt.Op == discover.WatchRemove {
+		c.mu.Lock()
+		delete(c.entries, evt.File.Path)
+		c.mu.Unlock()
+		return nil
+	}
+
+	// Load outside the lock so readers are only blocked for the swap
+	loaded := loadEntry(evt.File, c.opts)
+	c.mu.Lock()
+	c.entries[evt.File.Path] = loaded
+	c.mu.Unlock()
+	return loaded.err
+}
+
+// Workflows returns the valid workflows in file path order
+func (c *WorkflowCache) Workflows() []*schema.Workflow {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var workflows []*schema.Workflow
+	for _, e := range c.sorted() {
+		if e.err == nil {
+			workflows = append(workflows, e.workflow)
+		}
+	}
+	return workflows
+}
+
+// Invalid returns "relative/path: error" for every file that failed to load,
+// in file path order
+func (c *WorkflowCache) Invalid() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var invalid []string
+	for _, e := range c.sorted() {
+		if e.err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", e.file.RelPath, e.err))
+		}
+	}
+	return invalid
+}
+
+// Dependencies returns the absolute paths of the files cached workflows
+// extend, sorted. Watch them to reload the workflows that extend them.
+func (c *WorkflowCache) Dependencies() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, e := range c.entries {
+		for _, base := range e.bases {
+			if !seen[base] {
+				seen[base] = true
+				paths = append(paths, base)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Dependents returns the cached workflow files that extend path, directly or
+// through another base, in file path order
+func (c *WorkflowCache) Dependents(path string) []discover.WorkflowFile {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var files []discover.WorkflowFile
+	for _, e := range c.sorted() {
+		for _, base := range e.bases {
+			if base == path {
+				files = append(files, e.file)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// Len returns the number of cached files, valid or not
+func (c *WorkflowCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// sorted returns the entries in file path order; the caller holds the lock
+func (c *WorkflowCache) sorted() []entry {
+	entries := make([]entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].file.Path < entries[j].file.Path
+	})
+	return entries
+}
+
+// loadEntry loads and validates one workflow file
+func loadEntry(file discover.WorkflowFile, opts schema.ValidateOptions) entry {
+	wf, err := schema.LoadAndValidateWorkflowWithOptions(file.Path, opts)
+	if err == nil {
+		// Compile the triggers once per load rather than once per event
+		trigger.MatcherFor(wf)
+	}
+	return entry{file: file, workflow: wf, err: err, bases: schema.ExtendsChain(file.Path)}
+}
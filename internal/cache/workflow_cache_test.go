@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/htekdev/gh-hookflow/internal/discover"
+)
+
+// writeWorkflow writes a workflow file and returns it as the watcher reports it
+func writeWorkflow(t *testing.T, root, name, content string) discover.WorkflowFile {
+	t.Helper()
+	dir := filepath.Join(root, ".github", "hookflows")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name+".yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return discover.WorkflowFile{Path: path, Name: name, RelPath: filepath.Join(".github", "hookflows", name+".yml")}
+}
+
+func workflowYAML(name, run string) string {
+	return "name: " + name + "\non:\n  tool:\n    name: edit\nsteps:\n  - name: Check\n    run: " + run + "\n"
+}
+
+func workflowNames(c *WorkflowCache) string {
+	var names []string
+	for _, wf := range c.Workflows() {
+		names = append(names, wf.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestWorkflowCacheLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflow(t, tmpDir, "b-lint", workflowYAML("lint", "echo lint"))
+	writeWorkflow(t, tmpDir, "a-secrets", workflowYAML("secrets", "echo secrets"))
+	writeWorkflow(t, tmpDir, "broken", "name: broken\nsteps: not-a-list\n")
+
+	c := NewWorkflowCache()
+	if err := c.Load(tmpDir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if c.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", c.Len())
+	}
+	// Valid workflows come back in file path order
+	if got := workflowNames(c); got != "secrets,lint" {
+		t.Errorf("Workflows() = %s, want secrets,lint", got)
+	}
+	for _, wf := range c.Workflows() {
+		if wf.Matcher() == nil {
+			t.Errorf("Expected %s to be loaded with its compiled matcher", wf.Name)
+		}
+	}
+	invalid := c.Invalid()
+	if len(invalid) != 1 || !strings.HasPrefix(invalid[0], filepath.Join(".github", "hookflows", "broken.yml")+": ") {
+		t.Errorf("Invalid() = %v, want broken.yml", invalid)
+	}
+
+	// Loading again replaces the contents
+	empty := t.TempDir()
+	if err := c.Load(empty); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c.Len() != 0 || len(c.Workflows()) != 0 {
+		t.Errorf("Expected an empty cache after loading an empty directory, got %d entries", c.Len())
+	}
+}
+
+func TestWorkflowCacheApply(t *testing.T) {
+	tmpDir := t.TempDir()
+	lint := writeWorkflow(t, tmpDir, "lint", workflowYAML("lint", "echo v1"))
+
+	c := NewWorkflowCache()
+	if err := c.Load(tmpDir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	writeWorkflow(t, tmpDir, "lint", workflowYAML("lint", "echo v2"))
+	if err := c.Apply(discover.WatchEvent{Op: discover.WatchUpdate, File: lint}); err != nil {
+		t.Fatalf("Apply update: %v", err)
+	}
+	if run := c.Workflows()[0].Steps[0].Run; run != "echo v2" {
+		t.Errorf("Expected the updated step, got %q", run)
+	}
+
+	secrets := writeWorkflow(t, tmpDir, "secrets", workflowYAML("secrets", "echo secrets"))
+	if err := c.Apply(discover.WatchEvent{Op: discover.WatchAdd, File: secrets}); err != nil {
+		t.Fatalf("Apply add: %v", err)
+	}
+	if got := workflowNames(c); got != "lint,secrets" {
+		t.Errorf("Workflows() = %s, want lint,secrets", got)
+	}
+
+	// A file broken by an edit stops running and is reported until it is fixed
+	writeWorkflow(t, tmpDir, "lint", "name: lint\nsteps: not-a-list\n")
+	if err := c.Apply(discover.WatchEvent{Op: discover.WatchUpdate, File: lint}); err == nil {
+		t.Error("Expected an error for an invalid update")
+	}
+	if got := workflowNames(c); got != "secrets" {
+		t.Errorf("Workflows() = %s, want secrets", got)
+	}
+	if len(c.Invalid()) != 1 {
+		t.Errorf("Invalid() = %v, want lint.yml", c.Invalid())
+	}
+
+	if err := os.Remove(lint.Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Apply(discover.WatchEvent{Op: discover.WatchRemove, File: lint}); err != nil {
+		t.Fatalf("Apply remove: %v", err)
+	}
+	if c.Len() != 1 || len(c.Invalid()) != 0 || workflowNames(c) != "secrets" {
+		t.Errorf("Expected only secrets after removing lint, got %d entries (%s)", c.Len(), workflowNames(c))
+	}
+}
+
+func TestWorkflowCacheDependents(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "shared", "base.yml")
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base, []byte(workflowYAML("base", "echo base")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	child := writeWorkflow(t, tmpDir, "child", "name: child\nextends: ../../shared/base.yml\nsteps:\n  - run: echo child\n")
+	writeWorkflow(t, tmpDir, "plain", workflowYAML("plain", "echo plain"))
+
+	c := NewWorkflowCache()
+	if err := c.Load(tmpDir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if deps := c.Dependencies(); len(deps) != 1 || deps[0] != base {
+		t.Errorf("Dependencies() = %v, want [%s]", deps, base)
+	}
+	dependents := c.Dependents(base)
+	if len(dependents) != 1 || dependents[0].Path != child.Path {
+		t.Errorf("Dependents(base) = %+v, want child.yml", dependents)
+	}
+	if dependents := c.Dependents(child.Path); len(dependents) != 0 {
+		t.Errorf("Dependents(child) = %+v, want none", dependents)
+	}
+}
+
+func TestWorkflowCacheConcurrentAccess(t *testing.T) {
+	tmpDir := t.TempDir()
+	lint := writeWorkflow(t, tmpDir, "lint", workflowYAML("lint", "echo lint"))
+
+	c := NewWorkflowCache()
+	if err := c.Load(tmpDir); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				for _, wf := range c.Workflows() {
+					if wf.Name != "lint" {
+						t.Errorf("Unexpected workflow %q", wf.Name)
+					}
+				}
+				_ = c.Invalid()
+			}
+		}()
+	}
+	for j := 0; j < 20; j++ {
+		if err := c.Apply(discover.WatchEvent{Op: discover.WatchUpdate, File: lint}); err != nil {
+			t.Errorf("Apply: %v", err)
+		}
+	}
+	wg.Wait()
+}
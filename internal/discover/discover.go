@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -13,12 +14,15 @@ const (
 
 // WorkflowFile represents a discovered workflow file
 type WorkflowFile struct {
-	Path     string // Full path to the file
-	Name     string // Workflow name (filename without extension)
-	RelPath  string // Relative path from root
+	Path     string    // Full path to the file
+	Name     string    // Workflow name (filename without extension)
+	RelPath  string    // Relative path from root
+	ModTime  time.Time // File modification time when it was discovered, for detecting stale copies
+	LoadedAt time.Time // When the file was discovered
 }
 
-// Discover finds all workflow files in the given directory
+// Discover finds all workflow files in the workflow directory under rootDir,
+// including its subdirectories
 func Discover(rootDir string) ([]WorkflowFile, error) {
 	workflowPath := filepath.Join(rootDir, WorkflowDir)
 	
@@ -28,6 +32,7 @@ func Discover(rootDir string) ([]WorkflowFile, error) {
 	}
 
 	var workflows []WorkflowFile
+	now := time.Now()
 
 	err := filepath.Walk(workflowPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -55,9 +60,11 @@ func Discover(rootDir string) ([]WorkflowFile, error) {
 		name := strings.TrimSuffix(filepath.Base(path), ext)
 
 		workflows = append(workflows, WorkflowFile{
-			Path:    path,
-			Name:    name,
-			RelPath: relPath,
+			Path:     path,
+			Name:     name,
+			RelPath:  relPath,
+			ModTime:  info.ModTime(),
+			LoadedAt: now,
 		})
 
 		return nil
@@ -80,6 +87,7 @@ func DiscoverByGlob(rootDir string, pattern string) ([]WorkflowFile, error) {
 	}
 
 	var workflows []WorkflowFile
+	now := time.Now()
 	for _, path := range matches {
 		info, err := os.Stat(path)
 		if err != nil || info.IsDir() {
@@ -99,9 +107,11 @@ func DiscoverByGlob(rootDir string, pattern string) ([]WorkflowFile, error) {
 		name := strings.TrimSuffix(filepath.Base(path), ext)
 
 		workflows = append(workflows, WorkflowFile{
-			Path:    path,
-			Name:    name,
-			RelPath: relPath,
+			Path:     path,
+			Name:     name,
+			RelPath:  relPath,
+			ModTime:  info.ModTime(),
+			LoadedAt: now,
 		})
 	}
 
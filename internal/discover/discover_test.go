@@ -1,9 +1,11 @@
 package discover
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDiscover(t *testing.T) {
@@ -132,6 +134,44 @@ func TestWorkflowFile(t *testing.T) {
 	}
 }
 
+func TestDiscoverModTime(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(workflowDir, "lint.yml")
+	if err := os.WriteFile(path, []byte("name: lint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	found, err := Discover(tmpDir)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	byGlob, err := DiscoverByGlob(tmpDir, "*.yml")
+	if err != nil {
+		t.Fatalf("DiscoverByGlob: %v", err)
+	}
+
+	for _, files := range [][]WorkflowFile{found, byGlob} {
+		if len(files) != 1 {
+			t.Fatalf("Expected 1 workflow, got %d", len(files))
+		}
+		if !files[0].ModTime.Equal(modTime) {
+			t.Errorf("ModTime = %v, want %v", files[0].ModTime, modTime)
+		}
+		if files[0].LoadedAt.Before(before) || files[0].LoadedAt.After(time.Now()) {
+			t.Errorf("LoadedAt = %v, want the time of discovery", files[0].LoadedAt)
+		}
+	}
+}
+
 func TestDiscoverByGlob(t *testing.T) {
 	tmpDir := t.TempDir()
 	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
@@ -674,3 +714,148 @@ func TestWorkflowFileNameExtraction(t *testing.T) {
 		}
 	}
 }
+
+func TestWatcherPoll(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(workflowDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	lint := write("lint.yml", "name: lint")
+
+	w, err := NewWatcher(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = w.Close() }()
+	if changes := w.poll(); len(changes) != 0 {
+		t.Fatalf("Expected no changes for existing files, got %+v", changes)
+	}
+
+	security := write("security.yaml", "name: security")
+	write("notes.md", "# not a workflow")
+	changes := w.poll()
+	if len(changes) != 1 || changes[0].Op != WatchAdd || changes[0].File.Path != security || changes[0].File.Name != "security" {
+		t.Fatalf("Expected security.yaml to be added, got %+v", changes)
+	}
+
+	write("lint.yml", "name: lint-v2")
+	changes = w.poll()
+	if len(changes) != 1 || changes[0].Op != WatchUpdate || changes[0].File.Path != lint {
+		t.Fatalf("Expected lint.yml to be updated, got %+v", changes)
+	}
+
+	if err := os.Remove(lint); err != nil {
+		t.Fatal(err)
+	}
+	write("security.yaml", "name: security-v2")
+	changes = w.poll()
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	// Changes are reported in path order
+	if changes[0].Op != WatchRemove || changes[0].File.Path != lint || changes[1].Op != WatchUpdate || changes[1].File.Path != security {
+		t.Errorf("Expected lint.yml removed then security.yaml updated, got %+v", changes)
+	}
+
+	if changes := w.poll(); len(changes) != 0 {
+		t.Errorf("Expected no changes without edits, got %+v", changes)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	w, err := NewWatcher(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// The workflow directory does not exist yet when the watcher starts
+	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workflowDir, "lint.yml"), []byte("name: lint"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case evt := <-w.Events():
+		if evt.Op != WatchAdd || evt.File.Name != "lint" || evt.File.RelPath != filepath.Join(WorkflowDir, "lint.yml") {
+			t.Errorf("Expected lint.yml to be added, got %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watcher did not report the new workflow")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-w.Events():
+		if ok {
+			t.Error("Expected no further events after cancel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Events was not closed after cancel")
+	}
+}
+
+func TestWatcherTrack(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(tmpDir, "shared", "base.yml")
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base, []byte("name: base"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWatcher(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Tracking records the current version, so nothing is reported yet
+	w.Track([]string{base})
+	go w.Run(ctx)
+
+	if err := os.WriteFile(base, []byte("name: base-v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case evt := <-w.Events():
+		if evt.Op != WatchUpdate || evt.File.Path != base || !evt.Dependency {
+			t.Errorf("Expected base.yml to be updated as a dependency, got %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watcher did not report the tracked file")
+	}
+
+	if err := os.Remove(base); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case evt := <-w.Events():
+		if evt.Op != WatchRemove || evt.File.Path != base || !evt.Dependency {
+			t.Errorf("Expected base.yml to be removed as a dependency, got %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watcher did not report the removed tracked file")
+	}
+}
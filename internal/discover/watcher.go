@@ -0,0 +1,275 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchOp is the kind of change a Watcher reports
+type WatchOp string
+
+const (
+	// WatchAdd reports a new workflow file
+	WatchAdd WatchOp = "add"
+	// WatchUpdate reports a workflow file whose content changed
+	WatchUpdate WatchOp = "update"
+	// WatchRemove reports a deleted workflow file
+	WatchRemove WatchOp = "remove"
+)
+
+// WatchEvent is one change to a workflow file
+type WatchEvent struct {
+	Op   WatchOp
+	File WorkflowFile
+	// Dependency is set when File is a tracked extends base rather than a
+	// workflow under the workflow directory
+	Dependency bool
+}
+
+// fileState identifies one version of a workflow file
+type fileState struct {
+	file       WorkflowFile
+	modTime    int64
+	size       int64
+	dependency bool
+}
+
+// Watcher reports workflow files added, updated or removed under a root
+// directory, and changes to extra files registered with Track. File system
+// notifications only tell it when to look: each one triggers a rescan that is
+// compared with the previous one, so editors that save through a temporary
+// file still produce a single update.
+type Watcher struct {
+	rootDir string
+	notify  *fsnotify.Watcher
+	events  chan WatchEvent
+
+	mu      sync.Mutex
+	tracked map[string]bool
+	last    map[string]fileState
+}
+
+// NewWatcher creates a watcher for the workflows under rootDir. The current
+// files are recorded immediately; only later changes are reported. The
+// workflow directory does not need to exist yet.
+func NewWatcher(rootDir string) (*Watcher, error) {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	w := &Watcher{
+		rootDir: rootDir,
+		notify:  notify,
+		events:  make(chan WatchEvent),
+		tracked: make(map[string]bool),
+	}
+	w.watchDirs()
+	w.last = w.snapshot()
+	return w, nil
+}
+
+// Events returns the channel changes are sent on. It is closed when Run returns.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Track replaces the extra files to report changes for, such as the bases
+// of workflows that use extends. Their current state is recorded, so only
+// later changes are reported, with Dependency set.
+func (w *Watcher) Track(paths []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tracked := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		tracked[path] = true
+		// Watch the directory so a file replaced by rename is still seen
+		_ = w.notify.Add(filepath.Dir(path))
+	}
+	for path := range w.tracked {
+		if state, ok := w.last[path]; ok && state.dependency && !tracked[path] {
+			delete(w.last, path)
+		}
+	}
+	w.tracked = tracked
+	for path := range tracked {
+		if _, known := w.last[path]; known {
+			continue
+		}
+		if state, ok := w.trackedState(path); ok {
+			w.last[path] = state
+		}
+	}
+}
+
+// Run sends changes on Events until ctx is cancelled, then releases the
+// file system watches
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.events)
+	defer func() { _ = w.Close() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			if !w.relevant(evt.Name) {
+				continue
+			}
+			if evt.Has(fsnotify.Create) {
+				// A new directory has to be watched before its files are seen
+				w.watchDirs()
+			}
+		case _, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			// Notifications may have been dropped; the rescan catches up
+		}
+
+		for _, change := range w.poll() {
+			select {
+			case w.events <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close releases the file system watches. Run calls it on return.
+func (w *Watcher) Close() error {
+	return w.notify.Close()
+}
+
+// relevant reports whether a notification for path may change a workflow or
+// a tracked file
+func (w *Watcher) relevant(path string) bool {
+	github := filepath.Join(w.rootDir, ".github")
+	if path == github || strings.HasPrefix(path, github+string(filepath.Separator)) {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tracked[path]
+}
+
+// watchDirs watches the root directory, .github and every directory under
+// the workflow directory that exists, so that creating any of them is noticed
+func (w *Watcher) watchDirs() {
+	_ = w.notify.Add(w.rootDir)
+	_ = w.notify.Add(filepath.Join(w.rootDir, ".github"))
+	_ = filepath.WalkDir(filepath.Join(w.rootDir, WorkflowDir), func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			_ = w.notify.Add(path)
+		}
+		return nil
+	})
+}
+
+// poll compares the workflow and tracked files with the previous scan and
+// returns the changes in path order
+func (w *Watcher) poll() []WatchEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current := w.snapshotLocked()
+
+	var changes []WatchEvent
+	for path, state := range current {
+		previous, existed := w.last[path]
+		switch {
+		case !existed:
+			changes = append(changes, WatchEvent{Op: WatchAdd, File: state.file, Dependency: state.dependency})
+		case previous.modTime != state.modTime || previous.size != state.size:
+			changes = append(changes, WatchEvent{Op: WatchUpdate, File: state.file, Dependency: state.dependency})
+		}
+	}
+	for path, state := range w.last {
+		if _, exists := current[path]; !exists {
+			changes = append(changes, WatchEvent{Op: WatchRemove, File: state.file, Dependency: state.dependency})
+		}
+	}
+	w.last = current
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].File.Path < changes[j].File.Path
+	})
+	return changes
+}
+
+// snapshot records the current workflow and tracked files
+func (w *Watcher) snapshot() map[string]fileState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.snapshotLocked()
+}
+
+// snapshotLocked records the current workflow and tracked files; the caller
+// holds mu. A tracked file that is also a workflow is reported as a workflow.
+func (w *Watcher) snapshotLocked() map[string]fileState {
+	states := snapshotWorkflows(w.rootDir)
+	workflows := make(map[string]bool, len(states))
+	for path := range states {
+		if abs, err := filepath.Abs(path); err == nil {
+			workflows[abs] = true
+		}
+	}
+	for path := range w.tracked {
+		if abs, err := filepath.Abs(path); err == nil && workflows[abs] {
+			continue
+		}
+		if state, ok := w.trackedState(path); ok {
+			states[path] = state
+		}
+	}
+	return states
+}
+
+// trackedState records the version of a tracked file, if it exists
+func (w *Watcher) trackedState(path string) (fileState, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return fileState{}, false
+	}
+	relPath, err := filepath.Rel(w.rootDir, path)
+	if err != nil {
+		relPath = path
+	}
+	file := WorkflowFile{
+		Path:    path,
+		Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		RelPath: relPath,
+		ModTime: info.ModTime(),
+	}
+	return fileState{file: file, modTime: info.ModTime().UnixNano(), size: info.Size(), dependency: true}, true
+}
+
+// snapshotWorkflows records the version of every workflow file under rootDir.
+// A directory that cannot be read yields an empty snapshot.
+func snapshotWorkflows(rootDir string) map[string]fileState {
+	states := make(map[string]fileState)
+	files, err := Discover(rootDir)
+	if err != nil {
+		return states
+	}
+	for _, file := range files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			continue
+		}
+		states[file.Path] = fileState{file: file, modTime: info.ModTime().UnixNano(), size: info.Size()}
+	}
+	return states
+}
@@ -14,9 +14,11 @@ import (
 
 // RawHookInput represents the raw input from a Copilot hook
 type RawHookInput struct {
-	ToolName string          `json:"toolName"`
-	ToolArgs json.RawMessage `json:"toolArgs"`
-	Cwd      string          `json:"cwd"`
+	ToolName  string                 `json:"toolName"`
+	ToolArgs  json.RawMessage        `json:"toolArgs"`
+	Cwd       string                 `json:"cwd"`
+	SessionID string                 `json:"sessionId,omitempty"` // Optional Copilot session ID, exposed as event.hook.session_id
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`  // Optional enrichment, exposed as event.metadata
 }
 
 // ToolArgs represents parsed tool arguments
@@ -54,6 +56,9 @@ type GitProvider interface {
 	GetPendingFiles(cwd string, command string) []schema.FileStatus
 	GetRemote(cwd string) string
 	GetAheadBehind(cwd string) (ahead, behind int)
+	GetRevision(cwd, rev string) string
+	GetDiffFiles(cwd, before, after string) []schema.FileStatus
+	GetStagedDiff(cwd string) string
 }
 
 // NewDetector creates a new event detector
@@ -80,11 +85,13 @@ func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
 	log.Debug("detecting event for tool=%s, cwd=%s", raw.ToolName, raw.Cwd)
 
 	event := &schema.Event{
-		Cwd: raw.Cwd,
+		Cwd:      raw.Cwd,
+		Metadata: schema.MetadataStrings(raw.Metadata),
 	}
 
 	// Parse tool args
 	var args ToolArgs
+	argsJSON := []byte(raw.ToolArgs)
 	if len(raw.ToolArgs) > 0 {
 		// Handle both object and string forms
 		if err := json.Unmarshal(raw.ToolArgs, &args); err != nil {
@@ -92,7 +99,8 @@ func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
 			var strArgs string
 			if err := json.Unmarshal(raw.ToolArgs, &strArgs); err == nil {
 				// Try parsing the string as JSON
-				_ = json.Unmarshal([]byte(strArgs), &args)
+				argsJSON = []byte(strArgs)
+				_ = json.Unmarshal(argsJSON, &args)
 			}
 		}
 	}
@@ -110,14 +118,20 @@ func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
 
 	// Always set tool event
 	toolArgs := make(map[string]interface{})
-	if len(raw.ToolArgs) > 0 {
-		_ = json.Unmarshal(raw.ToolArgs, &toolArgs)
+	if len(argsJSON) > 0 {
+		_ = json.Unmarshal(argsJSON, &toolArgs)
 	}
 	event.Tool = &schema.ToolEvent{
 		Name:     raw.ToolName,
 		Args:     toolArgs,
 		HookType: "preToolUse",
 	}
+	event.Hook = &schema.HookEvent{
+		Type:      "preToolUse",
+		Cwd:       raw.Cwd,
+		Tool:      event.Tool,
+		SessionID: raw.SessionID,
+	}
 
 	// Detect specific event types based on tool and command
 	switch raw.ToolName {
@@ -130,6 +144,9 @@ func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
 	case "edit":
 		log.Debug("edit tool for path=%s", args.Path)
 		d.detectEditEvent(event, &args)
+	case "delete", "remove":
+		log.Debug("%s tool for path=%s", raw.ToolName, args.Path)
+		d.detectDeleteEvent(event, &args)
 	}
 
 	// Log what was detected
@@ -177,18 +194,37 @@ func (d *Detector) buildCommitEvent(event *schema.Event, command, cwd string) {
 		SHA:     "pending",
 		Message: ExtractCommitMessage(command),
 		Author:  d.gitProvider.GetAuthor(cwd),
+		Branch:  d.gitProvider.GetBranch(cwd),
 		Files:   stagedFiles,
+		Diff:    capDiff(d.gitProvider.GetStagedDiff(cwd)),
 	}
 }
 
+// capDiff keeps large diffs out of the event, where every workflow's
+// expression context would hold a copy
+func capDiff(diff string) string {
+	if len(diff) > schema.MaxCommitDiffBytes {
+		return schema.DiffTruncated
+	}
+	return diff
+}
+
 // buildPushEvent builds a push event from a git push command
 func (d *Detector) buildPushEvent(event *schema.Event, command, cwd string) {
 	branch := d.gitProvider.GetBranch(cwd)
 
+	// The push sends what is between the upstream and HEAD; a branch
+	// without an upstream has no known before and no file list
+	before := d.gitProvider.GetRevision(cwd, "@{upstream}")
+	after := d.gitProvider.GetRevision(cwd, "HEAD")
+
 	event.Push = &schema.PushEvent{
 		Ref:    ExtractPushRef(command, branch),
-		Before: "",
-		After:  "",
+		Before: before,
+		After:  after,
+	}
+	if before != "" && after != "" {
+		event.Push.Files = d.gitProvider.GetDiffFiles(cwd, before, after)
 	}
 }
 
@@ -203,10 +239,27 @@ func (d *Detector) detectCreateEvent(event *schema.Event, args *ToolArgs) {
 
 // detectEditEvent handles file edits
 func (d *Detector) detectEditEvent(event *schema.Event, args *ToolArgs) {
+	event.File = &schema.FileEvent{
+		Path:         args.Path,
+		Action:       "edit",
+		ChangedLines: max(countLines(args.OldStr), countLines(args.NewStr)),
+	}
+}
+
+// detectDeleteEvent handles file deletion
+func (d *Detector) detectDeleteEvent(event *schema.Event, args *ToolArgs) {
 	event.File = &schema.FileEvent{
 		Path:   args.Path,
-		Action: "edit",
+		Action: "delete",
+	}
+}
+
+// countLines returns the number of lines in s, or 0 for an empty string
+func countLines(s string) int {
+	if s == "" {
+		return 0
 	}
+	return strings.Count(s, "\n") + 1
 }
 
 // Git command detection patterns
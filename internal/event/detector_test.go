@@ -2,6 +2,7 @@ package event
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/htekdev/gh-hookflow/internal/schema"
@@ -239,11 +240,153 @@ func TestDetector(t *testing.T) {
 		if evt.Commit.Author != "test@example.com" {
 			t.Errorf("Author = %q, want %q", evt.Commit.Author, "test@example.com")
 		}
+		if evt.Commit.Branch != "main" {
+			t.Errorf("Branch = %q, want %q", evt.Commit.Branch, "main")
+		}
 		if len(evt.Commit.Files) != 1 {
 			t.Errorf("Files count = %d, want 1", len(evt.Commit.Files))
 		}
 	})
 
+	t.Run("git commit diff", func(t *testing.T) {
+		input := []byte(`{"toolName": "bash", "toolArgs": {"command": "git commit -m x"}, "cwd": "/test/repo"}`)
+
+		diffMock := *mock
+		diffMock.StagedDiff = "+added line\n"
+		evt, err := NewDetector(&diffMock).DetectFromRawInput(input)
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Commit.Diff != "+added line\n" {
+			t.Errorf("Diff = %q, want the staged diff", evt.Commit.Diff)
+		}
+
+		diffMock.StagedDiff = strings.Repeat("x", schema.MaxCommitDiffBytes)
+		evt, _ = NewDetector(&diffMock).DetectFromRawInput(input)
+		if len(evt.Commit.Diff) != schema.MaxCommitDiffBytes {
+			t.Errorf("Expected a diff of exactly the limit to be kept, got %d bytes", len(evt.Commit.Diff))
+		}
+
+		diffMock.StagedDiff += "x"
+		evt, _ = NewDetector(&diffMock).DetectFromRawInput(input)
+		if evt.Commit.Diff != schema.DiffTruncated {
+			t.Errorf("Diff = %.40q, want %q", evt.Commit.Diff, schema.DiffTruncated)
+		}
+	})
+
+	t.Run("metadata passthrough", func(t *testing.T) {
+		input := `{
+			"toolName": "edit",
+			"toolArgs": {"path": "src/app.ts"},
+			"cwd": "/test/repo",
+			"metadata": {"ticket": "JIRA-123", "environment": "staging"}
+		}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+
+		if evt.Metadata["ticket"] != "JIRA-123" {
+			t.Errorf("Metadata[ticket] = %q, want %q", evt.Metadata["ticket"], "JIRA-123")
+		}
+		if evt.Metadata["environment"] != "staging" {
+			t.Errorf("Metadata[environment] = %q, want %q", evt.Metadata["environment"], "staging")
+		}
+	})
+
+	t.Run("non-string metadata", func(t *testing.T) {
+		input := `{
+			"toolName": "edit",
+			"toolArgs": {"path": "src/app.ts"},
+			"cwd": "/test/repo",
+			"metadata": {"ticket": "JIRA-123", "n": 3, "ratio": 0.5, "urgent": true, "owner": null, "labels": ["a"], "extra": {"k": "v"}}
+		}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		want := map[string]string{"ticket": "JIRA-123"}
+		if len(evt.Metadata) != len(want) {
+			t.Errorf("Metadata = %v, want %v", evt.Metadata, want)
+		}
+		for k, v := range want {
+			if evt.Metadata[k] != v {
+				t.Errorf("Metadata[%s] = %q, want %q", k, evt.Metadata[k], v)
+			}
+		}
+	})
+
+	t.Run("hook event", func(t *testing.T) {
+		input := `{"toolName": "edit", "toolArgs": {"path": "src/app.ts"}, "cwd": "/test/repo"}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+
+		if evt.Hook == nil {
+			t.Fatal("Expected Hook event")
+		}
+		if evt.Hook.Type != "preToolUse" || evt.Hook.Cwd != "/test/repo" {
+			t.Errorf("Hook = %+v, want preToolUse in /test/repo", evt.Hook)
+		}
+		if evt.Hook.Tool == nil || evt.Hook.Tool.Name != "edit" {
+			t.Errorf("Hook.Tool = %+v, want edit", evt.Hook.Tool)
+		}
+		if evt.Hook.SessionID != "" {
+			t.Errorf("Hook.SessionID = %q, want empty without sessionId", evt.Hook.SessionID)
+		}
+	})
+
+	t.Run("session ID", func(t *testing.T) {
+		input := `{"toolName": "edit", "toolArgs": {"path": "src/app.ts"}, "cwd": "/test/repo", "sessionId": "3f2a-session"}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Hook == nil || evt.Hook.SessionID != "3f2a-session" {
+			t.Errorf("Hook = %+v, want session ID 3f2a-session", evt.Hook)
+		}
+	})
+
+	t.Run("file create with string args", func(t *testing.T) {
+		input := `{"toolName": "create", "toolArgs": "{\"path\": \"config.env\", \"file_text\": \"API_KEY=secret\"}", "cwd": "/test/repo"}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.File == nil || evt.File.Content != "API_KEY=secret" {
+			t.Fatalf("File = %+v, want content API_KEY=secret", evt.File)
+		}
+		if evt.Tool.Args["file_text"] != "API_KEY=secret" || evt.Tool.Args["path"] != "config.env" {
+			t.Errorf("Tool.Args = %v, want the decoded string args", evt.Tool.Args)
+		}
+	})
+
+	t.Run("edit changed lines", func(t *testing.T) {
+		input := `{
+			"toolName": "edit",
+			"toolArgs": {"path": "src/app.ts", "old_str": "a\nb", "new_str": "a\nb\nc\nd"},
+			"cwd": "/test/repo"
+		}`
+
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+
+		if evt.File == nil {
+			t.Fatal("Expected File event")
+		}
+		if evt.File.ChangedLines != 4 {
+			t.Errorf("ChangedLines = %d, want 4", evt.File.ChangedLines)
+		}
+	})
+
 	t.Run("git add && commit chain", func(t *testing.T) {
 		input := `{
 			"toolName": "powershell",
@@ -285,6 +428,35 @@ func TestDetector(t *testing.T) {
 		}
 	})
 
+	t.Run("git push diff files", func(t *testing.T) {
+		pushMock := &MockGitProvider{
+			Branch:    "main",
+			Revisions: map[string]string{"@{upstream}": "aaa111", "HEAD": "bbb222"},
+			DiffFiles: []schema.FileStatus{{Path: "src/app.ts", Status: "modified"}},
+		}
+		input := `{"toolName": "bash", "toolArgs": {"command": "git push"}, "cwd": "/test/repo"}`
+		evt, err := NewDetector(pushMock).DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Push.Before != "aaa111" || evt.Push.After != "bbb222" {
+			t.Errorf("Before/After = %q/%q, want aaa111/bbb222", evt.Push.Before, evt.Push.After)
+		}
+		if len(evt.Push.Files) != 1 || evt.Push.Files[0].Path != "src/app.ts" {
+			t.Errorf("Files = %v, want src/app.ts", evt.Push.Files)
+		}
+
+		// Without an upstream the range and files are unknown
+		pushMock.Revisions = map[string]string{"HEAD": "bbb222"}
+		evt, err = NewDetector(pushMock).DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Push.Before != "" || evt.Push.Files != nil {
+			t.Errorf("Expected no before or files without upstream, got %+v", evt.Push)
+		}
+	})
+
 	t.Run("file create detection", func(t *testing.T) {
 		input := `{
 			"toolName": "create",
@@ -308,6 +480,20 @@ func TestDetector(t *testing.T) {
 		}
 	})
 
+	t.Run("file delete detection", func(t *testing.T) {
+		for _, tool := range []string{"delete", "remove"} {
+			input := `{"toolName": "` + tool + `", "toolArgs": {"path": "config/prod.yml"}, "cwd": "/test/repo"}`
+
+			evt, err := detector.DetectFromRawInput([]byte(input))
+			if err != nil {
+				t.Fatalf("DetectFromRawInput failed: %v", err)
+			}
+			if evt.File == nil || evt.File.Path != "config/prod.yml" || evt.File.Action != "delete" {
+				t.Errorf("%s tool: File = %+v, want a delete of config/prod.yml", tool, evt.File)
+			}
+		}
+	})
+
 	t.Run("file edit detection", func(t *testing.T) {
 		input := `{
 			"toolName": "edit",
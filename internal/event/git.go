@@ -1,6 +1,7 @@
 package event
 
 import (
+	"io"
 	"os/exec"
 	"path/filepath"
 	"strings"
@@ -115,7 +116,60 @@ func (g *RealGitProvider) GetAheadBehind(cwd string) (ahead, behind int) {
 	return ahead, behind
 }
 
-// parseGitStatus parses git diff --name-status output
+// GetRevision resolves rev (e.g. HEAD or @{upstream}) to a commit SHA, or
+// returns "" when it does not exist
+func (g *RealGitProvider) GetRevision(cwd, rev string) string {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GetDiffFiles returns the files changed between two commits
+func (g *RealGitProvider) GetDiffFiles(cwd, before, after string) []schema.FileStatus {
+	// Renames are reported as a delete and an add so both paths can match
+	cmd := exec.Command("git", "diff", "--name-status", "--no-renames", before+".."+after)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return parseGitStatus(string(out))
+}
+
+// GetStagedDiff returns the diff of the files staged for commit. Only one
+// byte more than schema.MaxCommitDiffBytes is read, enough to tell that the
+// diff is too large, and git is then stopped rather than left to write the
+// rest. External diff drivers and color are turned off so the diff is plain.
+func (g *RealGitProvider) GetStagedDiff(cwd string) string {
+	cmd := exec.Command("git", "diff", "--cached", "--no-ext-diff", "--no-color")
+	cmd.Dir = cwd
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return ""
+	}
+	if err := cmd.Start(); err != nil {
+		return ""
+	}
+
+	out, readErr := io.ReadAll(io.LimitReader(stdout, schema.MaxCommitDiffBytes+1))
+	if len(out) > schema.MaxCommitDiffBytes {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return string(out)
+	}
+	if err := cmd.Wait(); err != nil || readErr != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// parseGitStatus parses git diff --name-status output. Fields are separated
+// by tabs; renames and copies carry a similarity score (R100, C075) and list
+// the old path before the new one, which is the path reported.
 func parseGitStatus(output string) []schema.FileStatus {
 	var files []schema.FileStatus
 	lines := strings.Split(strings.TrimSpace(output), "\n")
@@ -123,10 +177,10 @@ func parseGitStatus(output string) []schema.FileStatus {
 		if line == "" {
 			continue
 		}
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
+		parts := strings.Split(line, "\t")
+		if len(parts) >= 2 && parts[0] != "" {
 			status := "modified"
-			switch parts[0] {
+			switch parts[0][:1] {
 			case "A":
 				status = "added"
 			case "M":
@@ -139,7 +193,7 @@ func parseGitStatus(output string) []schema.FileStatus {
 				status = "copied"
 			}
 			files = append(files, schema.FileStatus{
-				Path:   parts[1],
+				Path:   parts[len(parts)-1],
 				Status: status,
 			})
 		}
@@ -265,6 +319,9 @@ type MockGitProvider struct {
 	Remote       string
 	Ahead        int
 	Behind       int
+	Revisions    map[string]string // rev -> SHA for GetRevision
+	DiffFiles    []schema.FileStatus
+	StagedDiff   string
 }
 
 func (m *MockGitProvider) GetBranch(cwd string) string {
@@ -293,3 +350,15 @@ func (m *MockGitProvider) GetRemote(cwd string) string {
 func (m *MockGitProvider) GetAheadBehind(cwd string) (ahead, behind int) {
 	return m.Ahead, m.Behind
 }
+
+func (m *MockGitProvider) GetRevision(cwd, rev string) string {
+	return m.Revisions[rev]
+}
+
+func (m *MockGitProvider) GetDiffFiles(cwd, before, after string) []schema.FileStatus {
+	return m.DiffFiles
+}
+
+func (m *MockGitProvider) GetStagedDiff(cwd string) string {
+	return m.StagedDiff
+}
@@ -1,6 +1,10 @@
 package event
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/htekdev/gh-hookflow/internal/schema"
@@ -35,8 +39,18 @@ func TestParseGitStatus(t *testing.T) {
 		},
 		{
 			name:   "renamed",
-			output: "R\told.ts\tnew.ts",
-			want:   []schema.FileStatus{{Path: "old.ts", Status: "renamed"}},
+			output: "R100\told.ts\tnew.ts",
+			want:   []schema.FileStatus{{Path: "new.ts", Status: "renamed"}},
+		},
+		{
+			name:   "copied",
+			output: "C075\tsrc/a.ts\tsrc/b.ts",
+			want:   []schema.FileStatus{{Path: "src/b.ts", Status: "copied"}},
+		},
+		{
+			name:   "path with spaces",
+			output: "M\tdocs/release notes.md",
+			want:   []schema.FileStatus{{Path: "docs/release notes.md", Status: "modified"}},
 		},
 		{
 			name:   "multiple files",
@@ -241,3 +255,119 @@ func TestMockGitProviderDefaults(t *testing.T) {
 		t.Error("GetStagedFiles should return nil")
 	}
 }
+
+// TestRealGitProviderDiffFiles tests resolving a push range and listing its files in a real repository
+func TestRealGitProviderDiffFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("old.txt", "old")
+	write("README.md", "v1")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	provider := &RealGitProvider{}
+	before := provider.GetRevision(dir, "HEAD")
+	if before == "" {
+		t.Fatal("Expected HEAD to resolve")
+	}
+	if rev := provider.GetRevision(dir, "@{upstream}"); rev != "" {
+		t.Errorf("Expected no upstream, got %q", rev)
+	}
+
+	write("src/main.go", "package main")
+	write("README.md", "v2")
+	git("mv", "old.txt", "new.txt")
+	git("add", ".")
+	git("commit", "-q", "-m", "second")
+	after := provider.GetRevision(dir, "HEAD")
+
+	got := make(map[string]string)
+	for _, f := range provider.GetDiffFiles(dir, before, after) {
+		got[f.Path] = f.Status
+	}
+	want := map[string]string{
+		"README.md":   "modified",
+		"src/main.go": "added",
+		"old.txt":     "deleted",
+		"new.txt":     "added",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetDiffFiles = %v, want %v", got, want)
+	}
+	for path, status := range want {
+		if got[path] != status {
+			t.Errorf("%s: status %q, want %q", path, got[path], status)
+		}
+	}
+
+	// A staged rename is listed by git as R<score>, old path, new path
+	git("mv", "new.txt", "renamed.txt")
+	cmd := exec.Command("git", "diff", "--cached", "--name-status")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "R100\tnew.txt\trenamed.txt") {
+		t.Fatalf("Expected git to report an R100 rename, got %q", out)
+	}
+	if staged := provider.GetStagedFiles(dir); len(staged) != 1 || staged[0].Path != "renamed.txt" || staged[0].Status != "renamed" {
+		t.Errorf("GetStagedFiles = %+v, want renamed.txt renamed", staged)
+	}
+	git("mv", "renamed.txt", "new.txt")
+
+	if files := provider.GetDiffFiles(dir, "nonexistent", after); files != nil {
+		t.Errorf("Expected nil for an unknown revision, got %v", files)
+	}
+
+	if diff := provider.GetStagedDiff(dir); diff != "" {
+		t.Errorf("Expected no staged diff after committing, got %q", diff)
+	}
+	write("README.md", "v3")
+	git("add", "README.md")
+	if diff := provider.GetStagedDiff(dir); !strings.Contains(diff, "+v3") || !strings.Contains(diff, "-v2") {
+		t.Errorf("Expected the staged README change, got %q", diff)
+	}
+
+	// Color and external diff drivers configured for the user are ignored
+	git("config", "color.diff", "always")
+	git("config", "diff.external", "false")
+	if diff := provider.GetStagedDiff(dir); !strings.HasPrefix(diff, "diff --git") || strings.Contains(diff, "\x1b[") {
+		t.Errorf("Expected a plain diff, got %q", diff)
+	}
+
+	// A diff over the cap is read only far enough to know it is too large
+	write("big.txt", strings.Repeat("0123456789abcdef\n", schema.MaxCommitDiffBytes/16))
+	git("add", "big.txt")
+	diff := provider.GetStagedDiff(dir)
+	if len(diff) != schema.MaxCommitDiffBytes+1 {
+		t.Errorf("Expected %d bytes of a large diff, got %d", schema.MaxCommitDiffBytes+1, len(diff))
+	}
+	if capDiff(diff) != schema.DiffTruncated {
+		t.Error("Expected the large diff to be reported as truncated")
+	}
+}
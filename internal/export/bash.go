@@ -0,0 +1,289 @@
+// Package export converts workflows into standalone shell scripts for
+// machines where hookflow is not installed.
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/expression"
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// EventEnvPrefix starts the environment variables an exported script reads
+// event data from: event.file.path is read from $HOOKFLOW_EVENT_FILE_PATH
+const EventEnvPrefix = "HOOKFLOW_EVENT_"
+
+// failedVar is the script variable recording whether a step has failed
+const failedVar = "hookflow_failed"
+
+// continuedVar records that a continue-on-error step failed. Later steps
+// still run, but a blocking workflow exits non-zero, as the runner denies it.
+const continuedVar = "hookflow_continued"
+
+// EventVar returns the environment variable an exported script reads an
+// event path such as event.tool.args.command from
+func EventVar(path string) string {
+	name := strings.ToUpper(strings.TrimPrefix(path, "event."))
+	return EventEnvPrefix + strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// runtimeRoots are the expression contexts that only exist while a workflow
+// runs; the rest of an expression can be evaluated when it is exported
+var runtimeRoots = map[string]bool{"event": true, "env": true, "steps": true, "matrix": true, "ctx": true}
+
+// runtimeFunctions depend on earlier steps or on files read at run time
+var runtimeFunctions = map[string]bool{"success": true, "failure": true, "cancelled": true, "readFile": true}
+
+// bashScript collects what a script needs while its steps are rendered
+type bashScript struct {
+	eventVars map[string]string // Environment variable to event path, for the header
+}
+
+// Bash renders a workflow as a bash script that runs its steps in order.
+// Expressions that do not depend on the event are evaluated now; event.*
+// and env.* references become shell variables, and if: conditions become
+// [[ ]] tests. Steps that cannot run without hookflow, such as uses: and
+// matrix steps, or expressions the shell cannot express, are an error.
+func Bash(wf *schema.Workflow) (string, error) {
+	s := &bashScript{eventVars: make(map[string]string)}
+
+	var body strings.Builder
+	if err := s.writeEnv(&body, wf.Env); err != nil {
+		return "", err
+	}
+	for i, step := range wf.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step-%d", i+1)
+		}
+		if err := s.writeStep(&body, i+1, name, step, wf.ContinuesOnError(step)); err != nil {
+			return "", fmt.Errorf("step %q: %w", name, err)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	fmt.Fprintf(&b, "# Exported from hookflow workflow %q\n", wf.Name)
+	if wf.Description != "" {
+		fmt.Fprintf(&b, "# %s\n", wf.Description)
+	}
+	if len(s.eventVars) > 0 {
+		b.WriteString("#\n# Event data is read from these environment variables:\n")
+		vars := make([]string, 0, len(s.eventVars))
+		width := 0
+		for v := range s.eventVars {
+			vars = append(vars, v)
+			width = max(width, len(v))
+		}
+		sort.Strings(vars)
+		for _, v := range vars {
+			fmt.Fprintf(&b, "#   %-*s  %s\n", width, v, s.eventVars[v])
+		}
+	}
+	b.WriteString("\n# hookflow compares strings case-insensitively\nshopt -s nocasematch\n")
+	b.WriteString("# Step outputs are discarded unless HOOKFLOW_OUTPUT names a file\n")
+	b.WriteString("export HOOKFLOW_OUTPUT=\"${HOOKFLOW_OUTPUT:-/dev/null}\"\n")
+	fmt.Fprintf(&b, "%s=0\n%s=0\n", failedVar, continuedVar)
+	b.WriteString(body.String())
+	if wf.IsBlocking() {
+		fmt.Fprintf(&b, "\nexit \"$(( %s | %s ))\"\n", failedVar, continuedVar)
+	} else {
+		b.WriteString("\n# Non-blocking workflow: failures are reported but never block\nexit 0\n")
+	}
+	return b.String(), nil
+}
+
+// writeEnv writes export lines for env in key order
+func (s *bashScript) writeEnv(b *strings.Builder, env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if name, ok := schema.EnvRef(env[k]); ok {
+			fmt.Fprintf(b, "export %s=\"${%s}\"\n", k, name)
+			continue
+		}
+		value, err := s.template(env[k], true)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", k, err)
+		}
+		fmt.Fprintf(b, "export %s=\"%s\"\n", k, value)
+	}
+	return nil
+}
+
+// writeStep writes one step as an if block around a subshell, so step env
+// and working-directory do not leak into later steps
+func (s *bashScript) writeStep(b *strings.Builder, n int, name string, step schema.Step, continueOnError bool) error {
+	switch {
+	case step.Uses != "":
+		return fmt.Errorf("uses: %s cannot be exported", step.Uses)
+	case len(step.Matrix) > 0:
+		return fmt.Errorf("matrix steps cannot be exported")
+	case step.Shell != "" && step.Shell != "bash" && step.Shell != "sh":
+		return fmt.Errorf("shell %s cannot be exported to bash", step.Shell)
+	}
+
+	condition, err := s.condition(step.If)
+	if err != nil {
+		return fmt.Errorf("if: %w", err)
+	}
+	run, err := s.template(step.Run, false)
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+
+	fmt.Fprintf(b, "\n# Step %d: %s\n", n, name)
+	if step.Timeout > 0 {
+		fmt.Fprintf(b, "# timeout: %ds is not enforced\n", step.Timeout)
+	}
+	fmt.Fprintf(b, "if %s; then\n(\n", condition)
+	if step.WorkingDirectory != "" {
+		dir, err := s.template(step.WorkingDirectory, true)
+		if err != nil {
+			return fmt.Errorf("working-directory: %w", err)
+		}
+		fmt.Fprintf(b, "cd -- \"%s\" || exit 1\n", dir)
+	}
+	if err := s.writeEnv(b, step.Env); err != nil {
+		return err
+	}
+	b.WriteString(strings.TrimRight(run, "\n"))
+	if continueOnError {
+		fmt.Fprintf(b, "\n) || %s=1\nfi\n", continuedVar)
+	} else {
+		fmt.Fprintf(b, "\n) || %s=1\nfi\n", failedVar)
+	}
+	return nil
+}
+
+// condition returns the bash test for a step's if:. Like the runner, a step
+// is skipped after a failure unless its condition calls always().
+func (s *bashScript) condition(ifExpr string) (string, error) {
+	skipOnFailure := !strings.Contains(ifExpr, "always()")
+	expr := strings.TrimSpace(ifExpr)
+	if inner := expression.ExtractExpressions(expr); len(inner) > 0 {
+		expr = inner[0]
+	}
+
+	var test string
+	if expr != "" {
+		tokens, err := expression.Tokenize(expr)
+		if err != nil {
+			return "", err
+		}
+		if isStatic(tokens) {
+			ok, err := expression.NewContext().EvaluateBool(expr)
+			if err != nil {
+				return "", err
+			}
+			test = boolTest(ok)
+		} else {
+			t := &translator{tokens: tokens, script: s}
+			test, err = t.translate()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	switch {
+	case test == "" && skipOnFailure:
+		return fmt.Sprintf("[[ $%s -eq 0 ]]", failedVar), nil
+	case test == "":
+		return "true", nil
+	case skipOnFailure:
+		return fmt.Sprintf("[[ $%s -eq 0 && ( %s ) ]]", failedVar, test), nil
+	default:
+		return "[[ " + test + " ]]", nil
+	}
+}
+
+// template replaces the ${{ }} expressions in a run command or value. Static
+// expressions are evaluated; event.* and env.* references become ${NAME}.
+// When quoted is set, the result is escaped for use inside double quotes.
+func (s *bashScript) template(tmpl string, quoted bool) (string, error) {
+	escape := func(v string) string { return v }
+	if quoted {
+		escape = escapeDoubleQuoted
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range expression.ExpressionPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		b.WriteString(escape(tmpl[last:m[0]]))
+		last = m[1]
+
+		expr := strings.TrimSpace(tmpl[m[2]:m[3]])
+		tokens, err := expression.Tokenize(expr)
+		if err != nil {
+			return "", err
+		}
+		if isStatic(tokens) {
+			value, err := expression.NewContext().EvaluateString("${{ " + expr + " }}")
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(escape(value))
+			continue
+		}
+		t := &translator{tokens: tokens, script: s}
+		ref, ok := t.reference()
+		if !ok || !t.check(expression.TokenEOF) {
+			return "", fmt.Errorf("expression %q depends on the event and is not a plain event.* or env.* reference", expr)
+		}
+		b.WriteString("${" + ref + "}")
+	}
+	b.WriteString(escape(tmpl[last:]))
+	return b.String(), nil
+}
+
+// isStatic reports whether an expression can be evaluated without running
+// the workflow: it reads no runtime context and calls no status function
+func isStatic(tokens []expression.Token) bool {
+	for i, tok := range tokens {
+		if tok.Type != expression.TokenIdentifier {
+			continue
+		}
+		afterDot := i > 0 && tokens[i-1].Type == expression.TokenDot
+		if !afterDot && (runtimeRoots[tok.Value] || runtimeFunctions[tok.Value]) {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeDoubleQuoted escapes the characters bash expands inside double quotes
+func escapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '$', '`':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// singleQuote quotes s as one bash word with no expansion
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// boolTest returns a [[ ]] test that is always true or always false
+func boolTest(ok bool) string {
+	if ok {
+		return "1 -eq 1"
+	}
+	return "1 -eq 0"
+}
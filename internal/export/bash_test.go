@@ -0,0 +1,242 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func loadWorkflow(t *testing.T, content string) *schema.Workflow {
+	t.Helper()
+	var wf schema.Workflow
+	if err := yaml.Unmarshal([]byte(content), &wf); err != nil {
+		t.Fatalf("Failed to parse workflow: %v", err)
+	}
+	return &wf
+}
+
+// runScript runs an exported script with the given environment and returns
+// its output and exit code
+func runScript(t *testing.T, script string, env ...string) (string, int) {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	path := filepath.Join(t.TempDir(), "export.sh")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("bash", path)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatalf("Failed to run script: %v", err)
+	}
+	return string(out), 0
+}
+
+func TestEventVar(t *testing.T) {
+	tests := map[string]string{
+		"event.file.path":           "HOOKFLOW_EVENT_FILE_PATH",
+		"event.tool.args.new_str":   "HOOKFLOW_EVENT_TOOL_ARGS_NEW_STR",
+		"event.commit.author-email": "HOOKFLOW_EVENT_COMMIT_AUTHOR_EMAIL",
+		"event.cwd":                 "HOOKFLOW_EVENT_CWD",
+	}
+	for path, want := range tests {
+		if got := EventVar(path); got != want {
+			t.Errorf("EventVar(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBash(t *testing.T) {
+	wf := loadWorkflow(t, `name: env-guard
+description: Block edits to env files
+env:
+  LEVEL: strict
+  GREETING: "${{ format('{0} there', 'hi') }} $HOME"
+steps:
+  - name: Check env file
+    if: endsWith(event.file.path, '.env') && event.file.action != 'delete'
+    run: |
+      echo "checking ${{ event.file.path }} at ${{ env.LEVEL }}: $GREETING"
+      test "${{ event.tool.args['new_str'] }}" != secret
+  - name: Report
+    if: ${{ always() }}
+    run: echo "failed=$hookflow_failed"
+  - name: After
+    run: echo after
+`)
+
+	script, err := Bash(wf)
+	if err != nil {
+		t.Fatalf("Bash: %v", err)
+	}
+	for _, want := range []string{
+		"#!/usr/bin/env bash\n",
+		`# Exported from hookflow workflow "env-guard"`,
+		"#   HOOKFLOW_EVENT_FILE_ACTION        event.file.action\n",
+		"#   HOOKFLOW_EVENT_TOOL_ARGS_NEW_STR  event.tool.args.new_str\n",
+		`export GREETING="hi there \$HOME"`,
+		`if [[ $hookflow_failed -eq 0 && ( "${HOOKFLOW_EVENT_FILE_PATH}" == *'.env' && "${HOOKFLOW_EVENT_FILE_ACTION}" != 'delete' ) ]]; then`,
+		`echo "checking ${HOOKFLOW_EVENT_FILE_PATH} at ${LEVEL}: $GREETING"`,
+		"if [[ 1 -eq 1 ]]; then",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+
+	out, code := runScript(t, script, "HOME=/home/dev", "HOOKFLOW_EVENT_FILE_PATH=config/.ENV", "HOOKFLOW_EVENT_FILE_ACTION=edit", "HOOKFLOW_EVENT_TOOL_ARGS_NEW_STR=ok")
+	if code != 0 {
+		t.Errorf("Expected exit 0, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "checking config/.ENV at strict: hi there $HOME") || !strings.Contains(out, "failed=0") || !strings.Contains(out, "after") {
+		t.Errorf("Expected every step to run, got: %s", out)
+	}
+
+	// A failed step blocks and skips later steps, except always()
+	out, code = runScript(t, script, "HOOKFLOW_EVENT_FILE_PATH=.env", "HOOKFLOW_EVENT_FILE_ACTION=edit", "HOOKFLOW_EVENT_TOOL_ARGS_NEW_STR=secret")
+	if code != 1 {
+		t.Errorf("Expected exit 1, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "failed=1") || strings.Contains(out, "after") {
+		t.Errorf("Expected only the always() step after the failure, got: %s", out)
+	}
+
+	// A false condition skips the step without failing
+	out, code = runScript(t, script, "HOOKFLOW_EVENT_FILE_PATH=main.go", "HOOKFLOW_EVENT_TOOL_ARGS_NEW_STR=secret")
+	if code != 0 || strings.Contains(out, "checking") || !strings.Contains(out, "after") {
+		t.Errorf("Expected the check to be skipped, got exit %d: %s", code, out)
+	}
+}
+
+func TestBashStepOptions(t *testing.T) {
+	dir := t.TempDir()
+	wf := loadWorkflow(t, `name: options
+blocking: false
+steps:
+  - name: Flaky
+    continue-on-error: true
+    timeout: 30
+    run: exit 3
+  - name: Where
+    working-directory: ${{ event.cwd }}
+    env:
+      STEP_VALUE: "${{ env.BASE }}-step"
+    run: echo "$(pwd) $STEP_VALUE"
+  - name: Fail
+    run: exit 1
+`)
+
+	script, err := Bash(wf)
+	if err != nil {
+		t.Fatalf("Bash: %v", err)
+	}
+	if !strings.Contains(script, "# timeout: 30s is not enforced") {
+		t.Errorf("Expected a note about the timeout, got:\n%s", script)
+	}
+
+	// continue-on-error does not skip later steps, and a non-blocking
+	// workflow exits 0 even though a step failed
+	out, code := runScript(t, script, "HOOKFLOW_EVENT_CWD="+dir, "BASE=base")
+	if code != 0 {
+		t.Errorf("Expected exit 0 for a non-blocking workflow, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, dir+" base-step") {
+		t.Errorf("Expected the step to run in %s with its env, got: %s", dir, out)
+	}
+}
+
+func TestBashContinueOnErrorBlocks(t *testing.T) {
+	wf := loadWorkflow(t, `name: lint
+steps:
+  - name: Flaky
+    continue-on-error: true
+    run: exit 3
+  - name: After
+    run: echo "after failed=$hookflow_failed"
+`)
+
+	script, err := Bash(wf)
+	if err != nil {
+		t.Fatalf("Bash: %v", err)
+	}
+
+	// Later steps run, but the failure still blocks like the runner's deny
+	out, code := runScript(t, script)
+	if code != 1 {
+		t.Errorf("Expected exit 1 for a blocking workflow, got %d: %s", code, out)
+	}
+	if !strings.Contains(out, "after failed=0") {
+		t.Errorf("Expected the next step to run, got: %s", out)
+	}
+}
+
+func TestBashCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "[[ $hookflow_failed -eq 0 ]]"},
+		{"always()", "[[ 1 -eq 1 ]]"},
+		{"1 == 2", "[[ $hookflow_failed -eq 0 && ( 1 -eq 0 ) ]]"},
+		{"${{ contains('abc', 'B') }}", "[[ $hookflow_failed -eq 0 && ( 1 -eq 1 ) ]]"},
+		{"event.file.path", `[[ $hookflow_failed -eq 0 && ( -n "${HOOKFLOW_EVENT_FILE_PATH}" ) ]]`},
+		{"!startsWith(event.file.path, 'docs/')", `[[ $hookflow_failed -eq 0 && ( ! ( "${HOOKFLOW_EVENT_FILE_PATH}" == 'docs/'* ) ) ]]`},
+		{"env.MODE == 'ci' || (event.commit.files_count >= 10 && success())",
+			`[[ $hookflow_failed -eq 0 && ( "${MODE}" == 'ci' || ( "${HOOKFLOW_EVENT_COMMIT_FILES_COUNT}" -ge 10 && $hookflow_failed -eq 0 ) ) ]]`},
+		{"always() && failure()", "[[ 1 -eq 1 && $hookflow_failed -ne 0 ]]"},
+		{"event.tool.args['it''s'] == 'x'", `[[ $hookflow_failed -eq 0 && ( "${HOOKFLOW_EVENT_TOOL_ARGS_IT_S}" == 'x' ) ]]`},
+		{"event.file.path == 'it''s'", `[[ $hookflow_failed -eq 0 && ( "${HOOKFLOW_EVENT_FILE_PATH}" == 'it'\''s' ) ]]`},
+	}
+
+	for _, tt := range tests {
+		s := &bashScript{eventVars: make(map[string]string)}
+		got, err := s.condition(tt.expr)
+		if err != nil {
+			t.Errorf("condition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("condition(%q) =\n  %s\nwant\n  %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestBashErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		step string
+		want string
+	}{
+		{"uses", "uses: ./actions/lint", "uses: ./actions/lint cannot be exported"},
+		{"matrix", "matrix:\n      os: [a, b]\n    run: echo ${{ matrix.os }}", "matrix steps cannot be exported"},
+		{"shell", "shell: pwsh\n    run: Write-Host hi", "shell pwsh cannot be exported"},
+		{"regex", "if: event.file.path =~ '\\.go$'\n    run: echo go", "operator =~ cannot be exported"},
+		{"function", "if: fromJSON(event.tool.args.body)\n    run: echo hi", "function fromJSON() cannot be exported"},
+		{"steps context", "if: steps.lint.outcome == 'success'\n    run: echo hi", "steps cannot be exported"},
+		{"run expression", "run: echo ${{ contains(event.file.path, 'x') }}", "is not a plain event.* or env.* reference"},
+		{"compare tests", "if: contains(event.file.path, 'a') == true\n    run: echo hi", "only values can be compared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := loadWorkflow(t, "name: broken\nsteps:\n  - name: Step\n    "+tt.step+"\n")
+			_, err := Bash(wf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if !strings.Contains(err.Error(), `step "Step"`) {
+				t.Errorf("Expected the error to name the step, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/expression"
+)
+
+// operand is a translated part of an if: expression
+type operand struct {
+	text string // Fragment of a [[ ]] test
+	test bool   // text is a test rather than a single word
+}
+
+// translator turns an if: expression into a bash [[ ]] test. It follows the
+// evaluator's precedence but supports only what bash can express: ==, !=,
+// numeric comparisons, &&, ||, !, contains, startsWith, endsWith and the
+// status functions. String comparisons rely on nocasematch.
+type translator struct {
+	tokens []expression.Token
+	pos    int
+	script *bashScript
+}
+
+func (t *translator) translate() (string, error) {
+	op, err := t.or()
+	if err != nil {
+		return "", err
+	}
+	if !t.check(expression.TokenEOF) {
+		return "", fmt.Errorf("unexpected %q", t.peek().Value)
+	}
+	return asTest(op), nil
+}
+
+func (t *translator) or() (operand, error) {
+	return t.binary("||", t.and)
+}
+
+func (t *translator) and() (operand, error) {
+	return t.binary("&&", t.equality)
+}
+
+// binary parses operands joined by a logical operator
+func (t *translator) binary(op string, next func() (operand, error)) (operand, error) {
+	left, err := next()
+	if err != nil {
+		return operand{}, err
+	}
+	for t.matchOperator(op) {
+		right, err := next()
+		if err != nil {
+			return operand{}, err
+		}
+		left = operand{text: asTest(left) + " " + op + " " + asTest(right), test: true}
+	}
+	return left, nil
+}
+
+func (t *translator) equality() (operand, error) {
+	left, err := t.comparison()
+	if err != nil {
+		return operand{}, err
+	}
+	if !t.check(expression.TokenOperator) {
+		return left, nil
+	}
+	op := t.peek().Value
+	switch op {
+	case "==", "!=":
+	case "=~", "!~":
+		return operand{}, fmt.Errorf("operator %s cannot be exported", op)
+	default:
+		return left, nil
+	}
+	t.pos++
+	right, err := t.comparison()
+	if err != nil {
+		return operand{}, err
+	}
+	if left.test || right.test {
+		return operand{}, fmt.Errorf("only values can be compared with %s", op)
+	}
+	return operand{text: left.text + " " + op + " " + right.text, test: true}, nil
+}
+
+// numericOperators maps comparison operators to their [[ ]] equivalents
+var numericOperators = map[string]string{"<": "-lt", "<=": "-le", ">": "-gt", ">=": "-ge"}
+
+func (t *translator) comparison() (operand, error) {
+	left, err := t.unary()
+	if err != nil {
+		return operand{}, err
+	}
+	if !t.check(expression.TokenOperator) {
+		return left, nil
+	}
+	op, ok := numericOperators[t.peek().Value]
+	if !ok {
+		return left, nil
+	}
+	t.pos++
+	right, err := t.unary()
+	if err != nil {
+		return operand{}, err
+	}
+	if left.test || right.test {
+		return operand{}, fmt.Errorf("only values can be compared with %s", t.tokens[t.pos-1].Value)
+	}
+	return operand{text: left.text + " " + op + " " + right.text, test: true}, nil
+}
+
+func (t *translator) unary() (operand, error) {
+	if t.matchOperator("!") {
+		inner, err := t.unary()
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{text: "! ( " + asTest(inner) + " )", test: true}, nil
+	}
+	return t.primary()
+}
+
+func (t *translator) primary() (operand, error) {
+	tok := t.peek()
+	switch tok.Type {
+	case expression.TokenNumber:
+		t.pos++
+		return operand{text: tok.Value}, nil
+	case expression.TokenString:
+		t.pos++
+		return operand{text: singleQuote(tok.Value)}, nil
+	case expression.TokenLeftParen:
+		t.pos++
+		inner, err := t.or()
+		if err != nil {
+			return operand{}, err
+		}
+		if !t.match(expression.TokenRightParen) {
+			return operand{}, fmt.Errorf("expected ')'")
+		}
+		if inner.test {
+			inner.text = "( " + inner.text + " )"
+		}
+		return inner, nil
+	case expression.TokenIdentifier:
+		switch tok.Value {
+		case "true", "false":
+			t.pos++
+			return operand{text: boolTest(tok.Value == "true"), test: true}, nil
+		case "null":
+			t.pos++
+			return operand{text: "''"}, nil
+		}
+		if t.pos+1 < len(t.tokens) && t.tokens[t.pos+1].Type == expression.TokenLeftParen {
+			return t.call()
+		}
+		if ref, ok := t.reference(); ok {
+			return operand{text: "\"${" + ref + "}\""}, nil
+		}
+		return operand{}, fmt.Errorf("%s cannot be exported", tok.Value)
+	}
+	return operand{}, fmt.Errorf("unexpected %q", tok.Value)
+}
+
+// call translates a function call
+func (t *translator) call() (operand, error) {
+	name := t.tokens[t.pos].Value
+	t.pos += 2
+
+	var args []operand
+	for !t.check(expression.TokenRightParen) {
+		arg, err := t.or()
+		if err != nil {
+			return operand{}, err
+		}
+		if arg.test {
+			return operand{}, fmt.Errorf("%s() arguments must be values", name)
+		}
+		args = append(args, arg)
+		if !t.match(expression.TokenComma) {
+			break
+		}
+	}
+	if !t.match(expression.TokenRightParen) {
+		return operand{}, fmt.Errorf("expected ')' after %s() arguments", name)
+	}
+
+	switch name {
+	case "always":
+		return operand{text: boolTest(true), test: true}, nil
+	case "cancelled":
+		return operand{text: boolTest(false), test: true}, nil
+	case "success":
+		return operand{text: "$" + failedVar + " -eq 0", test: true}, nil
+	case "failure":
+		return operand{text: "$" + failedVar + " -ne 0", test: true}, nil
+	case "contains", "startsWith", "endsWith":
+		if len(args) != 2 {
+			return operand{}, fmt.Errorf("%s requires 2 arguments", name)
+		}
+		pattern := args[1].text
+		switch name {
+		case "contains":
+			pattern = "*" + pattern + "*"
+		case "startsWith":
+			pattern += "*"
+		case "endsWith":
+			pattern = "*" + pattern
+		}
+		return operand{text: args[0].text + " == " + pattern, test: true}, nil
+	}
+	return operand{}, fmt.Errorf("function %s() cannot be exported", name)
+}
+
+// shellName matches names bash can expand as ${NAME}
+var shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reference consumes an event.* or env.* path and returns the shell
+// variable it is read from. Other paths are left unconsumed.
+func (t *translator) reference() (string, bool) {
+	root := t.peek()
+	if root.Type != expression.TokenIdentifier || (root.Value != "event" && root.Value != "env") {
+		return "", false
+	}
+
+	start := t.pos
+	t.pos++
+	var segments []string
+	for {
+		switch {
+		case t.check(expression.TokenDot) && t.pos+1 < len(t.tokens) && t.tokens[t.pos+1].Type == expression.TokenIdentifier:
+			segments = append(segments, t.tokens[t.pos+1].Value)
+			t.pos += 2
+			continue
+		case t.check(expression.TokenLeftBracket) && t.pos+2 < len(t.tokens) &&
+			t.tokens[t.pos+1].Type == expression.TokenString && t.tokens[t.pos+2].Type == expression.TokenRightBracket:
+			segments = append(segments, t.tokens[t.pos+1].Value)
+			t.pos += 3
+			continue
+		}
+		break
+	}
+
+	if len(segments) == 0 || (root.Value == "env" && (len(segments) != 1 || !shellName.MatchString(segments[0]))) {
+		t.pos = start
+		return "", false
+	}
+	if root.Value == "env" {
+		return segments[0], true
+	}
+	path := "event." + strings.Join(segments, ".")
+	name := EventVar(path)
+	t.script.eventVars[name] = path
+	return name, true
+}
+
+// asTest converts a value to the test "value is not empty", matching how
+// the evaluator treats strings as booleans
+func asTest(op operand) string {
+	if op.test {
+		return op.text
+	}
+	return "-n " + op.text
+}
+
+func (t *translator) peek() expression.Token {
+	if t.pos >= len(t.tokens) {
+		return expression.Token{Type: expression.TokenEOF}
+	}
+	return t.tokens[t.pos]
+}
+
+func (t *translator) check(tt expression.TokenType) bool {
+	return t.peek().Type == tt
+}
+
+func (t *translator) match(tt expression.TokenType) bool {
+	if t.check(tt) {
+		t.pos++
+		return true
+	}
+	return false
+}
+
+func (t *translator) matchOperator(op string) bool {
+	if tok := t.peek(); tok.Type == expression.TokenOperator && tok.Value == op {
+		t.pos++
+		return true
+	}
+	return false
+}
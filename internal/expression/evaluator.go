@@ -3,9 +3,17 @@ package expression
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/htekdev/gh-hookflow/internal/logging"
 )
 
 // Context holds the evaluation context for expressions
@@ -13,14 +21,17 @@ type Context struct {
 	Event            map[string]interface{}
 	Env              map[string]string
 	Steps            map[string]StepContext
+	Vars             map[string]string // Caller-supplied values, read as ctx.<key>
+	Matrix           map[string]string // Values of the running matrix combination, read as matrix.<key>
 	Functions        map[string]Function
 	ContextFunctions map[string]ContextFunction
+	Redacted         []string // Values returned by env(), hidden from logged output by Redact
 }
 
 // StepContext holds the output of a previous step
 type StepContext struct {
-	Outputs map[string]string
-	Outcome string // success, failure, cancelled, skipped
+	Outputs map[string]string `json:"outputs"`
+	Outcome string            `json:"outcome"` // success, failure, cancelled, skipped
 }
 
 // Function represents a built-in function
@@ -35,10 +46,18 @@ func NewContext() *Context {
 		Event:            make(map[string]interface{}),
 		Env:              make(map[string]string),
 		Steps:            make(map[string]StepContext),
+		Vars:             make(map[string]string),
+		Matrix:           make(map[string]string),
 		Functions:        make(map[string]Function),
 		ContextFunctions: make(map[string]ContextFunction),
 	}
-	// Register built-in functions
+	addBuiltins(ctx)
+	addRegistered(ctx)
+	return ctx
+}
+
+// addBuiltins adds the built-in functions to ctx
+func addBuiltins(ctx *Context) {
 	ctx.Functions["contains"] = builtinContains
 	ctx.Functions["startsWith"] = builtinStartsWith
 	ctx.Functions["endsWith"] = builtinEndsWith
@@ -47,11 +66,206 @@ func NewContext() *Context {
 	ctx.Functions["toJSON"] = builtinToJSON
 	ctx.Functions["fromJSON"] = builtinFromJSON
 	ctx.Functions["always"] = builtinAlways
+	ctx.Functions["never"] = builtinNever
+	ctx.Functions["min"] = builtinMin
+	ctx.Functions["max"] = builtinMax
+	ctx.Functions["abs"] = builtinAbs
 	// Register context-aware functions
 	ctx.ContextFunctions["success"] = builtinSuccess
 	ctx.ContextFunctions["failure"] = builtinFailure
 	ctx.ContextFunctions["cancelled"] = builtinCancelled
-	return ctx
+	ctx.ContextFunctions["readFile"] = builtinReadFile
+	ctx.ContextFunctions["env"] = builtinEnv
+}
+
+// minRedactLength is the shortest value Redact hides; shorter values such as
+// "1" or "dev" would mask unrelated output
+const minRedactLength = 4
+
+// AddRedacted registers value to be hidden by Redact
+func (ctx *Context) AddRedacted(value string) {
+	if len(value) < minRedactLength {
+		return
+	}
+	for _, existing := range ctx.Redacted {
+		if existing == value {
+			return
+		}
+	}
+	ctx.Redacted = append(ctx.Redacted, value)
+	// Longer values first, so one that contains another is hidden whole
+	sort.SliceStable(ctx.Redacted, func(i, j int) bool {
+		return len(ctx.Redacted[i]) > len(ctx.Redacted[j])
+	})
+}
+
+// Redact replaces every registered value in s with ***
+func (ctx *Context) Redact(s string) string {
+	for _, value := range ctx.Redacted {
+		s = strings.ReplaceAll(s, value, "***")
+	}
+	return s
+}
+
+// SetEnv sets env.<key> for later evaluations, overriding any value for key
+func (c *Context) SetEnv(key, value string) {
+	if c.Env == nil {
+		c.Env = make(map[string]string)
+	}
+	c.Env[key] = value
+}
+
+// Clone returns a copy of the context that can be read and updated without
+// affecting the original. Event, Env, Steps, Vars and Matrix are deep-copied; functions are
+// shared since they carry no state.
+func (ctx *Context) Clone() *Context {
+	clone := ctx.Copy()
+	clone.Event = cloneValue(ctx.Event).(map[string]interface{})
+	return clone
+}
+
+// Copy is like Clone but shares the Event map with the original instead of
+// deep-copying it, so only Env, Steps, Vars and Matrix, which change while a
+// workflow runs, are copied. Clone's cost grows with the number of maps and
+// slices in the event while Copy's does not: with a 100KB event Copy takes
+// about a seventh of the time and a fifteenth of the memory (BenchmarkClone,
+// BenchmarkCopy), so goroutines that each run steps against the same event
+// should use Copy. The price is that no context may change the shared event
+// afterwards, since every copy would see it; use Clone when the event itself
+// will be modified.
+func (ctx *Context) Copy() *Context {
+	clone := &Context{
+		Event:            ctx.Event,
+		Env:              make(map[string]string, len(ctx.Env)),
+		Steps:            make(map[string]StepContext, len(ctx.Steps)),
+		Vars:             make(map[string]string, len(ctx.Vars)),
+		Matrix:           make(map[string]string, len(ctx.Matrix)),
+		Functions:        make(map[string]Function, len(ctx.Functions)),
+		ContextFunctions: make(map[string]ContextFunction, len(ctx.ContextFunctions)),
+		Redacted:         append([]string(nil), ctx.Redacted...),
+	}
+	for k, v := range ctx.Env {
+		clone.Env[k] = v
+	}
+	for k, v := range ctx.Vars {
+		clone.Vars[k] = v
+	}
+	for k, v := range ctx.Matrix {
+		clone.Matrix[k] = v
+	}
+	for name, step := range ctx.Steps {
+		outputs := make(map[string]string, len(step.Outputs))
+		for k, v := range step.Outputs {
+			outputs[k] = v
+		}
+		clone.Steps[name] = StepContext{Outputs: outputs, Outcome: step.Outcome}
+	}
+	for name, fn := range ctx.Functions {
+		clone.Functions[name] = fn
+	}
+	for name, fn := range ctx.ContextFunctions {
+		clone.ContextFunctions[name] = fn
+	}
+	return clone
+}
+
+// contextJSON is the serialized form of a Context
+type contextJSON struct {
+	Event  map[string]interface{} `json:"event"`
+	Env    map[string]string      `json:"env"`
+	Steps  map[string]StepContext `json:"steps"`
+	Vars   map[string]string      `json:"vars,omitempty"`
+	Matrix map[string]string      `json:"matrix,omitempty"`
+}
+
+// MarshalJSON encodes the event, env, steps, vars and matrix of the context
+// so it can be checkpointed and restored later. Functions are not encoded.
+func (ctx *Context) MarshalJSON() ([]byte, error) {
+	return json.Marshal(contextJSON{
+		Event:  ctx.Event,
+		Env:    ctx.Env,
+		Steps:  ctx.Steps,
+		Vars:   ctx.Vars,
+		Matrix: ctx.Matrix,
+	})
+}
+
+// UnmarshalJSON replaces the context with data written by MarshalJSON.
+// Functions are reset to the built-ins registered by NewContext; custom
+// functions must be registered again. Numbers in event data decode as float64.
+func (ctx *Context) UnmarshalJSON(data []byte) error {
+	var decoded contextJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	restored := NewContext()
+	if decoded.Event != nil {
+		restored.Event = decoded.Event
+	}
+	for k, v := range decoded.Env {
+		restored.Env[k] = v
+	}
+	for k, v := range decoded.Vars {
+		restored.Vars[k] = v
+	}
+	for k, v := range decoded.Matrix {
+		restored.Matrix[k] = v
+	}
+	for id, step := range decoded.Steps {
+		restored.SetStepResult(id, step)
+	}
+	*ctx = *restored
+	return nil
+}
+
+// cloneValue deep-copies the maps and slices that make up event data
+func cloneValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = cloneValue(item)
+		}
+		return m
+	case map[string]string:
+		m := make(map[string]string, len(val))
+		for k, item := range val {
+			m[k] = item
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(val))
+		for i, item := range val {
+			s[i] = cloneValue(item)
+		}
+		return s
+	case []string:
+		return append([]string(nil), val...)
+	case []map[string]string:
+		s := make([]map[string]string, len(val))
+		for i, item := range val {
+			s[i] = cloneValue(item).(map[string]string)
+		}
+		return s
+	case []map[string]interface{}:
+		s := make([]map[string]interface{}, len(val))
+		for i, item := range val {
+			s[i] = cloneValue(item).(map[string]interface{})
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// SetStepResult records a completed (or pending) step so later expressions
+// can read steps.<id>.outcome and steps.<id>.outputs.<name>
+func (ctx *Context) SetStepResult(id string, result StepContext) {
+	if result.Outputs == nil {
+		result.Outputs = make(map[string]string)
+	}
+	ctx.Steps[id] = result
 }
 
 // Evaluate evaluates an expression string against the context
@@ -73,16 +287,89 @@ func (ctx *Context) Evaluate(expr string) (interface{}, error) {
 }
 
 // EvaluateString evaluates an expression and returns a string result
+// Objects and arrays are interpolated as JSON so they can be embedded in commands
 func (ctx *Context) EvaluateString(input string) (string, error) {
 	return ReplaceExpressions(input, func(expr string) (string, error) {
 		result, err := ctx.Evaluate(expr)
 		if err != nil {
 			return "", err
 		}
-		return toString(result), nil
+		return interpolationString(result), nil
 	})
 }
 
+// EvaluateTemplate evaluates every ${{ }} placeholder in tmpl, like
+// EvaluateString, but keeps going after a failure. All failures are returned
+// together as a *TemplateError; failed placeholders are left unchanged in the
+// returned string.
+func (ctx *Context) EvaluateTemplate(tmpl string) (string, error) {
+	var b strings.Builder
+	var failures []ExpressionError
+	last := 0
+
+	for _, match := range ExpressionPattern.FindAllStringSubmatchIndex(tmpl, -1) {
+		fullStart, fullEnd := match[0], match[1]
+		expr := strings.TrimSpace(tmpl[match[2]:match[3]])
+
+		b.WriteString(tmpl[last:fullStart])
+		last = fullEnd
+
+		result, err := ctx.Evaluate(expr)
+		if err != nil {
+			failures = append(failures, ExpressionError{Position: fullStart, Expression: expr, Err: err})
+			b.WriteString(tmpl[fullStart:fullEnd])
+			continue
+		}
+		b.WriteString(interpolationString(result))
+	}
+	b.WriteString(tmpl[last:])
+
+	if len(failures) > 0 {
+		return b.String(), &TemplateError{Errors: failures}
+	}
+	return b.String(), nil
+}
+
+// ExpressionError describes one placeholder that failed in EvaluateTemplate
+type ExpressionError struct {
+	Position   int    // Byte offset of the placeholder's "${{" in the template
+	Expression string // Expression inside the placeholder
+	Err        error
+}
+
+func (e ExpressionError) Error() string {
+	return fmt.Sprintf("position %d '%s': %v", e.Position, e.Expression, e.Err)
+}
+
+// TemplateError lists every placeholder that failed in EvaluateTemplate
+type TemplateError struct {
+	Errors []ExpressionError
+}
+
+func (e *TemplateError) Error() string {
+	positions := make([]string, len(e.Errors))
+	details := make([]string, len(e.Errors))
+	for i, exprErr := range e.Errors {
+		positions[i] = strconv.Itoa(exprErr.Position)
+		details[i] = exprErr.Error()
+	}
+
+	summary := "expression at position " + positions[0] + " failed"
+	if n := len(positions); n > 1 {
+		summary = "expressions at positions " + strings.Join(positions[:n-1], ", ") + " and " + positions[n-1] + " failed"
+	}
+	return summary + ": " + strings.Join(details, "; ")
+}
+
+// Unwrap returns the underlying evaluation errors
+func (e *TemplateError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, exprErr := range e.Errors {
+		errs[i] = exprErr.Err
+	}
+	return errs
+}
+
 // EvaluateBool evaluates an expression and returns a boolean result
 func (ctx *Context) EvaluateBool(expr string) (bool, error) {
 	// Check if the expression contains the ${{ }} syntax
@@ -106,6 +393,26 @@ func (ctx *Context) EvaluateBool(expr string) (bool, error) {
 	return toBool(result), nil
 }
 
+// EvaluateNumber evaluates an expression and returns its numeric result.
+// Numeric strings such as step outputs are parsed; any other result is an error.
+func (ctx *Context) EvaluateNumber(expr string) (float64, error) {
+	if ContainsExpression(expr) {
+		if expressions := ExtractExpressions(expr); len(expressions) > 0 {
+			expr = expressions[0]
+		}
+	}
+
+	result, err := ctx.Evaluate(expr)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := numericValue(result)
+	if !ok {
+		return 0, fmt.Errorf("expression '%s' is not a number: %s", expr, interpolationString(result))
+	}
+	return n, nil
+}
+
 // evaluator walks through tokens and evaluates expressions
 type evaluator struct {
 	tokens []Token
@@ -159,22 +466,50 @@ func (e *evaluator) parseEquality() (interface{}, error) {
 		return nil, err
 	}
 
-	for e.check(TokenOperator) && (e.peek().Value == "==" || e.peek().Value == "!=") {
+	for e.check(TokenOperator) && isEqualityOperator(e.peek().Value) {
 		op := e.advance().Value
 		right, err := e.parseComparison()
 		if err != nil {
 			return nil, err
 		}
-		if op == "==" {
+		switch op {
+		case "==":
 			left = equals(left, right)
-		} else {
+		case "!=":
 			left = !equals(left, right)
+		case "=~", "!~":
+			re, err := compileRegex(toString(right))
+			if err != nil {
+				return nil, err
+			}
+			left = re.MatchString(toString(left)) == (op == "=~")
 		}
 	}
 
 	return left, nil
 }
 
+// isEqualityOperator reports whether op binds at equality precedence
+func isEqualityOperator(op string) bool {
+	return op == "==" || op == "!=" || op == "=~" || op == "!~"
+}
+
+// regexCache holds compiled =~ and !~ patterns keyed by pattern string
+var regexCache sync.Map
+
+// compileRegex compiles a Go regular expression, reusing earlier compilations
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
 func (e *evaluator) parseComparison() (interface{}, error) {
 	left, err := e.parseUnary()
 	if err != nil {
@@ -324,9 +659,17 @@ func (e *evaluator) parsePrimary() (interface{}, error) {
 		case "event":
 			return e.ctx.Event, nil
 		case "env":
+			// env('NAME') calls the builtin; env.NAME reads the workflow env
+			if e.check(TokenLeftParen) {
+				return name, nil
+			}
 			return e.ctx.Env, nil
 		case "steps":
 			return e.ctx.Steps, nil
+		case "ctx":
+			return e.ctx.Vars, nil
+		case "matrix":
+			return e.ctx.Matrix, nil
 		}
 		// Return identifier for potential function call
 		return name, nil
@@ -396,6 +739,9 @@ func (e *evaluator) getIndex(obj interface{}, index interface{}) interface{} {
 		return v[toString(index)]
 	case map[string]string:
 		return v[toString(index)]
+	case map[string]StepContext:
+		// steps['Step name'] reaches names that are not identifiers
+		return e.getProperty(v, toString(index))
 	default:
 		return nil
 	}
@@ -457,6 +803,18 @@ func toString(v interface{}) string {
 	}
 }
 
+// interpolationString converts a value for substitution into a string,
+// rendering structured values as JSON rather than Go's %v format
+func interpolationString(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, map[string]string, []interface{}, []map[string]string, []string:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return toString(v)
+}
+
 func toBool(v interface{}) bool {
 	if v == nil {
 		return false
@@ -497,6 +855,22 @@ func toNumber(v interface{}) float64 {
 	}
 }
 
+// numericValue converts numbers and numeric strings to float64. Unlike
+// toNumber, it reports whether v was numeric instead of falling back to 0.
+func numericValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int64:
+		return float64(val), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
 func equals(a, b interface{}) bool {
 	// Handle case-insensitive string comparison
 	aStr, aIsStr := a.(string)
@@ -606,6 +980,66 @@ func builtinAlways(args ...interface{}) (interface{}, error) {
 	return true, nil
 }
 
+func builtinNever(args ...interface{}) (interface{}, error) {
+	// never() permanently disables a step without removing it
+	return false, nil
+}
+
+func builtinMin(args ...interface{}) (interface{}, error) {
+	a, b, err := numericPair("min", args)
+	if err != nil {
+		return nil, err
+	}
+	return math.Min(a, b), nil
+}
+
+func builtinMax(args ...interface{}) (interface{}, error) {
+	a, b, err := numericPair("max", args)
+	if err != nil {
+		return nil, err
+	}
+	return math.Max(a, b), nil
+}
+
+func builtinAbs(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("abs requires 1 argument")
+	}
+	n, ok := numericValue(args[0])
+	if !ok {
+		return nil, fmt.Errorf("abs requires a number, got %s", interpolationString(args[0]))
+	}
+	return math.Abs(n), nil
+}
+
+func builtinEnv(ctx *Context, args ...interface{}) (interface{}, error) {
+	// env(name) reads the environment hookflow runs in, unlike env.NAME,
+	// which reads the workflow's env: and may override the same variable.
+	// The value may be a token, so it is registered for redaction.
+	if len(args) != 1 {
+		return nil, fmt.Errorf("env requires 1 argument")
+	}
+	value := os.Getenv(toString(args[0]))
+	ctx.AddRedacted(value)
+	return value, nil
+}
+
+// numericPair checks the two numeric arguments of min and max
+func numericPair(name string, args []interface{}) (float64, float64, error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("%s requires 2 arguments", name)
+	}
+	var pair [2]float64
+	for i, arg := range args {
+		n, ok := numericValue(arg)
+		if !ok {
+			return 0, 0, fmt.Errorf("%s requires numbers, got %s", name, interpolationString(arg))
+		}
+		pair[i] = n
+	}
+	return pair[0], pair[1], nil
+}
+
 func builtinSuccess(ctx *Context, args ...interface{}) (interface{}, error) {
 	// success() returns true if no previous steps have failed or been cancelled
 	for _, step := range ctx.Steps {
@@ -635,3 +1069,64 @@ func builtinCancelled(ctx *Context, args ...interface{}) (interface{}, error) {
 	}
 	return false, nil
 }
+
+// maxReadFileSize caps how much readFile loads into an expression
+const maxReadFileSize = 1 << 20
+
+func builtinReadFile(ctx *Context, args ...interface{}) (interface{}, error) {
+	// readFile(path) returns a file's content, resolved relative to event.cwd.
+	// Missing, oversized and out-of-tree files read as an empty string.
+	if len(args) != 1 {
+		return nil, fmt.Errorf("readFile requires 1 argument")
+	}
+
+	root, _ := ctx.Event["cwd"].(string)
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", nil
+	}
+
+	path := toString(args[0])
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if !isWithinDir(root, path) {
+		logging.Context("expression").Warn("readFile: %s is outside %s", path, root)
+		return "", nil
+	}
+	// Resolve symlinks so a link cannot point outside the sandbox
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		resolvedRoot, rootErr := filepath.EvalSymlinks(root)
+		if rootErr != nil || !isWithinDir(resolvedRoot, resolved) {
+			logging.Context("expression").Warn("readFile: %s is outside %s", path, root)
+			return "", nil
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", nil
+	}
+	if info.Size() > maxReadFileSize {
+		logging.Context("expression").Warn("readFile: %s is %d bytes, over the %d byte limit", path, info.Size(), maxReadFileSize)
+		return "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// isWithinDir reports whether path is dir or inside it
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -1,7 +1,14 @@
 package expression
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -199,6 +206,25 @@ func TestContextEvaluateBool(t *testing.T) {
 	}
 }
 
+func TestContextSetEnv(t *testing.T) {
+	ctx := NewContext()
+	ctx.Env["MODE"] = "workflow"
+	ctx.SetEnv("MODE", "step")
+	ctx.SetEnv("STEP_VAR", "x")
+
+	got, err := ctx.EvaluateBool("${{ env.STEP_VAR == 'x' && env.MODE == 'step' }}")
+	if err != nil || !got {
+		t.Errorf("Expected SetEnv values in expressions, got %v, %v", got, err)
+	}
+
+	// A context built without NewContext has no Env map yet
+	empty := &Context{}
+	empty.SetEnv("KEY", "value")
+	if empty.Env["KEY"] != "value" {
+		t.Errorf("Expected SetEnv to create the env map, got %v", empty.Env)
+	}
+}
+
 func TestBuiltinContains(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -506,6 +532,79 @@ func TestComparisonOperators(t *testing.T) {
 	}
 }
 
+// TestRegexMatchOperators tests =~ and !~
+func TestRegexMatchOperators(t *testing.T) {
+	ctx := NewContext()
+	ctx.Event["file"] = map[string]interface{}{"path": "src/app/main_test.go"}
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"substring match", "'hello world' =~ 'o w'", true},
+		{"no match", "'hello' =~ 'xyz'", false},
+		{"anchored start", "event.file.path =~ '^src/'", true},
+		{"anchored start fails", "event.file.path =~ '^app/'", false},
+		{"anchored end", "event.file.path =~ '_test\\.go$'", true},
+		{"full anchor", "'v1.2.3' =~ '^v[0-9]+\\.[0-9]+\\.[0-9]+$'", true},
+		{"capture groups ignored", "'feature/login' =~ '^(feature|fix)/(.+)$'", true},
+		{"alternation", "'fix/bug' =~ '^(feature|fix)/'", true},
+		{"escaped dot is literal", "'mainXgo' =~ 'main\\.go'", false},
+		{"unescaped dot is any", "'mainXgo' =~ 'main.go'", true},
+		{"special characters", "'a+b=(c)' =~ 'a\\+b=\\(c\\)'", true},
+		{"quote in pattern", "'it''s' =~ 't''s$'", true},
+		{"case sensitive", "'README' =~ 'readme'", false},
+		{"case insensitive flag", "'README' =~ '(?i)readme'", true},
+		{"not match", "event.file.path !~ '^docs/'", true},
+		{"not match fails", "event.file.path !~ '\\.go$'", false},
+		{"number operand", "42 =~ '^4'", true},
+		{"missing value is empty", "event.missing =~ '^$'", true},
+		{"combines with and", "event.file.path =~ '^src/' && event.file.path !~ '_test'", false},
+		{"negated", "!(event.file.path =~ '^src/')", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate(%s) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%s) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexMatchInvalidPattern(t *testing.T) {
+	ctx := NewContext()
+	for _, expr := range []string{"'a' =~ '('", "'a' !~ '[z-a]'"} {
+		_, err := ctx.Evaluate(expr)
+		if err == nil || !strings.Contains(err.Error(), "invalid regex") {
+			t.Errorf("Evaluate(%s) error = %v, want invalid regex error", expr, err)
+		}
+	}
+}
+
+func TestRegexCache(t *testing.T) {
+	pattern := "^cache-[0-9]+$"
+	first, err := compileRegex(pattern)
+	if err != nil {
+		t.Fatalf("compileRegex() error: %v", err)
+	}
+	second, _ := compileRegex(pattern)
+	if first != second {
+		t.Error("Expected the cached *regexp.Regexp to be reused")
+	}
+	if _, err := compileRegex("("); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+	if _, ok := regexCache.Load("("); ok {
+		t.Error("Invalid patterns should not be cached")
+	}
+}
+
 // TestIndexAccess tests array and map index access
 func TestIndexAccess(t *testing.T) {
 	ctx := NewContext()
@@ -1177,6 +1276,139 @@ func TestToNumberConversions(t *testing.T) {
 	}
 }
 
+func TestNumericBuiltins(t *testing.T) {
+	ctx := NewContext()
+	ctx.Steps["build"] = StepContext{Outputs: map[string]string{"count": "12", "name": "app"}}
+	ctx.Event["delta"] = float64(-4)
+	ctx.Event["offset"] = int64(-3)
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"min(3, 7)", 3},
+		{"max(3, 7)", 7},
+		{"abs(event.delta)", 4},
+		{"abs(event.offset)", 3},
+		{"abs(5)", 5},
+		{"min(2.5, 1.25)", 1.25},
+		{"max('-0.5', '-1.5')", -0.5},
+		{"abs('-2.75')", 2.75},
+		{"min(3, 2.5)", 2.5},
+		{"max(1.5, 2)", 2},
+		{"max(steps.build.outputs.count, 10)", 12},
+		{"min('4', 5.5)", 4},
+		{"abs(min(event.offset, event.delta))", 4},
+		{"event.delta", -4},
+		{"steps.build.outputs.count", 12},
+		{"${{ max(1, 2) }}", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ctx.EvaluateNumber(tt.expr)
+			if err != nil {
+				t.Fatalf("EvaluateNumber(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateNumber(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	// Builtins return float64 so they compare with any numeric operand
+	if ok, err := ctx.EvaluateBool("max(steps.build.outputs.count, 5) > 10"); err != nil || !ok {
+		t.Errorf("Expected max(...) > 10 to be true, got %v (err %v)", ok, err)
+	}
+	if v, _ := ctx.Evaluate("abs(1)"); v != float64(1) {
+		t.Errorf("Expected abs to return float64 1, got %#v", v)
+	}
+
+	errorCases := []string{
+		"min(1)",
+		"max(1, 2, 3)",
+		"abs()",
+		"min('abc', 1)",
+		"max(1, steps.build.outputs.name)",
+		"abs(true)",
+		"abs(null)",
+		"min(fromJSON('[1]'), 2)",
+		"'abc'",
+		"true",
+		"steps.build.outputs.missing",
+	}
+	for _, expr := range errorCases {
+		if got, err := ctx.EvaluateNumber(expr); err == nil {
+			t.Errorf("EvaluateNumber(%q) = %v, expected error", expr, got)
+		}
+	}
+}
+
+func TestEnvBuiltin(t *testing.T) {
+	t.Setenv("HOOKFLOW_TEST_STAGE", "production")
+	t.Setenv("HOOKFLOW_TEST_EMPTY", "")
+	ctx := NewContext()
+	ctx.Env["HOOKFLOW_TEST_STAGE"] = "test"
+	ctx.Env["STAGE_VAR"] = "HOOKFLOW_TEST_STAGE"
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"env('HOOKFLOW_TEST_STAGE')", "production"},
+		{"env.HOOKFLOW_TEST_STAGE", "test"},
+		{"env['HOOKFLOW_TEST_STAGE']", "test"},
+		{"env(env.STAGE_VAR)", "production"},
+		{"env('HOOKFLOW_TEST_UNSET')", ""},
+		{"env('HOOKFLOW_TEST_EMPTY')", ""},
+		{"env('HOOKFLOW_TEST_STAGE') != env.HOOKFLOW_TEST_STAGE", true},
+		{"startsWith(env('HOOKFLOW_TEST_STAGE'), 'prod')", true},
+	}
+	for _, tt := range tests {
+		got, err := ctx.Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("Evaluate(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+
+	if got, err := ctx.EvaluateString("stage=${{ env('HOOKFLOW_TEST_STAGE') }}"); err != nil || got != "stage=production" {
+		t.Errorf("Expected env() in a template, got %q (err %v)", got, err)
+	}
+	for _, expr := range []string{"env()", "env('A', 'B')"} {
+		if _, err := ctx.Evaluate(expr); err == nil || !strings.Contains(err.Error(), "env requires 1 argument") {
+			t.Errorf("Evaluate(%q) error = %v, want an argument count error", expr, err)
+		}
+	}
+}
+
+func TestEnvBuiltinRedacted(t *testing.T) {
+	t.Setenv("HOOKFLOW_TEST_TOKEN", "abc123")
+	t.Setenv("HOOKFLOW_TEST_TOKEN_LONG", "abc123-xyz")
+	t.Setenv("HOOKFLOW_TEST_SHORT", "dev")
+	ctx := NewContext()
+	for _, expr := range []string{"env('HOOKFLOW_TEST_TOKEN')", "env('HOOKFLOW_TEST_TOKEN_LONG')", "env('HOOKFLOW_TEST_SHORT')", "env('HOOKFLOW_TEST_TOKEN')"} {
+		if _, err := ctx.Evaluate(expr); err != nil {
+			t.Fatalf("Evaluate(%q): %v", expr, err)
+		}
+	}
+	if len(ctx.Redacted) != 2 {
+		t.Errorf("Expected two redacted values, got %q", ctx.Redacted)
+	}
+	if got, want := ctx.Redact("a=abc123 b=abc123-xyz env=dev"), "a=*** b=*** env=dev"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+	if got := ctx.Copy().Redact("abc123"); got != "***" {
+		t.Errorf("Expected a copy to keep the redacted values, got %q", got)
+	}
+	if got := NewContext().Redact("abc123"); got != "abc123" {
+		t.Errorf("Expected a new context to redact nothing, got %q", got)
+	}
+}
+
 // TestToBoolConversions tests toBool with various types
 func TestToBoolConversions(t *testing.T) {
 	tests := []struct {
@@ -1293,6 +1525,39 @@ func TestEvaluateStringErrors(t *testing.T) {
 	}
 }
 
+// TestEvaluateStringStructuredValues tests embedding JSON values in strings
+func TestEvaluateStringStructuredValues(t *testing.T) {
+	ctx := NewContext()
+	ctx.Event["tool"] = map[string]interface{}{
+		"args": map[string]interface{}{"path": "src/app.go"},
+	}
+	ctx.Env["CONFIG_JSON"] = `{"key": "value", "list": [1, 2]}`
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"toJSON of args", "echo '${{ toJSON(event.tool.args) }}'", `echo '{"path":"src/app.go"}'`},
+		{"fromJSON property", "key=${{ fromJSON(env.CONFIG_JSON).key }}", "key=value"},
+		{"fromJSON index", "${{ fromJSON(env.CONFIG_JSON).list[1] }}", "2"},
+		{"object interpolated as JSON", "${{ event.tool.args }}", `{"path":"src/app.go"}`},
+		{"array interpolated as JSON", "${{ fromJSON(env.CONFIG_JSON).list }}", "[1,2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.EvaluateString(tt.input)
+			if err != nil {
+				t.Fatalf("EvaluateString() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateString(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestParseCallErrors tests parseCall error paths
 func TestParseCallErrors(t *testing.T) {
 	ctx := NewContext()
@@ -1504,3 +1769,466 @@ func TestInequalityOperator(t *testing.T) {
 		})
 	}
 }
+
+// TestAlwaysAndNever tests the always() and never() boolean shorthands
+func TestAlwaysAndNever(t *testing.T) {
+	ctx := NewContext()
+
+	tests := []struct {
+		name string
+		expr string
+		want bool
+	}{
+		{"always", "always()", true},
+		{"never", "never()", false},
+		{"never wrapped", "${{ never() }}", false},
+		{"not never", "!never()", true},
+		{"always and never", "always() && never()", false},
+		{"never or true literal", "never() || true", true},
+		{"false literal", "false", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.EvaluateBool(tt.expr)
+			if err != nil {
+				t.Fatalf("EvaluateBool() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvaluateBool(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextClone(t *testing.T) {
+	base := NewContext()
+	base.Event["file"] = map[string]interface{}{"path": "src/main.go"}
+	base.Event["commit"] = map[string]interface{}{
+		"files": []map[string]string{{"path": "a.go", "status": "modified"}},
+	}
+	base.Env["MODE"] = "base"
+	base.Steps["lint"] = StepContext{Outputs: map[string]string{"count": "1"}, Outcome: "success"}
+
+	clone := base.Clone()
+	clone.Event["file"].(map[string]interface{})["path"] = "changed.go"
+	clone.Event["commit"].(map[string]interface{})["files"].([]map[string]string)[0]["path"] = "b.go"
+	clone.Env["MODE"] = "clone"
+	clone.Steps["lint"].Outputs["count"] = "2"
+	clone.Steps["test"] = StepContext{Outcome: "failure"}
+
+	if got, _ := base.EvaluateString("${{ event.file.path }}"); got != "src/main.go" {
+		t.Errorf("Base event changed through clone: %q", got)
+	}
+	if files := base.Event["commit"].(map[string]interface{})["files"].([]map[string]string); files[0]["path"] != "a.go" {
+		t.Errorf("Base commit files changed through clone: %v", files)
+	}
+	if base.Env["MODE"] != "base" {
+		t.Errorf("Base env changed through clone: %q", base.Env["MODE"])
+	}
+	if base.Steps["lint"].Outputs["count"] != "1" {
+		t.Errorf("Base step outputs changed through clone: %v", base.Steps["lint"].Outputs)
+	}
+	if _, ok := base.Steps["test"]; ok {
+		t.Error("Step added to clone should not appear in base")
+	}
+
+	// Built-in functions remain available on the clone
+	if got, err := clone.EvaluateBool("success()"); err != nil || got {
+		t.Errorf("Expected success() false after failed step on clone, got %v (err %v)", got, err)
+	}
+}
+
+// TestContextCloneConcurrent evaluates on clones of one base context in parallel; run with -race
+func TestContextJSONRoundtrip(t *testing.T) {
+	ctx := NewContext()
+	// Event data as decoded from hook JSON: nested maps, arrays, numbers, booleans and nulls
+	ctx.Event = map[string]interface{}{
+		"cwd": "/repo",
+		"tool": map[string]interface{}{
+			"name": "edit",
+			"args": map[string]interface{}{
+				"path":    "src/app.ts",
+				"lines":   float64(42),
+				"ratio":   0.5,
+				"dry_run": true,
+				"labels":  []interface{}{"a", "b", map[string]interface{}{"nested": []interface{}{float64(1), nil}}},
+			},
+		},
+		"commit": map[string]interface{}{
+			"message": "Fix \"quotes\" and ünïcode\nsecond line",
+			"files": []interface{}{
+				map[string]interface{}{"path": "a.go", "status": "added"},
+				map[string]interface{}{"path": "b.go", "status": "deleted"},
+			},
+		},
+		"metadata": map[string]interface{}{},
+	}
+	ctx.Env["STAGE"] = "ci"
+	ctx.Vars["environment"] = "prod"
+	ctx.Matrix["os"] = "linux"
+	ctx.SetStepResult("build", StepContext{Outputs: map[string]string{"count": "12"}, Outcome: "success"})
+	ctx.SetStepResult("lint", StepContext{Outcome: "failure"})
+
+	data, err := json.Marshal(ctx)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("Unmarshal raw: %v", err)
+	}
+	for _, key := range []string{"event", "env", "steps", "vars", "matrix"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("Expected %q in serialized context: %s", key, data)
+		}
+	}
+	if len(raw) != 5 {
+		t.Errorf("Expected only data maps to be serialized, got keys in: %s", data)
+	}
+
+	var restored Context
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Event, ctx.Event) {
+		t.Errorf("Event did not survive the roundtrip:\n got %#v\nwant %#v", restored.Event, ctx.Event)
+	}
+	if !reflect.DeepEqual(restored.Env, ctx.Env) || !reflect.DeepEqual(restored.Vars, ctx.Vars) || !reflect.DeepEqual(restored.Matrix, ctx.Matrix) {
+		t.Errorf("Env, vars or matrix did not survive: %+v", restored)
+	}
+	if !reflect.DeepEqual(restored.Steps, ctx.Steps) {
+		t.Errorf("Steps did not survive:\n got %#v\nwant %#v", restored.Steps, ctx.Steps)
+	}
+
+	// Built-in functions are registered again, so expressions keep working
+	checks := map[string]interface{}{
+		"event.tool.args.labels[2].nested[0]":       float64(1),
+		"event.commit.files[1].status":              "deleted",
+		"contains(event.commit.message, 'ÜNÏCODE')": true,
+		"steps.build.outputs.count > 10":            true,
+		"failure()":                                 true,
+		"ctx.environment":                           "prod",
+		"matrix.os":                                 "linux",
+		"env.STAGE":                                 "ci",
+	}
+	for expr, want := range checks {
+		got, err := restored.Evaluate(expr)
+		if err != nil || got != want {
+			t.Errorf("Evaluate(%q) = %#v, %v; want %#v", expr, got, err, want)
+		}
+	}
+
+	// A second roundtrip is stable
+	again, err := json.Marshal(&restored)
+	if err != nil || string(again) != string(data) {
+		t.Errorf("Expected a stable encoding, got %s (err %v), want %s", again, err, data)
+	}
+}
+
+func TestContextUnmarshalJSON(t *testing.T) {
+	ctx := NewContext()
+	ctx.Env["OLD"] = "1"
+	ctx.Functions["custom"] = func(args ...interface{}) (interface{}, error) { return "custom", nil }
+
+	if err := json.Unmarshal([]byte(`{"steps": {"build": {"outcome": "success"}}}`), ctx); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	// The decoded data replaces the context, including custom functions
+	if len(ctx.Env) != 0 || ctx.Event == nil || ctx.Vars == nil || ctx.Matrix == nil {
+		t.Errorf("Expected empty, non-nil maps for missing keys, got %+v", ctx)
+	}
+	if _, ok := ctx.Functions["custom"]; ok {
+		t.Error("Expected custom functions to be dropped")
+	}
+	if ctx.Steps["build"].Outputs == nil {
+		t.Error("Expected restored steps to have an outputs map")
+	}
+	if got, err := ctx.Evaluate("steps.build.outputs.missing"); err != nil || got != "" {
+		t.Errorf("Expected a missing output to be empty, got %#v (err %v)", got, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"event": "not an object"}`), ctx); err == nil {
+		t.Error("Expected error for an event that is not an object")
+	}
+	if err := json.Unmarshal([]byte(`{`), ctx); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestContextCloneConcurrent(t *testing.T) {
+	base := NewContext()
+	base.Event["file"] = map[string]interface{}{"path": "src/main.go"}
+	base.Env["MODE"] = "base"
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clone := base.Clone()
+			clone.Env["MODE"] = fmt.Sprintf("clone-%d", i)
+			clone.Steps["step"] = StepContext{Outputs: map[string]string{"n": fmt.Sprint(i)}, Outcome: "success"}
+
+			got, err := clone.EvaluateString("${{ event.file.path }} ${{ env.MODE }} ${{ steps.step.outputs.n }}")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("src/main.go clone-%d %d", i, i); got != want {
+				errs <- fmt.Errorf("got %q, want %q", got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if base.Env["MODE"] != "base" || len(base.Steps) != 0 {
+		t.Errorf("Base context changed by clones: env=%v steps=%v", base.Env, base.Steps)
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	base := NewContext()
+	base.Event["file"] = map[string]interface{}{"path": "src/main.go"}
+	base.Env["MODE"] = "base"
+	base.Vars["environment"] = "staging"
+	base.Matrix["os"] = "linux"
+	base.Steps["lint"] = StepContext{Outputs: map[string]string{"count": "1"}, Outcome: "success"}
+
+	copied := base.Copy()
+	if reflect.ValueOf(copied.Event).Pointer() != reflect.ValueOf(base.Event).Pointer() {
+		t.Error("Copy should share the event map with the base context")
+	}
+	copied.Env["MODE"] = "copy"
+	copied.Vars["environment"] = "production"
+	copied.Matrix["os"] = "windows"
+	copied.Steps["lint"].Outputs["count"] = "2"
+	copied.Steps["test"] = StepContext{Outcome: "failure"}
+
+	if base.Env["MODE"] != "base" || base.Vars["environment"] != "staging" || base.Matrix["os"] != "linux" {
+		t.Errorf("Base context changed through copy: env=%v vars=%v matrix=%v", base.Env, base.Vars, base.Matrix)
+	}
+	if base.Steps["lint"].Outputs["count"] != "1" || len(base.Steps) != 1 {
+		t.Errorf("Base steps changed through copy: %v", base.Steps)
+	}
+	if got, err := copied.EvaluateString("${{ event.file.path }} ${{ env.MODE }}"); err != nil || got != "src/main.go copy" {
+		t.Errorf("EvaluateString on copy = %q (err %v)", got, err)
+	}
+	if got, err := copied.EvaluateBool("success()"); err != nil || got {
+		t.Errorf("Expected success() false after failed step on copy, got %v (err %v)", got, err)
+	}
+}
+
+// largeEventContext returns a context whose event holds about 100KB of file
+// content and tool args, the size Copy is meant for
+func largeEventContext() *Context {
+	ctx := NewContext()
+	lines := make([]interface{}, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("line %04d: %s", i, strings.Repeat("x", 30)))
+	}
+	ctx.Event["file"] = map[string]interface{}{"path": "big.txt", "content": strings.Repeat("y", 50*1024)}
+	ctx.Event["tool"] = map[string]interface{}{"name": "create", "args": map[string]interface{}{"lines": lines}}
+	ctx.Env["MODE"] = "bench"
+	ctx.Steps["lint"] = StepContext{Outputs: map[string]string{"count": "1"}, Outcome: "success"}
+	return ctx
+}
+
+func BenchmarkClone(b *testing.B) {
+	ctx := largeEventContext()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ctx.Clone()
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	ctx := largeEventContext()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ctx.Copy()
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "repo")
+	if err := os.MkdirAll(filepath.Join(root, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(root, "security-policy.json"), `{"rules": ["no-eval"]}`)
+	writeFile(filepath.Join(root, "config", "app.yml"), "level: strict")
+	writeFile(filepath.Join(root, "big.txt"), strings.Repeat("x", maxReadFileSize+1))
+	writeFile(filepath.Join(parent, "secret.txt"), "top secret")
+	if err := os.Symlink(filepath.Join(parent, "secret.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Logf("Symlinks unavailable: %v", err)
+	}
+
+	ctx := NewContext()
+	ctx.Event["cwd"] = root
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"condition on file content", "contains(readFile('security-policy.json'), 'no-eval')", true},
+		{"subdirectory", "readFile('config/app.yml')", "level: strict"},
+		{"absolute path inside cwd", "readFile('" + filepath.ToSlash(filepath.Join(root, "config", "app.yml")) + "')", "level: strict"},
+		{"missing file", "readFile('missing.txt')", ""},
+		{"directory", "readFile('config')", ""},
+		{"over size limit", "readFile('big.txt')", ""},
+		{"parent traversal", "readFile('../secret.txt')", ""},
+		{"nested traversal", "readFile('config/../../secret.txt')", ""},
+		{"absolute path outside cwd", "readFile('" + filepath.ToSlash(filepath.Join(parent, "secret.txt")) + "')", ""},
+		{"symlink outside cwd", "readFile('link.txt')", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ctx.Evaluate("readFile()"); err == nil {
+		t.Error("Expected error for readFile without arguments")
+	}
+}
+
+func TestContextVars(t *testing.T) {
+	ctx := NewContext()
+	ctx.Vars["environment"] = "staging"
+
+	if got, err := ctx.EvaluateString("deploy to ${{ ctx.environment }}"); err != nil || got != "deploy to staging" {
+		t.Errorf("EvaluateString = %q (err %v), want %q", got, err, "deploy to staging")
+	}
+	if got, err := ctx.EvaluateBool("${{ ctx.environment == 'production' }}"); err != nil || got {
+		t.Errorf("EvaluateBool = %v (err %v), want false", got, err)
+	}
+	if got, err := ctx.Evaluate("ctx.missing"); err != nil || got != "" {
+		t.Errorf("Evaluate(ctx.missing) = %v (err %v), want empty", got, err)
+	}
+
+	clone := ctx.Clone()
+	clone.Vars["environment"] = "production"
+	if ctx.Vars["environment"] != "staging" {
+		t.Error("Clone should not share Vars with the base context")
+	}
+}
+
+func TestMatrixContext(t *testing.T) {
+	ctx := NewContext()
+	ctx.Matrix["env"] = "staging"
+	ctx.SetStepResult("Deploy (env=dev)", StepContext{Outcome: "success"})
+
+	if got, err := ctx.EvaluateString("deploy to ${{ matrix.env }}"); err != nil || got != "deploy to staging" {
+		t.Errorf("EvaluateString = %q (err %v), want %q", got, err, "deploy to staging")
+	}
+	if got, err := ctx.Evaluate("matrix.missing"); err != nil || got != "" {
+		t.Errorf("Evaluate(matrix.missing) = %v (err %v), want empty", got, err)
+	}
+	if got, err := ctx.Evaluate("steps['Deploy (env=dev)'].outcome"); err != nil || got != "success" {
+		t.Errorf("Evaluate(steps[...].outcome) = %v (err %v), want success", got, err)
+	}
+
+	clone := ctx.Clone()
+	clone.Matrix["env"] = "prod"
+	if ctx.Matrix["env"] != "staging" {
+		t.Error("Clone should not share Matrix with the base context")
+	}
+}
+
+func TestEvaluateTemplate(t *testing.T) {
+	ctx := NewContext()
+	ctx.Env["NAME"] = "world"
+	ctx.Event["tool"] = map[string]interface{}{"args": map[string]interface{}{"path": "a.go"}}
+
+	tmpl := "echo ${{ env.NAME }} ${{ toJSON(event.tool.args) }}"
+	got, err := ctx.EvaluateTemplate(tmpl)
+	if err != nil {
+		t.Fatalf("EvaluateTemplate error: %v", err)
+	}
+	want, _ := ctx.EvaluateString(tmpl)
+	if got != want || got != `echo world {"path":"a.go"}` {
+		t.Errorf("EvaluateTemplate = %q, want %q", got, want)
+	}
+
+	tmpl = "echo ${{ env.NAME }} ${{ bogus(1) }} and ${{ env.NAME == }}"
+	got, err = ctx.EvaluateTemplate(tmpl)
+	var tmplErr *TemplateError
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("Expected *TemplateError, got %v", err)
+	}
+	if len(tmplErr.Errors) != 2 {
+		t.Fatalf("Expected 2 failed expressions, got %d: %v", len(tmplErr.Errors), err)
+	}
+	first, second := strings.Index(tmpl, "${{ bogus"), strings.Index(tmpl, "${{ env.NAME ==")
+	if tmplErr.Errors[0].Position != first || tmplErr.Errors[1].Position != second {
+		t.Errorf("Positions = %d, %d, want %d, %d", tmplErr.Errors[0].Position, tmplErr.Errors[1].Position, first, second)
+	}
+	if tmplErr.Errors[0].Expression != "bogus(1)" {
+		t.Errorf("Expression = %q, want %q", tmplErr.Errors[0].Expression, "bogus(1)")
+	}
+	wantMsg := fmt.Sprintf("expressions at positions %d and %d failed", first, second)
+	if !strings.HasPrefix(err.Error(), wantMsg) {
+		t.Errorf("Error = %q, want prefix %q", err.Error(), wantMsg)
+	}
+	// Successful placeholders are still substituted; failed ones are kept
+	if got != "echo world ${{ bogus(1) }} and ${{ env.NAME == }}" {
+		t.Errorf("Partial result = %q", got)
+	}
+
+	_, err = ctx.EvaluateTemplate("${{ bogus() }}")
+	if err == nil || !strings.HasPrefix(err.Error(), "expression at position 0 failed") {
+		t.Errorf("Expected single-expression error, got %v", err)
+	}
+}
+
+func TestSetStepResult(t *testing.T) {
+	ctx := NewContext()
+	ctx.SetStepResult("step-1", StepContext{Outcome: "success"})
+	ctx.SetStepResult("parse", StepContext{Outcome: "failure", Outputs: map[string]string{"component": "auth"}})
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"${{ steps.step-1.outcome == 'success' }}", "true"},
+		{"${{ steps.step-1.outputs.missing }}", ""},
+		{"${{ steps.parse.outputs.component }}", "auth"},
+		{"${{ steps.parse.outcome }}", "failure"},
+		{"${{ failure() }}", "true"},
+	}
+	for _, tt := range tests {
+		got, err := ctx.EvaluateString(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+
+	// A missing outputs map is replaced so later writes don't panic
+	if ctx.Steps["step-1"].Outputs == nil {
+		t.Error("Expected SetStepResult to create an outputs map")
+	}
+}
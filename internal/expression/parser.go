@@ -110,6 +110,32 @@ func ReplaceExpressions(input string, replacer func(expr string) (string, error)
 	return result, nil
 }
 
+// identifierPattern matches a simple lookup such as name or env.HOME
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\.[A-Za-z_][A-Za-z0-9_-]*)*$`)
+
+// Interpolate replaces each ${{ name }} in template with vars[name], without
+// needing a Context. Names are looked up as written, so ${{ env.HOME }} reads
+// the "env.HOME" key. Anything but a simple lookup, such as a function call
+// or operator, is an error, as is a name missing from vars.
+func Interpolate(template string, vars map[string]interface{}) (string, error) {
+	return ReplaceExpressions(template, func(expr string) (string, error) {
+		if !identifierPattern.MatchString(expr) {
+			return "", fmt.Errorf("only simple names can be interpolated")
+		}
+		value, ok := vars[expr]
+		if !ok {
+			return "", fmt.Errorf("unknown name")
+		}
+		return interpolationString(value), nil
+	})
+}
+
+// Tokenize breaks an expression, written without ${{ }}, into tokens. The
+// last token is always TokenEOF.
+func Tokenize(expr string) ([]Token, error) {
+	return tokenize(expr)
+}
+
 // tokenize breaks an expression string into tokens
 func tokenize(expr string) ([]Token, error) {
 	var tokens []Token
@@ -205,7 +231,7 @@ func readOperator(runes []rune) (string, int) {
 	if len(runes) >= 2 {
 		two := string(runes[:2])
 		switch two {
-		case "==", "!=", "<=", ">=", "&&", "||":
+		case "==", "!=", "<=", ">=", "&&", "||", "=~", "!~":
 			return two, 2
 		}
 	}
@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -211,3 +212,157 @@ func TestReadNumber(t *testing.T) {
 		})
 	}
 }
+
+// TestLexer pins token boundaries so new operators do not change how
+// existing expressions split into tokens
+func TestLexer(t *testing.T) {
+	type tok struct {
+		typ   TokenType
+		value string
+	}
+	ident := func(v string) tok { return tok{TokenIdentifier, v} }
+	op := func(v string) tok { return tok{TokenOperator, v} }
+	str := func(v string) tok { return tok{TokenString, v} }
+	num := func(v string) tok { return tok{TokenNumber, v} }
+	var (
+		lparen   = tok{TokenLeftParen, "("}
+		rparen   = tok{TokenRightParen, ")"}
+		lbracket = tok{TokenLeftBracket, "["}
+		rbracket = tok{TokenRightBracket, "]"}
+		dot      = tok{TokenDot, "."}
+		comma    = tok{TokenComma, ","}
+	)
+
+	tests := []struct {
+		name string
+		expr string
+		want []tok
+	}{
+		{"and", "a&&b", []tok{ident("a"), op("&&"), ident("b")}},
+		{"or", "a||b", []tok{ident("a"), op("||"), ident("b")}},
+		{"not equal", "a!=b", []tok{ident("a"), op("!="), ident("b")}},
+		{"equal", "a==b", []tok{ident("a"), op("=="), ident("b")}},
+		{"less or equal", "a<=b", []tok{ident("a"), op("<="), ident("b")}},
+		{"greater or equal", "a>=b", []tok{ident("a"), op(">="), ident("b")}},
+		{"less then not", "a<!b", []tok{ident("a"), op("<"), op("!"), ident("b")}},
+		{"not not", "!!a", []tok{op("!"), op("!"), ident("a")}},
+		{"not then equal", "! ==", []tok{op("!"), op("==")}},
+		{"regex match", "a=~'^x'", []tok{ident("a"), op("=~"), str("^x")}},
+		{"regex not match", "a!~'^x'", []tok{ident("a"), op("!~"), str("^x")}},
+		{"equals then not", "a==!b", []tok{ident("a"), op("=="), op("!"), ident("b")}},
+		{"consecutive operators", "a&&!b||c", []tok{ident("a"), op("&&"), op("!"), ident("b"), op("||"), ident("c")}},
+		{"doubled quote escape", "'it''s'", []tok{str("it's")}},
+		{"doubled quote at edges", "'''quoted'''", []tok{str("'quoted'")}},
+		{"empty string", "''", []tok{str("")}},
+		{"backslash is literal", `'C:\dir'`, []tok{str(`C:\dir`)}},
+		{"string then operator", "'a'=='b'", []tok{str("a"), op("=="), str("b")}},
+		{"decimal", "3.14", []tok{num("3.14")}},
+		{"trailing decimal point", "1.", []tok{num("1.")}},
+		{"decimal comparison", "x>=0.5", []tok{ident("x"), op(">="), num("0.5")}},
+		{"minus is an operator", "-7", []tok{op("-"), num("7")}},
+		{"underscore identifier", "_private", []tok{ident("_private")}},
+		{"underscore segments", "env.__x_1", []tok{ident("env"), dot, ident("__x_1")}},
+		{"dash in identifier", "runs-on", []tok{ident("runs-on")}},
+		{"bracket index", "steps[0]", []tok{ident("steps"), lbracket, num("0"), rbracket}},
+		{"bracket string key", "env['MY_VAR'].x", []tok{ident("env"), lbracket, str("MY_VAR"), rbracket, dot, ident("x")}},
+		{"nested calls", "contains(startsWith(x, 'a'), 'true')", []tok{
+			ident("contains"), lparen, ident("startsWith"), lparen, ident("x"), comma, str("a"), rparen, comma, str("true"), rparen,
+		}},
+		{"whitespace variants", "a\t==\n\r b", []tok{ident("a"), op("=="), ident("b")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenize(tt.expr)
+			if err != nil {
+				t.Fatalf("tokenize(%q) error: %v", tt.expr, err)
+			}
+			if last := tokens[len(tokens)-1]; last.Type != TokenEOF {
+				t.Fatalf("tokenize(%q) last token = %v, want EOF", tt.expr, last)
+			}
+			got := make([]tok, 0, len(tokens)-1)
+			for _, token := range tokens[:len(tokens)-1] {
+				got = append(got, tok{token.Type, token.Value})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("tokenize(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tokenize(%q) token %d = %v, want %v", tt.expr, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"unterminated string", "'open"},
+		{"backslash does not escape a quote", `'it\'s'`},
+		{"single equals", "a = b"},
+		{"single ampersand", "a & b"},
+		{"single pipe", "a | b"},
+		{"lone tilde", "a ~ b"},
+		{"tilde then equals", "a ~= b"},
+		{"double quotes", `"text"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tokens, err := tokenize(tt.expr); err == nil {
+				t.Errorf("tokenize(%q) = %v, want error", tt.expr, tokens)
+			}
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]interface{}{
+		"name":                        "hookflow",
+		"env.HOME":                    "/home/dev",
+		"steps.parse.outputs.comp-id": "auth",
+		"count":                       float64(3),
+		"enabled":                     true,
+		"files":                       []interface{}{"a.go", "b.go"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{"no placeholders", "plain text", "plain text", ""},
+		{"simple name", "hello ${{ name }}", "hello hookflow", ""},
+		{"dotted name", "${{env.HOME}}/bin", "/home/dev/bin", ""},
+		{"hyphenated segment", "${{ steps.parse.outputs.comp-id }}", "auth", ""},
+		{"several placeholders", "${{ name }}:${{ count }}:${{ enabled }}", "hookflow:3:true", ""},
+		{"structured value as JSON", "${{ files }}", `["a.go","b.go"]`, ""},
+		{"unknown name", "${{ env.PATH }}", "", "unknown name"},
+		{"function call", "${{ format('{0}', name) }}", "", "only simple names"},
+		{"operator", "${{ name == 'x' }}", "", "only simple names"},
+		{"index", "${{ files[0] }}", "", "only simple names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Interpolate(tt.template, vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Interpolate(%q) error = %v, want %q", tt.template, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Interpolate(%q): %v", tt.template, err)
+			}
+			if got != tt.want {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
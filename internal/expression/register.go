@@ -0,0 +1,181 @@
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// registry holds the functions added with Register; every new Context
+// gets them alongside the built-in functions
+var registry = struct {
+	sync.RWMutex
+	functions map[string]Function
+}{functions: make(map[string]Function)}
+
+// functionName matches the names Register accepts
+var functionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reservedNames are identifiers the evaluator reads as values, not calls
+var reservedNames = map[string]bool{
+	"true": true, "false": true, "null": true,
+	"event": true, "env": true, "steps": true, "ctx": true, "matrix": true,
+}
+
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Register adds a function that expressions in every Context created
+// afterwards can call by name. fn must be a func returning
+// (interface{}, error) whose parameters are string, bool, int, float64 or
+// interface{}, optionally variadic. Arguments are converted the way the
+// evaluator converts values, so isLicenseHeader(event.file.content) passes
+// the content as a string. Built-in names cannot be replaced and a name can
+// only be registered once.
+func Register(name string, fn interface{}) error {
+	if err := checkFunctionName(name); err != nil {
+		return err
+	}
+	wrapped, err := wrapFunction(name, fn)
+	if err != nil {
+		return err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.functions[name]; ok {
+		return fmt.Errorf("function %s is already registered", name)
+	}
+	registry.functions[name] = wrapped
+	return nil
+}
+
+// AddFunction adds a function that expressions evaluated in this context,
+// and contexts cloned from it afterwards, can call by name. It is how a
+// caller such as the runner gives one workflow functions of its own without
+// a plugin. Functions should be pure: they may run any number of times, or
+// not at all when an && or || short-circuits. Built-in and registered names
+// cannot be replaced.
+func (ctx *Context) AddFunction(name string, fn Function) error {
+	if err := checkFunctionName(name); err != nil {
+		return err
+	}
+	if fn == nil {
+		return fmt.Errorf("function %s: expected a func, got nil", name)
+	}
+	if _, ok := ctx.Functions[name]; ok {
+		return fmt.Errorf("function %s is already defined", name)
+	}
+	if ctx.Functions == nil {
+		ctx.Functions = make(map[string]Function)
+	}
+	ctx.Functions[name] = fn
+	return nil
+}
+
+// checkFunctionName rejects names that are not identifiers or that belong
+// to the evaluator
+func checkFunctionName(name string) error {
+	if !functionName.MatchString(name) {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	if reservedNames[name] || isBuiltin(name) {
+		return fmt.Errorf("function name %q is reserved", name)
+	}
+	return nil
+}
+
+// isBuiltin reports whether name is one of the built-in functions
+func isBuiltin(name string) bool {
+	builtins := &Context{Functions: make(map[string]Function), ContextFunctions: make(map[string]ContextFunction)}
+	addBuiltins(builtins)
+	_, fn := builtins.Functions[name]
+	_, ctxFn := builtins.ContextFunctions[name]
+	return fn || ctxFn
+}
+
+// addRegistered adds the registered functions to ctx
+func addRegistered(ctx *Context) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for name, fn := range registry.functions {
+		ctx.Functions[name] = fn
+	}
+}
+
+// wrapFunction checks fn's signature and adapts it to a Function
+func wrapFunction(name string, fn interface{}) (Function, error) {
+	v := reflect.ValueOf(fn)
+	if fn == nil || v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("function %s: expected a func, got %T", name, fn)
+	}
+	t := v.Type()
+	if t.NumOut() != 2 || t.Out(0) != interfaceType || t.Out(1) != errorType {
+		return nil, fmt.Errorf("function %s: must return (interface{}, error), got %s", name, t)
+	}
+	params := make([]reflect.Type, t.NumIn())
+	for i := range params {
+		params[i] = t.In(i)
+		if t.IsVariadic() && i == len(params)-1 {
+			params[i] = params[i].Elem()
+		}
+		if !supportedParam(params[i]) {
+			return nil, fmt.Errorf("function %s: unsupported parameter type %s", name, t.In(i))
+		}
+	}
+
+	return func(args ...interface{}) (result interface{}, err error) {
+		switch {
+		case t.IsVariadic() && len(args) < len(params)-1:
+			return nil, fmt.Errorf("%s requires at least %d arguments", name, len(params)-1)
+		case !t.IsVariadic() && len(args) != len(params):
+			return nil, fmt.Errorf("%s requires %d arguments", name, len(params))
+		}
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			in[i] = convertArg(arg, params[min(i, len(params)-1)])
+		}
+
+		// A panicking extension fails the expression, not the hook
+		defer func() {
+			if r := recover(); r != nil {
+				result, err = nil, fmt.Errorf("%s panicked: %v", name, r)
+			}
+		}()
+		out := v.Call(in)
+		if e, _ := out[1].Interface().(error); e != nil {
+			return nil, e
+		}
+		return out[0].Interface(), nil
+	}, nil
+}
+
+// supportedParam reports whether Register can convert arguments to t
+func supportedParam(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+		return true
+	}
+	return t == interfaceType
+}
+
+// convertArg converts an evaluated value to a parameter of type t
+func convertArg(arg interface{}, t reflect.Type) reflect.Value {
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(toString(arg)).Convert(t)
+	case reflect.Bool:
+		return reflect.ValueOf(toBool(arg)).Convert(t)
+	case reflect.Int:
+		return reflect.ValueOf(int(toNumber(arg))).Convert(t)
+	case reflect.Float64:
+		return reflect.ValueOf(toNumber(arg)).Convert(t)
+	}
+	if arg == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(arg)
+}
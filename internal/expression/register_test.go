@@ -0,0 +1,195 @@
+package expression
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// unregister removes functions added by a test from the shared registry
+func unregister(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		registry.Lock()
+		defer registry.Unlock()
+		for _, name := range names {
+			delete(registry.functions, name)
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	unregister(t, "isLicenseHeader", "sum", "repeat", "describe", "explode")
+
+	if err := Register("isLicenseHeader", func(content string) (interface{}, error) {
+		return strings.HasPrefix(content, "// Copyright"), nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := Register("sum", func(nums ...float64) (interface{}, error) {
+		total := 0.0
+		for _, n := range nums {
+			total += n
+		}
+		return total, nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := Register("repeat", func(s string, n int) (interface{}, error) {
+		if n < 0 {
+			return nil, errors.New("count must not be negative")
+		}
+		return strings.Repeat(s, n), nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := Register("describe", func(v interface{}, flag bool) (interface{}, error) {
+		if v == nil {
+			return "nil", nil
+		}
+		return v, nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := Register("explode", func() (interface{}, error) {
+		panic("boom")
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	ctx := NewContext()
+	ctx.Event["file"] = map[string]interface{}{"content": "// Copyright 2026\npackage main"}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"isLicenseHeader(event.file.content)", true},
+		{"isLicenseHeader('package main') || contains('ab', 'b')", true},
+		{"sum()", 0.0},
+		{"sum(1, '2', 3.5)", 6.5},
+		{"repeat('ab', '3')", "ababab"},
+		{"describe(null, 1)", "nil"},
+		{"describe(event.missing, true)", "nil"},
+		{"describe(1 == 1, false)", true},
+	}
+	for _, tt := range tests {
+		got, err := ctx.Evaluate(tt.expr)
+		if err != nil {
+			t.Errorf("Evaluate(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+
+	errorTests := map[string]string{
+		"isLicenseHeader()":     "isLicenseHeader requires 1 arguments",
+		"repeat('a')":           "repeat requires 2 arguments",
+		"repeat('a', '-1')":     "count must not be negative",
+		"explode()":             "explode panicked: boom",
+		"notRegistered('text')": "unknown function: notRegistered",
+	}
+	for expr, want := range errorTests {
+		if _, err := ctx.Evaluate(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Evaluate(%q) error = %v, want %q", expr, err, want)
+		}
+	}
+
+	// Registered functions survive Clone and are seen by contexts created later
+	if got, err := ctx.Clone().EvaluateBool("isLicenseHeader('// Copyright')"); err != nil || !got {
+		t.Errorf("Expected the clone to have registered functions, got %v (err %v)", got, err)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	unregister(t, "once")
+	valid := func(s string) (interface{}, error) { return s, nil }
+	if err := Register("once", valid); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		fn   interface{}
+		want string
+	}{
+		{"once", valid, "already registered"},
+		{"contains", valid, "reserved"},
+		{"success", valid, "reserved"},
+		{"event", valid, "reserved"},
+		{"has-dash", valid, "invalid function name"},
+		{"", valid, "invalid function name"},
+		{"notFunc", "text", "expected a func"},
+		{"nilFunc", nil, "expected a func"},
+		{"typedNil", (func(string) (interface{}, error))(nil), "expected a func"},
+		{"noError", func(s string) interface{} { return s }, "must return (interface{}, error)"},
+		{"stringResult", func(s string) (string, error) { return s, nil }, "must return (interface{}, error)"},
+		{"sliceParam", func(s []string) (interface{}, error) { return s, nil }, "unsupported parameter type []string"},
+		{"mapVariadic", func(m ...map[string]string) (interface{}, error) { return m, nil }, "unsupported parameter type"},
+	}
+	for _, tt := range tests {
+		err := Register(tt.name, tt.fn)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Register(%q) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	for _, name := range []string{"notFunc", "noError", "sliceParam"} {
+		if _, ok := NewContext().Functions[name]; ok {
+			t.Errorf("Expected rejected function %s not to be registered", name)
+		}
+	}
+}
+
+func TestContextAddFunction(t *testing.T) {
+	unregister(t, "registeredOnly")
+	if err := Register("registeredOnly", func() (interface{}, error) { return true, nil }); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	ctx := NewContext()
+	ctx.Event["file"] = map[string]interface{}{"path": "internal/api/v2/handler.go"}
+	isVersioned := func(args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, errors.New("isVersioned requires 1 argument")
+		}
+		return strings.Contains(toString(args[0]), "/v2/"), nil
+	}
+	if err := ctx.AddFunction("isVersioned", isVersioned); err != nil {
+		t.Fatalf("AddFunction: %v", err)
+	}
+	if got, err := ctx.EvaluateBool("${{ isVersioned(event.file.path) && endsWith(event.file.path, '.go') }}"); err != nil || !got {
+		t.Errorf("Expected isVersioned to be callable from a condition, got %v (err %v)", got, err)
+	}
+	if got, err := ctx.Clone().EvaluateBool("isVersioned('a/v2/b')"); err != nil || !got {
+		t.Errorf("Expected clones to keep added functions, got %v (err %v)", got, err)
+	}
+	if _, ok := NewContext().Functions["isVersioned"]; ok {
+		t.Error("Expected AddFunction to affect only its own context")
+	}
+
+	tests := []struct {
+		name string
+		fn   Function
+		want string
+	}{
+		{"isVersioned", isVersioned, "already defined"},
+		{"registeredOnly", isVersioned, "already defined"},
+		{"contains", isVersioned, "reserved"},
+		{"always", isVersioned, "reserved"},
+		{"env", isVersioned, "reserved"},
+		{"bad name", isVersioned, "invalid function name"},
+		{"nilFunc", nil, "expected a func"},
+	}
+	for _, tt := range tests {
+		if err := ctx.AddFunction(tt.name, tt.fn); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("AddFunction(%q) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	// A context built without NewContext gets a function map
+	bare := &Context{}
+	if err := bare.AddFunction("isVersioned", isVersioned); err != nil || bare.Functions["isVersioned"] == nil {
+		t.Errorf("AddFunction on a bare context: %v", err)
+	}
+}
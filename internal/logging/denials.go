@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxLogFilesEnv overrides how many denial logs are kept
+const MaxLogFilesEnv = "HOOKFLOW_MAX_LOG_FILES"
+
+// DefaultMaxLogFiles is the number of denial logs kept when MaxLogFilesEnv is unset
+const DefaultMaxLogFiles = 50
+
+// Filesystem hooks, replaced in tests
+var (
+	readDir    = os.ReadDir
+	removeFile = os.Remove
+)
+
+// DenialLogDir returns the directory that holds per-denial workflow logs
+func DenialLogDir() string {
+	return filepath.Join(os.TempDir(), "hookflow-denials")
+}
+
+// MaxLogFiles returns the denial log limit from MaxLogFilesEnv, or
+// DefaultMaxLogFiles when it is unset or not a positive number
+func MaxLogFiles() int {
+	if n, err := strconv.Atoi(os.Getenv(MaxLogFilesEnv)); err == nil && n > 0 {
+		return n
+	}
+	return DefaultMaxLogFiles
+}
+
+// PruneLogs deletes the oldest .log files in dir so that at most keep remain.
+// It returns how many files were deleted.
+func PruneLogs(dir string, keep int) (int, error) {
+	entries, err := readDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	type logFile struct {
+		path    string
+		modTime int64
+	}
+	var logs []logFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, logFile{filepath.Join(dir, entry.Name()), info.ModTime().UnixNano()})
+	}
+	if len(logs) <= keep {
+		return 0, nil
+	}
+
+	// Newest first, so everything past keep is stale
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].modTime > logs[j].modTime
+	})
+
+	removed := 0
+	for _, log := range logs[keep:] {
+		if err := removeFile(log.path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
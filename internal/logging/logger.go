@@ -1,14 +1,20 @@
 // Package logging provides production logging for hookflow.
 // Logs are written to a known location (~/.hookflow/logs/) with automatic rotation.
-// Enable verbose logging with HOOKFLOW_DEBUG=1 or --verbose flag.
+// Only warnings and errors are logged by default; set HOOKFLOW_LOG_LEVEL to
+// debug, info, warn or error to change that. HOOKFLOW_DEBUG=1 is the same as
+// HOOKFLOW_LOG_LEVEL=debug.
 package logging
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -39,6 +45,47 @@ func (l Level) String() string {
 	}
 }
 
+// LogLevelEnv sets the minimum log level: debug, info, warn or error
+const LogLevelEnv = "HOOKFLOW_LOG_LEVEL"
+
+// DefaultLevel is used when neither HOOKFLOW_LOG_LEVEL nor HOOKFLOW_DEBUG is
+// set. Hook scripts run on every tool call, so only problems are logged.
+const DefaultLevel = LevelWarn
+
+// ParseLevel converts a level name, in any case, to a Level. "warning" is
+// accepted for warn.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return DefaultLevel, fmt.Errorf("unknown log level %q: must be debug, info, warn or error", name)
+}
+
+// levelFromEnv returns the level set by HOOKFLOW_LOG_LEVEL, then
+// HOOKFLOW_DEBUG or HOOKFLOW_VERBOSE, then DefaultLevel. An invalid
+// HOOKFLOW_LOG_LEVEL is returned as an error alongside the fallback level.
+func levelFromEnv() (Level, error) {
+	var invalid error
+	if name := os.Getenv(LogLevelEnv); name != "" {
+		level, err := ParseLevel(name)
+		if err == nil {
+			return level, nil
+		}
+		invalid = err
+	}
+	if os.Getenv("HOOKFLOW_DEBUG") == "1" || os.Getenv("HOOKFLOW_VERBOSE") == "1" {
+		return LevelDebug, invalid
+	}
+	return DefaultLevel, invalid
+}
+
 // Logger is the main logging interface
 type Logger struct {
 	mu       sync.Mutex
@@ -46,11 +93,15 @@ type Logger struct {
 	file     *os.File
 	filePath string
 	session  string // Unique session ID for correlating logs
+	noColor  bool   // Strip ANSI escape sequences from entries
 }
 
 var (
 	defaultLogger *Logger
 	once          sync.Once
+
+	// ansiPattern matches ANSI CSI escape sequences such as color codes
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
 )
 
 // logDir returns the hookflow log directory
@@ -87,16 +138,17 @@ func Init() error {
 		sessionID := fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano()%100000)
 
 		// Determine log level from environment
-		level := LevelInfo
-		if os.Getenv("HOOKFLOW_DEBUG") == "1" || os.Getenv("HOOKFLOW_VERBOSE") == "1" {
-			level = LevelDebug
-		}
+		level, levelErr := levelFromEnv()
 
 		defaultLogger = &Logger{
 			level:    level,
 			file:     f,
 			filePath: logFile,
 			session:  sessionID,
+			noColor:  NoColorEnv(),
+		}
+		if levelErr != nil {
+			Warn("ignoring %s: %v", LogLevelEnv, levelErr)
 		}
 
 		// Clean up old logs (keep last 7 days)
@@ -119,6 +171,25 @@ func EnableDebug() {
 	SetLevel(LevelDebug)
 }
 
+// SetNoColor enables or disables stripping of ANSI escape sequences
+func SetNoColor(noColor bool) {
+	if defaultLogger != nil {
+		defaultLogger.mu.Lock()
+		defaultLogger.noColor = noColor
+		defaultLogger.mu.Unlock()
+	}
+}
+
+// NoColorEnv reports whether the NO_COLOR environment variable is set (see https://no-color.org/)
+func NoColorEnv() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// StripANSI removes ANSI escape sequences from s
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
 // Close closes the log file
 func Close() {
 	if defaultLogger != nil && defaultLogger.file != nil {
@@ -154,6 +225,9 @@ func log(level Level, format string, args ...interface{}) {
 
 	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
 	message := fmt.Sprintf(format, args...)
+	if defaultLogger.noColor {
+		message = StripANSI(message)
+	}
 
 	// Get caller info for debug logs
 	caller := ""
@@ -196,28 +270,142 @@ func Error(format string, args ...interface{}) {
 
 // WithContext returns a contextual logger that prefixes all messages
 type ContextLogger struct {
-	prefix string
+	prefix      string
+	executionID string // Workflow execution ID, empty when not running a workflow
+	fields      Fields // Structured fields added to every line
 }
 
+// Fields are key/value pairs attached to log lines by WithFields
+type Fields map[string]string
+
+// Field names identifying the workflow run a line belongs to
+const (
+	FieldWorkflowName = "workflow_name"
+	FieldExecutionID  = "execution_id"
+	FieldStepName     = "step_name"
+)
+
 // Context creates a new contextual logger
 func Context(prefix string) *ContextLogger {
 	return &ContextLogger{prefix: prefix}
 }
 
+// executionIDKey is the context key for workflow execution IDs
+type executionIDKey struct{}
+
+// WithExecutionID returns a context carrying a workflow execution ID
+func WithExecutionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, executionIDKey{}, id)
+}
+
+// ExecutionID returns the workflow execution ID carried by ctx, if any
+func ExecutionID(ctx context.Context) string {
+	id, _ := ctx.Value(executionIDKey{}).(string)
+	return id
+}
+
+// FromContext creates a contextual logger that also tags every line with
+// [exec-id:...] when ctx carries a workflow execution ID
+func FromContext(ctx context.Context, prefix string) *ContextLogger {
+	return &ContextLogger{prefix: prefix, executionID: ExecutionID(ctx)}
+}
+
+// WithFields returns a copy of the logger that adds fields to every line,
+// overriding any fields of the same name already set
+func (c *ContextLogger) WithFields(fields Fields) *ContextLogger {
+	merged := make(Fields, len(c.fields)+len(fields))
+	for k, v := range c.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &ContextLogger{prefix: c.prefix, executionID: c.executionID, fields: merged}
+}
+
+// tag builds the line prefix for this logger
+func (c *ContextLogger) tag() string {
+	tag := fmt.Sprintf("[%s] ", c.prefix)
+	if c.executionID != "" {
+		tag = fmt.Sprintf("[exec-id:%s] %s", c.executionID, tag)
+	}
+	if len(c.fields) > 0 {
+		tag += formatFields(c.fields) + " "
+	}
+	return tag
+}
+
+// formatFields renders fields as {key="value" ...} in key order
+func formatFields(fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + strconv.Quote(fields[k])
+	}
+	return "{" + strings.Join(pairs, " ") + "}"
+}
+
+var (
+	// execIDPattern matches the [exec-id:...] tag
+	execIDPattern = regexp.MustCompile(`\[exec-id:([^\]]+)\]`)
+	// fieldsPattern matches a {key="value" ...} block following a logger tag
+	fieldsPattern = regexp.MustCompile(`\] \{(\w+="(?:[^"\\]|\\.)*"(?: \w+="(?:[^"\\]|\\.)*")*)\} `)
+	// fieldPattern matches one key="value" pair
+	fieldPattern = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*")`)
+)
+
+// WorkflowRunLog identifies the workflow run and step a log line came from
+type WorkflowRunLog struct {
+	WorkflowName string
+	ExecutionID  string
+	StepName     string
+}
+
+// ParseWorkflowRunLog extracts the workflow run fields from a log line.
+// It returns false when the line carries no workflow fields or execution ID.
+func ParseWorkflowRunLog(line string) (WorkflowRunLog, bool) {
+	var run WorkflowRunLog
+	if m := execIDPattern.FindStringSubmatch(line); m != nil {
+		run.ExecutionID = m[1]
+	}
+	if m := fieldsPattern.FindStringSubmatch(line); m != nil {
+		for _, pair := range fieldPattern.FindAllStringSubmatch(m[1], -1) {
+			value, err := strconv.Unquote(pair[2])
+			if err != nil {
+				continue
+			}
+			switch pair[1] {
+			case FieldWorkflowName:
+				run.WorkflowName = value
+			case FieldExecutionID:
+				run.ExecutionID = value
+			case FieldStepName:
+				run.StepName = value
+			}
+		}
+	}
+	return run, run != WorkflowRunLog{}
+}
+
 func (c *ContextLogger) Debug(format string, args ...interface{}) {
-	Debug("[%s] "+format, append([]interface{}{c.prefix}, args...)...)
+	Debug("%s"+format, append([]interface{}{c.tag()}, args...)...)
 }
 
 func (c *ContextLogger) Info(format string, args ...interface{}) {
-	Info("[%s] "+format, append([]interface{}{c.prefix}, args...)...)
+	Info("%s"+format, append([]interface{}{c.tag()}, args...)...)
 }
 
 func (c *ContextLogger) Warn(format string, args ...interface{}) {
-	Warn("[%s] "+format, append([]interface{}{c.prefix}, args...)...)
+	Warn("%s"+format, append([]interface{}{c.tag()}, args...)...)
 }
 
 func (c *ContextLogger) Error(format string, args ...interface{}) {
-	Error("[%s] "+format, append([]interface{}{c.prefix}, args...)...)
+	Error("%s"+format, append([]interface{}{c.tag()}, args...)...)
 }
 
 // cleanOldLogs removes log files older than maxDays
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,6 +50,7 @@ func TestInitAndLog(t *testing.T) {
 	originalHome := os.Getenv("HOME")
 	_ = os.Setenv("HOME", tmpDir)
 	defer func() { _ = os.Setenv("HOME", originalHome) }()
+	t.Setenv(LogLevelEnv, "info")
 
 	// Initialize
 	err := Init()
@@ -211,14 +213,100 @@ func TestCleanOldLogs(t *testing.T) {
 }
 
 func TestLogLevelFiltering(t *testing.T) {
+	tests := []struct {
+		name     string
+		logLevel string
+		debug    string
+		want     []string // Levels expected in the log, of debug, info, warn, error
+	}{
+		{name: "default is warn", want: []string{"warn", "error"}},
+		{name: "debug", logLevel: "debug", want: []string{"debug", "info", "warn", "error"}},
+		{name: "info", logLevel: "info", want: []string{"info", "warn", "error"}},
+		{name: "warn", logLevel: "warn", want: []string{"warn", "error"}},
+		{name: "error", logLevel: "ERROR", want: []string{"error"}},
+		{name: "HOOKFLOW_DEBUG", debug: "1", want: []string{"debug", "info", "warn", "error"}},
+		{name: "level wins over HOOKFLOW_DEBUG", logLevel: "error", debug: "1", want: []string{"error"}},
+		{name: "invalid level falls back", logLevel: "loud", want: []string{"warn", "error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reset the singleton
+			defaultLogger = nil
+			once = sync.Once{}
+
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv(LogLevelEnv, tt.logLevel)
+			t.Setenv("HOOKFLOW_DEBUG", tt.debug)
+			t.Setenv("HOOKFLOW_VERBOSE", "")
+
+			if err := Init(); err != nil {
+				t.Fatalf("Init() failed: %v", err)
+			}
+			defer Close()
+
+			Debug("debug message")
+			Info("info message")
+			Warn("warn message")
+			Error("error message")
+
+			content, _ := os.ReadFile(LogPath())
+			logContent := string(content)
+			for _, level := range []string{"debug", "info", "warn", "error"} {
+				want := false
+				for _, w := range tt.want {
+					want = want || w == level
+				}
+				if got := strings.Contains(logContent, level+" message"); got != want {
+					t.Errorf("%s message logged = %v, want %v; log:\n%s", level, got, want, logContent)
+				}
+			}
+			if tt.logLevel == "loud" && !strings.Contains(logContent, "ignoring HOOKFLOW_LOG_LEVEL") {
+				t.Errorf("Expected a warning about the invalid level, got:\n%s", logContent)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{"debug": LevelDebug, "Info": LevelInfo, "warn": LevelWarn, "WARNING": LevelWarn, " error ": LevelError}
+	for name, want := range tests {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"plain text", "plain text"},
+		{"\x1b[31mred\x1b[0m", "red"},
+		{"\x1b[1;32mbold green\x1b[0m done", "bold green done"},
+		{"\x1b[2K\x1b[?25lprogress", "progress"},
+	}
+
+	for _, tt := range tests {
+		if got := StripANSI(tt.input); got != tt.want {
+			t.Errorf("StripANSI(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNoColorStripsLogEntries(t *testing.T) {
 	// Reset the singleton
 	defaultLogger = nil
 	once = sync.Once{}
 
 	tmpDir := t.TempDir()
-	originalHome := os.Getenv("HOME")
-	_ = os.Setenv("HOME", tmpDir)
-	defer func() { _ = os.Setenv("HOME", originalHome) }()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(LogLevelEnv, "info")
+	t.Setenv("NO_COLOR", "1")
 
 	err := Init()
 	if err != nil {
@@ -226,17 +314,196 @@ func TestLogLevelFiltering(t *testing.T) {
 	}
 	defer Close()
 
-	// Default level is INFO, so DEBUG should be filtered
-	Debug("should be filtered")
-	Info("should appear")
+	Info("step output: %s", "\x1b[31mfailed\x1b[0m")
 
 	content, _ := os.ReadFile(LogPath())
 	logContent := string(content)
 
-	if strings.Contains(logContent, "should be filtered") {
-		t.Error("Debug message should be filtered at INFO level")
+	if strings.Contains(logContent, "\x1b[") {
+		t.Error("Log file should not contain ANSI escapes when NO_COLOR is set")
+	}
+	if !strings.Contains(logContent, "step output: failed") {
+		t.Errorf("Expected stripped message in log, got: %s", logContent)
+	}
+}
+
+func TestExecutionIDPrefix(t *testing.T) {
+	// Reset the singleton
+	defaultLogger = nil
+	once = sync.Once{}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(LogLevelEnv, "info")
+
+	err := Init()
+	if err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	defer Close()
+
+	ctx := WithExecutionID(context.Background(), "abc-123")
+	if got := ExecutionID(ctx); got != "abc-123" {
+		t.Errorf("ExecutionID() = %q, want %q", got, "abc-123")
+	}
+	if got := ExecutionID(context.Background()); got != "" {
+		t.Errorf("ExecutionID() without ID = %q, want empty", got)
+	}
+
+	FromContext(ctx, "runner").Info("step %s finished", "lint")
+	FromContext(context.Background(), "runner").Info("no execution")
+
+	content, _ := os.ReadFile(LogPath())
+	logContent := string(content)
+
+	if !strings.Contains(logContent, "[exec-id:abc-123] [runner] step lint finished") {
+		t.Errorf("Expected execution ID prefix in log, got: %s", logContent)
+	}
+	if !strings.Contains(logContent, "] [runner] no execution") || strings.Contains(logContent, "[exec-id:] ") {
+		t.Errorf("Expected plain prefix without execution ID, got: %s", logContent)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	// Reset the singleton
+	defaultLogger = nil
+	once = sync.Once{}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(LogLevelEnv, "info")
+
+	if err := Init(); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+	defer Close()
+
+	ctx := WithExecutionID(context.Background(), "abc-123")
+	wfLogger := FromContext(ctx, "runner").WithFields(Fields{FieldWorkflowName: "Lint Code"})
+	wfLogger.WithFields(Fields{FieldStepName: `say "hi"`}).Info("step done")
+	wfLogger.Info("workflow done")
+
+	content, _ := os.ReadFile(LogPath())
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), content)
+	}
+	if !strings.Contains(lines[0], `[exec-id:abc-123] [runner] {step_name="say \"hi\"" workflow_name="Lint Code"} step done`) {
+		t.Errorf("Expected fields after prefix, got: %s", lines[0])
+	}
+
+	run, ok := ParseWorkflowRunLog(lines[0])
+	want := WorkflowRunLog{WorkflowName: "Lint Code", ExecutionID: "abc-123", StepName: `say "hi"`}
+	if !ok || run != want {
+		t.Errorf("ParseWorkflowRunLog() = %+v, %v; want %+v", run, ok, want)
+	}
+
+	// Adding fields does not change the parent logger
+	run, _ = ParseWorkflowRunLog(lines[1])
+	if run.StepName != "" || run.WorkflowName != "Lint Code" {
+		t.Errorf("ParseWorkflowRunLog() = %+v, want workflow fields only", run)
+	}
+}
+
+func TestParseWorkflowRunLogUntagged(t *testing.T) {
+	lines := []string{
+		"[2024-01-01 00:00:00.000] [INFO] [1-2] [matcher] found 2 workflows",
+		`[2024-01-01 00:00:00.000] [INFO] [1-2] [run] event {"a":1}`,
+		`[2024-01-01 00:00:00.000] [INFO] [1-2] [run] message with workflow_name="x"`,
+	}
+	for _, line := range lines {
+		if run, ok := ParseWorkflowRunLog(line); ok {
+			t.Errorf("ParseWorkflowRunLog(%q) = %+v, want no match", line, run)
+		}
 	}
-	if !strings.Contains(logContent, "should appear") {
-		t.Error("Info message should appear")
+}
+
+// fakeLog is a directory entry with a fixed modification time
+type fakeLog struct {
+	name    string
+	dir     bool
+	modTime time.Time
+}
+
+func (f fakeLog) Name() string               { return f.name }
+func (f fakeLog) IsDir() bool                { return f.dir }
+func (f fakeLog) Type() os.FileMode          { return 0 }
+func (f fakeLog) Info() (os.FileInfo, error) { return f, nil }
+func (f fakeLog) Size() int64                { return 0 }
+func (f fakeLog) Mode() os.FileMode          { return 0644 }
+func (f fakeLog) ModTime() time.Time         { return f.modTime }
+func (f fakeLog) Sys() interface{}           { return nil }
+
+func TestPruneLogs(t *testing.T) {
+	origReadDir, origRemove := readDir, removeFile
+	defer func() { readDir, removeFile = origReadDir, origRemove }()
+
+	now := time.Now()
+	readDir = func(dir string) ([]os.DirEntry, error) {
+		return []os.DirEntry{
+			fakeLog{name: "hookflow-c.log", modTime: now.Add(-1 * time.Hour)},
+			fakeLog{name: "hookflow-a.log", modTime: now.Add(-3 * time.Hour)},
+			fakeLog{name: "notes.txt", modTime: now.Add(-9 * time.Hour)},
+			fakeLog{name: "old.log", dir: true, modTime: now.Add(-9 * time.Hour)},
+			fakeLog{name: "hookflow-d.log", modTime: now},
+			fakeLog{name: "hookflow-b.log", modTime: now.Add(-2 * time.Hour)},
+		}, nil
+	}
+
+	var removed []string
+	removeFile = func(path string) error {
+		removed = append(removed, filepath.Base(path))
+		return nil
+	}
+
+	t.Run("removes oldest beyond limit", func(t *testing.T) {
+		removed = nil
+		n, err := PruneLogs("logs", 2)
+		if err != nil {
+			t.Fatalf("PruneLogs() error: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("PruneLogs() removed %d, want 2", n)
+		}
+		if strings.Join(removed, ",") != "hookflow-b.log,hookflow-a.log" {
+			t.Errorf("removed %v, want hookflow-b.log and hookflow-a.log", removed)
+		}
+	})
+
+	t.Run("under limit", func(t *testing.T) {
+		removed = nil
+		n, err := PruneLogs("logs", 4)
+		if err != nil || n != 0 || len(removed) != 0 {
+			t.Errorf("PruneLogs() = %d, %v, removed %v; want nothing removed", n, err, removed)
+		}
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		readDir = func(dir string) ([]os.DirEntry, error) {
+			return nil, os.ErrNotExist
+		}
+		if n, err := PruneLogs("missing", 1); err != nil || n != 0 {
+			t.Errorf("PruneLogs() = %d, %v; want 0, nil", n, err)
+		}
+	})
+}
+
+func TestMaxLogFiles(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{"", DefaultMaxLogFiles},
+		{"10", 10},
+		{"0", DefaultMaxLogFiles},
+		{"-5", DefaultMaxLogFiles},
+		{"lots", DefaultMaxLogFiles},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(MaxLogFilesEnv, tt.env)
+		if got := MaxLogFiles(); got != tt.want {
+			t.Errorf("MaxLogFiles() with %q = %d, want %d", tt.env, got, tt.want)
+		}
 	}
 }
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// Denial log header keys read back by ReadDenialLog
+const (
+	denialLogWorkflowKey = "Workflow: "
+	denialLogExecIDKey   = "Execution ID: "
+	denialLogEventKey    = "Event: "
+)
+
+// denialLogHeaderEnd is the line that separates the header from the step results
+var denialLogHeaderEnd = strings.Repeat("=", 60)
+
+// DenialLog is the machine-readable part of a denial log
+type DenialLog struct {
+	Workflow    string        // Workflow name
+	ExecutionID string        // Execution that wrote the log
+	Event       *schema.Event // Event the workflow ran with; nil for logs written without one
+}
+
+// encodeDenialEvent returns the event as base64-encoded JSON for the Event: header
+func encodeDenialEvent(evt *schema.Event) (string, error) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ReadDenialLog reads the header of a log written by a denied workflow run
+func ReadDenialLog(path string) (*DenialLog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	log := &DenialLog{}
+	scanner := bufio.NewScanner(f)
+	// The encoded event can be longer than the default 64KB line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == denialLogHeaderEnd {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, denialLogWorkflowKey):
+			log.Workflow = strings.TrimPrefix(line, denialLogWorkflowKey)
+		case strings.HasPrefix(line, denialLogExecIDKey):
+			log.ExecutionID = strings.TrimPrefix(line, denialLogExecIDKey)
+		case strings.HasPrefix(line, denialLogEventKey):
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, denialLogEventKey))
+			if err != nil {
+				return nil, fmt.Errorf("invalid event in %s: %w", path, err)
+			}
+			log.Event = &schema.Event{}
+			if err := json.Unmarshal(data, log.Event); err != nil {
+				return nil, fmt.Errorf("invalid event in %s: %w", path, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if log.Workflow == "" {
+		return nil, fmt.Errorf("%s is not a hookflow denial log: no %q line", path, strings.TrimSpace(denialLogWorkflowKey))
+	}
+	return log, nil
+}
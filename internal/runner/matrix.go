@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// plannedStep is one execution of a workflow step. A step with a matrix is
+// planned once per combination of its values.
+type plannedStep struct {
+	schema.Step
+	Index   int               // Position of the step in the workflow, from 0
+	Name    string            // Display name, including the matrix values
+	Matrix  map[string]string // Values of this combination; nil without a matrix
+	Variant int               // Position of this combination, from 0
+	Empty   bool              // The step's matrix has no combinations, so it does not run
+}
+
+// planSteps expands matrix steps into one plannedStep per combination
+func planSteps(steps []schema.Step) []plannedStep {
+	var plan []plannedStep
+	for i, step := range steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("Step %d", i+1)
+		}
+		combinations := matrixCombinations(step.Matrix)
+		if len(combinations) == 0 {
+			// matrix: {} or a key without values leaves nothing to run
+			plan = append(plan, plannedStep{Step: step, Index: i, Name: name, Empty: step.Matrix != nil})
+			continue
+		}
+		for v, combination := range combinations {
+			plan = append(plan, plannedStep{
+				Step:    step,
+				Index:   i,
+				Name:    name + " (" + formatMatrix(combination) + ")",
+				Matrix:  combination,
+				Variant: v,
+			})
+		}
+	}
+	return plan
+}
+
+// matrixCombinations returns every combination of matrix values, with keys
+// in sorted order and the first key changing slowest
+func matrixCombinations(matrix map[string][]string) []map[string]string {
+	if len(matrix) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(matrix))
+	for key := range matrix {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	combinations := []map[string]string{{}}
+	for _, key := range keys {
+		var next []map[string]string
+		for _, combination := range combinations {
+			for _, value := range matrix[key] {
+				expanded := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					expanded[k] = v
+				}
+				expanded[key] = value
+				next = append(next, expanded)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// formatMatrix renders a combination as key=value pairs in key order
+func formatMatrix(combination map[string]string) string {
+	keys := make([]string, 0, len(combination))
+	for key := range combination {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + combination[key]
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,124 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/htekdev/gh-hookflow/internal/cache"
+	"github.com/htekdev/gh-hookflow/internal/logging"
+)
+
+// rawContentBaseURL serves action files for github.com actions, replaced in tests
+var rawContentBaseURL = "https://raw.githubusercontent.com"
+
+// remoteActionTimeout bounds each action metadata download
+const remoteActionTimeout = 30 * time.Second
+
+// SetActionCacheDir sets the directory fetched remote actions are cached in.
+// The default is the actions directory of the default file cache.
+func (r *Runner) SetActionCacheDir(dir string) {
+	r.actionCacheDir = dir
+}
+
+// SetNoActionCache makes remote actions be re-fetched even when cached
+func (r *Runner) SetNoActionCache(noCache bool) {
+	r.noActionCache = noCache
+}
+
+// fetchRemoteAction downloads a remote action's metadata into the action
+// cache and returns the cached action directory. A cached copy is used when
+// present, unless caching is disabled; it is also the fallback when the
+// download fails.
+func (r *Runner) fetchRemoteAction(ctx context.Context, parsed *ParsedUses) (string, error) {
+	log := logging.Context("uses")
+
+	if parsed.Host != "github.com" {
+		return "", fmt.Errorf("unsupported action host %q in %s: only github.com is supported", parsed.Host, parsed.Source)
+	}
+
+	cacheDir := r.actionCacheDir
+	if cacheDir == "" {
+		cacheDir = cache.NewFileCache("").ActionsDir()
+	}
+	actionDir := filepath.Join(cacheDir, remoteActionKey(parsed))
+	_, cacheErr := loadActionMetadata(actionDir)
+	cached := cacheErr == nil
+	if cached && !r.noActionCache {
+		log.Debug("using cached action %s from %s", parsed.Source, actionDir)
+		return actionDir, nil
+	}
+
+	filename, data, err := downloadActionMetadata(ctx, parsed)
+	if err != nil {
+		if cached {
+			log.Warn("failed to re-fetch %s, using cached copy: %v", parsed.Source, err)
+			return actionDir, nil
+		}
+		return "", fmt.Errorf("failed to fetch remote action %s and no cached copy exists: %w", parsed.Source, err)
+	}
+
+	if err := os.MkdirAll(actionDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create action cache directory: %w", err)
+	}
+	// Remove the other spelling so loadActionMetadata sees the fresh file
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		_ = os.Remove(filepath.Join(actionDir, name))
+	}
+	if err := os.WriteFile(filepath.Join(actionDir, filename), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to cache action %s: %w", parsed.Source, err)
+	}
+	log.Info("fetched action %s into %s", parsed.Source, actionDir)
+	return actionDir, nil
+}
+
+// remoteActionKey identifies a remote action in the cache by owner, repo, ref and path
+func remoteActionKey(parsed *ParsedUses) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		parsed.Owner, parsed.Repo, parsed.Version, filepath.ToSlash(parsed.Path),
+	}, "/")))
+	return hex.EncodeToString(sum[:])
+}
+
+// downloadActionMetadata fetches action.yml, or action.yaml when that is
+// missing, and returns the file name and contents
+func downloadActionMetadata(ctx context.Context, parsed *ParsedUses) (string, []byte, error) {
+	base := strings.Join([]string{rawContentBaseURL, parsed.Owner, parsed.Repo, parsed.Version}, "/")
+	if parsed.Path != "" {
+		base += "/" + filepath.ToSlash(parsed.Path)
+	}
+
+	client := &http.Client{Timeout: remoteActionTimeout}
+	for _, filename := range []string{"action.yml", "action.yaml"} {
+		url := base + "/" + filename
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", url, err)
+		}
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return filename, data, nil
+		case resp.StatusCode == http.StatusNotFound:
+			continue
+		default:
+			return "", nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+	}
+	return "", nil, fmt.Errorf("action.yml or action.yaml not found at %s", base)
+}
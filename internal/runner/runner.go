@@ -3,37 +3,72 @@ package runner
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/htekdev/gh-hookflow/internal/expression"
+	"github.com/htekdev/gh-hookflow/internal/logging"
 	"github.com/htekdev/gh-hookflow/internal/schema"
 )
 
 // Runner executes workflow steps
 type Runner struct {
-	workflow   *schema.Workflow
-	event      *schema.Event
-	exprCtx    *expression.Context
-	workingDir string
-	env        map[string]string
+	workflow    *schema.Workflow
+	event       *schema.Event
+	exprCtx     *expression.Context
+	workingDir  string
+	env         map[string]string
+	executionID string // Random UUID correlating log lines and files for one run
+	runName     string // Evaluated run-name, set when the run starts
+
+	noActionCache  bool   // Re-fetch remote actions instead of using the action cache
+	actionCacheDir string // Where remote actions are cached; empty for the default
+
+	opts RunnerOptions
+}
+
+// RunnerOptions configures a Runner. The zero value runs every step and
+// keeps all of its output.
+type RunnerOptions struct {
+	MaxLogBytes int64  // Keep at most this many bytes of each step's output; 0 for no limit
+	ExecutionID string // ID for log entries and results; empty for a random UUID
+	DryRun      bool   // Evaluate if: conditions but skip running the steps
+	Verbose     bool   // Log each step's output as well as its result
 }
 
-// StepResult contains the result of running a step
+// StepResult contains the result of running a step.
+//
+// A skipped step did not run. Its Success is true when its if condition was
+// false, since nothing went wrong, and false when it was skipped because an
+// earlier step failed or timed out, so that the workflow still fails.
 type StepResult struct {
-	Name     string
-	Success  bool
-	Output   string
-	Error    error
-	Duration time.Duration
+	Name        string
+	Success     bool
+	Skipped     bool // The step did not run, because of its if condition or an earlier failure
+	Output      string
+	Error       error
+	Duration    time.Duration
+	ExitCode    int               // Process exit code, -1 if the step did not produce one
+	ExecutionID string            // Execution ID of the runner that produced this result
+	Outputs     map[string]string // Values the step wrote to $HOOKFLOW_OUTPUT
 }
 
-// NewRunner creates a new step runner
+// NewRunner creates a new step runner with the default options
 func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string) *Runner {
+	return NewRunnerWithOptions(workflow, event, workingDir, RunnerOptions{})
+}
+
+// NewRunnerWithOptions creates a new step runner configured by opts
+func NewRunnerWithOptions(workflow *schema.Workflow, event *schema.Event, workingDir string, opts RunnerOptions) *Runner {
 	exprCtx := expression.NewContext()
 
 	// Populate event context
@@ -43,8 +78,9 @@ func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string
 
 		if event.Hook != nil {
 			exprCtx.Event["hook"] = map[string]interface{}{
-				"type": event.Hook.Type,
-				"cwd":  event.Hook.Cwd,
+				"type":       event.Hook.Type,
+				"cwd":        event.Hook.Cwd,
+				"session_id": event.Hook.SessionID,
 			}
 			if event.Hook.Tool != nil {
 				exprCtx.Event["hook"].(map[string]interface{})["tool"] = map[string]interface{}{
@@ -79,63 +115,195 @@ func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string
 				"sha":     event.Commit.SHA,
 				"message": event.Commit.Message,
 				"author":  event.Commit.Author,
+				"branch":  event.Commit.Branch,
 				"files":   files,
+				"diff":    event.Commit.Diff,
 			}
 		}
 
 		if event.Push != nil {
+			files := make([]map[string]string, len(event.Push.Files))
+			for i, f := range event.Push.Files {
+				files[i] = map[string]string{"path": f.Path, "status": f.Status}
+			}
 			exprCtx.Event["push"] = map[string]interface{}{
 				"ref":    event.Push.Ref,
 				"before": event.Push.Before,
 				"after":  event.Push.After,
+				"files":  files,
+			}
+		}
+
+		if event.Schedule != nil {
+			exprCtx.Event["schedule"] = map[string]interface{}{
+				"cron": event.Schedule.Cron,
 			}
 		}
+
+		metadata := make(map[string]interface{}, len(event.Metadata))
+		for k, v := range event.Metadata {
+			metadata[k] = v
+		}
+		exprCtx.Event["metadata"] = metadata
 	}
 
 	// Merge workflow env with event env
 	env := make(map[string]string)
 	for k, v := range workflow.Env {
-		env[k] = v
+		env[k] = resolveEnvRef(k, v)
 	}
 	exprCtx.Env = env
 
+	executionID := opts.ExecutionID
+	if executionID == "" {
+		executionID = newExecutionID()
+	}
+
 	return &Runner{
-		workflow:   workflow,
-		event:      event,
-		exprCtx:    exprCtx,
-		workingDir: workingDir,
-		env:        env,
+		workflow:    workflow,
+		event:       event,
+		exprCtx:     exprCtx,
+		workingDir:  workingDir,
+		env:         env,
+		executionID: executionID,
+		opts:        opts,
+	}
+}
+
+// SetContextVars makes vars available to expressions as ctx.<key>
+func (r *Runner) SetContextVars(vars map[string]string) {
+	for k, v := range vars {
+		r.exprCtx.Vars[k] = v
 	}
 }
 
+// AddFunction makes fn callable by name from this workflow's expressions,
+// such as if: conditions; see expression.Context.AddFunction
+func (r *Runner) AddFunction(name string, fn expression.Function) error {
+	return r.exprCtx.AddFunction(name, fn)
+}
+
+// ExecutionID returns the ID that tags this runner's log entries
+func (r *Runner) ExecutionID() string {
+	return r.executionID
+}
+
+// RunName returns the workflow's run-name evaluated against the event, or
+// its name when run-name is empty or cannot be evaluated. It is evaluated
+// once, before the first step runs, so it can read event.* and ctx.* but
+// not step outputs.
+func (r *Runner) RunName() string {
+	if r.runName == "" {
+		r.runName = r.evaluateRunName()
+	}
+	return r.runName
+}
+
+func (r *Runner) evaluateRunName() string {
+	if r.workflow.RunName == "" {
+		return r.workflow.Name
+	}
+	name, err := r.exprCtx.EvaluateString(r.workflow.RunName)
+	if err != nil {
+		logging.Context("runner").Warn("failed to evaluate run-name of workflow %s, using its name: %v", r.workflow.Name, err)
+		return r.workflow.Name
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		return r.workflow.Name
+	}
+	return name
+}
+
+// newExecutionID returns a random version 4 UUID
+func newExecutionID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // Run executes all steps in the workflow
 func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 	var results []StepResult
 	var prevStepFailed bool
 
-	for i, step := range r.workflow.Steps {
-		stepName := step.Name
-		if stepName == "" {
-			stepName = fmt.Sprintf("Step %d", i+1)
+	ctx = logging.WithExecutionID(ctx, r.executionID)
+	logger := logging.FromContext(ctx, "runner").WithFields(logging.Fields{
+		logging.FieldWorkflowName: r.workflow.Name,
+	})
+	logger.Info("running workflow %s (%d steps)", r.RunName(), len(r.workflow.Steps))
+
+	// Matrix steps run once per combination; steps.<id> combines their outcomes
+	combined := make(map[string]expression.StepContext)
+	var blocked bool
+
+	for _, planned := range planSteps(r.workflow.Steps) {
+		step := planned.Step
+		stepName := planned.Name
+		baseName := step.Name
+		if baseName == "" {
+			baseName = fmt.Sprintf("Step %d", planned.Index+1)
+		}
+		stepID := step.ID
+		if stepID == "" {
+			stepID = fmt.Sprintf("step-%d", planned.Index+1)
+		}
+		r.exprCtx.Matrix = planned.Matrix
+
+		record := func(result expression.StepContext) {
+			if planned.Matrix == nil {
+				r.setStepResult(stepID, stepName, result)
+				return
+			}
+			r.exprCtx.SetStepResult(stepName, result)
+			merged := combineMatrixOutcome(combined[stepID], result)
+			combined[stepID] = merged
+			r.setStepResult(stepID, baseName, merged)
 		}
 
-		// Update step context for expressions
-		r.exprCtx.Steps[stepName] = expression.StepContext{
-			Outputs: make(map[string]string),
-			Outcome: "pending",
+		// Update step context for expressions; later matrix variants keep
+		// the outcome of earlier ones
+		if planned.Variant == 0 {
+			r.setStepResult(stepID, baseName, expression.StepContext{Outcome: "pending"})
+			// A failure within a matrix does not skip its other combinations
+			blocked = prevStepFailed
+		}
+		if planned.Matrix != nil {
+			r.exprCtx.SetStepResult(stepName, expression.StepContext{Outcome: "pending"})
+		}
+
+		if planned.Empty {
+			logger.Warn("step %s has a matrix with no combinations; skipping it", stepName)
+			results = append(results, StepResult{
+				Name:    stepName,
+				Success: true,
+				Skipped: true,
+				Output:  "Skipped (empty matrix)",
+			})
+			record(expression.StepContext{Outcome: "skipped"})
+			continue
 		}
 
+		// Step env is visible to the step's if, run and working-directory.
+		// An env error fails the step only once its if: lets it run.
+		stepEnv, envErr := r.applyStepEnv(step)
+
 		// Check if condition
 		if step.If != "" {
 			// Evaluate if condition
 			shouldRun, err := r.exprCtx.EvaluateBool(step.If)
 			if err != nil {
 				results = append(results, StepResult{
-					Name:    stepName,
-					Success: false,
-					Error:   fmt.Errorf("failed to evaluate if condition: %w", err),
+					Name:     stepName,
+					Success:  false,
+					Error:    fmt.Errorf("failed to evaluate if condition: %w", err),
+					ExitCode: -1,
 				})
-				if !step.ContinueOnError {
+				record(expression.StepContext{Outcome: "failure"})
+				if !r.workflow.ContinuesOnError(step) {
 					prevStepFailed = true
 				}
 				continue
@@ -144,56 +312,160 @@ func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 				results = append(results, StepResult{
 					Name:    stepName,
 					Success: true,
+					Skipped: true,
 					Output:  "Skipped (condition not met)",
 				})
+				record(expression.StepContext{Outcome: "skipped"})
 				continue
 			}
 		}
 
 		// If previous step failed and this doesn't have always(), skip
-		if prevStepFailed && !strings.Contains(step.If, "always()") {
+		if blocked && !strings.Contains(step.If, "always()") {
+			results = append(results, StepResult{
+				Name:     stepName,
+				Success:  false,
+				Skipped:  true,
+				Output:   "Skipped (previous step failed)",
+				ExitCode: -1,
+			})
+			record(expression.StepContext{Outcome: "skipped"})
+			continue
+		}
+
+		if envErr != nil {
+			results = append(results, StepResult{
+				Name:     stepName,
+				Success:  false,
+				Error:    envErr,
+				ExitCode: -1,
+			})
+			record(expression.StepContext{Outcome: "failure"})
+			if !r.workflow.ContinuesOnError(step) {
+				prevStepFailed = true
+			}
+			continue
+		}
+
+		stepLogger := logger.WithFields(logging.Fields{logging.FieldStepName: stepName})
+		if r.opts.DryRun {
+			stepLogger.Info("dry run: skipping step %s", stepName)
 			results = append(results, StepResult{
 				Name:    stepName,
-				Success: false,
-				Output:  "Skipped (previous step failed)",
+				Success: true,
+				Skipped: true,
+				Output:  "Skipped (dry run)",
 			})
+			record(expression.StepContext{Outcome: "skipped"})
 			continue
 		}
 
 		// Execute the step
-		result := r.runStep(ctx, step, stepName)
+		stepLogger.Debug("running step %s", stepName)
+		result := r.runStep(ctx, step, stepName, stepEnv)
+		// Values read with env() are hidden before the output is logged or reported
+		result.Output = truncateOutput(r.exprCtx.Redact(result.Output), r.opts.MaxLogBytes)
 		results = append(results, result)
+		stepLogger.Info("step %s finished: success=%t exit=%d duration=%s", stepName, result.Success, result.ExitCode, result.Duration.Round(time.Millisecond))
+		if r.opts.Verbose && result.Output != "" {
+			stepLogger.Info("step %s output:\n%s", stepName, result.Output)
+		}
 
 		// Update step context
 		outcome := "success"
 		if !result.Success {
 			outcome = "failure"
-			if !step.ContinueOnError {
+			if !r.workflow.ContinuesOnError(step) {
 				prevStepFailed = true
 			}
 		}
-		r.exprCtx.Steps[stepName] = expression.StepContext{
-			Outputs: make(map[string]string),
+		record(expression.StepContext{
+			Outputs: result.Outputs,
 			Outcome: outcome,
-		}
+		})
+	}
+	r.exprCtx.Matrix = nil
+	r.exprCtx.Env = r.env
+
+	for i := range results {
+		results[i].ExecutionID = r.executionID
 	}
 
 	return results, nil
 }
 
+// truncateOutput cuts output to at most maxBytes bytes, noting how much was
+// dropped; maxBytes <= 0 keeps all of it. The cut backs up to the start of a
+// UTF-8 character so the result is never split inside one.
+func truncateOutput(output string, maxBytes int64) string {
+	if maxBytes <= 0 || int64(len(output)) <= maxBytes {
+		return output
+	}
+	cut := int(maxBytes)
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return output[:cut] + fmt.Sprintf("\n... output truncated (%d of %d bytes shown)", cut, len(output))
+}
+
+// combineMatrixOutcome folds one matrix combination's result into the
+// step's overall result: failure if any combination failed, success if any
+// succeeded, otherwise skipped. Outputs from later combinations win.
+func combineMatrixOutcome(sofar, result expression.StepContext) expression.StepContext {
+	merged := expression.StepContext{Outputs: make(map[string]string), Outcome: result.Outcome}
+	for k, v := range sofar.Outputs {
+		merged.Outputs[k] = v
+	}
+	for k, v := range result.Outputs {
+		merged.Outputs[k] = v
+	}
+	switch {
+	case sofar.Outcome == "failure" || result.Outcome == "failure":
+		merged.Outcome = "failure"
+	case sofar.Outcome == "success" || result.Outcome == "success":
+		merged.Outcome = "success"
+	}
+	return merged
+}
+
+// setStepResult records a step's outcome and outputs for steps.<id> and,
+// for workflows written before step ids, steps.<name>
+func (r *Runner) setStepResult(id, name string, result expression.StepContext) {
+	r.exprCtx.SetStepResult(id, result)
+	if name != id {
+		r.exprCtx.SetStepResult(name, result)
+	}
+}
+
 // RunWithBlocking executes all steps and returns a WorkflowResult based on blocking mode
 // If blocking=true and any step fails, returns a deny result with detailed logs
 // If blocking=false, returns an allow result even if steps fail (logs warnings instead)
 func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
+	startedAt := time.Now()
 	results, err := r.Run(ctx)
 	if err != nil {
+		var result *schema.WorkflowResult
 		if r.workflow.IsBlocking() {
-			return schema.NewDenyResult(fmt.Sprintf("workflow execution error: %v", err))
+			result = schema.NewDenyResult(fmt.Sprintf("workflow execution error: %v", err))
+		} else {
+			log.Printf("Warning: workflow execution error (non-blocking): %v", err)
+			result = schema.NewAllowResult()
 		}
-		log.Printf("Warning: workflow execution error (non-blocking): %v", err)
-		return schema.NewAllowResult()
+		result.WorkflowName = r.RunName()
+		result.StartedAt = startedAt
+		return result
 	}
 
+	result := r.decide(results)
+	result.WorkflowName = r.RunName()
+	result.StepResults = summarizeResults(results)
+	result.StartedAt = startedAt
+	result.FinishedAt = time.Now()
+	return result
+}
+
+// decide converts step results into an allow or deny decision based on blocking mode
+func (r *Runner) decide(results []StepResult) *schema.WorkflowResult {
 	// Check if any step failed
 	anyStepFailed := false
 	for _, result := range results {
@@ -216,6 +488,7 @@ func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
 		if logFile != "" {
 			result.LogFile = logFile
 		}
+		result.Actions = suggestedActions(results)
 		return result
 	}
 
@@ -228,26 +501,76 @@ func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
 	return schema.NewAllowResult()
 }
 
+// summarizeResults converts runner step results into their serializable form
+func summarizeResults(results []StepResult) []schema.StepResult {
+	summaries := make([]schema.StepResult, 0, len(results))
+	for _, result := range results {
+		summaries = append(summaries, schema.StepResult{
+			Name:          result.Name,
+			Success:       result.Success,
+			Skipped:       result.Skipped,
+			DurationMs:    result.Duration.Milliseconds(),
+			ExitCode:      result.ExitCode,
+			OutputPreview: previewOutput(result.Output),
+			ExecutionID:   result.ExecutionID,
+		})
+	}
+	return summaries
+}
+
+// previewOutput returns the trimmed output shortened to at most
+// outputPreviewLength bytes, cutting before any character split by the limit
+func previewOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > outputPreviewLength {
+		cut := outputPreviewLength
+		for cut > 0 && !utf8.RuneStart(output[cut]) {
+			cut--
+		}
+		output = output[:cut] + "..."
+	}
+	return output
+}
+
+// outputPreviewLength is the maximum output length included in summaries and denial reasons
+const outputPreviewLength = 200
+
 // buildDenialWithLogs creates a detailed log file and returns the path and denial reason
 func (r *Runner) buildDenialWithLogs(results []StepResult) (logFile string, reason string) {
 	var failedSteps []string
 	var logContent strings.Builder
 
 	// Header
-	fmt.Fprintf(&logContent, "Workflow: %s\n", r.workflow.Name)
-	fmt.Fprintf(&logContent, "Description: %s\n", r.workflow.Description)
+	fmt.Fprintf(&logContent, "%s%s\n", denialLogWorkflowKey, r.workflow.Name)
+	fmt.Fprintf(&logContent, "%s%s\n", denialLogExecIDKey, r.executionID)
+	if name := r.RunName(); name != r.workflow.Name {
+		fmt.Fprintf(&logContent, "Run: %s\n", name)
+	}
+	if r.workflow.Description != "" {
+		fmt.Fprintf(&logContent, "Description: %s\n", r.workflow.Description)
+	}
 	fmt.Fprintf(&logContent, "Time: %s\n", time.Now().Format(time.RFC3339))
-	logContent.WriteString(strings.Repeat("=", 60) + "\n\n")
+	// Base64 JSON so hookflow run --replay can re-run with the same event
+	if r.event != nil {
+		if encoded, err := encodeDenialEvent(r.event); err == nil {
+			fmt.Fprintf(&logContent, "%s%s\n", denialLogEventKey, encoded)
+		}
+	}
+	logContent.WriteString(denialLogHeaderEnd + "\n\n")
 
 	// Write each step's result
 	for _, result := range results {
 		fmt.Fprintf(&logContent, "Step: %s\n", result.Name)
-		fmt.Fprintf(&logContent, "Status: %s\n", map[bool]string{true: "✓ SUCCESS", false: "✗ FAILED"}[result.Success])
+		status := map[bool]string{true: "✓ SUCCESS", false: "✗ FAILED"}[result.Success]
+		if result.Skipped {
+			status = "- SKIPPED"
+		}
+		fmt.Fprintf(&logContent, "Status: %s\n", status)
 		if result.Duration > 0 {
 			fmt.Fprintf(&logContent, "Duration: %s\n", result.Duration.Round(time.Millisecond))
 		}
 		if result.Error != nil {
-			fmt.Fprintf(&logContent, "Error: %v\n", result.Error)
+			fmt.Fprintf(&logContent, "Error: %s\n", r.exprCtx.Redact(result.Error.Error()))
 		}
 		if result.Output != "" {
 			logContent.WriteString("Output:\n")
@@ -263,49 +586,72 @@ func (r *Runner) buildDenialWithLogs(results []StepResult) (logFile string, reas
 		}
 	}
 
-	// Write to temp file
-	tmpFile, err := os.CreateTemp("", "hookflow-*.log")
+	// Write to the denial log directory, falling back to the system temp dir
+	logDir := logging.DenialLogDir()
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		logDir = ""
+	}
+	tmpFile, err := os.CreateTemp(logDir, "hookflow-"+r.executionID+"-*.log")
 	if err != nil {
 		// Can't create temp file, return reason without log file
-		return "", fmt.Sprintf("workflow '%s' blocked due to step failures: %s", r.workflow.Name, strings.Join(failedSteps, ", "))
+		return "", fmt.Sprintf("workflow '%s' blocked due to step failures: %s", r.RunName(), strings.Join(failedSteps, ", "))
 	}
 	defer func() { _ = tmpFile.Close() }()
 
 	_, err = tmpFile.WriteString(logContent.String())
 	if err != nil {
-		return "", fmt.Sprintf("workflow '%s' blocked due to step failures: %s", r.workflow.Name, strings.Join(failedSteps, ", "))
+		return "", fmt.Sprintf("workflow '%s' blocked due to step failures: %s", r.RunName(), strings.Join(failedSteps, ", "))
 	}
 
 	logFile = tmpFile.Name()
 
+	// Drop the oldest denial logs so the directory doesn't grow unbounded
+	if logDir != "" {
+		if _, err := logging.PruneLogs(logDir, logging.MaxLogFiles()); err != nil {
+			logging.Context("runner").Warn("failed to prune denial logs: %v", err)
+		}
+	}
+
 	// Build detailed reason message
 	var reasonBuilder strings.Builder
-	fmt.Fprintf(&reasonBuilder, "Workflow '%s' blocked.\n\n", r.workflow.Name)
+	fmt.Fprintf(&reasonBuilder, "Workflow '%s' blocked.\n", r.RunName())
+	// The description tells the user what the workflow guards against
+	if r.workflow.Description != "" {
+		fmt.Fprintf(&reasonBuilder, "%s\n", r.workflow.Description)
+	}
+	reasonBuilder.WriteString("\n")
 	reasonBuilder.WriteString("Failed steps:\n")
 	for _, result := range results {
 		if !result.Success {
 			fmt.Fprintf(&reasonBuilder, "  • %s", result.Name)
 			if result.Error != nil {
-				fmt.Fprintf(&reasonBuilder, ": %v", result.Error)
+				fmt.Fprintf(&reasonBuilder, ": %s", r.exprCtx.Redact(result.Error.Error()))
 			}
 			reasonBuilder.WriteString("\n")
 			// Include brief output snippet (first 200 chars)
 			if result.Output != "" {
-				output := strings.TrimSpace(result.Output)
-				if len(output) > 200 {
-					output = output[:200] + "..."
-				}
+				output := previewOutput(result.Output)
 				fmt.Fprintf(&reasonBuilder, "    Output: %s\n", strings.ReplaceAll(output, "\n", " "))
 			}
 		}
 	}
+	if actions := suggestedActions(results); len(actions) > 0 {
+		reasonBuilder.WriteString("\nSuggested actions:\n")
+		for _, action := range actions {
+			fmt.Fprintf(&reasonBuilder, "  • %s", action.Command)
+			if action.Description != "" {
+				fmt.Fprintf(&reasonBuilder, " (%s)", action.Description)
+			}
+			reasonBuilder.WriteString("\n")
+		}
+	}
 	fmt.Fprintf(&reasonBuilder, "\nFull logs: %s", logFile)
 
 	return logFile, reasonBuilder.String()
 }
 
-// runStep executes a single step
-func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) StepResult {
+// runStep executes a single step with its evaluated env
+func (r *Runner) runStep(ctx context.Context, step schema.Step, name string, stepEnv map[string]string) StepResult {
 	start := time.Now()
 
 	// Handle timeout
@@ -322,7 +668,7 @@ func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) Ste
 
 	// Execute run: command
 	if step.Run != "" {
-		return r.runCommand(ctx, step, name, start)
+		return r.runCommand(ctx, step, name, start, stepEnv)
 	}
 
 	return StepResult{
@@ -330,19 +676,21 @@ func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) Ste
 		Success:  false,
 		Error:    fmt.Errorf("step has neither 'run' nor 'uses'"),
 		Duration: time.Since(start),
+		ExitCode: -1,
 	}
 }
 
 // runCommand executes a shell command
-func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string, start time.Time) StepResult {
-	// Evaluate expressions in command
-	command, err := r.exprCtx.EvaluateString(step.Run)
+func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string, start time.Time, stepEnv map[string]string) StepResult {
+	// Evaluate expressions in command, reporting every failed placeholder
+	command, err := r.exprCtx.EvaluateTemplate(step.Run)
 	if err != nil {
 		return StepResult{
 			Name:     name,
 			Success:  false,
 			Error:    fmt.Errorf("failed to evaluate command: %w", err),
 			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 
@@ -366,6 +714,7 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 					"  macOS: brew install powershell\n" +
 					"  Linux: https://learn.microsoft.com/en-us/powershell/scripting/install/installing-powershell-on-linux"),
 				Duration: time.Since(start),
+				ExitCode: -1,
 			}
 		}
 		cmd = exec.CommandContext(ctx, "pwsh", "-NoProfile", "-NonInteractive", "-Command", command)
@@ -375,31 +724,49 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 		cmd = exec.CommandContext(ctx, "sh", "-c", command)
 	case "cmd":
 		cmd = exec.CommandContext(ctx, "cmd", "/c", command)
+	case "node":
+		cmd = exec.CommandContext(ctx, "node", "-e", command)
 	default:
 		cmd = exec.CommandContext(ctx, shell, "-c", command)
 	}
 
 	// Set working directory
-	workDir := r.workingDir
-	if step.WorkingDirectory != "" {
-		wd, err := r.exprCtx.EvaluateString(step.WorkingDirectory)
-		if err == nil {
-			workDir = wd
+	workDir, err := r.stepWorkingDir(step)
+	if err != nil {
+		return StepResult{
+			Name:     name,
+			Success:  false,
+			Error:    err,
+			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 	cmd.Dir = workDir
 
 	// Set environment
 	cmd.Env = os.Environ()
+	envVars := r.interpolationVars()
 	for k, v := range r.env {
-		val, _ := r.exprCtx.EvaluateString(v)
+		val, _ := r.expandEnv(v, envVars)
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, val))
 	}
-	for k, v := range step.Env {
-		val, _ := r.exprCtx.EvaluateString(v)
-		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, val))
+	for k, v := range stepEnv {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
 
+	outputFile, err := newOutputFile()
+	if err != nil {
+		return StepResult{
+			Name:     name,
+			Success:  false,
+			Error:    err,
+			Duration: time.Since(start),
+			ExitCode: -1,
+		}
+	}
+	defer func() { _ = os.Remove(outputFile) }()
+	cmd.Env = append(cmd.Env, OutputEnv+"="+outputFile)
+
 	// Capture output
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -413,6 +780,18 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 		output += "\n" + stderr.String()
 	}
 
+	outputs, outputErr := readStepOutputs(outputFile)
+	if outputErr != nil && err == nil {
+		return StepResult{
+			Name:     name,
+			Success:  false,
+			Output:   output,
+			Error:    fmt.Errorf("failed to read step outputs: %w", outputErr),
+			Duration: time.Since(start),
+			ExitCode: -1,
+		}
+	}
+
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return StepResult{
@@ -421,6 +800,8 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 				Output:   output,
 				Error:    fmt.Errorf("step timed out after %d seconds", step.Timeout),
 				Duration: time.Since(start),
+				ExitCode: -1,
+				Outputs:  outputs,
 			}
 		}
 		return StepResult{
@@ -429,6 +810,8 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 			Output:   output,
 			Error:    err,
 			Duration: time.Since(start),
+			ExitCode: exitCodeOf(err),
+			Outputs:  outputs,
 		}
 	}
 
@@ -437,9 +820,132 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 		Success:  true,
 		Output:   output,
 		Duration: time.Since(start),
+		Outputs:  outputs,
 	}
 }
 
+// applyStepEnv evaluates a step's env and makes it visible to expressions
+// as env.*, over the workflow env, until the next step. Step env can
+// reference earlier steps, e.g. ${{ steps.parse.outputs.component }}, but
+// not other values of the same step env. When a value fails to evaluate,
+// the others are still applied, so the step's if: can be checked first,
+// and the error for the first failing key is returned.
+func (r *Runner) applyStepEnv(step schema.Step) (map[string]string, error) {
+	r.exprCtx.Env = make(map[string]string, len(r.env)+len(step.Env))
+	for k, v := range r.env {
+		r.exprCtx.Env[k] = v
+	}
+	if len(step.Env) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(step.Env))
+	for k := range step.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	vars := r.interpolationVars()
+	stepEnv := make(map[string]string, len(step.Env))
+	var firstErr error
+	for _, k := range keys {
+		v := step.Env[k]
+		if _, ok := schema.EnvRef(v); ok {
+			stepEnv[k] = resolveEnvRef(k, v)
+			continue
+		}
+		val, err := r.expandEnv(v, vars)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to evaluate env %s: %w", k, err)
+			}
+			continue
+		}
+		stepEnv[k] = val
+	}
+	for k, v := range stepEnv {
+		r.exprCtx.SetEnv(k, v)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return stepEnv, nil
+}
+
+// resolveEnvRef returns the OS environment value a $ENV:NAME env value
+// refers to, or the value unchanged when it is not a reference
+func resolveEnvRef(key, value string) string {
+	name, ok := schema.EnvRef(value)
+	if !ok {
+		return value
+	}
+	resolved, found := os.LookupEnv(name)
+	if !found {
+		logging.Context("runner").Debug("env %s: $ENV:%s is not set, using an empty value", key, name)
+	}
+	return resolved
+}
+
+// interpolationVars flattens the env, step, ctx and matrix values of the
+// expression context into the names Interpolate looks up, e.g.
+// "steps.parse.outputs.component"
+func (r *Runner) interpolationVars() map[string]interface{} {
+	vars := make(map[string]interface{})
+	for k, v := range r.exprCtx.Env {
+		vars["env."+k] = v
+	}
+	for id, s := range r.exprCtx.Steps {
+		vars["steps."+id+".outcome"] = s.Outcome
+		for k, v := range s.Outputs {
+			vars["steps."+id+".outputs."+k] = v
+		}
+	}
+	for k, v := range r.exprCtx.Vars {
+		vars["ctx."+k] = v
+	}
+	for k, v := range r.exprCtx.Matrix {
+		vars["matrix."+k] = v
+	}
+	return vars
+}
+
+// expandEnv evaluates an env value. Values that only look up names in vars
+// are interpolated directly; anything else, such as a function call or an
+// event field, goes through the full evaluator.
+func (r *Runner) expandEnv(value string, vars map[string]interface{}) (string, error) {
+	if result, err := expression.Interpolate(value, vars); err == nil {
+		return result, nil
+	}
+	return r.exprCtx.EvaluateString(value)
+}
+
+// stepWorkingDir evaluates a step's working-directory. Relative paths are
+// resolved against the runner's working directory and cleaned, so
+// "${{ event.file.path }}/.." names the edited file's directory.
+func (r *Runner) stepWorkingDir(step schema.Step) (string, error) {
+	if step.WorkingDirectory == "" {
+		return r.workingDir, nil
+	}
+
+	dir, err := r.exprCtx.EvaluateString(step.WorkingDirectory)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate working-directory: %w", err)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.workingDir, dir)
+	}
+	dir = filepath.Clean(dir)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("working-directory not found: %s", dir)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working-directory is not a directory: %s", dir)
+	}
+	return dir, nil
+}
+
 // runAction executes a reusable action
 func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, start time.Time) StepResult {
 	// Parse the uses: string
@@ -450,6 +956,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 			Success:  false,
 			Error:    fmt.Errorf("failed to parse uses: %w", err),
 			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 
@@ -461,6 +968,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 			Success:  false,
 			Error:    fmt.Errorf("failed to resolve action: %w", err),
 			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 
@@ -472,6 +980,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 			Success:  false,
 			Error:    fmt.Errorf("failed to load action metadata: %w", err),
 			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 
@@ -483,11 +992,16 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 			Success:  false,
 			Error:    fmt.Errorf("failed to evaluate inputs: %w", err),
 			Duration: time.Since(start),
+			ExitCode: -1,
 		}
 	}
 
+	// with.shell selects the action's shell and is not passed on as an input
+	shell := inputs[withShellKey]
+	delete(inputs, withShellKey)
+
 	// Execute the action
-	output, err := r.executeAction(ctx, actionDir, metadata, inputs)
+	output, err := r.executeAction(ctx, actionDir, metadata, inputs, shell)
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return StepResult{
@@ -496,6 +1010,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 				Output:   output,
 				Error:    fmt.Errorf("action timed out"),
 				Duration: time.Since(start),
+				ExitCode: -1,
 			}
 		}
 		return StepResult{
@@ -504,6 +1019,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 			Output:   output,
 			Error:    err,
 			Duration: time.Since(start),
+			ExitCode: exitCodeOf(err),
 		}
 	}
 
@@ -515,8 +1031,45 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 	}
 }
 
-// defaultShell returns the default shell for workflows
+// exitCodeOf extracts the process exit code from a command error
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// ShellEnv overrides the default shell for steps without a shell
+const ShellEnv = "HOOKFLOW_SHELL"
+
+// shellOverride is set by SetDefaultShell and takes precedence over ShellEnv
+var shellOverride string
+
+// SetDefaultShell overrides the default shell for this process, ahead of
+// ShellEnv. An empty shell restores the environment/built-in default.
+func SetDefaultShell(shell string) {
+	shellOverride = shell
+}
+
+// DefaultShell returns the shell that runs steps without a shell:, as
+// chosen by SetDefaultShell, $HOOKFLOW_SHELL or the pwsh default
+func DefaultShell() string {
+	return defaultShell()
+}
+
+// defaultShell returns the default shell for workflows: the SetDefaultShell
+// override, then $HOOKFLOW_SHELL, then pwsh.
 // We standardize on PowerShell Core (pwsh) for cross-platform consistency
 func defaultShell() string {
+	if shellOverride != "" {
+		return shellOverride
+	}
+	if shell := os.Getenv(ShellEnv); shell != "" {
+		return shell
+	}
 	return "pwsh"
 }
@@ -3,7 +3,12 @@ package runner
 import (
 	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/htekdev/gh-hookflow/internal/schema"
 )
@@ -112,7 +117,7 @@ func TestRunWithBlockingMultipleStepFailures(t *testing.T) {
 			{
 				Name:           "fail-step-2",
 				Run:            "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 		},
 	}
@@ -210,3 +215,388 @@ func TestRunWithBlockingCreatesLogFile(t *testing.T) {
 		_ = os.Remove(result.LogFile)
 	}
 }
+
+// TestRunWithBlockingDescription tests that the workflow description explains a denial
+func TestRunWithBlockingDescription(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name:        "no-secrets",
+		Description: "Blocks edits that add credentials",
+		Steps:       []schema.Step{{Name: "scan", Shell: "bash", Run: "exit 1"}},
+	}
+	result := NewRunner(workflow, nil, ".").RunWithBlocking(context.Background())
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	defer func() { _ = os.Remove(result.LogFile) }()
+
+	if !strings.Contains(result.PermissionDecisionReason, "Workflow 'no-secrets' blocked.\nBlocks edits that add credentials\n") {
+		t.Errorf("Expected description in reason, got: %s", result.PermissionDecisionReason)
+	}
+	content, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(content), "Description: Blocks edits that add credentials\n") {
+		t.Errorf("Expected description in log, got:\n%s", content)
+	}
+
+	// Without a description the log omits the line
+	workflow.Description = ""
+	result = NewRunner(workflow, nil, ".").RunWithBlocking(context.Background())
+	defer func() { _ = os.Remove(result.LogFile) }()
+	content, _ = os.ReadFile(result.LogFile)
+	if strings.Contains(string(content), "Description:") {
+		t.Errorf("Expected no description line, got:\n%s", content)
+	}
+}
+
+// TestRunWithBlockingIncludesStepResults tests that per-step outcomes are attached to the result
+func TestRunWithBlockingIncludesStepResults(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name:     "test-step-results",
+		Blocking: ptrBool(true),
+		Steps: []schema.Step{
+			{Name: "pass-step", Shell: "bash", Run: "echo 'all good'"},
+			{Name: "fail-step", Shell: "bash", Run: "echo 'broken'; exit 3"},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	result := runner.RunWithBlocking(context.Background())
+	if result.LogFile != "" {
+		defer func() { _ = os.Remove(result.LogFile) }()
+	}
+
+	if len(result.StepResults) != 2 {
+		t.Fatalf("Expected 2 step results, got %d", len(result.StepResults))
+	}
+
+	pass := result.StepResults[0]
+	if pass.Name != "pass-step" || !pass.Success || pass.ExitCode != 0 {
+		t.Errorf("Unexpected pass-step result: %+v", pass)
+	}
+	if pass.OutputPreview != "all good" {
+		t.Errorf("Expected output preview 'all good', got %q", pass.OutputPreview)
+	}
+
+	fail := result.StepResults[1]
+	if fail.Name != "fail-step" || fail.Success || fail.ExitCode != 3 {
+		t.Errorf("Unexpected fail-step result: %+v", fail)
+	}
+}
+
+// TestRunWithBlockingSkippedSteps tests that skipped steps are flagged in results and denial logs
+func TestRunWithBlockingSkippedSteps(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name:     "test-skipped",
+		Blocking: ptrBool(true),
+		Steps: []schema.Step{
+			{Name: "disabled", Shell: "bash", If: "${{ false }}", Run: "echo never"},
+			{Name: "fail-step", Shell: "bash", Run: "exit 1"},
+			{Name: "after", Shell: "bash", Run: "echo after"},
+		},
+	}
+
+	result := NewRunner(workflow, nil, ".").RunWithBlocking(context.Background())
+	if result.LogFile != "" {
+		defer func() { _ = os.Remove(result.LogFile) }()
+	}
+	if len(result.StepResults) != 3 {
+		t.Fatalf("Expected 3 step results, got %d", len(result.StepResults))
+	}
+
+	// A false if condition is a success; a skip after a failure is not
+	if disabled := result.StepResults[0]; !disabled.Skipped || !disabled.Success {
+		t.Errorf("Expected disabled to be skipped and successful, got %+v", disabled)
+	}
+	if fail := result.StepResults[1]; fail.Skipped || fail.Success {
+		t.Errorf("Expected fail-step to run and fail, got %+v", fail)
+	}
+	if after := result.StepResults[2]; !after.Skipped || after.Success {
+		t.Errorf("Expected after to be skipped and unsuccessful, got %+v", after)
+	}
+
+	data, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatalf("Expected a denial log: %v", err)
+	}
+	if strings.Count(string(data), "Status: - SKIPPED") != 2 || !strings.Contains(string(data), "Status: ✗ FAILED") {
+		t.Errorf("Expected two skipped steps and one failed step in the log, got:\n%s", data)
+	}
+}
+
+// TestRunWithBlockingStepResultOutputTruncated tests that output previews are capped
+func TestRunWithBlockingStepResultOutputTruncated(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-step-preview",
+		Steps: []schema.Step{
+			{Name: "noisy-step", Shell: "bash", Run: "printf 'x%.0s' {1..500}"},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	result := runner.RunWithBlocking(context.Background())
+
+	if len(result.StepResults) != 1 {
+		t.Fatalf("Expected 1 step result, got %d", len(result.StepResults))
+	}
+	preview := result.StepResults[0].OutputPreview
+	if len(preview) != 203 || !strings.HasSuffix(preview, "...") {
+		t.Errorf("Expected 200 chars plus ellipsis, got %d chars: %q", len(preview), preview)
+	}
+}
+
+// TestRunWithBlockingExecutionID tests that one execution ID tags every step and the denial log
+func TestRunWithBlockingExecutionID(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-execution-id",
+		Steps: []schema.Step{
+			{Name: "first", Shell: "bash", Run: "echo one"},
+			{Name: "second", Shell: "bash", Run: "exit 1"},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	id := runner.ExecutionID()
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("Expected UUID v4 execution ID, got %q", id)
+	}
+	if other := NewRunner(workflow, nil, ".").ExecutionID(); other == id {
+		t.Error("Expected each runner to get a distinct execution ID")
+	}
+
+	result := runner.RunWithBlocking(context.Background())
+	if result.LogFile != "" {
+		defer func() { _ = os.Remove(result.LogFile) }()
+	}
+
+	for _, step := range result.StepResults {
+		if step.ExecutionID != id {
+			t.Errorf("Step %s has execution ID %q, want %q", step.Name, step.ExecutionID, id)
+		}
+	}
+	if !strings.Contains(filepath.Base(result.LogFile), id) {
+		t.Errorf("Expected denial log file name to contain %s, got %s", id, result.LogFile)
+	}
+}
+
+func TestNewRunnerWithOptions(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-options",
+		Steps: []schema.Step{
+			{Name: "long", Shell: "bash", Run: "printf '0123456789abcdef'"},
+			{Name: "fail", Shell: "bash", Run: "exit 1"},
+		},
+	}
+
+	runner := NewRunnerWithOptions(workflow, nil, ".", RunnerOptions{ExecutionID: "run-42", MaxLogBytes: 10, Verbose: true})
+	if runner.ExecutionID() != "run-42" {
+		t.Errorf("Expected execution ID run-42, got %q", runner.ExecutionID())
+	}
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := "0123456789\n... output truncated (10 of 16 bytes shown)"; results[0].Output != want {
+		t.Errorf("Output = %q, want %q", results[0].Output, want)
+	}
+	if results[1].Success || results[1].ExecutionID != "run-42" {
+		t.Errorf("Expected the failing step to run with execution ID run-42, got %+v", results[1])
+	}
+
+	// A dry run evaluates conditions but runs nothing, so nothing fails
+	workflow.Steps = append(workflow.Steps, schema.Step{Name: "never", If: "false", Shell: "bash", Run: "exit 1"})
+	result := NewRunnerWithOptions(workflow, nil, ".", RunnerOptions{DryRun: true}).RunWithBlocking(context.Background())
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected a dry run to allow, got %s: %s", result.PermissionDecision, result.PermissionDecisionReason)
+	}
+	for i, want := range []string{"Skipped (dry run)", "Skipped (dry run)", "Skipped (condition not met)"} {
+		if got := result.StepResults[i]; !got.Skipped || got.OutputPreview != want {
+			t.Errorf("Step %d = %+v, want skipped with %q", i, got, want)
+		}
+	}
+}
+
+func TestRunnerAddFunction(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-add-function",
+		Steps: []schema.Step{
+			{Name: "protected", If: "${{ isProtected(event.file.path) }}", Shell: "bash", Run: "exit 1"},
+			{Name: "other", If: "${{ !isProtected('README.md') }}", Shell: "bash", Run: "echo ok"},
+		},
+	}
+	evt := &schema.Event{File: &schema.FileEvent{Path: "deploy/prod.yml", Action: "edit"}}
+
+	runner := NewRunner(workflow, evt, ".")
+	err := runner.AddFunction("isProtected", func(args ...interface{}) (interface{}, error) {
+		path, _ := args[0].(string)
+		return strings.HasPrefix(path, "deploy/"), nil
+	})
+	if err != nil {
+		t.Fatalf("AddFunction: %v", err)
+	}
+	if err := runner.AddFunction("contains", nil); err == nil {
+		t.Error("Expected AddFunction to refuse a built-in name")
+	}
+
+	result := runner.RunWithBlocking(context.Background())
+	if result.LogFile != "" {
+		defer func() { _ = os.Remove(result.LogFile) }()
+	}
+	if result.PermissionDecision != "deny" {
+		t.Errorf("Expected the protected path step to run and deny, got %s", result.PermissionDecision)
+	}
+}
+
+// TestRunWithBlockingTimestamps tests that results record when the run started and finished
+func TestRunWithBlockingTimestamps(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name:  "test-timestamps",
+		Steps: []schema.Step{{Name: "sleep", Shell: "bash", Run: "sleep 0.05"}},
+	}
+
+	before := time.Now()
+	result := NewRunner(workflow, nil, ".").RunWithBlocking(context.Background())
+	after := time.Now()
+
+	if result.StartedAt.Before(before) || result.FinishedAt.After(after) {
+		t.Errorf("timestamps %v - %v outside the run %v - %v", result.StartedAt, result.FinishedAt, before, after)
+	}
+	if !result.FinishedAt.After(result.StartedAt) {
+		t.Errorf("Expected FinishedAt %v after StartedAt %v", result.FinishedAt, result.StartedAt)
+	}
+	if elapsed := result.FinishedAt.Sub(result.StartedAt); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected timestamps to span the 50ms step, got %v", elapsed)
+	}
+}
+
+func TestRunWithBlockingWorkflowName(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name:  "test-named",
+		Steps: []schema.Step{{Name: "fail", Shell: "bash", Run: "exit 1"}},
+	}
+	result := NewRunner(workflow, nil, ".").RunWithBlocking(context.Background())
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	if result.WorkflowName != "test-named" {
+		t.Errorf("Expected WorkflowName test-named, got %q", result.WorkflowName)
+	}
+}
+
+// TestRunWithBlockingSuggestedActions tests that ::suggest:: lines of failed steps become actions
+func TestRunWithBlockingSuggestedActions(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	workflow := &schema.Workflow{
+		Name: "test-suggest",
+		Steps: []schema.Step{
+			{
+				Name:            "passing",
+				Shell:           "bash",
+				ContinueOnError: ptrBool(true),
+				Run:             "echo '::suggest::ignored because the step passed'",
+			},
+			{
+				Name:            "vet",
+				Shell:           "bash",
+				ContinueOnError: ptrBool(true),
+				Run:             "echo 'vet failed'; echo '::suggest::go vet ./...'; exit 1",
+			},
+			{
+				Name:  "fmt",
+				Shell: "bash",
+				Run:   "echo '::suggest description=Format the code::go fmt ./...'; echo '::suggest::go vet ./...'; exit 1",
+			},
+		},
+	}
+
+	result := NewRunner(workflow, nil, t.TempDir()).RunWithBlocking(context.Background())
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	want := []schema.SuggestedAction{
+		{Command: "go vet ./..."},
+		{Command: "go fmt ./...", Description: "Format the code"},
+	}
+	if len(result.Actions) != len(want) {
+		t.Fatalf("Actions = %+v, want %+v", result.Actions, want)
+	}
+	for i := range want {
+		if result.Actions[i] != want[i] {
+			t.Errorf("Actions[%d] = %+v, want %+v", i, result.Actions[i], want[i])
+		}
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "Suggested actions:\n  • go vet ./...\n  • go fmt ./... (Format the code)\n") {
+		t.Errorf("Expected the suggestions in the reason, got:\n%s", result.PermissionDecisionReason)
+	}
+
+	// A non-blocking workflow allows, so it suggests nothing
+	workflow.Blocking = ptrBool(false)
+	if result := NewRunner(workflow, nil, t.TempDir()).RunWithBlocking(context.Background()); len(result.Actions) != 0 {
+		t.Errorf("Expected no actions for an allow, got %+v", result.Actions)
+	}
+}
+
+func TestParseSuggestions(t *testing.T) {
+	output := strings.Join([]string{
+		"::suggest::go vet ./...",
+		"  ::suggest::  make lint  ",
+		"::suggest description = Regenerate mocks ::go generate ./...",
+		"::suggest description=Only a description::",
+		"::suggestion::not a suggest command",
+		"::suggest go test",
+		"note: ::suggest::only at the start of a line",
+	}, "\n")
+	want := []schema.SuggestedAction{
+		{Command: "go vet ./..."},
+		{Command: "make lint"},
+		{Command: "go generate ./...", Description: "Regenerate mocks"},
+	}
+	got := parseSuggestions(output)
+	if len(got) != len(want) {
+		t.Fatalf("parseSuggestions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseSuggestions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
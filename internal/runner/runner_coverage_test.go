@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -87,6 +88,36 @@ func TestShellTypeSh(t *testing.T) {
 	}
 }
 
+// TestShellTypeScripting tests the python and node shells
+func TestShellTypeScripting(t *testing.T) {
+	tests := []struct {
+		shell string
+		run   string
+	}{
+		{"python", "print('hello from ' + 'python')"},
+		{"node", "console.log('hello from ' + 'node')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			if _, err := exec.LookPath(tt.shell); err != nil {
+				t.Skipf("Skipping - %s not available", tt.shell)
+			}
+			workflow := &schema.Workflow{
+				Name:  "test-" + tt.shell + "-shell",
+				Steps: []schema.Step{{Name: tt.shell + "-step", Shell: tt.shell, Run: tt.run}},
+			}
+
+			results, err := NewRunner(workflow, nil, ".").Run(context.Background())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !results[0].Success || !strings.Contains(results[0].Output, "hello from "+tt.shell) {
+				t.Errorf("Expected %s to print its greeting, got %q (error %v)", tt.shell, results[0].Output, results[0].Error)
+			}
+		})
+	}
+}
+
 // TestShellTypePwsh tests pwsh (PowerShell Core) shell execution
 func TestShellTypePwsh(t *testing.T) {
 	workflow := &schema.Workflow{
@@ -201,6 +232,43 @@ func TestShellTypeDefaultOnUnix(t *testing.T) {
 	}
 }
 
+// TestDefaultShellOverride tests HOOKFLOW_SHELL and SetDefaultShell precedence
+func TestDefaultShellOverride(t *testing.T) {
+	defer SetDefaultShell("")
+
+	t.Setenv(ShellEnv, "sh")
+	if got := defaultShell(); got != "sh" {
+		t.Errorf("Expected HOOKFLOW_SHELL to set default shell 'sh', got: %s", got)
+	}
+
+	SetDefaultShell("bash")
+	if got := defaultShell(); got != "bash" {
+		t.Errorf("Expected SetDefaultShell to win over HOOKFLOW_SHELL, got: %s", got)
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("Skipping step run - sh not available")
+	}
+	SetDefaultShell("")
+	workflow := &schema.Workflow{
+		Name: "test-default-shell",
+		Steps: []schema.Step{
+			{Name: "default", Run: "echo $0"},
+			{Name: "explicit", Shell: "bash", Run: "echo $0"},
+		},
+	}
+	results, err := NewRunner(workflow, nil, ".").Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !results[0].Success || strings.TrimSpace(results[0].Output) != "sh" {
+		t.Errorf("Expected step without shell to use sh, got success=%v output=%q", results[0].Success, results[0].Output)
+	}
+	if !strings.Contains(results[1].Output, "bash") {
+		t.Errorf("Expected step shell to override HOOKFLOW_SHELL, got %q", results[1].Output)
+	}
+}
+
 // TestShellTypeCustom tests a custom shell (falls back to -c convention)
 func TestShellTypeCustom(t *testing.T) {
 	if runtime.GOOS == "windows" {
@@ -354,7 +422,7 @@ func TestEventContextCwdAndTimestamp(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when event.cwd matches, but it was skipped")
 	}
 }
@@ -389,7 +457,7 @@ func TestEventContextHook(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when hook type matches")
 	}
 }
@@ -425,7 +493,7 @@ func TestEventContextTool(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when tool name matches")
 	}
 }
@@ -464,7 +532,7 @@ func TestEventContextToolWithHook(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when hook.tool.name matches")
 	}
 }
@@ -500,7 +568,7 @@ func TestEventContextFile(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when file action matches")
 	}
 }
@@ -540,11 +608,41 @@ func TestEventContextCommit(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when commit author matches")
 	}
 }
 
+// TestEventContextCommitDiff tests that the staged diff is visible to expressions
+func TestEventContextCommitDiff(t *testing.T) {
+	workflow := &schema.Workflow{
+		Name: "test-commit-diff-context",
+		Steps: []schema.Step{
+			{
+				Name: "check-diff",
+				If:   "${{ contains(event.commit.diff, '+password') }}",
+				Run:  "echo 'secret in diff'",
+			},
+		},
+	}
+
+	event := &schema.Event{
+		Cwd: "/test",
+		Commit: &schema.CommitEvent{
+			SHA:  "pending",
+			Diff: "--- a/config.yml\n+++ b/config.yml\n+password: hunter2\n",
+		},
+	}
+
+	results, err := NewRunner(workflow, event, ".").Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if results[0].Skipped {
+		t.Errorf("Expected step to run when the diff matches")
+	}
+}
+
 // TestEventContextPush tests that push event data is populated in context
 func TestEventContextPush(t *testing.T) {
 	workflow := &schema.Workflow{
@@ -576,7 +674,7 @@ func TestEventContextPush(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when push ref matches")
 	}
 }
@@ -815,7 +913,7 @@ func TestWorkingDirectoryInvalidPath(t *testing.T) {
 	result := results[0]
 	// The step should fail because the working directory doesn't exist
 	if result.Success {
-		t.Logf("Note: Step succeeded despite invalid working directory (shell-dependent behavior)")
+		t.Error("Expected step to fail with invalid working directory")
 	}
 }
 
@@ -894,6 +992,108 @@ func TestMultipleStepsWithDifferentWorkingDirectories(t *testing.T) {
 	}
 }
 
+// TestWorkingDirectoryExpressions tests working-directory built from event and env expressions
+func TestWorkingDirectoryExpressions(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	cwd := t.TempDir()
+	srcDir := filepath.Join(cwd, "src")
+	libDir := filepath.Join(cwd, "src", "lib")
+	if err := os.MkdirAll(libDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Resolve symlinks (e.g. macOS /var -> /private/var) to compare with pwd -P
+	srcDir, _ = filepath.EvalSymlinks(srcDir)
+	libDir, _ = filepath.EvalSymlinks(libDir)
+
+	tests := []struct {
+		name       string
+		workingDir string
+		want       string
+	}{
+		{"event cwd", "${{ event.cwd }}/src", srcDir},
+		{"file path dirname", "${{ event.file.path }}/..", libDir},
+		{"env relative to runner dir", "${{ env.SUB_DIR }}", srcDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				Name: "test-wd-expressions",
+				Env:  map[string]string{"SUB_DIR": "src"},
+				Steps: []schema.Step{
+					{
+						Name:             "print-dir",
+						Shell:            "bash",
+						Run:              "pwd -P",
+						WorkingDirectory: tt.workingDir,
+					},
+				},
+			}
+			event := &schema.Event{
+				Cwd:  cwd,
+				File: &schema.FileEvent{Path: "src/lib/util.go", Action: "edit"},
+			}
+
+			results, err := NewRunner(workflow, event, cwd).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !results[0].Success {
+				t.Fatalf("Expected step to succeed, got: %v", results[0].Error)
+			}
+			if got := strings.TrimSpace(results[0].Output); got != tt.want {
+				t.Errorf("Step ran in %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWorkingDirectoryNotFound tests that a missing evaluated working-directory fails clearly
+func TestWorkingDirectoryNotFound(t *testing.T) {
+	cwd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cwd, "file.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		workingDir string
+		wantErr    string
+	}{
+		{"missing directory", "${{ event.cwd }}/missing", "working-directory not found"},
+		{"file instead of directory", "${{ event.cwd }}/file.txt", "working-directory is not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				Name: "test-wd-missing",
+				Steps: []schema.Step{
+					{Name: "step", Shell: "bash", Run: "echo unreachable", WorkingDirectory: tt.workingDir},
+				},
+			}
+
+			results, err := NewRunner(workflow, &schema.Event{Cwd: cwd}, cwd).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Expected no error from Run(), got %v", err)
+			}
+			result := results[0]
+			if result.Success {
+				t.Fatal("Expected step to fail")
+			}
+			if result.Error == nil || !strings.Contains(result.Error.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, result.Error)
+			}
+			if strings.Contains(result.Output, "unreachable") {
+				t.Error("Command should not run when working-directory is invalid")
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Environment Variable Interpolation Tests
 // ============================================================================
@@ -1046,7 +1246,7 @@ func TestIfConditionErrorSetsFailure(t *testing.T) {
 		t.Logf("Step failed (condition evaluation issue): %v", result.Error)
 	} else {
 		// Step marked success means it was skipped
-		if !strings.Contains(result.Output, "Skipped") {
+		if !result.Skipped {
 			t.Errorf("Expected step to be skipped when condition is falsy, got output: %s", result.Output)
 		}
 	}
@@ -1061,7 +1261,7 @@ func TestIfConditionErrorWithContinueOnError(t *testing.T) {
 				Name:            "error-condition-step",
 				If:              "${{ invalid_func_xxx() }}",
 				Run:             "echo 'should not run'",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name: "next-step",
@@ -1162,7 +1362,7 @@ func TestFailurePropagationStopsSubsequentSteps(t *testing.T) {
 	if results[2].Success {
 		t.Errorf("Third step should not succeed (should be skipped)")
 	}
-	if !strings.Contains(results[2].Output, "Skipped") {
+	if !results[2].Skipped {
 		t.Errorf("Third step should be skipped, got: %s", results[2].Output)
 	}
 }
@@ -1659,6 +1859,54 @@ this is not valid yaml:
 	}
 }
 
+// TestActionWithShellOverride tests that with.shell selects the action's shell and is not an input
+func TestActionWithShellOverride(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	script := `echo "bash=${BASH_VERSION:+yes} input=${INPUT_SHELL:-unset} msg=$INPUT_MESSAGE"`
+	actions := map[string]string{
+		"shell-action":     "name: Shell Action\nruns:\n  using: shell\n  shell: sh\n  run: '" + script + "'\n",
+		"composite-action": "name: Composite Action\nruns:\n  using: composite\n  steps:\n    - run: '" + script + "'\n",
+	}
+
+	for name, actionYml := range actions {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			actionDir := filepath.Join(tmpDir, name)
+			if err := os.MkdirAll(actionDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(actionDir, "action.yml"), []byte(actionYml), 0644); err != nil {
+				t.Fatalf("Failed to write action.yml: %v", err)
+			}
+
+			workflow := &schema.Workflow{
+				Name: "test-with-shell",
+				Steps: []schema.Step{
+					{
+						Name: "action-step",
+						Uses: "./" + name,
+						With: map[string]string{"shell": "bash", "message": "hi"},
+					},
+				},
+			}
+
+			results, err := NewRunner(workflow, nil, tmpDir).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !results[0].Success {
+				t.Fatalf("Expected action to succeed, got: %v", results[0].Error)
+			}
+			if !strings.Contains(results[0].Output, "bash=yes input=unset msg=hi") {
+				t.Errorf("Expected action to run in bash without INPUT_SHELL, got: %q", results[0].Output)
+			}
+		})
+	}
+}
+
 // TestActionUnsupportedType tests action with unsupported type (docker)
 func TestActionUnsupportedType(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "test-docker-action")
@@ -2183,3 +2431,71 @@ func TestRunWithBlockingMixedResults(t *testing.T) {
 	}
 }
 
+
+// TestNeverConditionSkipsStep tests that if: never() skips the step without failing
+func TestNeverConditionSkipsStep(t *testing.T) {
+	workflow := &schema.Workflow{
+		Name: "test-never",
+		Steps: []schema.Step{
+			{Name: "disabled", If: "${{ never() }}", Run: "exit 1"},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if !results[0].Success {
+		t.Errorf("Skipped step should not fail: %v", results[0].Error)
+	}
+	if !results[0].Skipped {
+		t.Errorf("Expected step to be skipped, got output: %s", results[0].Output)
+	}
+}
+
+// TestToJSONInRunCommand tests that toJSON interpolates tool args as valid JSON in a step command
+func TestToJSONInRunCommand(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-tojson",
+		Env: map[string]string{
+			"CONFIG_JSON": `{"level": "strict"}`,
+		},
+		Steps: []schema.Step{
+			{Name: "args", Shell: "bash", Run: "echo '${{ toJSON(event.tool.args) }}'"},
+			{Name: "config", Shell: "bash", Run: "echo '${{ fromJSON(env.CONFIG_JSON).level }}'"},
+		},
+	}
+
+	event := &schema.Event{
+		Tool: &schema.ToolEvent{
+			Name: "edit",
+			Args: map[string]interface{}{"path": "src/app.go", "old_str": "a", "new_str": "b"},
+		},
+	}
+
+	runner := NewRunner(workflow, event, t.TempDir())
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(results[0].Output)), &args); err != nil {
+		t.Fatalf("Expected valid JSON output, got %q: %v", results[0].Output, err)
+	}
+	if args["path"] != "src/app.go" {
+		t.Errorf("Expected path in JSON output, got %v", args)
+	}
+
+	if got := strings.TrimSpace(results[1].Output); got != "strict" {
+		t.Errorf("Expected fromJSON value 'strict', got %q", got)
+	}
+}
@@ -2,12 +2,16 @@ package runner
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/htekdev/gh-hookflow/internal/expression"
 	"github.com/htekdev/gh-hookflow/internal/schema"
 )
 
@@ -354,12 +358,12 @@ func TestContinueOnErrorTrueAllowsSubsequentSteps(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 2 - Should Run",
 				Run:             "echo 'This should run'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 		},
 	}
@@ -404,12 +408,12 @@ func TestContinueOnErrorFalseStopsSubsequentSteps(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail",
 				Run:             "exit 1",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name:            "Step 2 - Should Skip",
 				Run:             "echo 'This should NOT run'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 		},
 	}
@@ -440,7 +444,7 @@ func TestContinueOnErrorFalseStopsSubsequentSteps(t *testing.T) {
 	if results[1].Success {
 		t.Errorf("Step 2 should not have succeeded (it should be skipped)")
 	}
-	if results[1].Output != "Skipped (previous step failed)" {
+	if !results[1].Skipped {
 		t.Errorf("Step 2 should be skipped with correct message, got: %s", results[1].Output)
 	}
 }
@@ -483,11 +487,42 @@ func TestDefaultContinueOnErrorIsFalse(t *testing.T) {
 	if results[1].Success {
 		t.Errorf("Step 2 should not have succeeded (it should be skipped)")
 	}
-	if results[1].Output != "Skipped (previous step failed)" {
+	if !results[1].Skipped {
 		t.Errorf("Step 2 should be skipped with correct message, got: %s", results[1].Output)
 	}
 }
 
+// TestWorkflowContinueOnErrorDefault verifies that steps without their own
+// continue-on-error inherit the workflow's
+func TestWorkflowContinueOnErrorDefault(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("Skipping - bash not available")
+	}
+	workflow := &schema.Workflow{
+		Name:            "test-workflow-continue-on-error",
+		ContinueOnError: ptrBool(true),
+		Steps: []schema.Step{
+			{Name: "Inherits", Run: "exit 1", Shell: "bash"},
+			{Name: "Overrides", Run: "exit 1", Shell: "bash", ContinueOnError: ptrBool(false)},
+			{Name: "Skipped", Run: "echo unreachable", Shell: "bash"},
+		},
+	}
+
+	results, err := NewRunner(workflow, nil, os.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[1].Skipped || results[1].Success {
+		t.Errorf("expected step 2 to run and fail after an inherited continue-on-error, got %+v", results[1])
+	}
+	if !results[2].Skipped {
+		t.Errorf("expected step 3 to be skipped after step 2 overrode continue-on-error, got %+v", results[2])
+	}
+}
+
 // TestAlwaysRunsRegardlessOfPreviousFailure verifies that steps with always() in their if condition
 // run even when a previous step failed
 func TestAlwaysRunsRegardlessOfPreviousFailure(t *testing.T) {
@@ -497,7 +532,7 @@ func TestAlwaysRunsRegardlessOfPreviousFailure(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail",
 				Run:             "exit 1",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name: "Step 2 - Always Run",
@@ -543,17 +578,17 @@ func TestMixedContinueOnErrorAndAlways(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail but Continue",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 2 - Should Run (continue-on-error from Step 1)",
 				Run:             "echo 'Step 2 runs because step 1 had continue-on-error'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name:            "Step 3 - Fail but Continue",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name: "Step 4 - Should Run (always)",
@@ -603,17 +638,17 @@ func TestContinueOnErrorWithMultipleFailures(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 2 - Fail",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 3 - Should Run",
 				Run:             "echo 'This should still run'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 		},
 	}
@@ -686,13 +721,13 @@ func TestContinueOnErrorWithEnvironmentVariables(t *testing.T) {
 				Name:            "Step 1 - Fail with env var",
 				Run:             "exit 1",
 				Env:             map[string]string{"TEST_VAR": "test_value"},
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 2 - Should Run with env var",
 				Run:             "echo 'Success with env'",
 				Env:             map[string]string{"TEST_VAR": "test_value"},
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 		},
 	}
@@ -727,12 +762,12 @@ func TestAlwaysWithContinueOnError(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail without continue",
 				Run:             "exit 1",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name:            "Step 2 - Regular step (should skip)",
 				Run:             "echo 'This should skip'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name: "Step 3 - Always run",
@@ -762,7 +797,7 @@ func TestAlwaysWithContinueOnError(t *testing.T) {
 	if results[1].Success {
 		t.Errorf("Step 2 should not have succeeded (should be skipped)")
 	}
-	if results[1].Output != "Skipped (previous step failed)" {
+	if !results[1].Skipped {
 		t.Errorf("Step 2 should be skipped with correct message, got: %s", results[1].Output)
 	}
 
@@ -782,22 +817,22 @@ func TestPrevStepFailedFlagOnly(t *testing.T) {
 			{
 				Name:            "Step 1 - Fail with continue=true",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name:            "Step 2 - Should execute (no skip)",
 				Run:             "echo 'Running after continue-on-error=true failure'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name:            "Step 3 - Fail with continue=false",
 				Run:             "exit 1",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 			{
 				Name:            "Step 4 - Should skip (prev failed with continue=false)",
 				Run:             "echo 'Should not run'",
-				ContinueOnError: false,
+				ContinueOnError: ptrBool(false),
 			},
 		},
 	}
@@ -832,7 +867,7 @@ func TestPrevStepFailedFlagOnly(t *testing.T) {
 	if results[3].Success {
 		t.Errorf("Step 4 should not have succeeded (should be skipped)")
 	}
-	if results[3].Output != "Skipped (previous step failed)" {
+	if !results[3].Skipped {
 		t.Errorf("Step 4 should be skipped with correct message, got: %s", results[3].Output)
 	}
 }
@@ -872,7 +907,7 @@ func TestStepIfConditionTrue(t *testing.T) {
 		t.Errorf("Expected step to succeed with if: true, got error: %v", result.Error)
 	}
 
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run, but it was skipped")
 	}
 }
@@ -911,7 +946,7 @@ func TestStepIfConditionFalse(t *testing.T) {
 		t.Errorf("Expected skipped step to be marked success, got error: %v", result.Error)
 	}
 
-	if !strings.Contains(result.Output, "Skipped") {
+	if !result.Skipped {
 		t.Errorf("Expected output to indicate skipped, got: %s", result.Output)
 	}
 }
@@ -1005,7 +1040,7 @@ func TestStepIfExpressionEvaluation(t *testing.T) {
 			}
 
 			result := results[0]
-			isSkipped := strings.Contains(result.Output, "Skipped")
+			isSkipped := result.Skipped
 
 			if tt.shouldRun && isSkipped {
 				t.Errorf("Expected step to run, but it was skipped")
@@ -1128,7 +1163,7 @@ func TestStepIfWithEnvironmentVariable(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when env variable is true")
 	}
 }
@@ -1163,7 +1198,7 @@ func TestStepIfWithEventData(t *testing.T) {
 	}
 
 	result := results[0]
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run when event.cwd is set")
 	}
 }
@@ -1207,17 +1242,17 @@ func TestMultipleStepsWithIfConditions(t *testing.T) {
 	}
 
 	// Step 1 should run
-	if strings.Contains(results[0].Output, "Skipped") {
+	if results[0].Skipped {
 		t.Errorf("Step 1 should run but was skipped")
 	}
 
 	// Step 2 should be skipped
-	if !strings.Contains(results[1].Output, "Skipped") {
+	if !results[1].Skipped {
 		t.Errorf("Step 2 should be skipped but ran")
 	}
 
 	// Step 3 should run
-	if strings.Contains(results[2].Output, "Skipped") {
+	if results[2].Skipped {
 		t.Errorf("Step 3 should run but was skipped")
 	}
 }
@@ -1253,7 +1288,7 @@ func TestStepIfWithComplexLogic(t *testing.T) {
 	result := results[0]
 	// (true && false) = false, (true && true) = true, false || true = true
 	// So step should run
-	if strings.Contains(result.Output, "Skipped") {
+	if result.Skipped {
 		t.Errorf("Expected step to run with complex logic that evaluates to true")
 	}
 }
@@ -1266,7 +1301,7 @@ func TestContinueOnErrorWithIfCondition(t *testing.T) {
 			{
 				Name:            "failing-step",
 				Run:             "exit 1",
-				ContinueOnError: true,
+				ContinueOnError: ptrBool(true),
 			},
 			{
 				Name: "step-after-failure",
@@ -1298,7 +1333,7 @@ func TestContinueOnErrorWithIfCondition(t *testing.T) {
 	}
 
 	// Second step should still run because first step has continue-on-error
-	if strings.Contains(results[1].Output, "Skipped") {
+	if results[1].Skipped {
 		t.Errorf("Expected second step to run despite first step failure due to continue-on-error")
 	}
 }
@@ -1567,6 +1602,9 @@ func TestEnvironmentVariableExpansion(t *testing.T) {
 
 // TestStepEnvironmentVariableOverride tests step-level env var override via expressions
 func TestStepEnvironmentVariableOverride(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
 	workflow := &schema.Workflow{
 		Name: "test-step-env-override",
 		Env: map[string]string{
@@ -1574,8 +1612,9 @@ func TestStepEnvironmentVariableOverride(t *testing.T) {
 		},
 		Steps: []schema.Step{
 			{
-				Name: "echo-step-env",
-				Run:  "echo ${{ env.MY_VAR }}",
+				Name:  "echo-step-env",
+				Shell: "bash",
+				Run:   "echo ${{ env.MY_VAR }}",
 				Env: map[string]string{
 					"MY_VAR": "step_value",
 				},
@@ -1595,11 +1634,60 @@ func TestStepEnvironmentVariableOverride(t *testing.T) {
 		t.Errorf("Echo step env var should succeed, got error: %v", result.Error)
 	}
 
-	// Note: Currently step env vars may not override workflow env in expression evaluation
-	// This test documents the current behavior - step env vars are added to the process env
-	// but expression evaluation uses the original workflow env
-	if strings.Contains(result.Output, "workflow_value") {
-		t.Logf("Note: Expression uses workflow env, step env added to process only")
+	// Step env overrides workflow env in expressions as well as in the process
+	if strings.TrimSpace(result.Output) != "step_value" {
+		t.Errorf("Expected the step env value in the expression, got %q", result.Output)
+	}
+}
+
+func TestStepEnvInIfAndWorkingDirectory(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workflow := &schema.Workflow{
+		Name: "step-env-expressions",
+		Env:  map[string]string{"MODE": "workflow"},
+		Steps: []schema.Step{
+			{
+				Name:             "matching",
+				Shell:            "bash",
+				If:               "${{ env.STEP_VAR == 'x' && env.MODE == 'step' }}",
+				WorkingDirectory: "${{ env.TARGET }}",
+				Env:              map[string]string{"STEP_VAR": "x", "MODE": "step", "TARGET": "sub"},
+				Run:              "basename \"$PWD\"",
+			},
+			{
+				Name:  "not-matching",
+				Shell: "bash",
+				If:    "env.STEP_VAR == 'x'",
+				Env:   map[string]string{"STEP_VAR": "y"},
+				Run:   "echo ran",
+			},
+			{
+				// Earlier step env does not leak into later steps
+				Name:  "later",
+				Shell: "bash",
+				Run:   "echo '${{ env.MODE }}-${{ env.STEP_VAR }}'",
+			},
+		},
+	}
+
+	results, err := NewRunner(workflow, nil, dir).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if results[0].Skipped || strings.TrimSpace(results[0].Output) != "sub" {
+		t.Errorf("Expected the first step to run in sub, got %+v", results[0])
+	}
+	if !results[1].Skipped {
+		t.Errorf("Expected the second step to be skipped by its step env, got %+v", results[1])
+	}
+	if strings.TrimSpace(results[2].Output) != "workflow-" {
+		t.Errorf("Expected only workflow env in the last step, got %q", results[2].Output)
 	}
 }
 
@@ -1798,6 +1886,73 @@ func TestExpressionWithEventData(t *testing.T) {
 	}
 }
 
+// TestToolArgsPathExpression verifies event.tool.args.path yields the path
+// string and a missing argument is empty rather than an error
+func TestToolArgsPathExpression(t *testing.T) {
+	event := &schema.Event{
+		Tool: &schema.ToolEvent{
+			Name: "edit",
+			Args: map[string]interface{}{"path": "src/app.go"},
+		},
+	}
+	runner := NewRunner(&schema.Workflow{Name: "args-path"}, event, ".")
+
+	got, err := runner.exprCtx.EvaluateString("${{ event.tool.args.path }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error: %v", err)
+	}
+	if got != "src/app.go" {
+		t.Errorf("event.tool.args.path = %q, want src/app.go", got)
+	}
+
+	got, err = runner.exprCtx.EvaluateString("${{ event.tool.args.missing }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error for missing arg: %v", err)
+	}
+	if got != "" {
+		t.Errorf("event.tool.args.missing = %q, want empty", got)
+	}
+}
+
+func TestHookSessionIDExpression(t *testing.T) {
+	event := &schema.Event{
+		Hook: &schema.HookEvent{Type: "preToolUse", SessionID: "session-42"},
+	}
+	runner := NewRunner(&schema.Workflow{Name: "session"}, event, ".")
+
+	got, err := runner.exprCtx.EvaluateString("${{ event.hook.session_id }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error: %v", err)
+	}
+	if got != "session-42" {
+		t.Errorf("event.hook.session_id = %q, want session-42", got)
+	}
+
+	// Agents that send no session ID leave it empty
+	runner = NewRunner(&schema.Workflow{Name: "session"}, &schema.Event{Hook: &schema.HookEvent{Type: "preToolUse"}}, ".")
+	if got, err := runner.exprCtx.EvaluateString("${{ event.hook.session_id }}"); err != nil || got != "" {
+		t.Errorf("event.hook.session_id = %q (err %v), want empty", got, err)
+	}
+}
+
+func TestPushFilesExpression(t *testing.T) {
+	event := &schema.Event{
+		Push: &schema.PushEvent{
+			Ref:   "refs/heads/main",
+			Files: []schema.FileStatus{{Path: "src/app.go", Status: "added"}},
+		},
+	}
+	runner := NewRunner(&schema.Workflow{Name: "push-files"}, event, ".")
+
+	got, err := runner.exprCtx.EvaluateString("${{ toJSON(event.push.files) }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error: %v", err)
+	}
+	if got != `[{"path":"src/app.go","status":"added"}]` {
+		t.Errorf("event.push.files = %s", got)
+	}
+}
+
 // TestComplexExpressionInterpolation tests complex expressions with multiple operations
 func TestComplexExpressionInterpolation(t *testing.T) {
 	workflow := &schema.Workflow{
@@ -2029,3 +2184,668 @@ func TestDurationCapture(t *testing.T) {
 		t.Errorf("Expected duration <= 5 seconds, got %v", result.Duration)
 	}
 }
+
+// BenchmarkRunnerProfile measures runner overhead for a 10-step no-op workflow
+func BenchmarkRunnerProfile(b *testing.B) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		b.Skip("Skipping - bash not available")
+	}
+
+	workflow := &schema.Workflow{Name: "profile"}
+	for i := 0; i < 10; i++ {
+		workflow.Steps = append(workflow.Steps, schema.Step{Name: fmt.Sprintf("noop-%d", i), Shell: "bash", Run: ":"})
+	}
+	ctx := context.Background()
+	dir := b.TempDir()
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		result := NewRunner(workflow, nil, dir).RunWithBlocking(ctx)
+		if result.PermissionDecision != "allow" {
+			b.Fatalf("Expected allow, got %s: %s", result.PermissionDecision, result.PermissionDecisionReason)
+		}
+	}
+	perRun := time.Since(start) / time.Duration(b.N)
+	if perRun > 200*time.Millisecond {
+		b.Fatalf("10 no-op steps took %s per run, want under 200ms", perRun)
+	}
+}
+
+func TestDenialLogRecordsEvent(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	wf := &schema.Workflow{
+		Name:  "replayable",
+		Steps: []schema.Step{{Name: "fail", Shell: "bash", Run: "exit 1"}},
+	}
+	evt := &schema.Event{
+		Tool:      &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "src/app.go"}},
+		File:      &schema.FileEvent{Path: "src/app.go", Action: "edit"},
+		Lifecycle: "post",
+	}
+	result := NewRunner(wf, evt, t.TempDir()).RunWithBlocking(context.Background())
+	if result.LogFile == "" {
+		t.Fatalf("Expected a denial log, got %+v", result)
+	}
+
+	denial, err := ReadDenialLog(result.LogFile)
+	if err != nil {
+		t.Fatalf("ReadDenialLog: %v", err)
+	}
+	if denial.Workflow != "replayable" || denial.ExecutionID == "" {
+		t.Errorf("header = %+v", denial)
+	}
+	if denial.Event == nil || denial.Event.File.Path != "src/app.go" || denial.Event.Tool.Args["path"] != "src/app.go" || denial.Event.GetLifecycle() != "post" {
+		t.Errorf("event = %+v", denial.Event)
+	}
+}
+
+func TestEnvValuesRedacted(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("HOOKFLOW_TEST_TOKEN", "s3cr3t-t0ken")
+
+	wf := &schema.Workflow{
+		Name: "leaky",
+		Steps: []schema.Step{{
+			Name:  "print",
+			Shell: "bash",
+			Run:   "echo \"token=${{ env('HOOKFLOW_TEST_TOKEN') }}\"; exit 1",
+		}},
+	}
+	result := NewRunnerWithOptions(wf, nil, t.TempDir(), RunnerOptions{Verbose: true}).RunWithBlocking(context.Background())
+	if result.PermissionDecision != "deny" || result.LogFile == "" {
+		t.Fatalf("Expected a deny with a log file, got %+v", result)
+	}
+	if strings.Contains(result.PermissionDecisionReason, "s3cr3t-t0ken") || !strings.Contains(result.PermissionDecisionReason, "token=***") {
+		t.Errorf("Expected the env() value redacted from the reason, got %q", result.PermissionDecisionReason)
+	}
+	content, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "s3cr3t-t0ken") || !strings.Contains(string(content), "token=***") {
+		t.Errorf("Expected the env() value redacted from the denial log, got:\n%s", content)
+	}
+}
+
+func TestRunName(t *testing.T) {
+	evt := &schema.Event{
+		File:      &schema.FileEvent{Path: "src/app.go", Action: "edit"},
+		Timestamp: "2026-01-02T03:04:05Z",
+	}
+	tests := []struct {
+		name    string
+		runName string
+		want    string
+	}{
+		{"templated", "Checking ${{ event.file.path }} at ${{ event.timestamp }}", "Checking src/app.go at 2026-01-02T03:04:05Z"},
+		{"static", "Nightly check", "Nightly check"},
+		{"empty falls back to name", "", "guard"},
+		{"blank result falls back to name", "${{ event.tool.name }}", "guard"},
+		{"evaluation error falls back to name", "${{ event.file.path == }}", "guard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &schema.Workflow{Name: "guard", RunName: tt.runName}
+			if got := NewRunner(wf, evt, t.TempDir()).RunName(); got != tt.want {
+				t.Errorf("RunName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunNameInResult(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	wf := &schema.Workflow{
+		Name:    "guard",
+		RunName: "Checking ${{ event.file.path }}",
+		Steps:   []schema.Step{{Name: "fail", Shell: "bash", Run: "exit 1"}},
+	}
+	evt := &schema.Event{File: &schema.FileEvent{Path: "src/app.go", Action: "edit"}}
+	result := NewRunner(wf, evt, t.TempDir()).RunWithBlocking(context.Background())
+
+	if result.WorkflowName != "Checking src/app.go" {
+		t.Errorf("WorkflowName = %q, want the evaluated run-name", result.WorkflowName)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "Workflow 'Checking src/app.go' blocked") {
+		t.Errorf("Expected the reason to use the run-name, got %q", result.PermissionDecisionReason)
+	}
+
+	// Replay looks the workflow up by name, so the denial log keeps it
+	denial, err := ReadDenialLog(result.LogFile)
+	if err != nil {
+		t.Fatalf("ReadDenialLog: %v", err)
+	}
+	if denial.Workflow != "guard" {
+		t.Errorf("denial log workflow = %q, want %q", denial.Workflow, "guard")
+	}
+}
+
+func TestReadDenialLogErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Logs from before events were recorded still parse, without an event
+	denial, err := ReadDenialLog(write("old.log", "Workflow: lint\nExecution ID: abc\n"+strings.Repeat("=", 60)+"\n\nStep: x\n"))
+	if err != nil || denial.Workflow != "lint" || denial.Event != nil {
+		t.Errorf("old log = %+v, %v", denial, err)
+	}
+
+	// Event: lines after the header belong to step output
+	denial, err = ReadDenialLog(write("output.log", "Workflow: lint\n"+strings.Repeat("=", 60)+"\nEvent: !!\n"))
+	if err != nil || denial.Event != nil {
+		t.Errorf("output after header = %+v, %v", denial, err)
+	}
+
+	if _, err := ReadDenialLog(write("bad.log", "Workflow: lint\nEvent: !!\n")); err == nil || !strings.Contains(err.Error(), "invalid event") {
+		t.Errorf("Expected invalid event error, got %v", err)
+	}
+	if _, err := ReadDenialLog(write("other.log", "hello\n")); err == nil || !strings.Contains(err.Error(), "not a hookflow denial log") {
+		t.Errorf("Expected not-a-denial-log error, got %v", err)
+	}
+	if _, err := ReadDenialLog(filepath.Join(dir, "missing.log")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestStepEnvUsesEarlierStepOutputs(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "outputs",
+		Steps: []schema.Step{
+			{
+				Name:  "parse",
+				Shell: "bash",
+				Run:   `echo "component=auth" >> "$HOOKFLOW_OUTPUT"; printf 'notes<<EOF\nline one\nline two\nEOF\n' >> "$HOOKFLOW_OUTPUT"`,
+			},
+			{
+				Name:  "report",
+				Shell: "bash",
+				Env: map[string]string{
+					"COMPONENT_NAME": "${{ steps.parse.outputs.component }}",
+					"NOTES":          "${{ steps.parse.outputs.notes }}",
+					"MISSING":        "[${{ steps.parse.outputs.missing }}]",
+				},
+				Run: `echo "component=$COMPONENT_NAME"; echo "$NOTES"; echo "missing=$MISSING"`,
+			},
+		},
+	}
+
+	results, err := NewRunner(workflow, nil, t.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 2 || !results[0].Success || !results[1].Success {
+		t.Fatalf("Expected both steps to succeed, got %+v", results)
+	}
+	if results[0].Outputs["component"] != "auth" || results[0].Outputs["notes"] != "line one\nline two" {
+		t.Errorf("parse outputs = %v", results[0].Outputs)
+	}
+	for _, want := range []string{"component=auth", "line one\nline two", "missing=[]"} {
+		if !strings.Contains(results[1].Output, want) {
+			t.Errorf("report output %q does not contain %q", results[1].Output, want)
+		}
+	}
+}
+
+func TestStepEnvEvaluationError(t *testing.T) {
+	workflow := &schema.Workflow{
+		Name: "bad-env",
+		Steps: []schema.Step{
+			{Name: "bad", Shell: "bash", Env: map[string]string{"X": "${{ unknownFn() }}"}, Run: "echo hi"},
+		},
+	}
+	results, err := NewRunner(workflow, nil, t.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if results[0].Success || results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "failed to evaluate env X") {
+		t.Errorf("Expected env evaluation error, got %+v", results[0])
+	}
+}
+
+func TestStepEnvErrorWithFalseCondition(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	workflow := &schema.Workflow{
+		Name: "bad-env-skipped",
+		Steps: []schema.Step{
+			{
+				Name: "deploy",
+				If:   "${{ env.TARGET == 'prod' }}",
+				Env:  map[string]string{"TARGET": "staging", "TOKEN": "${{ unknownFn() }}"},
+				Run:  "echo deploy",
+			},
+			{Name: "after", Shell: "bash", Run: "echo after"},
+		},
+	}
+	results, err := NewRunner(workflow, nil, t.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// The if: is checked first, with the step env that did evaluate
+	if len(results) != 2 || !results[0].Skipped || !results[0].Success || results[0].Error != nil {
+		t.Fatalf("Expected the step to be skipped by its condition, got %+v", results)
+	}
+	if !results[1].Success || results[1].Skipped {
+		t.Errorf("Expected the next step to run, got %+v", results[1])
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	workflow := &schema.Workflow{Name: "env", Env: map[string]string{"TARGET": "prod"}}
+	r := NewRunner(workflow, &schema.Event{Cwd: "/repo"}, t.TempDir())
+	r.exprCtx.SetStepResult("parse", expression.StepContext{Outputs: map[string]string{"component": "auth"}, Outcome: "success"})
+	r.exprCtx.Vars["region"] = "eu"
+	vars := r.interpolationVars()
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"${{ env.TARGET }}-${{ steps.parse.outputs.component }}", "prod-auth"},
+		{"${{ steps.parse.outcome }}", "success"},
+		{"${{ ctx.region }}", "eu"},
+		// Not simple lookups: evaluated in full, with the same results as before
+		{"${{ format('{0}!', env.TARGET) }}", "prod!"},
+		{"${{ event.cwd }}", "/repo"},
+		{"[${{ env.MISSING }}]", "[]"},
+	}
+	for _, tt := range tests {
+		got, err := r.expandEnv(tt.value, vars)
+		if err != nil {
+			t.Errorf("expandEnv(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := r.expandEnv("${{ unknownFn() }}", vars); err == nil {
+		t.Error("Expected an error for an unknown function")
+	}
+}
+
+func TestWorkflowEnvRef(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("HOOKFLOW_TEST_SECRET", "s3cret")
+
+	workflow := &schema.Workflow{
+		Name: "secrets",
+		Env: map[string]string{
+			"TOKEN":   "$ENV:HOOKFLOW_TEST_SECRET",
+			"MISSING": "$ENV:HOOKFLOW_TEST_UNSET_SECRET",
+			"LITERAL": "plain",
+		},
+		Steps: []schema.Step{
+			{
+				Name:  "use",
+				Shell: "bash",
+				If:    "${{ env.TOKEN == 's3cret' }}",
+				Run:   `echo "token=$TOKEN missing=[$MISSING] literal=$LITERAL step=$STEP_TOKEN"`,
+				Env:   map[string]string{"STEP_TOKEN": "$ENV:HOOKFLOW_TEST_SECRET"},
+			},
+		},
+	}
+
+	r := NewRunner(workflow, nil, t.TempDir())
+	if got := r.exprCtx.Env["TOKEN"]; got != "s3cret" {
+		t.Errorf("env.TOKEN = %q, want the OS environment value", got)
+	}
+	results, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected the step to run and succeed, got %+v", results)
+	}
+	if want := "token=s3cret missing=[] literal=plain step=s3cret"; !strings.Contains(results[0].Output, want) {
+		t.Errorf("Output %q does not contain %q", results[0].Output, want)
+	}
+}
+
+func TestStepOutputFile(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	run := func(script string) StepResult {
+		t.Helper()
+		workflow := &schema.Workflow{
+			Name:  "outputs",
+			Steps: []schema.Step{{Name: "write", Shell: "bash", Run: script}},
+		}
+		results, err := NewRunner(workflow, nil, t.TempDir()).Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return results[0]
+	}
+
+	t.Run("several lines", func(t *testing.T) {
+		result := run(`printf 'a=1\nb=two words\n\nc=x=y\na=3\n' >> "$HOOKFLOW_OUTPUT"`)
+		want := map[string]string{"a": "3", "b": "two words", "c": "x=y"}
+		if !result.Success || len(result.Outputs) != len(want) {
+			t.Fatalf("Expected outputs %v, got %+v", want, result)
+		}
+		for k, v := range want {
+			if result.Outputs[k] != v {
+				t.Errorf("Outputs[%s] = %q, want %q", k, result.Outputs[k], v)
+			}
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		result := run(`echo "path=$HOOKFLOW_OUTPUT"`)
+		if !result.Success || len(result.Outputs) != 0 {
+			t.Fatalf("Expected success without outputs, got %+v", result)
+		}
+		// Each step gets its own file, removed once the step finishes
+		path := strings.TrimPrefix(strings.TrimSpace(result.Output), "path=")
+		if path == "" {
+			t.Fatal("Expected $HOOKFLOW_OUTPUT to be set")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected the output file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		result := run(`echo "checking"; echo "no equals sign" >> "$HOOKFLOW_OUTPUT"`)
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "failed to read step outputs") {
+			t.Fatalf("Expected the step to fail on a malformed output line, got %+v", result)
+		}
+		if !strings.Contains(result.Error.Error(), "no equals sign") || !strings.Contains(result.Output, "checking") {
+			t.Errorf("Expected the error to quote the line and the step output to be kept, got %+v", result)
+		}
+	})
+}
+
+func TestParseStepOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr string
+	}{
+		{"empty", "", map[string]string{}, ""},
+		{"single", "count=3\n", map[string]string{"count": "3"}, ""},
+		{"value with equals", "expr=a=b\n", map[string]string{"expr": "a=b"}, ""},
+		{"empty value", "flag=\n", map[string]string{"flag": ""}, ""},
+		{"later value wins", "a=1\na=2\n", map[string]string{"a": "2"}, ""},
+		{"blank lines and CRLF", "\r\na=1\r\n\nb=2", map[string]string{"a": "1", "b": "2"}, ""},
+		{"heredoc", "body<<END\nx=1\n\ny\nEND\nafter=ok\n", map[string]string{"body": "x=1\n\ny", "after": "ok"}, ""},
+		{"heredoc marker inside value", "cmd=a<<b\n", map[string]string{"cmd": "a<<b"}, ""},
+		{"unterminated heredoc", "body<<END\nx\n", nil, "missing its closing delimiter"},
+		{"empty delimiter", "body<<\n", nil, "empty delimiter"},
+		{"no equals", "just text\n", nil, "invalid output line"},
+		{"empty name", "=value\n", nil, "invalid output line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStepOutputs(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStepOutputs: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("outputs = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("outputs[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestStepResultsInContext(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "step-ids",
+		Steps: []schema.Step{
+			{Name: "first", Shell: "bash", Run: "echo one"},
+			{Name: "second", Shell: "bash", If: "${{ steps.step-1.outcome == 'success' }}", Run: "echo two"},
+			{ID: "gate", Shell: "bash", If: "${{ false }}", Run: "echo never"},
+			{ID: "lint", Shell: "bash", Run: `echo "issues=0" >> "$HOOKFLOW_OUTPUT"; exit 1`, ContinueOnError: ptrBool(true)},
+			{
+				Name:  "report",
+				Shell: "bash",
+				If:    "${{ always() }}",
+				Run:   "echo gate=${{ steps.gate.outcome }} lint=${{ steps.lint.outcome }} issues=${{ steps.lint.outputs.issues }} first=${{ steps.first.outcome }}",
+			},
+		},
+	}
+
+	r := NewRunner(workflow, nil, t.TempDir())
+	results, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+	if !strings.Contains(results[1].Output, "two") {
+		t.Errorf("Expected step 2 to run after steps.step-1.outcome == 'success', got %q", results[1].Output)
+	}
+	if want := "gate=skipped lint=failure issues=0 first=success"; !strings.Contains(results[4].Output, want) {
+		t.Errorf("report output %q does not contain %q", results[4].Output, want)
+	}
+
+	// Steps with an id are not also listed under step-N
+	for id, outcome := range map[string]string{"step-1": "success", "step-2": "success", "first": "success", "gate": "skipped", "lint": "failure", "step-5": "success"} {
+		if got := r.exprCtx.Steps[id].Outcome; got != outcome {
+			t.Errorf("steps.%s.outcome = %q, want %q", id, got, outcome)
+		}
+	}
+	if _, ok := r.exprCtx.Steps["step-3"]; ok {
+		t.Error("Expected step with id gate not to be registered as step-3")
+	}
+}
+
+func TestSkippedAfterFailureOutcome(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "skipped",
+		Steps: []schema.Step{
+			{Shell: "bash", Run: "exit 1"},
+			{Shell: "bash", Run: "echo unreachable"},
+			{Shell: "bash", If: "${{ always() }}", Run: "echo ${{ steps.step-1.outcome }}/${{ steps.step-2.outcome }}"},
+		},
+	}
+	results, err := NewRunner(workflow, nil, t.TempDir()).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.Contains(results[2].Output, "failure/skipped") {
+		t.Errorf("Expected failure/skipped, got %q", results[2].Output)
+	}
+}
+
+func TestMatrixStep(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "matrix",
+		Steps: []schema.Step{
+			{
+				ID:     "deploy",
+				Name:   "Deploy",
+				Shell:  "bash",
+				Matrix: map[string][]string{"env": {"dev", "staging", "prod"}},
+				Run:    `echo "to ${{ matrix.env }}"; [ "${{ matrix.env }}" != staging ]`,
+			},
+			{Name: "After", Shell: "bash", Run: "echo unreachable"},
+			{
+				Name:  "Report",
+				Shell: "bash",
+				If:    "${{ always() }}",
+				Run:   "echo ${{ steps.deploy.outcome }}/${{ steps['Deploy (env=prod)'].outcome }}/[${{ matrix.env }}]",
+			},
+		},
+	}
+	result := NewRunner(workflow, nil, t.TempDir()).RunWithBlocking(context.Background())
+	if result.PermissionDecision != "deny" {
+		t.Errorf("Expected a failing combination to deny, got %s", result.PermissionDecision)
+	}
+
+	var names []string
+	for _, step := range result.StepResults {
+		names = append(names, step.Name)
+	}
+	want := "Deploy (env=dev),Deploy (env=staging),Deploy (env=prod),After,Report"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("Step results = %s, want %s", got, want)
+	}
+
+	steps := result.StepResults
+	if !steps[0].Success || steps[1].Success || !steps[2].Success {
+		t.Errorf("Expected only env=staging to fail, and env=prod to still run, got %+v", steps[:3])
+	}
+	if !strings.Contains(steps[2].OutputPreview, "to prod") {
+		t.Errorf("Expected matrix.env in the command, got %q", steps[2].OutputPreview)
+	}
+	if steps[3].Success {
+		t.Errorf("Expected step after the failed matrix to be skipped, got %+v", steps[3])
+	}
+	if !strings.Contains(steps[4].OutputPreview, "failure/success/[]") {
+		t.Errorf("Expected combined and per-combination outcomes, got %q", steps[4].OutputPreview)
+	}
+}
+
+func TestMatrixStepEmpty(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	// Validation rejects these, but workflows loaded without it still reach
+	// the runner
+	for _, matrix := range []map[string][]string{{}, {"env": {}}, {"env": {"dev"}, "os": nil}} {
+		workflow := &schema.Workflow{
+			Name: "empty-matrix",
+			Steps: []schema.Step{
+				{ID: "deploy", Name: "Deploy", Shell: "bash", Matrix: matrix, Run: "exit 1"},
+				{Name: "Report", Shell: "bash", Run: "echo ${{ steps.deploy.outcome }}"},
+			},
+		}
+		result := NewRunner(workflow, nil, t.TempDir()).RunWithBlocking(context.Background())
+		if result.PermissionDecision != "allow" || len(result.StepResults) != 2 {
+			t.Fatalf("matrix %v: expected the step to be skipped and the workflow to allow, got %+v", matrix, result)
+		}
+		if step := result.StepResults[0]; !step.Skipped || step.Name != "Deploy" {
+			t.Errorf("matrix %v: expected Deploy to be skipped, got %+v", matrix, step)
+		}
+		if got := result.StepResults[1].OutputPreview; !strings.Contains(got, "skipped") {
+			t.Errorf("matrix %v: expected steps.deploy.outcome to be skipped, got %q", matrix, got)
+		}
+	}
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	if got := matrixCombinations(nil); got != nil {
+		t.Errorf("Expected no combinations without a matrix, got %v", got)
+	}
+
+	combinations := matrixCombinations(map[string][]string{
+		"os":  {"linux", "windows"},
+		"env": {"dev", "prod"},
+	})
+	var got []string
+	for _, combination := range combinations {
+		got = append(got, formatMatrix(combination))
+	}
+	want := "env=dev, os=linux|env=dev, os=windows|env=prod, os=linux|env=prod, os=windows"
+	if strings.Join(got, "|") != want {
+		t.Errorf("combinations = %s, want %s", strings.Join(got, "|"), want)
+	}
+}
+
+func TestTruncateOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		maxBytes int64
+		want     string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hello\n... output truncated (5 of 11 bytes shown)"},
+		// "é" is two bytes and "€" three; a cut inside either backs up before it
+		{"caféine", 4, "caf\n... output truncated (3 of 8 bytes shown)"},
+		{"€uro", 2, "\n... output truncated (0 of 6 bytes shown)"},
+		{"a€b", 4, "a€\n... output truncated (4 of 5 bytes shown)"},
+	}
+	for _, tt := range tests {
+		got := truncateOutput(tt.output, tt.maxBytes)
+		if got != tt.want {
+			t.Errorf("truncateOutput(%q, %d) = %q, want %q", tt.output, tt.maxBytes, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateOutput(%q, %d) is not valid UTF-8: %q", tt.output, tt.maxBytes, got)
+		}
+	}
+}
+
+func TestPreviewOutput(t *testing.T) {
+	ascii := strings.Repeat("a", outputPreviewLength)
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"  short \n", "short"},
+		{ascii, ascii},
+		{ascii + "b", ascii + "..."},
+		// "€" is three bytes starting at byte 199, so the cut backs up before it
+		{strings.Repeat("a", outputPreviewLength-1) + "€uro", strings.Repeat("a", outputPreviewLength-1) + "..."},
+		// "é" is two bytes ending at byte 200, so it fits
+		{strings.Repeat("a", outputPreviewLength-2) + "éé", strings.Repeat("a", outputPreviewLength-2) + "é..."},
+	}
+	for _, tt := range tests {
+		got := previewOutput(tt.output)
+		if got != tt.want {
+			t.Errorf("previewOutput(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("previewOutput(%q) is not valid UTF-8: %q", tt.output, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OutputEnv names the file a run: step writes its outputs to, one
+// name=value per line, read back as ${{ steps.<name>.outputs.<output> }}.
+// Multi-line values use name<<DELIMITER, the value lines, then DELIMITER.
+const OutputEnv = "HOOKFLOW_OUTPUT"
+
+// newOutputFile creates an empty file for a step to write outputs to
+func newOutputFile() (string, error) {
+	f, err := os.CreateTemp("", "hookflow-output-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return name, nil
+}
+
+// readStepOutputs parses the outputs a step wrote to path
+func readStepOutputs(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseStepOutputs(string(data))
+}
+
+// parseStepOutputs parses name=value and name<<DELIMITER lines. A later
+// value for the same name replaces the earlier one.
+func parseStepOutputs(data string) (map[string]string, error) {
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		heredoc := strings.Index(line, "<<")
+		if heredoc > 0 && (eq < 0 || heredoc < eq) {
+			name := line[:heredoc]
+			delimiter := line[heredoc+2:]
+			if delimiter == "" {
+				return nil, fmt.Errorf("output %q has an empty delimiter", name)
+			}
+			var value []string
+			closed := false
+			for scanner.Scan() {
+				valueLine := strings.TrimSuffix(scanner.Text(), "\r")
+				if valueLine == delimiter {
+					closed = true
+					break
+				}
+				value = append(value, valueLine)
+			}
+			if !closed {
+				return nil, fmt.Errorf("output %q is missing its closing delimiter %q", name, delimiter)
+			}
+			outputs[name] = strings.Join(value, "\n")
+			continue
+		}
+
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid output line %q: want name=value or name<<DELIMITER", line)
+		}
+		outputs[line[:eq]] = line[eq+1:]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
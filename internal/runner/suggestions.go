@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// suggestCommand starts an output line that suggests a fix after a denial,
+// like a GitHub Actions workflow command:
+//
+//	::suggest::go vet ./...
+//	::suggest description=Format the code::go fmt ./...
+const suggestCommand = "::suggest"
+
+// parseSuggestions returns the ::suggest:: lines in a step's output
+func parseSuggestions(output string) []schema.SuggestedAction {
+	var actions []schema.SuggestedAction
+	for _, line := range strings.Split(output, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), suggestCommand)
+		if !ok {
+			continue
+		}
+		params, command, ok := strings.Cut(rest, "::")
+		command = strings.TrimSpace(command)
+		if !ok || command == "" || (params != "" && !strings.HasPrefix(params, " ")) {
+			continue
+		}
+		action := schema.SuggestedAction{Command: command}
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "description" {
+			action.Description = strings.TrimSpace(value)
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// suggestedActions collects the suggestions printed by failed steps, in
+// step order and without repeating a command
+func suggestedActions(results []StepResult) []schema.SuggestedAction {
+	var actions []schema.SuggestedAction
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.Success {
+			continue
+		}
+		for _, action := range parseSuggestions(result.Output) {
+			if !seen[action.Command] {
+				seen[action.Command] = true
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
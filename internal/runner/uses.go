@@ -46,6 +46,7 @@ type ActionRuns struct {
 // ParsedUses contains the parsed uses: reference
 type ParsedUses struct {
 	IsLocal bool   // true for local paths (./path/to/action)
+	Host    string // host for remote actions fetched over HTTP (github.com/owner/repo@ref)
 	Owner   string // GitHub owner
 	Repo    string // GitHub repo name
 	Path    string // optional path within repo (for sub-actions)
@@ -79,6 +80,13 @@ func parseUsesString(uses string) (*ParsedUses, error) {
 	actionPath := parts[0]
 	pathParts := strings.Split(actionPath, "/")
 
+	// A leading segment with a dot is a hostname, since owners cannot contain dots
+	host := ""
+	if strings.Contains(pathParts[0], ".") {
+		host = pathParts[0]
+		pathParts = pathParts[1:]
+	}
+
 	if len(pathParts) < 2 {
 		return nil, fmt.Errorf("invalid uses format: %s (expected at least owner/repo)", uses)
 	}
@@ -94,6 +102,7 @@ func parseUsesString(uses string) (*ParsedUses, error) {
 
 	return &ParsedUses{
 		IsLocal: false,
+		Host:    host,
 		Owner:   owner,
 		Repo:    repo,
 		Path:    path,
@@ -119,6 +128,11 @@ func (r *Runner) resolveActionPath(ctx context.Context, parsed *ParsedUses) (str
 		return actionPath, nil
 	}
 
+	// Actions with a host are fetched over HTTP and cached
+	if parsed.Host != "" {
+		return r.fetchRemoteAction(ctx, parsed)
+	}
+
 	// For GitHub actions, clone or use cached version
 	// For MVP, we'll use a simple temp directory approach
 	return r.cloneGitHubAction(ctx, parsed)
@@ -176,8 +190,13 @@ func loadActionMetadata(actionDir string) (*ActionMetadata, error) {
 	return nil, fmt.Errorf("action.yaml or action.yml not found in %s", actionDir)
 }
 
-// executeAction runs the action based on its metadata
-func (r *Runner) executeAction(ctx context.Context, actionDir string, metadata *ActionMetadata, inputs map[string]string) (string, error) {
+// withShellKey is the with: key that overrides the shell an action runs in
+const withShellKey = "shell"
+
+// executeAction runs the action based on its metadata.
+// A non-empty shellOverride replaces the shell from runs.shell, and the
+// default shell for composite steps that do not set their own.
+func (r *Runner) executeAction(ctx context.Context, actionDir string, metadata *ActionMetadata, inputs map[string]string, shellOverride string) (string, error) {
 	runs := metadata.Runs
 
 	// Prepare environment variables from inputs
@@ -202,7 +221,7 @@ func (r *Runner) executeAction(ctx context.Context, actionDir string, metadata *
 	case "composite", "node12", "node16", "node20":
 		// Composite action or Node.js-based action
 		if len(runs.Steps) > 0 {
-			return r.executeCompositeAction(ctx, actionDir, runs.Steps, env)
+			return r.executeCompositeAction(ctx, actionDir, runs.Steps, env, shellOverride)
 		}
 
 		// Fall through to shell script execution if main is specified
@@ -219,7 +238,10 @@ func (r *Runner) executeAction(ctx context.Context, actionDir string, metadata *
 			return "", fmt.Errorf("shell action has no run command")
 		}
 
-		shell := runs.Shell
+		shell := shellOverride
+		if shell == "" {
+			shell = runs.Shell
+		}
 		if shell == "" {
 			shell = defaultShell()
 		}
@@ -231,8 +253,9 @@ func (r *Runner) executeAction(ctx context.Context, actionDir string, metadata *
 	}
 }
 
-// executeCompositeAction executes composite action steps
-func (r *Runner) executeCompositeAction(ctx context.Context, actionDir string, steps []schema.Step, env []string) (string, error) {
+// executeCompositeAction executes composite action steps.
+// Steps without a shell use defaultShellOverride when set.
+func (r *Runner) executeCompositeAction(ctx context.Context, actionDir string, steps []schema.Step, env []string, defaultShellOverride string) (string, error) {
 	var output string
 
 	for _, step := range steps {
@@ -242,6 +265,9 @@ func (r *Runner) executeCompositeAction(ctx context.Context, actionDir string, s
 		}
 
 		shell := step.Shell
+		if shell == "" {
+			shell = defaultShellOverride
+		}
 		if shell == "" {
 			shell = defaultShell()
 		}
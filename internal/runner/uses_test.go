@@ -1,7 +1,17 @@
 package runner
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
 )
 
 // TestParseUsesStringLocalPath tests parsing local action paths
@@ -169,3 +179,144 @@ func TestParseUsesStringEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUsesStringRemoteHost(t *testing.T) {
+	parsed, err := parseUsesString("github.com/owner/repo/actions/lint@v2")
+	if err != nil {
+		t.Fatalf("parseUsesString() error: %v", err)
+	}
+	if parsed.Host != "github.com" || parsed.Owner != "owner" || parsed.Repo != "repo" || parsed.Version != "v2" {
+		t.Errorf("parseUsesString() = %+v, want host github.com, owner/repo@v2", parsed)
+	}
+	if filepath.ToSlash(parsed.Path) != "actions/lint" {
+		t.Errorf("Path = %q, want actions/lint", parsed.Path)
+	}
+
+	if _, err := parseUsesString("github.com/owner@v1"); err == nil {
+		t.Error("Expected error for host without repo")
+	}
+
+	// Plain owner/repo references have no host
+	parsed, _ = parseUsesString("actions/checkout@v4")
+	if parsed.Host != "" {
+		t.Errorf("Host = %q, want empty", parsed.Host)
+	}
+}
+
+func TestFetchRemoteAction(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+
+	var hits atomic.Int32
+	var actionYAML atomic.Value
+	actionYAML.Store("name: Greet\nruns:\n  using: composite\n  steps:\n    - run: echo \"hello $INPUT_WHO\"\n      shell: bash\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		if req.URL.Path != "/owner/repo/v1/greet/action.yml" {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write([]byte(actionYAML.Load().(string)))
+	}))
+	defer server.Close()
+
+	origBase := rawContentBaseURL
+	rawContentBaseURL = server.URL
+	defer func() { rawContentBaseURL = origBase }()
+
+	workflow := &schema.Workflow{
+		Name: "remote",
+		Steps: []schema.Step{
+			{Name: "greet", Uses: "github.com/owner/repo/greet@v1", With: map[string]string{"who": "world"}},
+		},
+	}
+
+	run := func(noCache bool) StepResult {
+		t.Helper()
+		r := NewRunner(workflow, nil, t.TempDir())
+		r.SetNoActionCache(noCache)
+		results, err := r.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		return results[0]
+	}
+
+	t.Run("fetches and caches", func(t *testing.T) {
+		result := run(false)
+		if !result.Success || !strings.Contains(result.Output, "hello world") {
+			t.Fatalf("Expected remote action to run, got success=%v output=%q err=%v", result.Success, result.Output, result.Error)
+		}
+		if hits.Load() != 1 {
+			t.Errorf("Expected 1 request, got %d", hits.Load())
+		}
+	})
+
+	t.Run("uses cache", func(t *testing.T) {
+		hits.Store(0)
+		actionYAML.Store("name: Greet\nruns:\n  using: shell\n  shell: bash\n  run: echo changed\n")
+		if result := run(false); !strings.Contains(result.Output, "hello world") {
+			t.Errorf("Expected cached action output, got %q", result.Output)
+		}
+		if hits.Load() != 0 {
+			t.Errorf("Expected no requests with a cached action, got %d", hits.Load())
+		}
+	})
+
+	t.Run("no-cache re-fetches", func(t *testing.T) {
+		if result := run(true); !strings.Contains(result.Output, "changed") {
+			t.Errorf("Expected re-fetched action output, got %q", result.Output)
+		}
+	})
+
+	t.Run("default cache location", func(t *testing.T) {
+		matches, _ := filepath.Glob(filepath.Join(os.Getenv("HOME"), ".hookflow", "cache", "actions", "*", "action.yml"))
+		if len(matches) != 1 {
+			t.Errorf("Expected the action under ~/.hookflow/cache/actions, found %v", matches)
+		}
+	})
+
+	t.Run("custom cache dir", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		r := NewRunner(workflow, nil, t.TempDir())
+		r.SetActionCacheDir(cacheDir)
+		results, err := r.Run(context.Background())
+		if err != nil || !results[0].Success {
+			t.Fatalf("Run() = %v, %v", results, err)
+		}
+		matches, _ := filepath.Glob(filepath.Join(cacheDir, "*", "action.yml"))
+		if len(matches) != 1 {
+			t.Errorf("Expected the action cached in %s, found %v", cacheDir, matches)
+		}
+	})
+
+	t.Run("offline falls back to cache", func(t *testing.T) {
+		rawContentBaseURL = "http://127.0.0.1:1"
+		if result := run(true); !result.Success || !strings.Contains(result.Output, "changed") {
+			t.Errorf("Expected cached copy when offline, got success=%v output=%q", result.Success, result.Output)
+		}
+	})
+
+	t.Run("offline without cache fails", func(t *testing.T) {
+		rawContentBaseURL = "http://127.0.0.1:1"
+		r := NewRunner(&schema.Workflow{
+			Name:  "remote",
+			Steps: []schema.Step{{Name: "other", Uses: "github.com/owner/other@v1"}},
+		}, nil, t.TempDir())
+		results, _ := r.Run(context.Background())
+		if results[0].Success || results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "no cached copy") {
+			t.Errorf("Expected clear offline error, got %v", results[0].Error)
+		}
+	})
+}
+
+func TestFetchRemoteActionUnsupportedHost(t *testing.T) {
+	r := NewRunner(&schema.Workflow{Name: "remote"}, nil, t.TempDir())
+	parsed, _ := parseUsesString("gitlab.com/owner/repo@v1")
+	if _, err := r.fetchRemoteAction(context.Background(), parsed); err == nil || !strings.Contains(err.Error(), "unsupported action host") {
+		t.Errorf("Expected unsupported host error, got %v", err)
+	}
+}
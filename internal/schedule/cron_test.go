@@ -0,0 +1,103 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr); err == nil {
+				t.Errorf("Parse(%q) expected error", expr)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	// Monday 2024-01-15 09:30
+	base := time.Date(2024, 1, 15, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		expr string
+		time time.Time
+		want bool
+	}{
+		{"* * * * *", base, true},
+		{"30 9 * * *", base, true},
+		{"0 9 * * *", base, false},
+		{"*/15 * * * *", base, true},
+		{"*/20 * * * *", base, false},
+		{"0,30 9-17 * * *", base, true},
+		{"30 9 * * 1-5", base, true},
+		{"30 9 * * 0,6", base, false},
+		{"30 9 15 * *", base, true},
+		{"30 9 1 * *", base, false},
+		{"30 9 1 * 1", base, true}, // Either day field may match
+		{"30 9 * 2 *", base, false},
+		{"30 9 * * 7", base.AddDate(0, 0, 6), true}, // 7 is Sunday
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+			}
+			if got := c.Matches(tt.time); got != tt.want {
+				t.Errorf("Matches(%s) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	base := time.Date(2024, 1, 15, 9, 30, 45, 0, time.Local)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 9, 31, 0, 0, time.Local)},
+		{"0 2 * * *", time.Date(2024, 1, 16, 2, 0, 0, 0, time.Local)},
+		{"30 9 * * *", time.Date(2024, 1, 16, 9, 30, 0, 0, time.Local)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+			}
+			if got := c.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextNeverFires(t *testing.T) {
+	c, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := c.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Expected zero time for impossible date, got %s", got)
+	}
+}
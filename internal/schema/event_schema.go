@@ -0,0 +1,36 @@
+package schema
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//go:embed event.schema.json
+var embeddedEventSchema []byte
+
+// EventSchema returns the canonical JSON Schema for event JSON passed to
+// hookflow run --event
+func EventSchema() []byte {
+	return embeddedEventSchema
+}
+
+// ValidateEventJSON checks event JSON against a JSON Schema and returns one
+// message per violation. The error is set when the schema or event cannot be
+// loaded at all.
+func ValidateEventJSON(schemaJSON, eventJSON []byte) ([]string, error) {
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(schemaJSON),
+		gojsonschema.NewBytesLoader(eventJSON),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate event: %w", err)
+	}
+
+	var violations []string
+	for _, verr := range result.Errors() {
+		violations = append(violations, verr.String())
+	}
+	return violations, nil
+}
@@ -0,0 +1,374 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Fix codes for validation errors that ValidationResult.Fix can repair
+const (
+	// FixUnknownField removes a property the schema does not allow
+	FixUnknownField = "unknown-field"
+	// FixMissingName sets a missing or empty workflow name from the file name
+	FixMissingName = "missing-name"
+)
+
+// Fix is a mechanical repair for one schema violation
+type Fix struct {
+	Code  string
+	Path  []string // Location of the enclosing mapping, e.g. ["steps", "0"]; empty for the document root
+	Field string   // Property to remove or set
+}
+
+// String describes the fix for display
+func (f Fix) String() string {
+	location := strings.Join(append(append([]string{}, f.Path...), f.Field), ".")
+	switch f.Code {
+	case FixUnknownField:
+		return fmt.Sprintf("remove unknown field '%s'", location)
+	case FixMissingName:
+		return fmt.Sprintf("set '%s' from the file name", location)
+	default:
+		return f.Code + " " + location
+	}
+}
+
+// schemaFix maps a schema violation to a Fix, or returns false when it
+// needs a human
+func schemaFix(err gojsonschema.ResultError) (Fix, bool) {
+	var path []string
+	if field := err.Field(); field != "(root)" {
+		path = strings.Split(field, ".")
+	}
+	property, _ := err.Details()["property"].(string)
+
+	switch err.Type() {
+	case "additional_property_not_allowed":
+		if property != "" {
+			return Fix{Code: FixUnknownField, Path: path, Field: property}, true
+		}
+	case "required":
+		if len(path) == 0 && property == "name" {
+			return Fix{Code: FixMissingName, Field: "name"}, true
+		}
+	case "string_gte":
+		if len(path) == 1 && path[0] == "name" {
+			return Fix{Code: FixMissingName, Field: "name"}, true
+		}
+	}
+	return Fix{}, false
+}
+
+// Fixes returns the fixes available for filePath
+func (r *ValidationResult) Fixes(filePath string) []Fix {
+	var fixes []Fix
+	for _, err := range r.Errors {
+		if err.File == filePath {
+			fixes = append(fixes, err.Fixes...)
+		}
+	}
+	return fixes
+}
+
+// FixContent applies the fixes for filePath to its contents and returns the
+// original and fixed YAML without writing the file
+func (r *ValidationResult) FixContent(filePath string) (before, after []byte, err error) {
+	before, err = os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fixes := r.Fixes(filePath)
+	if len(fixes) == 0 {
+		return before, before, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(before, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	if len(doc.Content) == 0 {
+		return before, before, nil
+	}
+	root := doc.Content[0]
+
+	// A field is removed from the mapping that defines it, which an alias or
+	// merge key may share between several places; every place the schema
+	// reports it from is counted before anything is removed
+	var removals []removal
+	reported := make(map[removal]int)
+	firstFix := make(map[removal]Fix)
+	seen := make(map[string]bool)
+	for _, fix := range fixes {
+		if seen[fix.String()] {
+			continue
+		}
+		seen[fix.String()] = true
+		node := lookupNode(root, fix.Path)
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("cannot %s: location not found", fix)
+		}
+		switch fix.Code {
+		case FixUnknownField:
+			owners := fieldOwners(node, fix.Field)
+			if len(owners) == 0 {
+				return nil, nil, fmt.Errorf("cannot %s: field not found", fix)
+			}
+			for _, owner := range owners {
+				key := removal{owner: owner, field: fix.Field}
+				if reported[key] == 0 {
+					removals = append(removals, key)
+					firstFix[key] = fix
+				}
+				reported[key]++
+			}
+		case FixMissingName:
+			setMappingKey(node, fix.Field, workflowNameFromFile(filePath))
+		}
+	}
+
+	for _, key := range removals {
+		if reported[key] < occurrences(root, key.owner) {
+			return nil, nil, fmt.Errorf("cannot %s: it comes from a block shared by an alias or << merge key where the field is allowed", firstFix[key])
+		}
+		i := mappingKeyIndex(key.owner, key.field)
+		for _, node := range key.owner.Content[i : i+2] {
+			if anchor := anchorUsedOutside(root, node); anchor != "" {
+				return nil, nil, fmt.Errorf("cannot %s: its value defines anchor &%s, which is used elsewhere", firstFix[key], anchor)
+			}
+		}
+	}
+	for _, key := range removals {
+		i := mappingKeyIndex(key.owner, key.field)
+		key.owner.Content = append(key.owner.Content[:i], key.owner.Content[i+2:]...)
+	}
+
+	clearMergeTags(root)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to write %s: %w", filePath, err)
+	}
+	_ = enc.Close()
+
+	// Never hand back a file that no longer parses
+	var check interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &check); err != nil {
+		return nil, nil, fmt.Errorf("fixing %s would produce invalid YAML: %w", filePath, err)
+	}
+	return before, buf.Bytes(), nil
+}
+
+// removal is an unknown field to delete from the mapping that defines it
+type removal struct {
+	owner *yaml.Node
+	field string
+}
+
+// Fix applies the fixes for filePath and overwrites the file.
+// Errors without a fix are left for the caller to report.
+func (r *ValidationResult) Fix(filePath string) error {
+	before, after, err := r.FixContent(filePath)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, after) {
+		return nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, after, info.Mode().Perm())
+}
+
+// lookupNode follows mapping keys and sequence indexes from node. Aliases
+// are followed to their anchor and keys pulled in by << merge keys are
+// found in the mapping they come from, so a fix applies to every use of it.
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, segment := range path {
+		node = resolveAlias(node)
+		switch node.Kind {
+		case yaml.MappingNode:
+			next := mappingValue(node, segment)
+			if next == nil {
+				return nil
+			}
+			node = next
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[i]
+		default:
+			return nil
+		}
+	}
+	return resolveAlias(node)
+}
+
+// resolveAlias returns the node an alias refers to, or node itself
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// mergeTag is the tag yaml.v3 gives the key of a << merge
+const mergeTag = "!!merge"
+
+// mergeSources returns node followed by the mappings its << merge keys pull
+// in, in the order their keys take effect: node's own keys win, then each
+// merged mapping with its own merges
+func mergeSources(node *yaml.Node) []*yaml.Node {
+	sources := []*yaml.Node{node}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != mergeTag {
+			continue
+		}
+		value := resolveAlias(node.Content[i+1])
+		items := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			items = value.Content
+		}
+		for _, item := range items {
+			if item = resolveAlias(item); item.Kind == yaml.MappingNode {
+				sources = append(sources, mergeSources(item)...)
+			}
+		}
+	}
+	return sources
+}
+
+// mappingKeyIndex returns the index of key in a mapping node's content,
+// ignoring merge keys, or -1
+func mappingKeyIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != mergeTag && node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value of key in a mapping node, including keys
+// pulled in by << merge keys, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for _, source := range mergeSources(node) {
+		if i := mappingKeyIndex(source, key); i >= 0 {
+			return source.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// fieldOwners returns the mappings that define key for node: node itself
+// and any mapping it merges in
+func fieldOwners(node *yaml.Node, key string) []*yaml.Node {
+	var owners []*yaml.Node
+	for _, source := range mergeSources(node) {
+		if mappingKeyIndex(source, key) >= 0 {
+			owners = append(owners, source)
+		}
+	}
+	return owners
+}
+
+// occurrences counts the places target appears in the document under node
+// once aliases and merge keys are expanded
+func occurrences(node, target *yaml.Node) int {
+	node = resolveAlias(node)
+	if node == target {
+		return 1
+	}
+	count := 0
+	for _, child := range node.Content {
+		count += occurrences(child, target)
+	}
+	return count
+}
+
+// anchorUsedOutside returns an anchor defined in subtree that an alias
+// elsewhere under root refers to, or ""
+func anchorUsedOutside(root, subtree *yaml.Node) string {
+	defined := make(map[*yaml.Node]bool)
+	var collect func(node *yaml.Node)
+	collect = func(node *yaml.Node) {
+		if node.Anchor != "" {
+			defined[node] = true
+		}
+		for _, child := range node.Content {
+			collect(child)
+		}
+	}
+	collect(subtree)
+	if len(defined) == 0 {
+		return ""
+	}
+
+	var find func(node *yaml.Node) string
+	find = func(node *yaml.Node) string {
+		if node == subtree {
+			return ""
+		}
+		if node.Kind == yaml.AliasNode && defined[node.Alias] {
+			return node.Alias.Anchor
+		}
+		for _, child := range node.Content {
+			if anchor := find(child); anchor != "" {
+				return anchor
+			}
+		}
+		return ""
+	}
+	return find(root)
+}
+
+// clearMergeTags drops the tag of << merge keys, which the encoder would
+// otherwise write out as "!!merge <<"
+func clearMergeTags(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == mergeTag {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		clearMergeTags(child)
+	}
+}
+
+// setMappingKey sets key to a string value, adding it first when missing
+func setMappingKey(node *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+			return
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	// Keep a leading comment at the top of the mapping
+	if len(node.Content) > 0 {
+		keyNode.HeadComment = node.Content[0].HeadComment
+		node.Content[0].HeadComment = ""
+	}
+	node.Content = append([]*yaml.Node{
+		keyNode,
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	}, node.Content...)
+}
+
+// workflowNameFromFile derives a workflow name from its file name
+func workflowNameFromFile(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,200 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Warning codes emitted by the linter on top of the validator's warnings
+const (
+	// LintUnnamedStep flags steps without a name
+	LintUnnamedStep = "unnamed-step"
+	// LintLongRun flags inline run scripts that belong in a script file
+	LintLongRun = "long-run"
+	// LintNoFailingStep flags blocking workflows where no step can fail
+	LintNoFailingStep = "no-failing-step"
+	// LintDuplicateName flags workflow names used by more than one file
+	LintDuplicateName = "duplicate-name"
+	// LintImplicitLifecycle flags tool, file, commit and push triggers relying on the pre default
+	LintImplicitLifecycle = "implicit-lifecycle"
+)
+
+// maxInlineRunLength is the longest run script the linter accepts before
+// suggesting a script file
+const maxInlineRunLength = 200
+
+// LintWorkflow validates a single workflow file and adds style warnings
+// for a file that passes validation
+func LintWorkflow(filePath string) *ValidationResult {
+	return LintWorkflowWithOptions(filePath, ValidateOptions{})
+}
+
+// LintWorkflowWithOptions lints a single workflow file, validating it with
+// the given options
+func LintWorkflowWithOptions(filePath string, opts ValidateOptions) *ValidationResult {
+	result := ValidateWorkflowWithOptions(filePath, opts)
+	if !result.Valid {
+		return result
+	}
+	if workflow, err := readLintWorkflow(filePath); err == nil {
+		result.Warnings = append(result.Warnings, checkWorkflowStyle(filePath, workflow)...)
+	}
+	return result
+}
+
+// LintWorkflowsInDir validates all workflow files in a directory and adds
+// style warnings for the valid ones, including names shared across files
+func LintWorkflowsInDir(dir string) *ValidationResult {
+	return LintWorkflowsInDirWithOptions(dir, ValidateOptions{})
+}
+
+// LintWorkflowsInDirWithOptions lints all workflow files in a directory,
+// validating them with the given options
+func LintWorkflowsInDirWithOptions(dir string, opts ValidateOptions) *ValidationResult {
+	result := ValidateWorkflowsInDirWithOptions(dir, opts)
+
+	invalid := make(map[string]bool)
+	for _, err := range result.Errors {
+		invalid[err.File] = true
+	}
+
+	filesByName := make(map[string][]string)
+	var names []string
+	_ = filepath.Walk(filepath.Join(dir, ".github", "hookflows"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || invalid[path] {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+		workflow, err := readLintWorkflow(path)
+		if err != nil {
+			return nil
+		}
+		result.Warnings = append(result.Warnings, checkWorkflowStyle(path, workflow)...)
+		if _, seen := filesByName[workflow.Name]; !seen {
+			names = append(names, workflow.Name)
+		}
+		filesByName[workflow.Name] = append(filesByName[workflow.Name], path)
+		return nil
+	})
+
+	sort.Strings(names)
+	for _, name := range names {
+		files := filesByName[name]
+		if len(files) < 2 {
+			continue
+		}
+		for _, file := range files {
+			var others []string
+			for _, other := range files {
+				if other != file {
+					others = append(others, filepath.Base(other))
+				}
+			}
+			result.Warnings = append(result.Warnings, ValidationWarning{
+				File:    file,
+				Code:    LintDuplicateName,
+				Message: fmt.Sprintf("workflow name '%s' is also used by %s", name, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	return result
+}
+
+// readLintWorkflow parses a workflow file as written, without resolving extends
+func readLintWorkflow(filePath string) (*Workflow, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var workflow Workflow
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+	return &workflow, nil
+}
+
+// checkWorkflowStyle inspects a valid workflow for style issues that do not
+// change how it runs
+func checkWorkflowStyle(filePath string, workflow *Workflow) []ValidationWarning {
+	var warnings []ValidationWarning
+
+	for i, step := range workflow.Steps {
+		if step.Name == "" {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    LintUnnamedStep,
+				Message: fmt.Sprintf("step %d has no name; add one so results and logs are easy to follow", i+1),
+			})
+		}
+		if len(step.Run) > maxInlineRunLength {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    LintLongRun,
+				Message: fmt.Sprintf("step '%s' has a %d character run script; consider moving it to a script file", stepLabel(step, i), len(step.Run)),
+			})
+		}
+	}
+
+	// A base workflow may supply the steps, so only check workflows that stand alone
+	if workflow.IsBlocking() && workflow.Extends == "" && len(workflow.Steps) > 0 {
+		canFail := false
+		for _, step := range workflow.Steps {
+			if !workflow.ContinuesOnError(step) && !isNeverCondition(step.If) {
+				canFail = true
+				break
+			}
+		}
+		if !canFail {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    LintNoFailingStep,
+				Message: "workflow is blocking but every step has continue-on-error or if: never(), so it can never deny",
+			})
+		}
+	}
+
+	implicitLifecycle := func(trigger string) {
+		warnings = append(warnings, ValidationWarning{
+			File:    filePath,
+			Code:    LintImplicitLifecycle,
+			Message: fmt.Sprintf("on.%s has no lifecycle and defaults to pre; set 'lifecycle: pre' to make it explicit", trigger),
+		})
+	}
+	// Tool triggers used to fire for every lifecycle, so the default changed
+	// what they match
+	implicitToolLifecycle := func(trigger string) {
+		warnings = append(warnings, ValidationWarning{
+			File:    filePath,
+			Code:    LintImplicitLifecycle,
+			Message: fmt.Sprintf("on.%s has no lifecycle and now fires only before the tool runs (pre); tool triggers used to fire for every lifecycle. Set 'lifecycle: any' to keep that, or 'lifecycle: pre' to make the default explicit", trigger),
+		})
+	}
+	if tool := workflow.On.Tool; tool != nil && tool.Lifecycle == "" {
+		implicitToolLifecycle("tool")
+	}
+	for i, tool := range workflow.On.Tools {
+		if tool.Lifecycle == "" {
+			implicitToolLifecycle(fmt.Sprintf("tools[%d]", i))
+		}
+	}
+	if file := workflow.On.File; file != nil && file.Lifecycle == "" {
+		implicitLifecycle("file")
+	}
+	if commit := workflow.On.Commit; commit != nil && commit.Lifecycle == "" {
+		implicitLifecycle("commit")
+	}
+	if push := workflow.On.Push; push != nil && push.Lifecycle == "" {
+		implicitLifecycle("push")
+	}
+
+	return warnings
+}
@@ -3,31 +3,177 @@ package schema
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // LoadWorkflow loads a workflow from a YAML file
 func LoadWorkflow(filePath string) (*Workflow, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	return loadWorkflowFile(absPath, nil)
+}
+
+// LoadWorkflowFromBytes parses a workflow from YAML data.
+// baseDir is used to resolve a relative extends path.
+func LoadWorkflowFromBytes(data []byte, baseDir string) (*Workflow, error) {
+	return parseWorkflow(data, baseDir, nil)
+}
+
+// loadWorkflowFile reads and parses a workflow file, tracking the chain of
+// files visited through extends so that cycles can be reported
+func loadWorkflowFile(filePath string, chain []string) (*Workflow, error) {
+	for _, visited := range chain {
+		if visited == filePath {
+			return nil, fmt.Errorf("circular extends chain: %s", strings.Join(append(chain, filePath), " -> "))
+		}
+	}
+
 	// Read the file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read workflow file: %w", err)
 	}
 
+	return parseWorkflow(data, filepath.Dir(filePath), append(chain, filePath))
+}
+
+// parseWorkflow parses YAML data and resolves extends relative to baseDir
+func parseWorkflow(data []byte, baseDir string, chain []string) (*Workflow, error) {
 	// Parse YAML
 	var workflow Workflow
 	if err := yaml.Unmarshal(data, &workflow); err != nil {
 		return nil, fmt.Errorf("failed to parse workflow YAML: %w", err)
 	}
 
-	return &workflow, nil
+	if workflow.Extends == "" {
+		return &workflow, nil
+	}
+
+	parentPath := workflow.Extends
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(baseDir, parentPath)
+	}
+
+	parent, err := loadWorkflowFile(filepath.Clean(parentPath), chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extended workflow %s: %w", workflow.Extends, err)
+	}
+
+	return mergeWorkflows(parent, &workflow), nil
+}
+
+// ExtendsChain returns the absolute paths of the workflows filePath extends,
+// nearest base first. A base that cannot be read or parsed ends the chain
+// but is still listed, so a caller watching the chain sees it appear.
+func ExtendsChain(filePath string) []string {
+	current, err := filepath.Abs(filePath)
+	if err != nil {
+		current = filePath
+	}
+
+	var chain []string
+	visited := map[string]bool{current: true}
+	for {
+		data, err := os.ReadFile(current)
+		if err != nil {
+			return chain
+		}
+		var header struct {
+			Extends string `yaml:"extends"`
+		}
+		if err := yaml.Unmarshal(data, &header); err != nil || header.Extends == "" {
+			return chain
+		}
+
+		parent := header.Extends
+		if !filepath.IsAbs(parent) {
+			parent = filepath.Join(filepath.Dir(current), parent)
+		}
+		parent = filepath.Clean(parent)
+		if visited[parent] {
+			return chain
+		}
+		visited[parent] = true
+		chain = append(chain, parent)
+		current = parent
+	}
+}
+
+// mergeWorkflows layers child on top of parent. Parent steps run first,
+// child env values win, and child triggers replace parent triggers of the
+// same type.
+func mergeWorkflows(parent, child *Workflow) *Workflow {
+	merged := *child
+
+	if merged.RunName == "" {
+		merged.RunName = parent.RunName
+	}
+	if merged.Description == "" {
+		merged.Description = parent.Description
+	}
+	if merged.Blocking == nil {
+		merged.Blocking = parent.Blocking
+	}
+	if merged.ContinueOnError == nil {
+		merged.ContinueOnError = parent.ContinueOnError
+	}
+	if merged.Concurrency == nil {
+		merged.Concurrency = parent.Concurrency
+	}
+
+	if len(parent.Env) > 0 || len(child.Env) > 0 {
+		merged.Env = make(map[string]string, len(parent.Env)+len(child.Env))
+		for k, v := range parent.Env {
+			merged.Env[k] = v
+		}
+		for k, v := range child.Env {
+			merged.Env[k] = v
+		}
+	}
+
+	merged.On = parent.On
+	if child.On.Hooks != nil {
+		merged.On.Hooks = child.On.Hooks
+	}
+	if child.On.Tool != nil {
+		merged.On.Tool = child.On.Tool
+	}
+	if len(child.On.Tools) > 0 {
+		merged.On.Tools = append(append([]ToolTrigger{}, parent.On.Tools...), child.On.Tools...)
+	}
+	if child.On.File != nil {
+		merged.On.File = child.On.File
+	}
+	if child.On.Commit != nil {
+		merged.On.Commit = child.On.Commit
+	}
+	if child.On.Push != nil {
+		merged.On.Push = child.On.Push
+	}
+	if child.On.Schedule != nil {
+		merged.On.Schedule = child.On.Schedule
+	}
+
+	merged.Steps = append(append([]Step{}, parent.Steps...), child.Steps...)
+
+	return &merged
 }
 
 // LoadAndValidateWorkflow loads and validates a workflow using JSON schema
 func LoadAndValidateWorkflow(filePath string) (*Workflow, error) {
+	return LoadAndValidateWorkflowWithOptions(filePath, ValidateOptions{})
+}
+
+// LoadAndValidateWorkflowWithOptions loads a workflow after validating it
+// with the given options
+func LoadAndValidateWorkflowWithOptions(filePath string, opts ValidateOptions) (*Workflow, error) {
 	// First validate with JSON schema
-	result := ValidateWorkflow(filePath)
+	result := ValidateWorkflowWithOptions(filePath, opts)
 	if !result.Valid {
 		// Return first error
 		if len(result.Errors) > 0 {
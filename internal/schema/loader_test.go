@@ -1,9 +1,12 @@
 package schema
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // ============================================================================
@@ -191,6 +194,186 @@ func TestWorkflow_IsBlocking_ExplicitFalse(t *testing.T) {
 	}
 }
 
+func TestWorkflow_ContinuesOnError(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		workflow, step *bool
+		want           bool
+	}{
+		{nil, nil, false},
+		{&yes, nil, true},
+		{&no, nil, false},
+		{&yes, &no, false},
+		{&no, &yes, true},
+		{nil, &yes, true},
+	}
+	for _, tt := range tests {
+		workflow := &Workflow{ContinueOnError: tt.workflow}
+		if got := workflow.ContinuesOnError(Step{ContinueOnError: tt.step}); got != tt.want {
+			t.Errorf("ContinuesOnError(workflow %v, step %v) = %v, want %v", tt.workflow, tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestLoadWorkflow_ContinueOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(tmpDir, "base.yml"), `name: Notify
+continue-on-error: true
+on:
+  push: {}
+steps:
+  - name: Post
+    run: echo post
+`)
+	writeWorkflowFile(t, filepath.Join(tmpDir, "child.yml"), `name: Notify more
+extends: ./base.yml
+steps:
+  - name: Required
+    continue-on-error: false
+    run: echo required
+`)
+
+	workflow, err := LoadWorkflow(filepath.Join(tmpDir, "child.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load workflow: %v", err)
+	}
+	if workflow.ContinueOnError == nil || !*workflow.ContinueOnError {
+		t.Fatalf("Expected continue-on-error inherited from the base, got %v", workflow.ContinueOnError)
+	}
+	if !workflow.ContinuesOnError(workflow.Steps[0]) || workflow.ContinuesOnError(workflow.Steps[1]) {
+		t.Errorf("Expected only the step without its own setting to continue on error, got %+v", workflow.Steps)
+	}
+	if result := ValidateWorkflow(filepath.Join(tmpDir, "base.yml")); !result.Valid {
+		t.Errorf("Expected workflow-level continue-on-error to be valid, got: %v", result.Errors)
+	}
+}
+
+func TestLoadWorkflow_Anchors(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "anchors.yml")
+	writeWorkflowFile(t, path, `name: Anchors
+on:
+  file:
+    paths: &sources ['src/**', 'lib/**']
+  commit:
+    paths: *sources
+env: &defaults
+  STAGE: test
+  LEVEL: debug
+steps:
+  - name: Vet
+    run: &check |
+      go vet ./...
+      go test ./...
+    env:
+      <<: *defaults
+      LEVEL: info
+  - name: Check again
+    run: *check
+    env: *defaults
+`)
+
+	workflow, err := LoadWorkflow(path)
+	if err != nil {
+		t.Fatalf("Failed to load workflow: %v", err)
+	}
+	want := "go vet ./...\ngo test ./...\n"
+	if workflow.Steps[0].Run != want || workflow.Steps[1].Run != want {
+		t.Errorf("Expected the aliased run to match the anchor, got %q and %q", workflow.Steps[0].Run, workflow.Steps[1].Run)
+	}
+	if got := workflow.On.Commit.Paths; len(got) != 2 || got[1] != "lib/**" {
+		t.Errorf("Expected aliased commit paths, got %v", got)
+	}
+	if env := workflow.Steps[0].Env; env["STAGE"] != "test" || env["LEVEL"] != "info" {
+		t.Errorf("Expected the merge key to add STAGE and keep the step's LEVEL, got %v", env)
+	}
+	if env := workflow.Steps[1].Env; env["STAGE"] != "test" || env["LEVEL"] != "debug" {
+		t.Errorf("Expected the aliased env, got %v", env)
+	}
+	if result := ValidateWorkflow(path); !result.Valid {
+		t.Errorf("Expected a workflow with anchors to be valid, got: %v", result.Errors)
+	}
+}
+
+func TestEvent_Equal(t *testing.T) {
+	base := func() *Event {
+		return &Event{
+			Hook: &HookEvent{Type: "preToolUse", Cwd: "/repo", SessionID: "s1",
+				Tool: &ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "a.go"}}},
+			Tool: &ToolEvent{Name: "edit", HookType: "preToolUse", Args: map[string]interface{}{
+				"path": "a.go", "old_str": "x", "new_str": "y",
+				"options": map[string]interface{}{"force": true, "lines": []interface{}{1.0, 2.0}},
+			}},
+			File:      &FileEvent{Path: "a.go", Action: "edit", ChangedLines: 1},
+			Cwd:       "/repo",
+			Timestamp: "2026-01-01T00:00:00Z",
+			Metadata:  map[string]string{"source": "watcher", "team": "core"},
+		}
+	}
+	commit := func() *Event {
+		return &Event{Cwd: "/repo", Commit: &CommitEvent{SHA: "abc", Message: "fix", Branch: "main",
+			Files: []FileStatus{{Path: "a.go", Status: "modified"}}, Diff: "+x"}}
+	}
+	push := func() *Event {
+		return &Event{Cwd: "/repo", Push: &PushEvent{Ref: "refs/heads/main", Before: "a", After: "b",
+			Commits: []CommitEvent{{SHA: "b"}}, Files: []FileStatus{{Path: "a.go", Status: "added"}}}}
+	}
+
+	tests := []struct {
+		name  string
+		a, b  *Event
+		equal bool
+	}{
+		{"identical", base(), base(), true},
+		{"timestamp ignored", base(), func() *Event { e := base(); e.Timestamp = "later"; return e }(), true},
+		{"default lifecycle", base(), func() *Event { e := base(); e.Lifecycle = "pre"; return e }(), true},
+		{"different lifecycle", base(), func() *Event { e := base(); e.Lifecycle = "post"; return e }(), false},
+		{"different cwd", base(), func() *Event { e := base(); e.Cwd = "/other"; return e }(), false},
+		{"maps built in another order", base(), func() *Event {
+			e := base()
+			e.Metadata = map[string]string{"team": "core", "source": "watcher"}
+			e.Tool.Args = map[string]interface{}{
+				"options": map[string]interface{}{"lines": []interface{}{1.0, 2.0}, "force": true},
+				"new_str": "y", "old_str": "x", "path": "a.go",
+			}
+			return e
+		}(), true},
+		{"different nested arg", base(), func() *Event {
+			e := base()
+			e.Tool.Args["options"].(map[string]interface{})["lines"] = []interface{}{2.0, 1.0}
+			return e
+		}(), false},
+		{"extra arg", base(), func() *Event { e := base(); e.Tool.Args["extra"] = nil; return e }(), false},
+		{"different arg type", base(), func() *Event { e := base(); e.Tool.Args["path"] = 1.0; return e }(), false},
+		{"nil and empty metadata", &Event{Cwd: "/repo"}, &Event{Cwd: "/repo", Metadata: map[string]string{}}, true},
+		{"different metadata", base(), func() *Event { e := base(); e.Metadata["team"] = "infra"; return e }(), false},
+		{"nil hook tool", base(), func() *Event { e := base(); e.Hook.Tool = nil; return e }(), false},
+		{"missing file", base(), func() *Event { e := base(); e.File = nil; return e }(), false},
+		{"file content", base(), func() *Event { e := base(); e.File.Content = "x"; return e }(), false},
+		{"commits", commit(), commit(), true},
+		{"commit files", commit(), func() *Event { e := commit(); e.Commit.Files[0].Status = "added"; return e }(), false},
+		{"nil and empty commit files", &Event{Commit: &CommitEvent{SHA: "a"}}, &Event{Commit: &CommitEvent{SHA: "a", Files: []FileStatus{}}}, true},
+		{"pushes", push(), push(), true},
+		{"push commits", push(), func() *Event { e := push(); e.Push.Commits[0].SHA = "c"; return e }(), false},
+		{"commit and push", commit(), push(), false},
+		{"schedules", &Event{Schedule: &ScheduleEvent{Cron: "0 * * * *"}}, &Event{Schedule: &ScheduleEvent{Cron: "0 * * * *"}}, true},
+		{"different schedules", &Event{Schedule: &ScheduleEvent{Cron: "0 * * * *"}}, &Event{Schedule: &ScheduleEvent{Cron: "5 * * * *"}}, false},
+		{"empty events", &Event{}, &Event{}, true},
+		{"nil and empty event", nil, &Event{}, false},
+		{"both nil", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.equal {
+				t.Errorf("a.Equal(b) = %v, want %v", got, tt.equal)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.equal {
+				t.Errorf("b.Equal(a) = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // Timeout Validation Tests
 // ============================================================================
@@ -698,6 +881,36 @@ func TestNewDenyResult(t *testing.T) {
 	}
 }
 
+func TestResultTimestamps(t *testing.T) {
+	before := time.Now()
+	for _, result := range []*WorkflowResult{NewAllowResult(), NewDenyResult("no")} {
+		if result.StartedAt.Before(before) || !result.FinishedAt.Equal(result.StartedAt) {
+			t.Errorf("%s: StartedAt=%v FinishedAt=%v, want both now", result.PermissionDecision, result.StartedAt, result.FinishedAt)
+		}
+	}
+
+	result := &WorkflowResult{
+		PermissionDecision: "allow",
+		StartedAt:          time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		FinishedAt:         time.Date(2026, 3, 1, 12, 0, 1, 500_000_000, time.UTC),
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"startedAt":"2026-03-01T12:00:00Z"`, `"finishedAt":"2026-03-01T12:00:01.5Z"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s does not contain %s", data, want)
+		}
+	}
+
+	// Results without timestamps leave the fields out
+	data, _ = json.Marshal(&WorkflowResult{PermissionDecision: "allow"})
+	if strings.Contains(string(data), "startedAt") || strings.Contains(string(data), "finishedAt") {
+		t.Errorf("Expected zero timestamps to be omitted, got %s", data)
+	}
+}
+
 func TestNewDenyResult_EmptyReason(t *testing.T) {
 	result := NewDenyResult("")
 	if result.PermissionDecision != "deny" {
@@ -781,6 +994,149 @@ steps: []
 	}
 }
 
+// ============================================================================
+// Extends Tests
+// ============================================================================
+
+const extendsBaseWorkflow = `name: Base Security
+run-name: Security checks for ${{ event.file.path }}
+description: Common checks
+on:
+  file:
+    paths: ['**/*.go']
+env:
+  LEVEL: strict
+  SHARED: base
+steps:
+  - name: Secret scan
+    run: echo scan
+`
+
+func TestLoadWorkflow_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(tmpDir, "base.yml"), extendsBaseWorkflow)
+	writeWorkflowFile(t, filepath.Join(tmpDir, "child.yml"), `name: Project Checks
+extends: ./base.yml
+on:
+  commit: {}
+env:
+  SHARED: child
+steps:
+  - name: Lint
+    run: echo lint
+`)
+
+	workflow, err := LoadWorkflow(filepath.Join(tmpDir, "child.yml"))
+	if err != nil {
+		t.Fatalf("Failed to load extending workflow: %v", err)
+	}
+	if workflow.Name != "Project Checks" {
+		t.Errorf("Expected child name, got '%s'", workflow.Name)
+	}
+	if workflow.Description != "Common checks" {
+		t.Errorf("Expected inherited description, got '%s'", workflow.Description)
+	}
+	if workflow.RunName != "Security checks for ${{ event.file.path }}" {
+		t.Errorf("Expected inherited run-name, got '%s'", workflow.RunName)
+	}
+	if len(workflow.Steps) != 2 || workflow.Steps[0].Name != "Secret scan" || workflow.Steps[1].Name != "Lint" {
+		t.Errorf("Expected parent steps before child steps, got %+v", workflow.Steps)
+	}
+	if workflow.Env["LEVEL"] != "strict" || workflow.Env["SHARED"] != "child" {
+		t.Errorf("Expected merged env with child override, got %v", workflow.Env)
+	}
+	if workflow.On.File == nil || workflow.On.Commit == nil {
+		t.Errorf("Expected file and commit triggers to be merged, got %+v", workflow.On)
+	}
+}
+
+func TestLoadWorkflowFromBytes_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(tmpDir, "base.yml"), extendsBaseWorkflow)
+
+	workflow, err := LoadWorkflowFromBytes([]byte(`name: Inline
+extends: base.yml
+steps:
+  - run: echo child
+`), tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load workflow from bytes: %v", err)
+	}
+	if len(workflow.Steps) != 2 {
+		t.Errorf("Expected 2 steps, got %d", len(workflow.Steps))
+	}
+	if workflow.On.File == nil {
+		t.Error("Expected file trigger inherited from base")
+	}
+}
+
+func TestLoadWorkflow_ExtendsCircular(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(tmpDir, "a.yml"), `name: A
+extends: b.yml
+steps:
+  - run: echo a
+`)
+	writeWorkflowFile(t, filepath.Join(tmpDir, "b.yml"), `name: B
+extends: a.yml
+steps:
+  - run: echo b
+`)
+
+	_, err := LoadWorkflow(filepath.Join(tmpDir, "a.yml"))
+	if err == nil || !strings.Contains(err.Error(), "circular extends") {
+		t.Fatalf("Expected circular extends error, got %v", err)
+	}
+
+	result := ValidateWorkflow(filepath.Join(tmpDir, "a.yml"))
+	if result.Valid {
+		t.Error("Expected circular extends to fail validation")
+	}
+}
+
+func TestLoadWorkflow_ExtendsMissingParent(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeWorkflowFile(t, filepath.Join(tmpDir, "child.yml"), `name: Child
+extends: missing.yml
+steps:
+  - run: echo child
+`)
+
+	if _, err := LoadWorkflow(filepath.Join(tmpDir, "child.yml")); err == nil {
+		t.Error("Expected error for missing extended workflow")
+	}
+}
+
+func TestExtendsChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeWorkflowFile(t, filepath.Join(tmpDir, "shared", "root.yml"), "name: Root\n")
+	writeWorkflowFile(t, filepath.Join(tmpDir, "shared", "base.yml"), "name: Base\nextends: root.yml\n")
+	writeWorkflowFile(t, filepath.Join(tmpDir, "child.yml"), "name: Child\nextends: shared/base.yml\n")
+	writeWorkflowFile(t, filepath.Join(tmpDir, "orphan.yml"), "name: Orphan\nextends: missing.yml\n")
+	writeWorkflowFile(t, filepath.Join(tmpDir, "a.yml"), "name: A\nextends: b.yml\n")
+	writeWorkflowFile(t, filepath.Join(tmpDir, "b.yml"), "name: B\nextends: a.yml\n")
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"child.yml", []string{filepath.Join(tmpDir, "shared", "base.yml"), filepath.Join(tmpDir, "shared", "root.yml")}},
+		{"shared/root.yml", nil},
+		// A missing base is listed so that creating it can be noticed
+		{"orphan.yml", []string{filepath.Join(tmpDir, "missing.yml")}},
+		{"a.yml", []string{filepath.Join(tmpDir, "b.yml")}},
+	}
+	for _, tt := range tests {
+		got := ExtendsChain(filepath.Join(tmpDir, tt.file))
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ExtendsChain(%s) = %v, want %v", tt.file, got, tt.want)
+		}
+	}
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -792,3 +1148,9 @@ func assertHasValidationError(t *testing.T, result *ValidationResult) {
 	}
 }
 
+func writeWorkflowFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow file: %v", err)
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/htekdev/gh-hookflow/internal/expression"
+	"github.com/htekdev/gh-hookflow/internal/schedule"
 	"github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v3"
 )
@@ -17,19 +24,132 @@ var embeddedSchema []byte
 
 // ValidationError represents a validation error
 type ValidationError struct {
-	File    string
-	Message string
-	Details []string
+	File    string   `json:"file"`
+	Code    string   `json:"code"` // One of the Err* codes, for tools that classify errors
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+	Fixes   []Fix    `json:"-"` // Mechanical repairs for some of the details, applied by ValidationResult.Fix
+}
+
+// ValidationWarning represents a non-fatal issue that does not make a workflow invalid
+type ValidationWarning struct {
+	File    string `json:"file"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes set on ValidationError.Code
+const (
+	// ErrReadFailed flags files that do not exist or cannot be read
+	ErrReadFailed = "read-failed"
+	// ErrInvalidYAML flags files that are not valid YAML
+	ErrInvalidYAML = "invalid-yaml"
+	// ErrUnknownField flags properties the schema does not allow
+	ErrUnknownField = "unknown-field"
+	// ErrMissingRequired flags required properties that are missing
+	ErrMissingRequired = "missing-required"
+	// ErrSchemaViolation flags other schema violations, or a mix of kinds
+	ErrSchemaViolation = "schema-violation"
+	// ErrInvalidExtends flags extends: chains with a missing or circular base
+	ErrInvalidExtends = "invalid-extends"
+	// ErrConflictingFields flags fields that cannot be used together
+	ErrConflictingFields = "conflicting-fields"
+	// ErrInvalidCron flags on.schedule cron expressions that do not parse
+	ErrInvalidCron = "invalid-cron"
+	// ErrInvalidGlob flags malformed path, branch or tag patterns
+	ErrInvalidGlob = "invalid-glob"
+	// ErrInvalidRegex flags malformed =~ and !~ patterns in if: conditions
+	ErrInvalidRegex = "invalid-regex"
+	// ErrUnknownShell flags step shells that are not in KnownShells
+	ErrUnknownShell = "unknown-shell"
+	// ErrInternal flags failures of the validator itself, such as a bad schema
+	ErrInternal = "internal"
+)
+
+// Warning codes emitted by the validator
+const (
+	// WarnNeverCondition flags steps permanently disabled with if: never()
+	WarnNeverCondition = "never-condition"
+	// WarnPartialDoubleStar flags branch or tag patterns where ** is not a whole segment
+	WarnPartialDoubleStar = "partial-double-star"
+	// WarnCmdShell flags steps using shell: cmd on a host that is not Windows
+	WarnCmdShell = "cmd-shell"
+	// WarnPlatformShell flags steps using bash or sh on Windows, where they
+	// are often missing
+	WarnPlatformShell = "platform-shell"
+	// WarnUndefinedEnvRef flags $ENV: env values naming an unset environment variable
+	WarnUndefinedEnvRef = "undefined-env-ref"
+	// WarnDeprecatedField flags fields that still work but have been renamed
+	WarnDeprecatedField = "deprecated-field"
+	// WarnUnreachableStep flags steps whose if: requires failure() where no
+	// earlier step can fail without skipping them
+	WarnUnreachableStep = "unreachable-step"
+	// WarnUnknownLifecycle flags trigger lifecycles that are neither built in
+	// nor named in $HOOKFLOW_LIFECYCLE_MAP, which are usually typos
+	WarnUnknownLifecycle = "unknown-lifecycle"
+)
+
+// LifecycleMapEnv maps custom hook event types to lifecycles, e.g. notification=post,background=post
+const LifecycleMapEnv = "HOOKFLOW_LIFECYCLE_MAP"
+
+// ParseLifecycleMap parses comma-separated type=lifecycle pairs. Malformed
+// entries are skipped and returned as invalid.
+func ParseLifecycleMap(value string) (mapping map[string]string, invalid []string) {
+	mapping = make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eventType, lifecycle, ok := strings.Cut(entry, "=")
+		eventType, lifecycle = strings.TrimSpace(eventType), strings.TrimSpace(lifecycle)
+		if !ok || eventType == "" || lifecycle == "" {
+			invalid = append(invalid, entry)
+			continue
+		}
+		mapping[eventType] = lifecycle
+	}
+	return mapping, invalid
+}
+
+// hostOS is the operating system shell warnings are checked against, replaced in tests
+var hostOS = runtime.GOOS
+
+// KnownShells are the shells a step's shell: accepts
+var KnownShells = []string{"bash", "sh", "pwsh", "powershell", "cmd", "python", "node"}
+
+// ValidateOptions changes what validation accepts
+type ValidateOptions struct {
+	// AllowUnknownShells accepts shells outside KnownShells, which the runner
+	// starts as <shell> -c <command>
+	AllowUnknownShells bool
+}
+
+// IsKnownShell reports whether shell is one of KnownShells
+func IsKnownShell(shell string) bool {
+	for _, known := range KnownShells {
+		if shell == known {
+			return true
+		}
+	}
+	return false
 }
 
 // ValidationResult contains the results of validating workflows
 type ValidationResult struct {
-	Valid  bool
-	Errors []ValidationError
+	Valid    bool
+	Errors   []ValidationError
+	Warnings []ValidationWarning
 }
 
 // ValidateWorkflow validates a single workflow file against the schema
 func ValidateWorkflow(filePath string) *ValidationResult {
+	return ValidateWorkflowWithOptions(filePath, ValidateOptions{})
+}
+
+// ValidateWorkflowWithOptions validates a single workflow file against the
+// schema with the given options
+func ValidateWorkflowWithOptions(filePath string, opts ValidateOptions) *ValidationResult {
 	result := &ValidationResult{
 		Valid:  true,
 		Errors: []ValidationError{},
@@ -40,6 +160,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrReadFailed,
 			Message: fmt.Sprintf("File not found: %v", err),
 		})
 		return result
@@ -51,6 +172,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrReadFailed,
 			Message: fmt.Sprintf("Failed to read file: %v", err),
 		})
 		return result
@@ -63,6 +185,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrInvalidYAML,
 			Message: fmt.Sprintf("Invalid YAML syntax: %v", err),
 		})
 		return result
@@ -74,6 +197,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrInternal,
 			Message: fmt.Sprintf("Failed to convert to JSON: %v", err),
 		})
 		return result
@@ -85,6 +209,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrInternal,
 			Message: fmt.Sprintf("Failed to load schema: %v", err),
 		})
 		return result
@@ -99,6 +224,7 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    ErrInternal,
 			Message: fmt.Sprintf("Validation error: %v", err),
 		})
 		return result
@@ -107,21 +233,483 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 	if !validationResult.Valid() {
 		result.Valid = false
 		details := []string{}
+		var fixes []Fix
 		for _, err := range validationResult.Errors() {
 			details = append(details, err.String())
+			if fix, ok := schemaFix(err); ok {
+				fixes = append(fixes, fix)
+			}
 		}
 		result.Errors = append(result.Errors, ValidationError{
 			File:    filePath,
+			Code:    schemaErrorCode(validationResult.Errors()),
 			Message: "Workflow validation failed",
 			Details: details,
+			Fixes:   fixes,
 		})
+		return result
+	}
+
+	// Schema is satisfied - look for suspicious but valid constructs
+	var workflow Workflow
+	if err := yaml.Unmarshal(content, &workflow); err == nil {
+		// Steps run after the steps of any base, so reachability is checked
+		// on the merged workflow
+		merged := &workflow
+		// Resolve the extends chain so missing or circular bases are reported
+		if workflow.Extends != "" {
+			loaded, err := LoadWorkflow(filePath)
+			if err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					File:    filePath,
+					Code:    ErrInvalidExtends,
+					Message: fmt.Sprintf("Invalid extends: %v", err),
+				})
+				return result
+			}
+			merged = loaded
+		}
+		errs := checkWorkflowErrors(filePath, &workflow, opts)
+		if len(errs) > 0 {
+			result.Valid = false
+			result.Errors = append(result.Errors, errs...)
+			return result
+		}
+		result.Warnings = append(result.Warnings, checkWorkflowWarnings(filePath, &workflow)...)
+		result.Warnings = append(result.Warnings, unreachableStepWarnings(filePath, merged)...)
 	}
 
 	return result
 }
 
-// ValidateWorkflowsInDir validates all workflow files in a directory
+// checkWorkflowErrors catches rules the JSON schema cannot express clearly
+func checkWorkflowErrors(filePath string, workflow *Workflow, opts ValidateOptions) []ValidationError {
+	var errs []ValidationError
+
+	checkTool := func(location string, trigger *ToolTrigger) {
+		if trigger.Name != "" && len(trigger.Names) > 0 {
+			errs = append(errs, ValidationError{
+				File:    filePath,
+				Code:    ErrConflictingFields,
+				Message: fmt.Sprintf("%s: 'name' and 'names' are mutually exclusive, use one or the other", location),
+			})
+		}
+	}
+	if workflow.On.Tool != nil {
+		checkTool("on.tool", workflow.On.Tool)
+	}
+	for i := range workflow.On.Tools {
+		checkTool(fmt.Sprintf("on.tools[%d]", i), &workflow.On.Tools[i])
+	}
+
+	if workflow.On.Schedule != nil {
+		if _, err := schedule.Parse(workflow.On.Schedule.Cron); err != nil {
+			errs = append(errs, ValidationError{
+				File:    filePath,
+				Code:    ErrInvalidCron,
+				Message: fmt.Sprintf("on.schedule: %v", err),
+			})
+		}
+	}
+
+	checkPatterns := func(location string, patterns ...string) {
+		for _, pattern := range patterns {
+			if err := checkPattern(pattern); err != nil {
+				errs = append(errs, ValidationError{
+					File:    filePath,
+					Code:    ErrInvalidGlob,
+					Message: fmt.Sprintf("%s: invalid pattern %q: %v", location, pattern, err),
+				})
+			}
+		}
+	}
+	if on := workflow.On; on.File != nil {
+		checkPatterns("on.file.paths", on.File.Paths...)
+		checkPatterns("on.file.paths-ignore", on.File.PathsIgnore...)
+	}
+	if on := workflow.On; on.Commit != nil {
+		checkPatterns("on.commit.branch", on.Commit.Branch)
+		checkPatterns("on.commit.branches", on.Commit.Branches...)
+		checkPatterns("on.commit.branches-ignore", on.Commit.BranchesIgnore...)
+		checkPatterns("on.commit.paths", on.Commit.Paths...)
+		checkPatterns("on.commit.paths-ignore", on.Commit.PathsIgnore...)
+	}
+	if on := workflow.On; on.Push != nil {
+		checkPatterns("on.push.branches", on.Push.Branches...)
+		checkPatterns("on.push.branches-ignore", on.Push.BranchesIgnore...)
+		checkPatterns("on.push.tags", on.Push.Tags...)
+		checkPatterns("on.push.tags-ignore", on.Push.TagsIgnore...)
+		checkPatterns("on.push.paths", on.Push.Paths...)
+		checkPatterns("on.push.paths-ignore", on.Push.PathsIgnore...)
+	}
+	checkArgs := func(location string, trigger *ToolTrigger) {
+		names := make([]string, 0, len(trigger.Args))
+		for name := range trigger.Args {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			checkPatterns(location+".args."+name, trigger.Args[name])
+		}
+	}
+	if workflow.On.Tool != nil {
+		checkArgs("on.tool", workflow.On.Tool)
+	}
+	for i := range workflow.On.Tools {
+		checkArgs(fmt.Sprintf("on.tools[%d]", i), &workflow.On.Tools[i])
+	}
+
+	checkRegexes := func(location, condition string) {
+		for _, pattern := range conditionRegexes(condition) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, ValidationError{
+					File:    filePath,
+					Code:    ErrInvalidRegex,
+					Message: fmt.Sprintf("%s: invalid regex %q: %v", location, pattern, err),
+				})
+			}
+		}
+	}
+	if workflow.On.Tool != nil {
+		checkRegexes("on.tool.if", workflow.On.Tool.If)
+	}
+	for i := range workflow.On.Tools {
+		checkRegexes(fmt.Sprintf("on.tools[%d].if", i), workflow.On.Tools[i].If)
+	}
+	for i, step := range workflow.Steps {
+		checkRegexes(fmt.Sprintf("step '%s' if", stepLabel(step, i)), step.If)
+	}
+
+	for i, step := range workflow.Steps {
+		if step.Shell != "" && !opts.AllowUnknownShells && !IsKnownShell(step.Shell) {
+			errs = append(errs, ValidationError{
+				File:    filePath,
+				Code:    ErrUnknownShell,
+				Message: fmt.Sprintf("step '%s': unknown shell %q: must be one of %s, or pass --allow-unknown-shells", stepLabel(step, i), step.Shell, strings.Join(KnownShells, ", ")),
+			})
+		}
+	}
+
+	return errs
+}
+
+// schemaErrorCode classifies schema violations: unknown fields or missing
+// required properties when every violation is of that kind, otherwise
+// ErrSchemaViolation
+func schemaErrorCode(errs []gojsonschema.ResultError) string {
+	code := ""
+	for _, err := range errs {
+		var c string
+		switch err.Type() {
+		case "additional_property_not_allowed":
+			c = ErrUnknownField
+		case "required":
+			c = ErrMissingRequired
+		default:
+			return ErrSchemaViolation
+		}
+		if code != "" && c != code {
+			return ErrSchemaViolation
+		}
+		code = c
+	}
+	if code == "" {
+		return ErrSchemaViolation
+	}
+	return code
+}
+
+// checkPattern reports whether a path, branch or tag pattern is malformed,
+// such as an unclosed [ or a trailing backslash. A leading "!" is allowed.
+func checkPattern(pattern string) error {
+	_, err := path.Match(filepath.ToSlash(strings.TrimPrefix(pattern, "!")), "")
+	return err
+}
+
+// conditionRegexes returns the string literals used as =~ and !~ patterns in
+// an expression. Patterns built at run time cannot be checked and are skipped.
+func conditionRegexes(condition string) []string {
+	if condition == "" {
+		return nil
+	}
+	expr := condition
+	if inner := expression.ExtractExpressions(condition); len(inner) > 0 {
+		expr = strings.Join(inner, " ")
+	}
+	tokens, err := expression.Tokenize(expr)
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	for i := 0; i+1 < len(tokens); i++ {
+		op := tokens[i]
+		if op.Type == expression.TokenOperator && (op.Value == "=~" || op.Value == "!~") && tokens[i+1].Type == expression.TokenString {
+			patterns = append(patterns, tokens[i+1].Value)
+		}
+	}
+	return patterns
+}
+
+// checkWorkflowWarnings inspects a schema-valid workflow for non-fatal issues
+func checkWorkflowWarnings(filePath string, workflow *Workflow) []ValidationWarning {
+	var warnings []ValidationWarning
+
+	for i, step := range workflow.Steps {
+		if isNeverCondition(step.If) {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    WarnNeverCondition,
+				Message: fmt.Sprintf("step '%s' has if: never() and will never run", stepLabel(step, i)),
+			})
+		}
+		if step.Shell == "cmd" && hostOS != "windows" {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    WarnCmdShell,
+				Message: fmt.Sprintf("step '%s' uses shell: cmd, which is only available on Windows", stepLabel(step, i)),
+			})
+		}
+		if (step.Shell == "bash" || step.Shell == "sh") && hostOS == "windows" {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    WarnPlatformShell,
+				Message: fmt.Sprintf("step '%s': shell '%s' may not be available on Windows; use pwsh unless %s is installed", stepLabel(step, i), step.Shell, step.Shell),
+			})
+		}
+	}
+
+	if file := workflow.On.File; file != nil && len(file.Types) > 0 {
+		message := "on.file.types is deprecated; rename it to on.file.actions"
+		if len(file.Actions) > 0 {
+			message = "on.file.types is deprecated and ignored because on.file.actions is set; remove it"
+		}
+		warnings = append(warnings, ValidationWarning{File: filePath, Code: WarnDeprecatedField, Message: message})
+	}
+
+	warnings = append(warnings, lifecycleWarnings(filePath, workflow)...)
+
+	warnings = append(warnings, envRefWarnings(filePath, "env", workflow.Env)...)
+	for i, step := range workflow.Steps {
+		warnings = append(warnings, envRefWarnings(filePath, fmt.Sprintf("step '%s' env", stepLabel(step, i)), step.Env)...)
+	}
+
+	// Branch and tag patterns only treat ** as "any depth" when it is a
+	// whole segment such as feature/**; elsewhere it behaves like *
+	if commit := workflow.On.Commit; commit != nil {
+		warnings = append(warnings, refPatternWarnings(filePath, "commit", []string{"branch", "branches", "branches-ignore"}, map[string][]string{
+			"branch":          {commit.Branch},
+			"branches":        commit.Branches,
+			"branches-ignore": commit.BranchesIgnore,
+		})...)
+	}
+	if push := workflow.On.Push; push != nil {
+		warnings = append(warnings, refPatternWarnings(filePath, "push", []string{"branches", "branches-ignore", "tags", "tags-ignore"}, map[string][]string{
+			"branches":        push.Branches,
+			"branches-ignore": push.BranchesIgnore,
+			"tags":            push.Tags,
+			"tags-ignore":     push.TagsIgnore,
+		})...)
+	}
+
+	return warnings
+}
+
+// lifecycleWarnings flags trigger lifecycles other than pre, post, any and *
+// that $HOOKFLOW_LIFECYCLE_MAP does not name as an event type or a
+// lifecycle. Such a trigger only fires for run --event-type with exactly
+// that value, so it is usually a typo such as prre or Pre.
+func lifecycleWarnings(filePath string, workflow *Workflow) []ValidationWarning {
+	known := map[string]bool{"pre": true, "post": true, "any": true, "*": true}
+	mapping, _ := ParseLifecycleMap(os.Getenv(LifecycleMapEnv))
+	for eventType, lifecycle := range mapping {
+		known[eventType] = true
+		known[lifecycle] = true
+	}
+
+	var warnings []ValidationWarning
+	check := func(location, lifecycle string) {
+		if lifecycle == "" || known[lifecycle] {
+			return
+		}
+		warnings = append(warnings, ValidationWarning{
+			File:    filePath,
+			Code:    WarnUnknownLifecycle,
+			Message: fmt.Sprintf("%s lifecycle '%s' is not pre, post or any and is not in $%s; the trigger only fires for run --event-type %s", location, lifecycle, LifecycleMapEnv, lifecycle),
+		})
+	}
+	on := workflow.On
+	if on.Tool != nil {
+		check("on.tool", on.Tool.Lifecycle)
+	}
+	for i := range on.Tools {
+		check(fmt.Sprintf("on.tools[%d]", i), on.Tools[i].Lifecycle)
+	}
+	if on.File != nil {
+		check("on.file", on.File.Lifecycle)
+	}
+	if on.Commit != nil {
+		check("on.commit", on.Commit.Lifecycle)
+	}
+	if on.Push != nil {
+		check("on.push", on.Push.Lifecycle)
+	}
+	return warnings
+}
+
+// refPatternWarnings warns about branch and tag patterns of on.<trigger>
+// that use ** inside a segment, checking fields in order
+func refPatternWarnings(filePath, trigger string, fields []string, patterns map[string][]string) []ValidationWarning {
+	var warnings []ValidationWarning
+	for _, field := range fields {
+		for _, pattern := range patterns[field] {
+			if hasPartialDoubleStar(pattern) {
+				warnings = append(warnings, ValidationWarning{
+					File:    filePath,
+					Code:    WarnPartialDoubleStar,
+					Message: fmt.Sprintf("on.%s.%s pattern '%s' uses ** inside a segment, where it matches like * and does not cross '/'; use a separate segment such as 'feature/**'", trigger, field, pattern),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// hasPartialDoubleStar reports whether ** appears in a pattern segment with other characters
+func hasPartialDoubleStar(pattern string) bool {
+	for _, segment := range strings.Split(strings.TrimPrefix(pattern, "!"), "/") {
+		if segment != "**" && strings.Contains(segment, "**") {
+			return true
+		}
+	}
+	return false
+}
+
+// isNeverCondition reports whether an if condition is exactly never()
+func isNeverCondition(condition string) bool {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(condition[3 : len(condition)-2])
+	}
+	return condition == "never()"
+}
+
+// unreachableStepWarnings flags steps that can never run: their if: requires
+// failure(), but a failure before them either cannot happen or skips them.
+// A failed step skips the following steps unless their if: calls always(),
+// so failure() can only be true for such a step after a continue-on-error
+// step. The step is dead code, so the workflow still runs.
+func unreachableStepWarnings(filePath string, workflow *Workflow) []ValidationWarning {
+	var warnings []ValidationWarning
+	canFailSoftly := false
+	for i, step := range workflow.Steps {
+		if requiresFailure(step.If) {
+			var reason string
+			switch {
+			case i == 0:
+				reason = "no step runs before it"
+			case !canFailSoftly && !strings.Contains(step.If, "always()"):
+				reason = "a failed step skips it; add always() to run it after a failure"
+			}
+			if reason != "" {
+				warnings = append(warnings, ValidationWarning{
+					File:    filePath,
+					Code:    WarnUnreachableStep,
+					Message: fmt.Sprintf("step '%s' can never run: its if: requires failure() but %s", stepLabel(step, i), reason),
+				})
+			}
+		}
+		if workflow.ContinuesOnError(step) {
+			canFailSoftly = true
+		}
+	}
+	return warnings
+}
+
+// requiresFailure reports whether a condition can only be true when
+// failure() is: failure() is one of the operands of a top-level && chain
+func requiresFailure(condition string) bool {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(condition[3 : len(condition)-2])
+	}
+	if !strings.Contains(condition, "failure") {
+		return false
+	}
+	tokens, err := expression.Tokenize(condition)
+	if err != nil {
+		return false
+	}
+
+	depth := 0
+	var operand []expression.Token
+	isFailureCall := func(t []expression.Token) bool {
+		return len(t) == 3 && t[0].Type == expression.TokenIdentifier && t[0].Value == "failure" &&
+			t[1].Type == expression.TokenLeftParen && t[2].Type == expression.TokenRightParen
+	}
+	found := false
+	for _, tok := range tokens {
+		switch {
+		case tok.Type == expression.TokenLeftParen || tok.Type == expression.TokenLeftBracket:
+			depth++
+		case tok.Type == expression.TokenRightParen || tok.Type == expression.TokenRightBracket:
+			depth--
+		case depth == 0 && tok.Type == expression.TokenOperator && tok.Value == "||":
+			return false
+		case depth == 0 && (tok.Type == expression.TokenEOF || (tok.Type == expression.TokenOperator && tok.Value == "&&")):
+			found = found || isFailureCall(operand)
+			operand = nil
+			continue
+		}
+		operand = append(operand, tok)
+	}
+	return found || isFailureCall(operand)
+}
+
+// stepLabel returns the step name, or its 1-based position when unnamed
+func stepLabel(step Step, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return fmt.Sprintf("Step %d", index+1)
+}
+
+// envRefWarnings flags $ENV: values in env that name a variable that is not
+// set, in key order. A variable set to an empty value is not flagged, as the
+// runner reads it the same way.
+func envRefWarnings(filePath, prefix string, env map[string]string) []ValidationWarning {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var warnings []ValidationWarning
+	for _, k := range keys {
+		name, ok := EnvRef(env[k])
+		if !ok {
+			continue
+		}
+		if _, found := os.LookupEnv(name); !found {
+			warnings = append(warnings, ValidationWarning{
+				File:    filePath,
+				Code:    WarnUndefinedEnvRef,
+				Message: fmt.Sprintf("%s.%s reads $ENV:%s, which is not set in the environment and will be empty", prefix, k, name),
+			})
+		}
+	}
+	return warnings
+}
+
+// ValidateWorkflowsInDir validates all workflow files in a directory. Files
+// are validated concurrently, one worker per CPU, and reported in path order.
 func ValidateWorkflowsInDir(dir string) *ValidationResult {
+	return ValidateWorkflowsInDirWithOptions(dir, ValidateOptions{})
+}
+
+// ValidateWorkflowsInDirWithOptions validates all workflow files in a
+// directory with the given options
+func ValidateWorkflowsInDirWithOptions(dir string, opts ValidateOptions) *ValidationResult {
 	result := &ValidationResult{
 		Valid:  true,
 		Errors: []ValidationError{},
@@ -137,6 +725,7 @@ func ValidateWorkflowsInDir(dir string) *ValidationResult {
 	}
 
 	// Walk the directory
+	var paths []string
 	err := filepath.Walk(workflowDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -153,20 +742,23 @@ func ValidateWorkflowsInDir(dir string) *ValidationResult {
 			return nil
 		}
 
-		// Validate this file
-		fileResult := ValidateWorkflow(path)
+		paths = append(paths, path)
+		return nil
+	})
+
+	for _, fileResult := range validateFiles(paths, runtime.NumCPU(), opts) {
 		if !fileResult.Valid {
 			result.Valid = false
 			result.Errors = append(result.Errors, fileResult.Errors...)
 		}
-
-		return nil
-	})
+		result.Warnings = append(result.Warnings, fileResult.Warnings...)
+	}
 
 	if err != nil {
 		result.Valid = false
 		result.Errors = append(result.Errors, ValidationError{
 			File:    dir,
+			Code:    ErrReadFailed,
 			Message: fmt.Sprintf("Failed to scan directory: %v", err),
 		})
 	}
@@ -174,6 +766,30 @@ func ValidateWorkflowsInDir(dir string) *ValidationResult {
 	return result
 }
 
+// validateFiles validates paths with up to workers goroutines and returns
+// their results in the order of paths. ValidateWorkflow only reads shared
+// state, and each worker writes its own results slots.
+func validateFiles(paths []string, workers int, opts ValidateOptions) []*ValidationResult {
+	results := make([]*ValidationResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = ValidateWorkflowWithOptions(paths[i], opts)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
 // loadSchemaLoader loads the workflow schema from the embedded data
 func loadSchemaLoader() (gojsonschema.JSONLoader, error) {
 	if len(embeddedSchema) == 0 {
@@ -1,8 +1,12 @@
 package schema
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -149,6 +153,80 @@ func TestValidateWorkflowsInDir_NoWorkflowDir(t *testing.T) {
 	}
 }
 
+// writeSyntheticWorkflows writes n workflows to dir's .github/hookflows,
+// every third one missing its required on: trigger
+func writeSyntheticWorkflows(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	workflowDir := filepath.Join(dir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		tb.Fatalf("Failed to create workflow directory: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		content := fmt.Sprintf("name: Workflow %03d\non:\n  file:\n    paths: ['**/*.go']\nsteps:\n  - name: Check\n    run: echo %d\n", i, i)
+		if i%3 == 0 {
+			content = fmt.Sprintf("name: Workflow %03d\nsteps:\n  - run: echo %d\n", i, i)
+		}
+		if err := os.WriteFile(filepath.Join(workflowDir, fmt.Sprintf("wf-%03d.yml", i)), []byte(content), 0644); err != nil {
+			tb.Fatalf("Failed to write workflow: %v", err)
+		}
+	}
+}
+
+func TestValidateWorkflowsInDir_Parallel(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSyntheticWorkflows(t, tmpDir, 40)
+
+	result := ValidateWorkflowsInDir(tmpDir)
+	if result.Valid {
+		t.Fatal("Expected invalid result for workflows without triggers")
+	}
+	// Errors come back in file order however the workers finish
+	var files []string
+	for _, e := range result.Errors {
+		if len(files) == 0 || files[len(files)-1] != e.File {
+			files = append(files, e.File)
+		}
+	}
+	if len(files) != 14 || !sort.StringsAreSorted(files) {
+		t.Errorf("Expected errors for 14 files in path order, got %v", files)
+	}
+
+	// Any number of workers gives the same results
+	paths := make([]string, 0, 40)
+	for i := 0; i < 40; i++ {
+		paths = append(paths, filepath.Join(tmpDir, ".github", "hookflows", fmt.Sprintf("wf-%03d.yml", i)))
+	}
+	sequential := validateFiles(paths, 1, ValidateOptions{})
+	for _, workers := range []int{0, 3, 64} {
+		for i, got := range validateFiles(paths, workers, ValidateOptions{}) {
+			if got.Valid != sequential[i].Valid || len(got.Errors) != len(sequential[i].Errors) {
+				t.Errorf("validateFiles(%d workers)[%d] = %+v, want %+v", workers, i, got, sequential[i])
+			}
+		}
+	}
+	if got := validateFiles(nil, 4, ValidateOptions{}); len(got) != 0 {
+		t.Errorf("Expected no results for no files, got %v", got)
+	}
+}
+
+func benchmarkValidateFiles(b *testing.B, workers int) {
+	tmpDir := b.TempDir()
+	writeSyntheticWorkflows(b, tmpDir, 100)
+	paths, _ := filepath.Glob(filepath.Join(tmpDir, ".github", "hookflows", "*.yml"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		validateFiles(paths, workers, ValidateOptions{})
+	}
+}
+
+func BenchmarkValidateWorkflowsSequential(b *testing.B) {
+	benchmarkValidateFiles(b, 1)
+}
+
+func BenchmarkValidateWorkflowsParallel(b *testing.B) {
+	benchmarkValidateFiles(b, runtime.NumCPU())
+}
+
 func TestValidationError_Details(t *testing.T) {
 	// Ensure validation errors contain details
 	result := ValidateWorkflow("../../testdata/workflows/invalid/missing-required.yml")
@@ -165,3 +243,1101 @@ func TestValidationError_Details(t *testing.T) {
 	}
 }
 
+
+func TestValidateWorkflow_ErrorCodes(t *testing.T) {
+	const steps = "steps:\n  - run: echo hi\n"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid yaml", "name: [broken\n", ErrInvalidYAML},
+		{"unknown field", "name: x\non:\n  file:\n    paths: ['**']\nbogus: 1\n" + steps, ErrUnknownField},
+		{"missing required", "name: x\non:\n  file:\n    paths: ['**']\n", ErrMissingRequired},
+		{"other schema violation", "name: x\non:\n  file:\n    paths: ['**']\nsteps:\n  - run: echo hi\n    timeout: 0\n", ErrSchemaViolation},
+		{"mixed schema violations", "name: x\non:\n  file:\n    paths: ['**']\nbogus: 1\n", ErrSchemaViolation},
+		{"invalid extends", "name: x\nextends: missing.yml\non:\n  file:\n    paths: ['**']\n" + steps, ErrInvalidExtends},
+		{"conflicting fields", "name: x\non:\n  tool:\n    name: edit\n    names: [create]\n" + steps, ErrConflictingFields},
+		{"invalid cron", "name: x\non:\n  schedule:\n    cron: '61 * * * *'\n" + steps, ErrInvalidCron},
+		{"invalid path glob", "name: x\non:\n  file:\n    paths: ['src/[abc']\n" + steps, ErrInvalidGlob},
+		{"invalid ignored branch", "name: x\non:\n  push:\n    branches-ignore: ['release\\']\n" + steps, ErrInvalidGlob},
+		{"invalid tool arg glob", "name: x\non:\n  tool:\n    name: edit\n    args:\n      path: '[abc'\n" + steps, ErrInvalidGlob},
+		{"invalid step regex", "name: x\non:\n  file:\n    paths: ['**']\nsteps:\n  - if: ${{ event.file.path =~ '(unclosed' }}\n    run: echo hi\n", ErrInvalidRegex},
+		{"invalid trigger regex", "name: x\non:\n  tool:\n    name: edit\n    if: event.tool.args.path !~ '*.go'\n" + steps, ErrInvalidRegex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workflow.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+			result := ValidateWorkflow(path)
+			if result.Valid {
+				t.Fatal("Expected an invalid workflow")
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Code != tt.want {
+				t.Errorf("Expected one %s error, got %+v", tt.want, result.Errors)
+			}
+		})
+	}
+
+	result := ValidateWorkflow(filepath.Join(t.TempDir(), "missing.yml"))
+	if len(result.Errors) != 1 || result.Errors[0].Code != ErrReadFailed {
+		t.Errorf("Expected one %s error for a missing file, got %+v", ErrReadFailed, result.Errors)
+	}
+
+	// Negated patterns and regexes built at run time are not errors
+	path := filepath.Join(t.TempDir(), "valid.yml")
+	content := "name: x\non:\n  file:\n    paths: ['src/**', '!src/[ab]*.go']\nsteps:\n  - if: event.file.path =~ env.PATTERN || event.file.path =~ '^src/'\n    run: echo hi\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+	if result := ValidateWorkflow(path); !result.Valid {
+		t.Errorf("Expected valid workflow, got errors: %+v", result.Errors)
+	}
+}
+
+func TestValidateWorkflow_UnreachableStepWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string
+	}{
+		{"first step", "  - if: failure()\n    run: echo hi\n"},
+		{"without always", "  - run: exit 1\n  - if: ${{ failure() && env.CI }}\n    run: echo cleanup\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workflow.yml")
+			content := "name: x\non:\n  file:\n    paths: ['docs/**']\nsteps:\n" + tt.steps
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+			// The step is dead code; the workflow itself still loads and runs
+			result := ValidateWorkflow(path)
+			if !result.Valid {
+				t.Fatalf("Expected a valid workflow, got errors: %+v", result.Errors)
+			}
+			if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnUnreachableStep {
+				t.Errorf("Expected one %s warning, got %+v", WarnUnreachableStep, result.Warnings)
+			}
+			if _, err := LoadAndValidateWorkflow(path); err != nil {
+				t.Errorf("Expected LoadAndValidateWorkflow to accept the workflow, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateWorkflow_ReachableFailureSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps string
+	}{
+		{"always", "  - run: exit 1\n  - if: always() && failure()\n    run: echo cleanup\n"},
+		{"after continue-on-error", "  - run: exit 1\n    continue-on-error: true\n  - if: failure()\n    run: echo report\n"},
+		{"either", "  - run: exit 1\n  - if: failure() || env.CI\n    run: echo report\n"},
+		{"negated", "  - if: ${{ !failure() }}\n    run: echo ok\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workflow.yml")
+			content := "name: x\non:\n  file:\n    paths: ['**']\nsteps:\n" + tt.steps
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+			result := ValidateWorkflow(path)
+			if !result.Valid {
+				t.Errorf("Expected valid workflow, got errors: %+v", result.Errors)
+			}
+			for _, warning := range result.Warnings {
+				if warning.Code == WarnUnreachableStep {
+					t.Errorf("Unexpected warning: %+v", warning)
+				}
+			}
+		})
+	}
+
+	// Base steps run first, so the first step of an extending workflow can
+	// follow a continue-on-error step
+	dir := t.TempDir()
+	base := "name: base\non:\n  file:\n    paths: ['**']\nsteps:\n  - run: exit 1\n    continue-on-error: true\n"
+	if err := os.WriteFile(filepath.Join(dir, "base.yml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	child := "name: child\nextends: base.yml\nsteps:\n  - if: failure()\n    run: echo report\n"
+	if err := os.WriteFile(filepath.Join(dir, "child.yml"), []byte(child), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result := ValidateWorkflow(filepath.Join(dir, "child.yml")); !result.Valid || len(result.Warnings) != 0 {
+		t.Errorf("Expected the extending workflow to be valid without warnings, got %+v", result)
+	}
+}
+
+func TestValidateWorkflow_UnknownLifecycleWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lifecycle.yml")
+	content := `name: Lifecycles
+on:
+  tool:
+    name: edit
+    lifecycle: prre
+  tools:
+    - name: create
+      lifecycle: Pre
+  file:
+    paths: ['**/*.go']
+    lifecycle: notification
+  commit:
+    lifecycle: any
+  push:
+    lifecycle: post
+steps:
+  - run: echo hi
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	lifecycleMessages := func(result *ValidationResult) []string {
+		var messages []string
+		for _, warning := range result.Warnings {
+			if warning.Code == WarnUnknownLifecycle {
+				messages = append(messages, warning.Message)
+			}
+		}
+		return messages
+	}
+
+	t.Setenv(LifecycleMapEnv, "")
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected a valid workflow, got errors: %+v", result.Errors)
+	}
+	got := lifecycleMessages(result)
+	if len(got) != 3 || !strings.HasPrefix(got[0], "on.tool lifecycle 'prre'") || !strings.HasPrefix(got[1], "on.tools[0] lifecycle 'Pre'") || !strings.HasPrefix(got[2], "on.file lifecycle 'notification'") {
+		t.Errorf("Expected warnings for prre, Pre and notification, got %q", got)
+	}
+
+	// A lifecycle named in the map, as an event type or a lifecycle, is intended
+	t.Setenv(LifecycleMapEnv, "notification=post,background=prre,bogus")
+	if got := lifecycleMessages(ValidateWorkflow(path)); len(got) != 1 || !strings.HasPrefix(got[0], "on.tools[0] lifecycle 'Pre'") {
+		t.Errorf("Expected only the Pre warning with the map set, got %q", got)
+	}
+}
+
+func TestParseLifecycleMap(t *testing.T) {
+	mapping, invalid := ParseLifecycleMap(" notification=post, background = pre,bogus,=post,,")
+	if len(mapping) != 2 || mapping["notification"] != "post" || mapping["background"] != "pre" {
+		t.Errorf("mapping = %v", mapping)
+	}
+	if strings.Join(invalid, "|") != "bogus|=post" {
+		t.Errorf("invalid = %q, want bogus and =post", invalid)
+	}
+}
+
+func TestValidateWorkflow_NeverConditionWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "never.yml")
+	content := `name: Disabled step
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - name: Temporarily disabled
+    if: ${{ never() }}
+    run: echo "off"
+  - name: Active
+    run: echo "on"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d: %v", len(result.Warnings), result.Warnings)
+	}
+	if result.Warnings[0].Code != WarnNeverCondition {
+		t.Errorf("Expected %s warning, got %s", WarnNeverCondition, result.Warnings[0].Code)
+	}
+	if !strings.Contains(result.Warnings[0].Message, "Temporarily disabled") {
+		t.Errorf("Expected warning to name the step, got: %s", result.Warnings[0].Message)
+	}
+}
+
+func TestValidateWorkflow_CmdShellWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cmd.yml")
+	content := `name: Windows only
+on:
+  file:
+    paths: ['**/*.bat']
+steps:
+  - name: Dir listing
+    shell: cmd
+    run: dir
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	origOS := hostOS
+	defer func() { hostOS = origOS }()
+
+	hostOS = "linux"
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnCmdShell {
+		t.Fatalf("Expected %s warning, got %v", WarnCmdShell, result.Warnings)
+	}
+	if !strings.Contains(result.Warnings[0].Message, "Dir listing") {
+		t.Errorf("Expected warning to name the step, got: %s", result.Warnings[0].Message)
+	}
+
+	hostOS = "windows"
+	if result := ValidateWorkflow(path); len(result.Warnings) != 0 {
+		t.Errorf("Expected no warning on Windows, got %v", result.Warnings)
+	}
+}
+
+func TestValidateWorkflow_UnknownShell(t *testing.T) {
+	dir := t.TempDir()
+	write := func(shell string) string {
+		path := filepath.Join(dir, shell+".yml")
+		content := "name: shells\non:\n  file:\n    paths: ['**']\nsteps:\n  - name: Run\n    shell: " + shell + "\n    run: echo hi\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		return path
+	}
+
+	origOS := hostOS
+	defer func() { hostOS = origOS }()
+	hostOS = "linux"
+
+	for _, shell := range KnownShells {
+		if result := ValidateWorkflow(write(shell)); !result.Valid {
+			t.Errorf("Expected shell %s to be accepted, got %v", shell, result.Errors)
+		}
+	}
+
+	path := write("fish")
+	result := ValidateWorkflow(path)
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != ErrUnknownShell {
+		t.Fatalf("Expected an %s error, got %+v", ErrUnknownShell, result.Errors)
+	}
+	if msg := result.Errors[0].Message; !strings.Contains(msg, `step 'Run': unknown shell "fish"`) || !strings.Contains(msg, "--allow-unknown-shells") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+	if _, err := LoadAndValidateWorkflow(path); err == nil {
+		t.Error("Expected LoadAndValidateWorkflow to reject the unknown shell")
+	}
+
+	opts := ValidateOptions{AllowUnknownShells: true}
+	if result := ValidateWorkflowWithOptions(path, opts); !result.Valid {
+		t.Errorf("Expected unknown shells to be allowed, got %v", result.Errors)
+	}
+	if _, err := LoadAndValidateWorkflowWithOptions(path, opts); err != nil {
+		t.Errorf("Expected LoadAndValidateWorkflowWithOptions to accept the unknown shell, got %v", err)
+	}
+	if result := ValidateWorkflow(path); result.Valid {
+		t.Error("Expected the default options to still reject the unknown shell")
+	}
+}
+
+func TestValidateWorkflow_PlatformShellWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bash.yml")
+	content := `name: Unix tools
+on:
+  file:
+    paths: ['**/*.sh']
+steps:
+  - name: Check
+    shell: bash
+    run: shellcheck "${{ event.file.path }}"
+  - name: Portable
+    shell: pwsh
+    run: Write-Host ok
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	origOS := hostOS
+	defer func() { hostOS = origOS }()
+
+	hostOS = "windows"
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnPlatformShell {
+		t.Fatalf("Expected one %s warning, got %v", WarnPlatformShell, result.Warnings)
+	}
+	if msg := result.Warnings[0].Message; !strings.Contains(msg, "step 'Check': shell 'bash' may not be available on Windows") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+
+	hostOS = "linux"
+	if result := ValidateWorkflow(path); len(result.Warnings) != 0 {
+		t.Errorf("Expected no warning off Windows, got %v", result.Warnings)
+	}
+}
+
+func TestValidateWorkflow_EnvRefWarning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+	content := `name: Publish
+on:
+  file:
+    paths: ['**/*.go']
+env:
+  GITHUB_TOKEN: $ENV:HOOKFLOW_TEST_TOKEN
+  NPM_TOKEN: $ENV:HOOKFLOW_TEST_NPM
+  LEVEL: strict
+steps:
+  - name: Upload
+    run: echo publish
+    env:
+      UPLOAD_KEY: $ENV:HOOKFLOW_TEST_UPLOAD
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+	t.Setenv("HOOKFLOW_TEST_TOKEN", "ghp_test")
+	// Set but empty counts as set, as it does when the runner resolves it
+	t.Setenv("HOOKFLOW_TEST_UPLOAD", "")
+	t.Setenv("HOOKFLOW_TEST_NPM", "")
+	_ = os.Unsetenv("HOOKFLOW_TEST_NPM")
+
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnUndefinedEnvRef {
+		t.Fatalf("Expected one %s warning, got %v", WarnUndefinedEnvRef, result.Warnings)
+	}
+	if msg := result.Warnings[0].Message; !strings.Contains(msg, "env.NPM_TOKEN") || !strings.Contains(msg, "$ENV:HOOKFLOW_TEST_NPM") {
+		t.Errorf("Expected the warning to name the key and variable, got: %s", msg)
+	}
+
+	// Step env references are checked too
+	_ = os.Unsetenv("HOOKFLOW_TEST_UPLOAD")
+	result = ValidateWorkflow(path)
+	if len(result.Warnings) != 2 || !strings.Contains(result.Warnings[1].Message, "step 'Upload' env.UPLOAD_KEY reads $ENV:HOOKFLOW_TEST_UPLOAD") {
+		t.Fatalf("Expected a step env warning, got %v", result.Warnings)
+	}
+
+	t.Setenv("HOOKFLOW_TEST_NPM", "npm_test")
+	t.Setenv("HOOKFLOW_TEST_UPLOAD", "key")
+	if result := ValidateWorkflow(path); len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings once the variable is set, got %v", result.Warnings)
+	}
+}
+
+func TestValidateWorkflow_DeprecatedFileTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string // Expected warning message fragment, "" for none
+	}{
+		{name: "actions", file: "actions: [edit]", want: ""},
+		{name: "delete actions", file: "actions: [delete, deleted]", want: ""},
+		{name: "types", file: "types: [edit]", want: "rename it to on.file.actions"},
+		{name: "both", file: "actions: [edit]\n    types: [create]", want: "ignored because on.file.actions is set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lint.yml")
+			content := "name: Lint\non:\n  file:\n    paths: ['**/*.go']\n    " + tt.file + "\nsteps:\n  - run: echo lint\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+
+			result := ValidateWorkflow(path)
+			if !result.Valid {
+				t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+			}
+			if tt.want == "" {
+				if len(result.Warnings) != 0 {
+					t.Errorf("Expected no warnings, got %v", result.Warnings)
+				}
+				return
+			}
+			if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnDeprecatedField {
+				t.Fatalf("Expected one %s warning, got %v", WarnDeprecatedField, result.Warnings)
+			}
+			if msg := result.Warnings[0].Message; !strings.Contains(msg, tt.want) {
+				t.Errorf("Expected warning containing %q, got: %s", tt.want, msg)
+			}
+		})
+	}
+}
+
+func TestEnvRef(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{"$ENV:GITHUB_TOKEN", "GITHUB_TOKEN", true},
+		{"$ENV:", "", false},
+		{"GITHUB_TOKEN", "", false},
+		{"prefix $ENV:GITHUB_TOKEN", "", false},
+		{"$env:GITHUB_TOKEN", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := EnvRef(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("EnvRef(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestValidateWorkflow_ToolNames(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	valid := filepath.Join(tmpDir, "names.yml")
+	if err := os.WriteFile(valid, []byte(`name: Edit or create
+on:
+  tool:
+    names: [edit, create]
+steps:
+  - run: echo check
+`), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+	if result := ValidateWorkflow(valid); !result.Valid {
+		t.Errorf("Expected names-only tool trigger to be valid, got errors: %v", result.Errors)
+	}
+
+	both := filepath.Join(tmpDir, "both.yml")
+	if err := os.WriteFile(both, []byte(`name: Both
+on:
+  tools:
+    - name: edit
+      names: [create]
+steps:
+  - run: echo check
+`), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+	result := ValidateWorkflow(both)
+	if result.Valid {
+		t.Fatal("Expected name and names together to be invalid")
+	}
+	if !strings.Contains(result.Errors[0].Message, "mutually exclusive") || !strings.Contains(result.Errors[0].Message, "on.tools[0]") {
+		t.Errorf("Expected clear mutual exclusion error, got: %s", result.Errors[0].Message)
+	}
+
+	neither := filepath.Join(tmpDir, "neither.yml")
+	if err := os.WriteFile(neither, []byte(`name: Neither
+on:
+  tool:
+    args:
+      path: '*.go'
+steps:
+  - run: echo check
+`), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+	if result := ValidateWorkflow(neither); result.Valid {
+		t.Error("Expected tool trigger without name or names to be invalid")
+	}
+}
+
+func TestValidateWorkflow_FileEncoding(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, tt := range []struct {
+		encoding string
+		valid    bool
+	}{
+		{"text", true},
+		{"binary", true},
+		{"utf-8", false},
+	} {
+		path := filepath.Join(tmpDir, tt.encoding+".yml")
+		if err := os.WriteFile(path, []byte(`name: Encoding
+on:
+  file:
+    encoding: `+tt.encoding+`
+steps:
+  - run: echo check
+`), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		if result := ValidateWorkflow(path); result.Valid != tt.valid {
+			t.Errorf("encoding %q: valid = %v, want %v (errors: %v)", tt.encoding, result.Valid, tt.valid, result.Errors)
+		}
+	}
+}
+
+func TestValidateWorkflow_FileSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, tt := range []struct {
+		symlinks string
+		valid    bool
+	}{
+		{"follow", true},
+		{"ignore", true},
+		{"only", true},
+		{"skip", false},
+	} {
+		path := filepath.Join(tmpDir, tt.symlinks+".yml")
+		if err := os.WriteFile(path, []byte(`name: Symlinks
+on:
+  file:
+    symlinks: `+tt.symlinks+`
+steps:
+  - run: echo check
+`), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		if result := ValidateWorkflow(path); result.Valid != tt.valid {
+			t.Errorf("symlinks %q: valid = %v, want %v (errors: %v)", tt.symlinks, result.Valid, tt.valid, result.Errors)
+		}
+	}
+}
+
+func TestValidateWorkflow_PartialDoubleStarWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "push.yml")
+	content := `name: Push checks
+on:
+  push:
+    branches: ['feature/**', 'release**']
+steps:
+  - run: echo push
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d: %v", len(result.Warnings), result.Warnings)
+	}
+	if result.Warnings[0].Code != WarnPartialDoubleStar || !strings.Contains(result.Warnings[0].Message, "release**") {
+		t.Errorf("Expected partial double star warning for release**, got: %+v", result.Warnings[0])
+	}
+}
+
+func TestValidateWorkflow_CommitBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "commit.yml")
+	content := `name: Strict main
+on:
+  commit:
+    branch: 'release**'
+    branches-ignore: ['wip/**']
+steps:
+  - run: echo commit
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnPartialDoubleStar || !strings.Contains(result.Warnings[0].Message, "on.commit.branch pattern 'release**'") {
+		t.Errorf("Expected one partial double star warning for on.commit.branch, got: %+v", result.Warnings)
+	}
+
+	wf, err := LoadWorkflow(path)
+	if err != nil {
+		t.Fatalf("LoadWorkflow: %v", err)
+	}
+	if wf.On.Commit.Branch != "release**" {
+		t.Errorf("Branch = %q, want release**", wf.On.Commit.Branch)
+	}
+}
+
+func TestValidateWorkflow_ScheduleCron(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		cron  string
+		valid bool
+	}{
+		{"valid cron", "0 2 * * 1-5", true},
+		{"too few fields", "0 2 * *", false},
+		{"out of range", "0 25 * * *", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, strings.ReplaceAll(tt.name, " ", "-")+".yml")
+			content := "name: Nightly\non:\n  schedule:\n    cron: '" + tt.cron + "'\nsteps:\n  - run: echo scan\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+			result := ValidateWorkflow(path)
+			if result.Valid != tt.valid {
+				t.Errorf("ValidateWorkflow() valid = %v, want %v (errors: %v)", result.Valid, tt.valid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateWorkflow_Lifecycle(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		lifecycle string
+		valid     bool
+	}{
+		{"pre", true},
+		{"post", true},
+		{"any", true},
+		{"'*'", true},
+		{"background", true},
+		{"'pr e'", false},
+		{"'-post'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lifecycle, func(t *testing.T) {
+			path := filepath.Join(tmpDir, "lifecycle.yml")
+			content := "name: Lint\non:\n  file:\n    lifecycle: " + tt.lifecycle + "\nsteps:\n  - run: echo lint\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write workflow: %v", err)
+			}
+			result := ValidateWorkflow(path)
+			if result.Valid != tt.valid {
+				t.Fatalf("ValidateWorkflow() valid = %v, want %v (errors: %v)", result.Valid, tt.valid, result.Errors)
+			}
+			// The error lists the known lifecycles
+			if !tt.valid && !strings.Contains(fmt.Sprint(result.Errors), `"pre", "post", "any", "*"`) {
+				t.Errorf("Expected the known lifecycles in the error, got: %v", result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidationResult_Fix(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "lint-check.yml")
+	content := `# Lint on edit
+on:
+  file:
+    paths: ['**/*.go']
+    bogus: 1
+extra: true
+steps:
+  - name: lint
+    run: go vet ./...
+    colour: red
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if result.Valid {
+		t.Fatal("Expected invalid workflow")
+	}
+	fixes := result.Fixes(path)
+	if len(fixes) != 4 {
+		t.Fatalf("Expected 4 fixes, got %d: %v", len(fixes), fixes)
+	}
+
+	// FixContent does not write the file
+	_, after, err := result.FixContent(path)
+	if err != nil {
+		t.Fatalf("FixContent() error: %v", err)
+	}
+	if unchanged, _ := os.ReadFile(path); string(unchanged) != content {
+		t.Error("FixContent() modified the file")
+	}
+	if !strings.HasPrefix(string(after), "# Lint on edit\nname: lint-check\n") {
+		t.Errorf("Expected name after leading comment, got:\n%s", after)
+	}
+
+	if err := result.Fix(path); err != nil {
+		t.Fatalf("Fix() error: %v", err)
+	}
+	fixed := ValidateWorkflow(path)
+	if !fixed.Valid {
+		t.Errorf("Expected fixed workflow to be valid, got: %v", fixed.Errors)
+	}
+	data, _ := os.ReadFile(path)
+	for _, removed := range []string{"bogus", "extra", "colour"} {
+		if strings.Contains(string(data), removed) {
+			t.Errorf("Expected %q to be removed, got:\n%s", removed, data)
+		}
+	}
+}
+
+func TestValidationResult_FixLeavesOtherErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partial.yml")
+	content := "name: ''\non:\n  file:\n    types: [rename]\nsteps:\n  - run: echo hi\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if err := result.Fix(path); err != nil {
+		t.Fatalf("Fix() error: %v", err)
+	}
+
+	fixed := ValidateWorkflow(path)
+	if fixed.Valid {
+		t.Fatal("Expected the invalid file type to remain an error")
+	}
+	if len(fixed.Fixes(path)) != 0 {
+		t.Errorf("Expected no remaining fixes, got %v", fixed.Fixes(path))
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "name: partial") {
+		t.Errorf("Expected empty name to be replaced, got:\n%s", data)
+	}
+}
+
+func TestValidationResult_FixKeepsAnchors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anchors.yml")
+	content := `name: Anchors
+extra: true
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - &lint
+    name: lint
+    run: go vet ./...
+    colour: red
+    env: &defaults
+      STAGE: test
+  - *lint
+  - name: test
+    run: go test ./...
+    env:
+      <<: *defaults
+      LEVEL: debug
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := ValidateWorkflow(path)
+	if result.Valid {
+		t.Fatal("Expected invalid workflow")
+	}
+	// The unknown field is reported for the anchor and for its alias
+	if err := result.Fix(path); err != nil {
+		t.Fatalf("Fix() error: %v", err)
+	}
+	if fixed := ValidateWorkflow(path); !fixed.Valid {
+		t.Errorf("Expected fixed workflow to be valid, got: %v", fixed.Errors)
+	}
+	data, _ := os.ReadFile(path)
+	for _, kept := range []string{"- &lint\n", "- *lint\n", "env: &defaults\n", "      <<: *defaults\n"} {
+		if !strings.Contains(string(data), kept) {
+			t.Errorf("Expected %q to be kept, got:\n%s", kept, data)
+		}
+	}
+	for _, removed := range []string{"extra", "colour", "!!merge"} {
+		if strings.Contains(string(data), removed) {
+			t.Errorf("Expected no %q, got:\n%s", removed, data)
+		}
+	}
+}
+
+func TestValidationResult_FixMergeKeys(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		return path
+	}
+
+	// Every step that merges the base reports the field, so it is removed
+	// from the base
+	path := write("merged.yml", `name: Merged
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - &base
+    name: lint
+    run: go vet ./...
+    shel: bash
+  - <<: *base
+    name: test
+`)
+	if err := ValidateWorkflow(path).Fix(path); err != nil {
+		t.Fatalf("Fix() error: %v", err)
+	}
+	if fixed := ValidateWorkflow(path); !fixed.Valid {
+		t.Errorf("Expected fixed workflow to be valid, got: %v", fixed.Errors)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "shel") || !strings.Contains(string(data), "<<: *base") {
+		t.Errorf("Expected shel removed and the merge kept, got:\n%s", data)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			// env allows any key, so removing it from the anchor would change env
+			name: "merged from a block where the field is allowed",
+			content: `name: Shared
+env: &shared
+  shel: bash
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - <<: *shared
+    name: lint
+    run: go vet ./...
+`,
+			want: "cannot remove unknown field 'steps.0.shel': it comes from a block shared",
+		},
+		{
+			name: "value anchor used elsewhere",
+			content: `name: Anchored
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - name: lint
+    run: go vet ./...
+    extra: &cmd go test ./...
+  - name: test
+    run: *cmd
+`,
+			want: "defines anchor &cmd, which is used elsewhere",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write("refused.yml", tt.content)
+			result := ValidateWorkflow(path)
+			if result.Valid {
+				t.Fatal("Expected invalid workflow")
+			}
+			if err := result.Fix(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Fix() error = %v, want %q", err, tt.want)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.content {
+				t.Errorf("Expected the file to be left alone, got:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestValidateEventJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  []string
+	}{
+		{"empty event", `{}`, nil},
+		{"tool event", `{"tool":{"name":"edit","args":{"path":"a.go"}},"cwd":"/repo"}`, nil},
+		{"commit event", `{"commit":{"sha":"abc","message":"m","files":[{"path":"a.go","status":"added"}]}}`, nil},
+		{"tool name not a string", `{"tool":{"name":5}}`, []string{"tool.name"}},
+		{"tool without name", `{"tool":{"args":{}}}`, []string{"name is required"}},
+		{"unknown file action", `{"file":{"path":"a.go","action":"rename"}}`, []string{"file.action"}},
+		{"non-string metadata", `{"metadata":{"run":1}}`, []string{"metadata.run"}},
+		{"push commits", `{"push":{"ref":"main","commits":[{"sha":1}]}}`, []string{"push.commits.0.sha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := ValidateEventJSON(EventSchema(), []byte(tt.event))
+			if err != nil {
+				t.Fatalf("ValidateEventJSON: %v", err)
+			}
+			if len(violations) != len(tt.want) {
+				t.Fatalf("violations = %v, want %d", violations, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(violations[i], want) {
+					t.Errorf("violation %q does not contain %q", violations[i], want)
+				}
+			}
+		})
+	}
+
+	if _, err := ValidateEventJSON([]byte(`{`), []byte(`{}`)); err == nil {
+		t.Error("Expected error for malformed schema")
+	}
+}
+
+func TestLintWorkflow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "style.yml")
+	content := `name: Style
+on:
+  tool:
+    name: edit
+    lifecycle: post
+  file:
+    paths: ['**/*.go']
+  commit:
+    lifecycle: pre
+steps:
+  - run: echo "unnamed"
+    continue-on-error: true
+  - name: Long script
+    continue-on-error: true
+    run: |
+      ` + strings.Repeat("echo long; ", 20) + `
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	if result := ValidateWorkflow(path); len(result.Warnings) != 0 {
+		t.Errorf("Expected validate to skip style checks, got: %v", result.Warnings)
+	}
+
+	result := LintWorkflow(path)
+	if !result.Valid {
+		t.Fatalf("Expected valid workflow, got errors: %v", result.Errors)
+	}
+	codes := make(map[string]int)
+	for _, warning := range result.Warnings {
+		codes[warning.Code]++
+	}
+	for _, code := range []string{LintUnnamedStep, LintLongRun, LintNoFailingStep, LintImplicitLifecycle} {
+		if codes[code] != 1 {
+			t.Errorf("Expected one %s warning, got %d: %v", code, codes[code], result.Warnings)
+		}
+	}
+}
+
+func TestLintWorkflow_ImplicitToolLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.yml")
+	content := `name: Tools
+on:
+  tool:
+    name: bash
+  tools:
+    - name: edit
+      lifecycle: post
+    - name: create
+steps:
+  - name: Check
+    run: echo check
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	result := LintWorkflow(path)
+	var messages []string
+	for _, warning := range result.Warnings {
+		if warning.Code == LintImplicitLifecycle {
+			messages = append(messages, warning.Message)
+		}
+	}
+	if len(messages) != 2 || !strings.HasPrefix(messages[0], "on.tool has no lifecycle") || !strings.HasPrefix(messages[1], "on.tools[1] has no lifecycle") {
+		t.Fatalf("Expected on.tool and on.tools[1] to be flagged, got: %v", messages)
+	}
+	// The message explains the change from matching every lifecycle
+	if !strings.Contains(messages[0], "used to fire for every lifecycle") || !strings.Contains(messages[0], "'lifecycle: any'") {
+		t.Errorf("Expected the message to explain the changed default, got: %s", messages[0])
+	}
+}
+
+func TestLintWorkflow_Clean(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clean.yml")
+	content := `name: Clean
+blocking: false
+on:
+  push:
+    lifecycle: post
+steps:
+  - name: Notify
+    continue-on-error: true
+    run: echo "pushed"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write workflow: %v", err)
+	}
+
+	if result := LintWorkflow(path); len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got: %v", result.Warnings)
+	}
+}
+
+func TestLintWorkflowsInDir_DuplicateName(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "hookflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "name: Same\non:\n  hooks:\n    types: [preToolUse]\nsteps:\n  - name: Check\n    run: exit 1\n"
+	for _, file := range []string{"a.yml", "b.yml"} {
+		if err := os.WriteFile(filepath.Join(workflowDir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := LintWorkflowsInDir(tmpDir)
+	if len(result.Warnings) != 2 {
+		t.Fatalf("Expected a duplicate-name warning per file, got: %v", result.Warnings)
+	}
+	for _, warning := range result.Warnings {
+		if warning.Code != LintDuplicateName {
+			t.Errorf("Expected %s warning, got %s", LintDuplicateName, warning.Code)
+		}
+	}
+	if !strings.Contains(result.Warnings[0].Message, "b.yml") {
+		t.Errorf("Expected a.yml warning to name b.yml, got: %s", result.Warnings[0].Message)
+	}
+}
+
+func TestValidateWorkflow_StepMatrix(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i, tt := range []struct {
+		matrix string
+		valid  bool
+	}{
+		{"{env: [dev, staging, prod]}", true},
+		{"{env: [dev], node: [18, 20], debug: [true]}", true},
+		{"{env: []}", false},
+		{"{env: dev}", false},
+		{"{}", false},
+		{"{'bad key': [a]}", false},
+	} {
+		path := filepath.Join(tmpDir, fmt.Sprintf("matrix-%d.yml", i))
+		if err := os.WriteFile(path, []byte(`name: Matrix
+on:
+  file:
+    paths: ['**/*.go']
+steps:
+  - name: Check
+    matrix: `+tt.matrix+`
+    run: echo ${{ matrix.env }}
+`), 0644); err != nil {
+			t.Fatalf("Failed to write workflow: %v", err)
+		}
+		if result := ValidateWorkflow(path); result.Valid != tt.valid {
+			t.Errorf("matrix %s: valid = %v, want %v (errors: %v)", tt.matrix, result.Valid, tt.valid, result.Errors)
+		}
+	}
+
+	wf, err := LoadWorkflow(filepath.Join(tmpDir, "matrix-1.yml"))
+	if err != nil {
+		t.Fatalf("LoadWorkflow: %v", err)
+	}
+	if got := wf.Steps[0].Matrix["node"]; len(got) != 2 || got[0] != "18" || got[1] != "20" {
+		t.Errorf("Expected numeric matrix values as strings, got %v", got)
+	}
+}
@@ -1,14 +1,38 @@
 package schema
 
+import (
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
 // Workflow represents a complete agent workflow definition
 type Workflow struct {
-	Name        string            `yaml:"name" json:"name"`
-	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
-	Blocking    *bool             `yaml:"blocking,omitempty" json:"blocking,omitempty"` // Default: true
-	Concurrency *ConcurrencyConfig `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
-	On          OnConfig          `yaml:"on" json:"on"`
-	Env         map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
-	Steps       []Step            `yaml:"steps" json:"steps"`
+	Name            string             `yaml:"name" json:"name"`
+	RunName         string             `yaml:"run-name,omitempty" json:"run-name,omitempty"` // Name for one run, may use ${{ }} expressions; defaults to Name
+	Description     string             `yaml:"description,omitempty" json:"description,omitempty"`
+	Extends         string             `yaml:"extends,omitempty" json:"extends,omitempty"`                     // Base workflow path, relative to this file
+	Blocking        *bool              `yaml:"blocking,omitempty" json:"blocking,omitempty"`                   // Default: true
+	ContinueOnError *bool              `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"` // Default for steps that do not set it
+	Concurrency     *ConcurrencyConfig `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	On              OnConfig           `yaml:"on" json:"on"`
+	Env             map[string]string  `yaml:"env,omitempty" json:"env,omitempty"`
+	Steps           []Step             `yaml:"steps" json:"steps"`
+
+	matcher atomic.Value // Compiled trigger matcher, see SetMatcher
+}
+
+// Matcher returns the value stored by SetMatcher, or nil
+func (w *Workflow) Matcher() interface{} {
+	return w.matcher.Load()
+}
+
+// SetMatcher caches the compiled trigger matcher of the workflow so every
+// event matched against a loaded workflow reuses it. The trigger package
+// stores it here because schema cannot import trigger.
+func (w *Workflow) SetMatcher(m interface{}) {
+	w.matcher.Store(m)
 }
 
 // IsBlocking returns whether the workflow should block on failure (default: true)
@@ -19,6 +43,29 @@ func (w *Workflow) IsBlocking() bool {
 	return *w.Blocking
 }
 
+// ContinuesOnError returns whether a failure of step lets the following
+// steps run: the step's continue-on-error, then the workflow's (default: false)
+func (w *Workflow) ContinuesOnError(step Step) bool {
+	if step.ContinueOnError != nil {
+		return *step.ContinueOnError
+	}
+	return w.ContinueOnError != nil && *w.ContinueOnError
+}
+
+// EnvRefPrefix marks a workflow env value read from the OS environment, as in
+// GITHUB_TOKEN: $ENV:GITHUB_TOKEN, so secrets stay out of workflow files
+const EnvRefPrefix = "$ENV:"
+
+// EnvRef returns the OS environment variable an env value refers to, if the
+// value is a $ENV: reference
+func EnvRef(value string) (string, bool) {
+	name, ok := strings.CutPrefix(value, EnvRefPrefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
 // ConcurrencyConfig controls parallel execution
 type ConcurrencyConfig struct {
 	Group       string `yaml:"group" json:"group"`
@@ -27,12 +74,13 @@ type ConcurrencyConfig struct {
 
 // OnConfig defines all trigger types
 type OnConfig struct {
-	Hooks  *HooksTrigger   `yaml:"hooks,omitempty" json:"hooks,omitempty"`
-	Tool   *ToolTrigger    `yaml:"tool,omitempty" json:"tool,omitempty"`
-	Tools  []ToolTrigger   `yaml:"tools,omitempty" json:"tools,omitempty"`
-	File   *FileTrigger    `yaml:"file,omitempty" json:"file,omitempty"`
-	Commit *CommitTrigger  `yaml:"commit,omitempty" json:"commit,omitempty"`
-	Push   *PushTrigger    `yaml:"push,omitempty" json:"push,omitempty"`
+	Hooks    *HooksTrigger    `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	Tool     *ToolTrigger     `yaml:"tool,omitempty" json:"tool,omitempty"`
+	Tools    []ToolTrigger    `yaml:"tools,omitempty" json:"tools,omitempty"`
+	File     *FileTrigger     `yaml:"file,omitempty" json:"file,omitempty"`
+	Commit   *CommitTrigger   `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Push     *PushTrigger     `yaml:"push,omitempty" json:"push,omitempty"`
+	Schedule *ScheduleTrigger `yaml:"schedule,omitempty" json:"schedule,omitempty"`
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for OnConfig
@@ -75,25 +123,68 @@ func (o *OnConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // HooksTrigger matches agent hook events
 type HooksTrigger struct {
-	Types []string `yaml:"types,omitempty" json:"types,omitempty"` // preToolUse, postToolUse
-	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"` // Filter by tool name
+	Types    []string `yaml:"types,omitempty" json:"types,omitempty"`         // preToolUse, postToolUse
+	Tools    []string `yaml:"tools,omitempty" json:"tools,omitempty"`         // Filter by tool name
+	HookType string   `yaml:"hook_type,omitempty" json:"hook_type,omitempty"` // Exact Copilot hook type, e.g. preToolUse; empty matches all
 }
 
 // ToolTrigger matches specific tools with argument filtering
 type ToolTrigger struct {
-	Name string            `yaml:"name" json:"name"`
-	Args map[string]string `yaml:"args,omitempty" json:"args,omitempty"` // Glob patterns on arg values
-	If   string            `yaml:"if,omitempty" json:"if,omitempty"`     // Expression condition
+	Name      string            `yaml:"name,omitempty" json:"name,omitempty"`
+	Names     []string          `yaml:"names,omitempty" json:"names,omitempty"` // Alternative to Name: match any listed tool
+	Lifecycle string            `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"` // pre (default), post, or any
+	Args      map[string]string `yaml:"args,omitempty" json:"args,omitempty"` // Glob patterns on arg values
+	If        string            `yaml:"if,omitempty" json:"if,omitempty"`     // Expression condition
+}
+
+// GetLifecycle returns the lifecycle (defaults to "pre")
+func (t *ToolTrigger) GetLifecycle() string {
+	if t.Lifecycle == "" {
+		return "pre"
+	}
+	return t.Lifecycle
+}
+
+// ToolNames returns the tool names this trigger matches
+func (t *ToolTrigger) ToolNames() []string {
+	if len(t.Names) > 0 {
+		return t.Names
+	}
+	if t.Name != "" {
+		return []string{t.Name}
+	}
+	return nil
 }
 
 // FileTrigger matches file create/edit events
 type FileTrigger struct {
 	Lifecycle   string   `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`       // pre (default) or post
-	Types       []string `yaml:"types,omitempty" json:"types,omitempty"`               // create, edit, delete
+	Actions     []string `yaml:"actions,omitempty" json:"actions,omitempty"`           // create, edit, delete (or deleted)
+	Types       []string `yaml:"types,omitempty" json:"types,omitempty"`               // Deprecated alias of Actions, read when Actions is empty
 	Paths       []string `yaml:"paths,omitempty" json:"paths,omitempty"`               // Include patterns
 	PathsIgnore []string `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"` // Exclude patterns
+
+	MinChangedLines int   `yaml:"min-changed-lines,omitempty" json:"min-changed-lines,omitempty"` // Fire only when at least this many lines change (0 = any change)
+	MinBytes        int64 `yaml:"min-bytes,omitempty" json:"min-bytes,omitempty"`                 // Fire only for files at least this large (0 = no lower bound)
+	MaxBytes        int64 `yaml:"max-bytes,omitempty" json:"max-bytes,omitempty"`                 // Fire only for files at most this large (0 = no upper bound)
+
+	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty"` // text or binary; empty fires for both
+	Symlinks string `yaml:"symlinks,omitempty" json:"symlinks,omitempty"` // follow (default), ignore or only
 }
 
+// File trigger encodings
+const (
+	FileEncodingText   = "text"
+	FileEncodingBinary = "binary"
+)
+
+// File trigger symlink policies
+const (
+	FileSymlinksFollow = "follow" // Match a symlink by the path it resolves to
+	FileSymlinksIgnore = "ignore" // Never fire for symlinks
+	FileSymlinksOnly   = "only"   // Fire only for symlinks, matched by the path they resolve to
+)
+
 // GetLifecycle returns the lifecycle (defaults to "pre")
 func (f *FileTrigger) GetLifecycle() string {
 	if f.Lifecycle == "" {
@@ -102,6 +193,40 @@ func (f *FileTrigger) GetLifecycle() string {
 	return f.Lifecycle
 }
 
+// GetActions returns the file actions to trigger on: Actions, or the
+// deprecated Types when Actions is empty
+func (f *FileTrigger) GetActions() []string {
+	if len(f.Actions) > 0 {
+		return f.Actions
+	}
+	return f.Types
+}
+
+// MetadataStrings converts decoded JSON metadata to event metadata,
+// keeping only string values, so loosely typed enrichment cannot fail the
+// hook
+func MetadataStrings(metadata map[string]interface{}) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+	strs := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if str, ok := v.(string); ok {
+			strs[k] = str
+		}
+	}
+	return strs
+}
+
+// NormalizeFileAction returns the canonical name of a file action, mapping
+// the alias deleted to delete
+func NormalizeFileAction(action string) string {
+	if action == "deleted" {
+		return "delete"
+	}
+	return action
+}
+
 // CommitTrigger matches git commit events
 type CommitTrigger struct {
 	Lifecycle      string   `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"` // pre (default) or post
@@ -109,6 +234,9 @@ type CommitTrigger struct {
 	PathsIgnore    []string `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"`
 	Branches       []string `yaml:"branches,omitempty" json:"branches,omitempty"`
 	BranchesIgnore []string `yaml:"branches-ignore,omitempty" json:"branches-ignore,omitempty"`
+	Author         string   `yaml:"author,omitempty" json:"author,omitempty"`               // Glob pattern on the commit author
+	Branch         string   `yaml:"branch,omitempty" json:"branch,omitempty"`               // Glob pattern on the branch being committed to
+	StatusFilter   []string `yaml:"status-filter,omitempty" json:"status-filter,omitempty"` // Only consider files with these statuses; empty for all
 }
 
 // GetLifecycle returns the lifecycle (defaults to "pre")
@@ -138,30 +266,39 @@ func (p *PushTrigger) GetLifecycle() string {
 	return p.Lifecycle
 }
 
+// ScheduleTrigger runs a workflow on a cron schedule under hookflow serve
+type ScheduleTrigger struct {
+	Cron string `yaml:"cron" json:"cron"` // Five-field cron expression, local time
+}
+
 // Step represents a single step in a workflow
 type Step struct {
-	Name            string            `yaml:"name,omitempty" json:"name,omitempty"`
-	If              string            `yaml:"if,omitempty" json:"if,omitempty"`
-	Run             string            `yaml:"run,omitempty" json:"run,omitempty"`
-	Shell           string            `yaml:"shell,omitempty" json:"shell,omitempty"` // pwsh, bash, sh, cmd
-	Uses            string            `yaml:"uses,omitempty" json:"uses,omitempty"`   // Reusable action
-	With            map[string]string `yaml:"with,omitempty" json:"with,omitempty"`   // Action inputs
-	Env             map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
-	WorkingDirectory string           `yaml:"working-directory,omitempty" json:"working-directory,omitempty"`
-	Timeout         int               `yaml:"timeout,omitempty" json:"timeout,omitempty"` // Seconds
-	ContinueOnError bool              `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"`
+	ID               string              `yaml:"id,omitempty" json:"id,omitempty"` // Key for steps.<id>; defaults to step-N
+	Name             string              `yaml:"name,omitempty" json:"name,omitempty"`
+	If               string              `yaml:"if,omitempty" json:"if,omitempty"`
+	Run              string              `yaml:"run,omitempty" json:"run,omitempty"`
+	Shell            string              `yaml:"shell,omitempty" json:"shell,omitempty"` // One of KnownShells, unless unknown shells are allowed
+	Uses             string              `yaml:"uses,omitempty" json:"uses,omitempty"`   // Reusable action
+	With             map[string]string   `yaml:"with,omitempty" json:"with,omitempty"`   // Action inputs
+	Env              map[string]string   `yaml:"env,omitempty" json:"env,omitempty"`
+	WorkingDirectory string              `yaml:"working-directory,omitempty" json:"working-directory,omitempty"`
+	Timeout          int                 `yaml:"timeout,omitempty" json:"timeout,omitempty"`                     // Seconds
+	ContinueOnError  *bool               `yaml:"continue-on-error,omitempty" json:"continue-on-error,omitempty"` // Unset inherits the workflow's
+	Matrix           map[string][]string `yaml:"matrix,omitempty" json:"matrix,omitempty"`                       // Run once per combination of values, read as matrix.<key>
 }
 
 // Event represents the runtime event context passed to workflows
 type Event struct {
-	Hook      *HookEvent   `json:"hook,omitempty"`
-	Tool      *ToolEvent   `json:"tool,omitempty"`
-	File      *FileEvent   `json:"file,omitempty"`
-	Commit    *CommitEvent `json:"commit,omitempty"`
-	Push      *PushEvent   `json:"push,omitempty"`
-	Cwd       string       `json:"cwd"`
-	Timestamp string       `json:"timestamp"`
-	Lifecycle string       `json:"lifecycle,omitempty"` // pre or post (defaults to pre)
+	Hook      *HookEvent        `json:"hook,omitempty"`
+	Tool      *ToolEvent        `json:"tool,omitempty"`
+	File      *FileEvent        `json:"file,omitempty"`
+	Commit    *CommitEvent      `json:"commit,omitempty"`
+	Push      *PushEvent        `json:"push,omitempty"`
+	Schedule  *ScheduleEvent    `json:"schedule,omitempty"`
+	Cwd       string            `json:"cwd"`
+	Timestamp string            `json:"timestamp"`
+	Lifecycle string            `json:"lifecycle,omitempty"` // pre or post (defaults to pre)
+	Metadata  map[string]string `json:"metadata,omitempty"`  // Arbitrary key-value enrichment from integrations
 }
 
 // GetLifecycle returns the event lifecycle (defaults to "pre")
@@ -172,11 +309,95 @@ func (e *Event) GetLifecycle() string {
 	return e.Lifecycle
 }
 
+// Equal reports whether e and other describe the same event, for dropping
+// duplicates. Timestamp is ignored, an empty Lifecycle equals "pre", and
+// maps and slices compare by content, with nil equal to empty.
+func (e *Event) Equal(other *Event) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+	return e.Cwd == other.Cwd &&
+		e.GetLifecycle() == other.GetLifecycle() &&
+		equalValues(reflect.ValueOf(e.Metadata), reflect.ValueOf(other.Metadata)) &&
+		equalValues(reflect.ValueOf(e.Hook), reflect.ValueOf(other.Hook)) &&
+		equalValues(reflect.ValueOf(e.Tool), reflect.ValueOf(other.Tool)) &&
+		equalValues(reflect.ValueOf(e.File), reflect.ValueOf(other.File)) &&
+		equalValues(reflect.ValueOf(e.Commit), reflect.ValueOf(other.Commit)) &&
+		equalValues(reflect.ValueOf(e.Push), reflect.ValueOf(other.Push)) &&
+		equalValues(reflect.ValueOf(e.Schedule), reflect.ValueOf(other.Schedule))
+}
+
+// equalValues is reflect.DeepEqual except that a nil map or slice equals an
+// empty one, as they do once the event is encoded
+func equalValues(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValues(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if !equalValues(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			other := b.MapIndex(key)
+			if !other.IsValid() || !equalValues(a.MapIndex(key), other) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// FillCreateFile populates File from the args of a create tool call when the
+// event does not carry them, so file.content is the new file's content
+// (tool.args.file_text) for every create event
+func (e *Event) FillCreateFile() {
+	if e.Tool == nil || e.Tool.Name != "create" {
+		return
+	}
+	if e.File == nil {
+		e.File = &FileEvent{Action: "create"}
+	}
+	if path, ok := e.Tool.Args["path"].(string); ok && e.File.Path == "" {
+		e.File.Path = path
+	}
+	if content, ok := e.Tool.Args["file_text"].(string); ok && e.File.Content == "" {
+		e.File.Content = content
+	}
+}
+
 // HookEvent contains hook-specific event data
 type HookEvent struct {
-	Type string     `json:"type"` // preToolUse, postToolUse
-	Tool *ToolEvent `json:"tool"`
-	Cwd  string     `json:"cwd"`
+	Type      string     `json:"type"` // preToolUse, postToolUse
+	Tool      *ToolEvent `json:"tool"`
+	Cwd       string     `json:"cwd"`
+	SessionID string     `json:"session_id,omitempty"` // Copilot session that made the tool call, when the input has one
 }
 
 // ToolEvent contains tool invocation data
@@ -191,6 +412,21 @@ type FileEvent struct {
 	Path    string `json:"path"`
 	Action  string `json:"action"` // create, edit
 	Content string `json:"content,omitempty"`
+	// ChangedLines is the number of lines the change touches, when known.
+	// Zero means unknown and the line count of Content is used instead.
+	ChangedLines int `json:"changed_lines,omitempty"`
+}
+
+// ChangedLineCount returns ChangedLines, falling back to the number of lines
+// in Content
+func (f *FileEvent) ChangedLineCount() int {
+	if f.ChangedLines > 0 {
+		return f.ChangedLines
+	}
+	if f.Content == "" {
+		return 0
+	}
+	return strings.Count(f.Content, "\n") + 1
 }
 
 // CommitEvent contains git commit data
@@ -198,39 +434,106 @@ type CommitEvent struct {
 	SHA     string       `json:"sha"`
 	Message string       `json:"message"`
 	Author  string       `json:"author"`
+	Branch  string       `json:"branch,omitempty"` // Branch checked out when committing; empty when unknown
 	Files   []FileStatus `json:"files"`
+	Diff    string       `json:"diff,omitempty"` // Staged diff, or DiffTruncated when it exceeds MaxCommitDiffBytes
 }
 
+// MaxCommitDiffBytes caps the staged diff recorded on a commit event
+const MaxCommitDiffBytes = 1 << 20
+
+// DiffTruncated replaces a commit diff larger than MaxCommitDiffBytes
+const DiffTruncated = "<diff truncated>"
+
 // PushEvent contains git push data
 type PushEvent struct {
 	Ref     string        `json:"ref"`
 	Before  string        `json:"before"`
 	After   string        `json:"after"`
 	Commits []CommitEvent `json:"commits"`
+	Files   []FileStatus  `json:"files,omitempty"` // Files changed between Before and After
+}
+
+// ScheduleEvent is the synthetic event sent when a schedule fires
+type ScheduleEvent struct {
+	Cron string `json:"cron"` // Expression that fired; empty matches every schedule trigger
 }
 
 // FileStatus represents a file's status in a commit
 type FileStatus struct {
 	Path   string `json:"path"`
-	Status string `json:"status"` // added, modified, deleted
+	Status string `json:"status"` // added, modified, deleted, renamed or copied
 }
 
 // WorkflowResult represents the outcome of running a workflow
 type WorkflowResult struct {
-	PermissionDecision       string `json:"permissionDecision"` // allow, deny
-	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
-	LogFile                  string `json:"logFile,omitempty"` // Path to detailed log file
+	PermissionDecision       string            `json:"permissionDecision"` // allow, deny
+	PermissionDecisionReason string            `json:"permissionDecisionReason,omitempty"`
+	LogFile                  string            `json:"logFile,omitempty"`         // Path to detailed log file
+	StepResults              []StepResult      `json:"stepResults,omitempty"`     // Per-step outcomes
+	WorkflowName             string            `json:"workflow,omitempty"`        // Workflow that produced the result; the first denying workflow in an aggregate
+	Workflows                []string          `json:"workflows,omitempty"`       // Names of every workflow run for the event, shown with --verbose
+	WorkflowResults          []WorkflowResult  `json:"workflowResults,omitempty"` // Every workflow's result with --continue-on-workflow-error
+	Profile                  *Profile          `json:"profile,omitempty"`         // Step timings with --profile
+	StartedAt                time.Time         `json:"startedAt,omitzero"`        // When processing the event began, RFC 3339
+	FinishedAt               time.Time         `json:"finishedAt,omitzero"`       // When the decision was made, RFC 3339
+	Truncated                bool              `json:"truncated,omitempty"`       // More workflows matched than --max-workflows allows
+	Actions                  []SuggestedAction `json:"actions,omitempty"`         // Fixes suggested by the steps that caused a deny
+}
+
+// SuggestedAction is a fix a failed step printed as a ::suggest:: line
+type SuggestedAction struct {
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+}
+
+// Profile reports how long each step of a run took
+type Profile struct {
+	TotalMs int64        `json:"totalMs"` // Sum of step durations
+	Steps   []StepTiming `json:"steps"`
+}
+
+// StepTiming is the duration of a single step in a Profile
+type StepTiming struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// NewProfile builds a Profile from step results
+func NewProfile(steps []StepResult) *Profile {
+	profile := &Profile{Steps: make([]StepTiming, 0, len(steps))}
+	for _, step := range steps {
+		profile.TotalMs += step.DurationMs
+		profile.Steps = append(profile.Steps, StepTiming{Name: step.Name, DurationMs: step.DurationMs})
+	}
+	return profile
+}
+
+// StepResult summarizes the outcome of a single workflow step
+type StepResult struct {
+	Name          string `json:"name"`
+	Success       bool   `json:"success"`
+	Skipped       bool   `json:"skipped,omitempty"`       // The step did not run; Success is false when an earlier step failed
+	DurationMs    int64  `json:"durationMs"`
+	ExitCode      int    `json:"exitCode"`                // -1 when no process exit code is available
+	OutputPreview string `json:"outputPreview,omitempty"` // First 200 chars of output
+	ExecutionID   string `json:"executionId,omitempty"`   // Correlates with [exec-id:...] log lines
 }
 
-// NewAllowResult creates an allow result
+// NewAllowResult creates an allow result. Both timestamps are set to now;
+// callers that run workflows replace them with the real run times.
 func NewAllowResult() *WorkflowResult {
-	return &WorkflowResult{PermissionDecision: "allow"}
+	now := time.Now()
+	return &WorkflowResult{PermissionDecision: "allow", StartedAt: now, FinishedAt: now}
 }
 
-// NewDenyResult creates a deny result with a reason
+// NewDenyResult creates a deny result with a reason, timestamped like NewAllowResult
 func NewDenyResult(reason string) *WorkflowResult {
+	now := time.Now()
 	return &WorkflowResult{
 		PermissionDecision:       "deny",
 		PermissionDecisionReason: reason,
+		StartedAt:                now,
+		FinishedAt:               now,
 	}
 }
@@ -0,0 +1,205 @@
+package trigger
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/htekdev/gh-hookflow/internal/schema"
+)
+
+// CompiledTrigger holds the path, branch and tag patterns of a workflow's
+// triggers, parsed once. A Matcher compiles its workflow when it is created,
+// so matching many events, or a commit with many changed files, does not
+// parse the same patterns again. A CompiledTrigger is never modified after
+// Compile and is safe for concurrent use.
+type CompiledTrigger struct {
+	filePaths         []globPattern
+	filePathsIgnore   []globPattern
+	commitBranch      *refPattern
+	commitBranches    []refPattern
+	commitIgnore      []refPattern
+	commitPaths       []globPattern
+	commitPathsIgnore []globPattern
+	pushBranches      []refPattern
+	pushIgnore        []refPattern
+	pushTags          []refPattern
+	pushTagsIgnore    []refPattern
+	pushPaths         []globPattern
+	pushPathsIgnore   []globPattern
+}
+
+// Compile parses the patterns of every trigger in the workflow
+func Compile(workflow *schema.Workflow) *CompiledTrigger {
+	c := &CompiledTrigger{}
+	on := workflow.On
+	if on.File != nil {
+		c.filePaths = compileGlobs(on.File.Paths, true)
+		c.filePathsIgnore = compileGlobs(on.File.PathsIgnore, false)
+	}
+	if on.Commit != nil {
+		if on.Commit.Branch != "" {
+			branch := compileRef(on.Commit.Branch, false)
+			c.commitBranch = &branch
+		}
+		c.commitBranches = compileRefs(on.Commit.Branches, true)
+		c.commitIgnore = compileRefs(on.Commit.BranchesIgnore, false)
+		c.commitPaths = compileGlobs(on.Commit.Paths, true)
+		c.commitPathsIgnore = compileGlobs(on.Commit.PathsIgnore, false)
+	}
+	if on.Push != nil {
+		c.pushBranches = compileRefs(on.Push.Branches, true)
+		c.pushIgnore = compileRefs(on.Push.BranchesIgnore, false)
+		c.pushTags = compileRefs(on.Push.Tags, true)
+		c.pushTagsIgnore = compileRefs(on.Push.TagsIgnore, false)
+		c.pushPaths = compileGlobs(on.Push.Paths, true)
+		c.pushPathsIgnore = compileGlobs(on.Push.PathsIgnore, false)
+	}
+	return c
+}
+
+// globPattern is a file path pattern split around its first **
+type globPattern struct {
+	raw     string // Pattern as written, for log messages
+	negate  bool   // Written with a leading "!"
+	pattern string // Slash-separated pattern without the "!"
+	double  bool   // Contains **
+	leading bool   // Starts with **, as in **/*.js
+	prefix  string // Before the first **, without a trailing /
+	suffix  string // After the first **, without a leading /
+}
+
+// compileGlob parses a file path pattern. In lists that allow negation a
+// leading "!" is recorded in negate; elsewhere it is part of the pattern.
+func compileGlob(raw string, negatable bool) globPattern {
+	g := globPattern{raw: raw}
+	pattern := raw
+	if negatable && strings.HasPrefix(pattern, "!") {
+		g.negate = true
+		pattern = pattern[1:]
+	}
+	g.pattern = filepath.ToSlash(pattern)
+
+	if parts := strings.Split(g.pattern, "**"); len(parts) > 1 {
+		g.double = true
+		g.leading = parts[0] == ""
+		g.prefix = strings.TrimSuffix(parts[0], "/")
+		g.suffix = strings.TrimPrefix(parts[1], "/")
+	}
+	return g
+}
+
+func compileGlobs(patterns []string, negatable bool) []globPattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	compiled := make([]globPattern, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = compileGlob(pattern, negatable)
+	}
+	return compiled
+}
+
+// match reports whether path matches the pattern. * does not cross "/",
+// while ** matches across directories.
+func (g globPattern) match(path string) bool {
+	path = filepath.ToSlash(path)
+	if !g.double {
+		matched, _ := filepath.Match(g.pattern, path)
+		return matched
+	}
+
+	// For patterns like **/*.js, match the suffix against any trailing
+	// part of the path, or just the file name
+	if g.leading {
+		if matchAnySubpath(g.suffix, path) {
+			return true
+		}
+		matched, _ := filepath.Match(g.suffix, filepath.Base(path))
+		return matched
+	}
+
+	// For patterns like src/**/test.js
+	if !strings.HasPrefix(path, g.prefix) {
+		return false
+	}
+	if g.suffix == "" {
+		return true
+	}
+	remaining := strings.TrimPrefix(strings.TrimPrefix(path, g.prefix), "/")
+	return matchAnySubpath(g.suffix, remaining)
+}
+
+// matchAnySubpath reports whether pattern matches path or any trailing part
+// of it that starts at a segment boundary
+func matchAnySubpath(pattern, path string) bool {
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		if matched, _ := filepath.Match(pattern, strings.Join(parts[i:], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// refPattern is a branch or tag pattern split into segments
+type refPattern struct {
+	raw      string   // Pattern as written
+	negate   bool     // Written with a leading "!"
+	segments []string // Pattern segments without the "!"
+}
+
+// compileRef parses a branch or tag pattern. In lists that allow negation a
+// leading "!" is recorded in negate; elsewhere it is part of the pattern.
+func compileRef(raw string, negatable bool) refPattern {
+	r := refPattern{raw: raw}
+	pattern := raw
+	if negatable && strings.HasPrefix(pattern, "!") {
+		r.negate = true
+		pattern = pattern[1:]
+	}
+	r.segments = strings.Split(pattern, "/")
+	return r
+}
+
+func compileRefs(patterns []string, negatable bool) []refPattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	compiled := make([]refPattern, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = compileRef(pattern, negatable)
+	}
+	return compiled
+}
+
+// match reports whether a branch or tag name matches the pattern, ignoring
+// negation. A "**" segment matches zero or more whole segments, so
+// "feature/**" matches "feature/team/topic"; "*" and other wildcards never
+// cross a "/". path.Match is used so behavior is identical on every platform.
+func (r refPattern) match(name string) bool {
+	return matchSegments(r.segments, strings.Split(name, "/"))
+}
+
+// matchSegments matches name segments against pattern segments
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
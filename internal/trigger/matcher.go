@@ -1,6 +1,9 @@
 package trigger
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -8,14 +11,45 @@ import (
 	"github.com/htekdev/gh-hookflow/internal/schema"
 )
 
-// Matcher determines if a workflow should be triggered by an event
+// Matcher determines if a workflow should be triggered by an event. It is
+// safe for concurrent use; keep one per workflow to reuse its compiled
+// patterns across events.
 type Matcher struct {
 	workflow *schema.Workflow
+	compiled *CompiledTrigger
 }
 
-// NewMatcher creates a new trigger matcher for a workflow
+// NewMatcher creates a new trigger matcher for a workflow, compiling its
+// trigger patterns
 func NewMatcher(workflow *schema.Workflow) *Matcher {
-	return &Matcher{workflow: workflow}
+	return &Matcher{workflow: workflow, compiled: Compile(workflow)}
+}
+
+// MatcherFor returns the matcher cached with a loaded workflow, compiling
+// and caching it on first use. Call it when a workflow is loaded to compile
+// its patterns up front; later calls for the same workflow reuse them.
+func MatcherFor(workflow *schema.Workflow) *Matcher {
+	// A copied Workflow carries the original's matcher, so check the owner
+	if m, ok := workflow.Matcher().(*Matcher); ok && m.workflow == workflow {
+		return m
+	}
+	m := NewMatcher(workflow)
+	workflow.SetMatcher(m)
+	return m
+}
+
+// MatchAll returns the events that match any of the workflow's triggers,
+// in their original order. Nil events are skipped. Match(event) is the
+// single-event case: it reports whether MatchAll([]*schema.Event{event})
+// returns the event.
+func (m *Matcher) MatchAll(events []*schema.Event) []*schema.Event {
+	var matched []*schema.Event
+	for _, event := range events {
+		if event != nil && m.Match(event) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
 }
 
 // Match checks if the event matches any of the workflow's triggers
@@ -27,7 +61,7 @@ func (m *Matcher) Match(event *schema.Event) bool {
 	// Check tool trigger (most specific)
 	if on.Tool != nil && event.Tool != nil {
 		log.Debug("[%s] checking tool trigger for tool=%s", workflowName, event.Tool.Name)
-		if m.matchToolTrigger(on.Tool, event.Tool) {
+		if m.matchToolTrigger(on.Tool, event.Tool, event.GetLifecycle()) {
 			log.Debug("[%s] tool trigger matched", workflowName)
 			return true
 		}
@@ -37,7 +71,7 @@ func (m *Matcher) Match(event *schema.Event) bool {
 	if len(on.Tools) > 0 && event.Tool != nil {
 		log.Debug("[%s] checking %d tools triggers", workflowName, len(on.Tools))
 		for i, toolTrigger := range on.Tools {
-			if m.matchToolTrigger(&toolTrigger, event.Tool) {
+			if m.matchToolTrigger(&toolTrigger, event.Tool, event.GetLifecycle()) {
 				log.Debug("[%s] tools[%d] trigger matched", workflowName, i)
 				return true
 			}
@@ -56,7 +90,7 @@ func (m *Matcher) Match(event *schema.Event) bool {
 	// Check file trigger
 	if on.File != nil && event.File != nil {
 		log.Debug("[%s] checking file trigger for path=%s", workflowName, event.File.Path)
-		if m.matchFileTrigger(on.File, event.File, event.GetLifecycle()) {
+		if m.matchFileTrigger(on.File, event.File, event.GetLifecycle(), event.Cwd) {
 			log.Debug("[%s] file trigger matched", workflowName)
 			return true
 		}
@@ -80,14 +114,35 @@ func (m *Matcher) Match(event *schema.Event) bool {
 		}
 	}
 
+	// Check schedule trigger
+	if on.Schedule != nil && event.Schedule != nil {
+		log.Debug("[%s] checking schedule trigger", workflowName)
+		if matchScheduleTrigger(on.Schedule, event.Schedule) {
+			log.Debug("[%s] schedule trigger matched", workflowName)
+			return true
+		}
+	}
+
 	log.Debug("[%s] no triggers matched", workflowName)
 	return false
 }
 
 // matchToolTrigger checks if a tool event matches a tool trigger
-func (m *Matcher) matchToolTrigger(trigger *schema.ToolTrigger, event *schema.ToolEvent) bool {
-	// Check tool name
-	if trigger.Name != event.Name {
+func (m *Matcher) matchToolTrigger(trigger *schema.ToolTrigger, event *schema.ToolEvent, eventLifecycle string) bool {
+	if !lifecycleMatches(trigger.GetLifecycle(), eventLifecycle) {
+		logging.Context("trigger").Debug("lifecycle mismatch: trigger=%s, event=%s", trigger.GetLifecycle(), eventLifecycle)
+		return false
+	}
+
+	// Check tool name (name or any of names)
+	found := false
+	for _, name := range trigger.ToolNames() {
+		if name == event.Name {
+			found = true
+			break
+		}
+	}
+	if !found {
 		return false
 	}
 
@@ -123,6 +178,11 @@ func (m *Matcher) matchHooksTrigger(trigger *schema.HooksTrigger, event *schema.
 		}
 	}
 
+	// The raw hook type is compared as is, without lifecycle normalization
+	if trigger.HookType != "" && trigger.HookType != event.Type {
+		return false
+	}
+
 	// Check tools filter
 	if len(trigger.Tools) > 0 && event.Tool != nil {
 		found := false
@@ -140,83 +200,247 @@ func (m *Matcher) matchHooksTrigger(trigger *schema.HooksTrigger, event *schema.
 	return true
 }
 
+// anyLifecycle and anyLifecycleName are the trigger lifecycles that match
+// every event lifecycle
+const (
+	anyLifecycle     = "*"
+	anyLifecycleName = "any"
+)
+
+// lifecycleMatches reports whether a trigger lifecycle accepts an event lifecycle
+func lifecycleMatches(triggerLifecycle, eventLifecycle string) bool {
+	return triggerLifecycle == anyLifecycle || triggerLifecycle == anyLifecycleName || triggerLifecycle == eventLifecycle
+}
+
 // matchFileTrigger checks if a file event matches a file trigger
-func (m *Matcher) matchFileTrigger(trigger *schema.FileTrigger, event *schema.FileEvent, eventLifecycle string) bool {
+func (m *Matcher) matchFileTrigger(trigger *schema.FileTrigger, event *schema.FileEvent, eventLifecycle, cwd string) bool {
 	log := logging.Context("trigger")
 
 	// Check lifecycle first
-	if trigger.GetLifecycle() != eventLifecycle {
+	if !lifecycleMatches(trigger.GetLifecycle(), eventLifecycle) {
 		log.Debug("lifecycle mismatch: trigger=%s, event=%s", trigger.GetLifecycle(), eventLifecycle)
 		return false
 	}
 
-	// Check file types
-	if len(trigger.Types) > 0 {
+	// Check file actions
+	if actions := trigger.GetActions(); len(actions) > 0 {
 		found := false
-		for _, t := range trigger.Types {
-			if t == event.Action {
+		for _, a := range actions {
+			if schema.NormalizeFileAction(a) == schema.NormalizeFileAction(event.Action) {
 				found = true
 				break
 			}
 		}
 		if !found {
-			log.Debug("action %s not in types %v", event.Action, trigger.Types)
+			log.Debug("action %s not in actions %v", event.Action, actions)
 			return false
 		}
 	}
 
+	// Apply the symlink policy; symlinks are matched by the path they resolve to
+	path := event.Path
+	resolved, isLink := resolveSymlink(event.Path, cwd)
+	switch {
+	case isLink && trigger.Symlinks == schema.FileSymlinksIgnore:
+		log.Debug("path %s is a symlink and symlinks are ignored", event.Path)
+		return false
+	case !isLink && trigger.Symlinks == schema.FileSymlinksOnly:
+		log.Debug("path %s is not a symlink", event.Path)
+		return false
+	case isLink:
+		log.Debug("path %s is a symlink to %s", event.Path, resolved)
+		path = resolved
+	}
+
 	// Check paths-ignore first
-	if len(trigger.PathsIgnore) > 0 {
-		for _, pattern := range trigger.PathsIgnore {
-			if matchGlob(pattern, event.Path) {
-				log.Debug("path %s matches paths-ignore pattern %s", event.Path, pattern)
-				return false
-			}
+	for _, pattern := range m.compiled.filePathsIgnore {
+		if pattern.match(path) {
+			log.Debug("path %s matches paths-ignore pattern %s", path, pattern.raw)
+			return false
 		}
 	}
 
 	// Check paths
-	if len(trigger.Paths) > 0 {
+	if len(m.compiled.filePaths) > 0 {
 		matched := false
-		for _, pattern := range trigger.Paths {
+		for _, pattern := range m.compiled.filePaths {
 			// Handle negation
-			if strings.HasPrefix(pattern, "!") {
-				if matchGlob(pattern[1:], event.Path) {
-					log.Debug("path %s matches negation pattern %s", event.Path, pattern)
+			if pattern.negate {
+				if pattern.match(path) {
+					log.Debug("path %s matches negation pattern %s", path, pattern.raw)
 					matched = false
 				}
-			} else if matchGlob(pattern, event.Path) {
-				log.Debug("path %s matches pattern %s", event.Path, pattern)
+			} else if pattern.match(path) {
+				log.Debug("path %s matches pattern %s", path, pattern.raw)
 				matched = true
 			}
 		}
 		if !matched {
-			log.Debug("path %s did not match any of %d patterns", event.Path, len(trigger.Paths))
+			log.Debug("path %s did not match any of %d patterns", path, len(m.compiled.filePaths))
 			return false
 		}
 	}
 
+	// Check changed-lines threshold
+	if trigger.MinChangedLines > 0 {
+		if changed := event.ChangedLineCount(); changed < trigger.MinChangedLines {
+			log.Debug("%d changed lines below min-changed-lines %d", changed, trigger.MinChangedLines)
+			return false
+		}
+	}
+
+	// Check size bounds against the file on disk
+	if trigger.MinBytes > 0 || trigger.MaxBytes > 0 {
+		filePath := event.Path
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(cwd, filePath)
+		}
+		// Files that do not exist yet (e.g. pre-create) skip the size check
+		if info, err := os.Stat(filePath); err == nil {
+			size := info.Size()
+			if trigger.MinBytes > 0 && size < trigger.MinBytes {
+				log.Debug("file size %d below min-bytes %d", size, trigger.MinBytes)
+				return false
+			}
+			if trigger.MaxBytes > 0 && size > trigger.MaxBytes {
+				log.Debug("file size %d above max-bytes %d", size, trigger.MaxBytes)
+				return false
+			}
+		}
+	}
+
+	// Check text vs binary content
+	if trigger.Encoding != "" {
+		if binary, known := isBinaryFile(event, cwd); known {
+			if binary != (trigger.Encoding == schema.FileEncodingBinary) {
+				log.Debug("path %s does not have encoding %s", event.Path, trigger.Encoding)
+				return false
+			}
+		}
+	}
+
 	log.Debug("file trigger matched for path=%s", event.Path)
 	return true
 }
 
+// resolveSymlink reports whether path is a symlink, checked with os.Lstat,
+// and returns the path it resolves to. A relative path resolves relative to
+// cwd when the target is inside it; a missing file is not a symlink.
+func resolveSymlink(path, cwd string) (resolved string, isLink bool) {
+	filePath := path
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(cwd, filePath)
+	}
+	info, err := os.Lstat(filePath)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return path, false
+	}
+
+	target, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		// A dangling or looping link is matched by its own path
+		return path, true
+	}
+	if !filepath.IsAbs(path) {
+		root := cwd
+		if realRoot, err := filepath.EvalSymlinks(cwd); err == nil {
+			root = realRoot
+		}
+		if rel, err := filepath.Rel(root, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel), true
+		}
+	}
+	return filepath.ToSlash(target), true
+}
+
+// binarySniffLen is how much of a file is checked for null bytes
+const binarySniffLen = 8 * 1024
+
+// isBinaryFile reports whether the file holds binary data, judged by a null
+// byte in its first 8 KB. The file on disk is checked, then the event content
+// for files that do not exist yet; known is false when neither is available.
+func isBinaryFile(event *schema.FileEvent, cwd string) (binary, known bool) {
+	filePath := event.Path
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(cwd, filePath)
+	}
+
+	var head []byte
+	if f, err := os.Open(filePath); err == nil {
+		buf := make([]byte, binarySniffLen)
+		n, _ := io.ReadFull(f, buf)
+		_ = f.Close()
+		head = buf[:n]
+	} else if event.Content != "" {
+		head = []byte(event.Content)
+		if len(head) > binarySniffLen {
+			head = head[:binarySniffLen]
+		}
+	} else {
+		return false, false
+	}
+	return bytes.IndexByte(head, 0) >= 0, true
+}
+
 // matchCommitTrigger checks if a commit event matches a commit trigger
 func (m *Matcher) matchCommitTrigger(trigger *schema.CommitTrigger, event *schema.CommitEvent, eventLifecycle string) bool {
 	// Check lifecycle first
-	if trigger.GetLifecycle() != eventLifecycle {
+	if !lifecycleMatches(trigger.GetLifecycle(), eventLifecycle) {
 		return false
 	}
 
-	// Check branches - would need branch info from context
-	// For now, focus on path matching
+	// Check author
+	if trigger.Author != "" {
+		if matched, err := filepath.Match(trigger.Author, event.Author); err != nil || !matched {
+			return false
+		}
+	}
 
+	// Check branch; an unknown branch skips the branch filters, as for push
+	if event.Branch != "" {
+		if m.compiled.commitBranch != nil && !m.compiled.commitBranch.match(event.Branch) {
+			return false
+		}
+		if !matchBranches(m.compiled.commitBranches, m.compiled.commitIgnore, event.Branch) {
+			return false
+		}
+	}
+
+	files := event.Files
+	if len(trigger.StatusFilter) > 0 {
+		files = filterFileStatuses(files, trigger.StatusFilter)
+		if len(files) == 0 {
+			return false
+		}
+	}
+	return matchChangedFiles(m.compiled.commitPaths, m.compiled.commitPathsIgnore, files)
+}
+
+// filterFileStatuses returns the files whose status is one of statuses
+func filterFileStatuses(files []schema.FileStatus, statuses []string) []schema.FileStatus {
+	var filtered []schema.FileStatus
+	for _, file := range files {
+		for _, status := range statuses {
+			if file.Status == status {
+				filtered = append(filtered, file)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// matchChangedFiles checks the files changed by a commit or push against
+// paths and paths-ignore: it fails when every file is ignored, or when paths
+// is set and no file matches it. Empty filters always pass.
+func matchChangedFiles(paths, pathsIgnore []globPattern, files []schema.FileStatus) bool {
 	// Check paths-ignore
-	if len(trigger.PathsIgnore) > 0 {
+	if len(pathsIgnore) > 0 {
 		allIgnored := true
-		for _, file := range event.Files {
+		for _, file := range files {
 			ignored := false
-			for _, pattern := range trigger.PathsIgnore {
-				if matchGlob(pattern, file.Path) {
+			for _, pattern := range pathsIgnore {
+				if pattern.match(file.Path) {
 					ignored = true
 					break
 				}
@@ -232,14 +456,14 @@ func (m *Matcher) matchCommitTrigger(trigger *schema.CommitTrigger, event *schem
 	}
 
 	// Check paths
-	if len(trigger.Paths) > 0 {
+	if len(paths) > 0 {
 		matched := false
-		for _, file := range event.Files {
-			for _, pattern := range trigger.Paths {
-				if strings.HasPrefix(pattern, "!") {
+		for _, file := range files {
+			for _, pattern := range paths {
+				if pattern.negate {
 					continue
 				}
-				if matchGlob(pattern, file.Path) {
+				if pattern.match(file.Path) {
 					matched = true
 					break
 				}
@@ -256,148 +480,93 @@ func (m *Matcher) matchCommitTrigger(trigger *schema.CommitTrigger, event *schem
 	return true
 }
 
-// matchPushTrigger checks if a push event matches a push trigger
-func (m *Matcher) matchPushTrigger(trigger *schema.PushTrigger, event *schema.PushEvent, eventLifecycle string) bool {
-	// Check lifecycle first
-	if trigger.GetLifecycle() != eventLifecycle {
+// matchBranches checks a branch against branches and branches-ignore.
+// A "!" pattern in branches excludes branches an earlier pattern matched.
+// Empty filters always pass.
+func matchBranches(branches, branchesIgnore []refPattern, branch string) bool {
+	if len(branches) > 0 && !matchRefs(branches, branch) {
 		return false
 	}
 
-	// Check branches
-	if len(trigger.Branches) > 0 {
-		branch := extractBranch(event.Ref)
-		if branch != "" {
-			matched := false
-			for _, pattern := range trigger.Branches {
-				if strings.HasPrefix(pattern, "!") {
-					if matchGlob(pattern[1:], branch) {
-						matched = false
-					}
-				} else if matchGlob(pattern, branch) {
-					matched = true
-				}
-			}
-			if !matched {
-				return false
-			}
+	for _, pattern := range branchesIgnore {
+		if pattern.match(branch) {
+			return false
 		}
 	}
+	return true
+}
 
-	// Check branches-ignore
-	if len(trigger.BranchesIgnore) > 0 {
-		branch := extractBranch(event.Ref)
-		if branch != "" {
-			for _, pattern := range trigger.BranchesIgnore {
-				if matchGlob(pattern, branch) {
-					return false
-				}
-			}
+// matchRefs reports whether a branch or tag name matches a pattern list.
+// Patterns apply in order, so a "!" pattern excludes names an earlier
+// pattern matched.
+func matchRefs(patterns []refPattern, name string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if pattern.match(name) {
+			matched = !pattern.negate
 		}
 	}
+	return matched
+}
 
-	// Check tags
-	if len(trigger.Tags) > 0 {
-		tag := extractTag(event.Ref)
-		if tag == "" {
-			return false
-		}
-		matched := false
-		for _, pattern := range trigger.Tags {
-			if strings.HasPrefix(pattern, "!") {
-				if matchGlob(pattern[1:], tag) {
-					matched = false
-				}
-			} else if matchGlob(pattern, tag) {
-				matched = true
-			}
-		}
-		if !matched {
+// matchPushTrigger checks if a push event matches a push trigger
+func (m *Matcher) matchPushTrigger(trigger *schema.PushTrigger, event *schema.PushEvent, eventLifecycle string) bool {
+	// Check lifecycle first
+	if !lifecycleMatches(trigger.GetLifecycle(), eventLifecycle) {
+		return false
+	}
+
+	// Check branches and branches-ignore
+	if branch := extractBranch(event.Ref); branch != "" {
+		if !matchBranches(m.compiled.pushBranches, m.compiled.pushIgnore, branch) {
 			return false
 		}
 	}
 
-	// Check tags-ignore
-	if len(trigger.TagsIgnore) > 0 {
+	// Check tags
+	if len(m.compiled.pushTags) > 0 {
 		tag := extractTag(event.Ref)
-		if tag != "" {
-			for _, pattern := range trigger.TagsIgnore {
-				if matchGlob(pattern, tag) {
-					return false
-				}
-			}
+		if tag == "" || !matchRefs(m.compiled.pushTags, tag) {
+			return false
 		}
 	}
 
-	return true
-}
-
-// matchGlob performs glob pattern matching
-func matchGlob(pattern, path string) bool {
-	// Normalize path separators
-	pattern = filepath.ToSlash(pattern)
-	path = filepath.ToSlash(path)
-
-	// Handle ** patterns
-	if strings.Contains(pattern, "**") {
-		return matchDoubleGlob(pattern, path)
-	}
-
-	// Use filepath.Match for simple patterns
-	matched, _ := filepath.Match(pattern, path)
-	return matched
-}
-
-// matchDoubleGlob handles ** patterns that match across directories
-func matchDoubleGlob(pattern, path string) bool {
-	parts := strings.Split(pattern, "**")
-	if len(parts) == 1 {
-		matched, _ := filepath.Match(pattern, path)
-		return matched
-	}
-
-	// For patterns like **/*.js
-	if parts[0] == "" {
-		suffix := strings.TrimPrefix(parts[1], "/")
-		// Match suffix against any path segment
-		pathParts := strings.Split(path, "/")
-		for i := range pathParts {
-			subpath := strings.Join(pathParts[i:], "/")
-			if matched, _ := filepath.Match(suffix, subpath); matched {
-				return true
+	// Check tags-ignore
+	if tag := extractTag(event.Ref); tag != "" {
+		for _, pattern := range m.compiled.pushTagsIgnore {
+			if pattern.match(tag) {
+				return false
 			}
 		}
-		// Also try matching just the filename
-		if matched, _ := filepath.Match(suffix, filepath.Base(path)); matched {
-			return true
-		}
-		return false
 	}
 
-	// For patterns like src/**/test.js
-	prefix := strings.TrimSuffix(parts[0], "/")
-	suffix := strings.TrimPrefix(parts[1], "/")
-
-	if !strings.HasPrefix(path, prefix) {
-		return false
+	// Check the files in the push diff. Without a before commit (e.g. a
+	// branch with no upstream yet) the changed files are unknown, so the
+	// path filters are skipped.
+	if event.Before == "" && len(event.Files) == 0 {
+		return true
 	}
+	return matchChangedFiles(m.compiled.pushPaths, m.compiled.pushPathsIgnore, event.Files)
+}
 
-	remaining := strings.TrimPrefix(path, prefix)
-	remaining = strings.TrimPrefix(remaining, "/")
-
-	if suffix == "" {
+// matchScheduleTrigger checks if a schedule event fired for this trigger's
+// cron expression. An event without a cron (run --schedule-now) matches every
+// schedule trigger.
+func matchScheduleTrigger(trigger *schema.ScheduleTrigger, event *schema.ScheduleEvent) bool {
+	if event.Cron == "" {
 		return true
 	}
+	return strings.Join(strings.Fields(trigger.Cron), " ") == strings.Join(strings.Fields(event.Cron), " ")
+}
 
-	// Match suffix against remaining path
-	pathParts := strings.Split(remaining, "/")
-	for i := range pathParts {
-		subpath := strings.Join(pathParts[i:], "/")
-		if matched, _ := filepath.Match(suffix, subpath); matched {
-			return true
-		}
-	}
+// matchGlob matches a file path against a single pattern
+func matchGlob(pattern, path string) bool {
+	return compileGlob(pattern, false).match(path)
+}
 
-	return false
+// matchRefGlob matches a branch or tag name against a single pattern
+func matchRefGlob(pattern, name string) bool {
+	return compileRef(pattern, false).match(name)
 }
 
 // extractBranch extracts branch name from a ref
@@ -1,6 +1,11 @@
 package trigger
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/htekdev/gh-hookflow/internal/schema"
@@ -35,6 +40,44 @@ func TestMatchToolTrigger(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "names list match",
+			trigger: &schema.ToolTrigger{
+				Names: []string{"edit", "create"},
+			},
+			event: &schema.ToolEvent{
+				Name: "create",
+				Args: map[string]interface{}{},
+			},
+			want: true,
+		},
+		{
+			name: "names list mismatch",
+			trigger: &schema.ToolTrigger{
+				Names: []string{"edit", "create"},
+			},
+			event: &schema.ToolEvent{
+				Name: "powershell",
+				Args: map[string]interface{}{},
+			},
+			want: false,
+		},
+		{
+			name: "names list with args glob",
+			trigger: &schema.ToolTrigger{
+				Names: []string{"edit", "create"},
+				Args: map[string]string{
+					"path": "**/*.env",
+				},
+			},
+			event: &schema.ToolEvent{
+				Name: "edit",
+				Args: map[string]interface{}{
+					"path": "config/.env",
+				},
+			},
+			want: true,
+		},
 		{
 			name: "args glob match",
 			trigger: &schema.ToolTrigger{
@@ -163,6 +206,58 @@ func TestMatchHooksTrigger(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "match raw hook type",
+			trigger: &schema.HooksTrigger{
+				HookType: "postToolUse",
+			},
+			event: &schema.HookEvent{
+				Type: "postToolUse",
+			},
+			want: true,
+		},
+		{
+			name: "no match raw hook type",
+			trigger: &schema.HooksTrigger{
+				HookType: "postToolUse",
+			},
+			event: &schema.HookEvent{
+				Type: "preToolUse",
+			},
+			want: false,
+		},
+		{
+			name: "raw hook type is not normalized",
+			trigger: &schema.HooksTrigger{
+				HookType: "pre",
+			},
+			event: &schema.HookEvent{
+				Type: "preToolUse",
+			},
+			want: false,
+		},
+		{
+			name: "raw hook type is case sensitive",
+			trigger: &schema.HooksTrigger{
+				HookType: "PreToolUse",
+			},
+			event: &schema.HookEvent{
+				Type: "preToolUse",
+			},
+			want: false,
+		},
+		{
+			name: "raw hook type with tool filter",
+			trigger: &schema.HooksTrigger{
+				HookType: "preToolUse",
+				Tools:    []string{"edit"},
+			},
+			event: &schema.HookEvent{
+				Type: "preToolUse",
+				Tool: &schema.ToolEvent{Name: "create"},
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -191,7 +286,62 @@ func TestMatchFileTrigger(t *testing.T) {
 		want    bool
 	}{
 		{
-			name: "match file type",
+			name: "match file action",
+			trigger: &schema.FileTrigger{
+				Actions: []string{"edit"},
+			},
+			event: &schema.FileEvent{
+				Path:   "src/main.go",
+				Action: "edit",
+			},
+			want: true,
+		},
+		{
+			name: "file action mismatch",
+			trigger: &schema.FileTrigger{
+				Actions: []string{"create"},
+			},
+			event: &schema.FileEvent{
+				Path:   "src/main.go",
+				Action: "edit",
+			},
+			want: false,
+		},
+		{
+			name: "deleted matches delete events",
+			trigger: &schema.FileTrigger{
+				Types: []string{"deleted"},
+			},
+			event: &schema.FileEvent{
+				Path:   "config/prod.yml",
+				Action: "delete",
+			},
+			want: true,
+		},
+		{
+			name: "delete matches deleted events",
+			trigger: &schema.FileTrigger{
+				Actions: []string{"delete"},
+			},
+			event: &schema.FileEvent{
+				Path:   "config/prod.yml",
+				Action: "deleted",
+			},
+			want: true,
+		},
+		{
+			name: "deleted does not match edit events",
+			trigger: &schema.FileTrigger{
+				Types: []string{"deleted"},
+			},
+			event: &schema.FileEvent{
+				Path:   "config/prod.yml",
+				Action: "edit",
+			},
+			want: false,
+		},
+		{
+			name: "match deprecated file type",
 			trigger: &schema.FileTrigger{
 				Types: []string{"edit"},
 			},
@@ -201,6 +351,18 @@ func TestMatchFileTrigger(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "actions take precedence over types",
+			trigger: &schema.FileTrigger{
+				Actions: []string{"create"},
+				Types:   []string{"edit"},
+			},
+			event: &schema.FileEvent{
+				Path:   "src/main.go",
+				Action: "edit",
+			},
+			want: false,
+		},
 		{
 			name: "match path pattern",
 			trigger: &schema.FileTrigger{
@@ -282,6 +444,46 @@ func TestMatchPushTrigger(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "match nested branch with double star",
+			trigger: &schema.PushTrigger{
+				Branches: []string{"feature/**"},
+			},
+			event: &schema.PushEvent{
+				Ref: "refs/heads/feature/my-team/my-feature",
+			},
+			want: true,
+		},
+		{
+			name: "double star does not match sibling prefix",
+			trigger: &schema.PushTrigger{
+				Branches: []string{"feature/**"},
+			},
+			event: &schema.PushEvent{
+				Ref: "refs/heads/features/my-feature",
+			},
+			want: false,
+		},
+		{
+			name: "single star does not match nested branch",
+			trigger: &schema.PushTrigger{
+				Branches: []string{"feature/*"},
+			},
+			event: &schema.PushEvent{
+				Ref: "refs/heads/feature/my-team/my-feature",
+			},
+			want: false,
+		},
+		{
+			name: "branch ignore with double star",
+			trigger: &schema.PushTrigger{
+				BranchesIgnore: []string{"temp/**"},
+			},
+			event: &schema.PushEvent{
+				Ref: "refs/heads/temp/a/b/c",
+			},
+			want: false,
+		},
 		{
 			name: "branch ignore",
 			trigger: &schema.PushTrigger{
@@ -356,6 +558,34 @@ func TestMatchGlob(t *testing.T) {
 	}
 }
 
+func TestMatchRefGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"main", "main", true},
+		{"feature/*", "feature/x", true},
+		{"feature/*", "feature/x/y", false},
+		{"feature/**", "feature/x", true},
+		{"feature/**", "feature/x/y/z", true},
+		{"feature/**", "featurex/y", false},
+		{"**/hotfix", "release/1.0/hotfix", true},
+		{"**/hotfix", "hotfix", true},
+		{"release/**/rc", "release/1.0/2/rc", true},
+		{"release/**/rc", "release/1.0/final", false},
+		{"**", "any/branch/name", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.name, func(t *testing.T) {
+			if got := matchRefGlob(tt.pattern, tt.name); got != tt.want {
+				t.Errorf("matchRefGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractBranch(t *testing.T) {
 	tests := []struct {
 		ref  string
@@ -850,6 +1080,16 @@ func TestPushTriggerBranchAndTag(t *testing.T) {
 			},
 			want: false, // No matching tag (branch is not a tag)
 		},
+		{
+			name: "nested branch with double star and negation",
+			trigger: &schema.PushTrigger{
+				Branches: []string{"release/**", "!release/**/experimental"},
+			},
+			event: &schema.PushEvent{
+				Ref: "refs/heads/release/2.0/experimental",
+			},
+			want: false,
+		},
 		{
 			name: "tag with negation pattern",
 			trigger: &schema.PushTrigger{
@@ -1053,6 +1293,364 @@ func TestFileTriggerNoTypes(t *testing.T) {
 	}
 }
 
+// TestFileTriggerSize tests the min-bytes and max-bytes bounds on file triggers
+func TestFileTriggerSize(t *testing.T) {
+	cwd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cwd, "model.bin"), make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		min  int64
+		max  int64
+		want bool
+	}{
+		{"no bounds", "model.bin", 0, 0, true},
+		{"above min", "model.bin", 1024, 0, true},
+		{"below min", "model.bin", 4096, 0, false},
+		{"below max", "model.bin", 0, 4096, true},
+		{"above max", "model.bin", 0, 1024, false},
+		{"within range", "model.bin", 1024, 4096, true},
+		{"exact bounds", "model.bin", 2048, 2048, true},
+		{"absolute path", filepath.Join(cwd, "model.bin"), 4096, 0, false},
+		{"missing file skips check", "new.bin", 4096, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					File: &schema.FileTrigger{MinBytes: tt.min, MaxBytes: tt.max},
+				},
+			}
+			event := &schema.Event{
+				Cwd:  cwd,
+				File: &schema.FileEvent{Path: tt.path, Action: "create"},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileTriggerEncoding tests the text and binary encoding filter on file triggers
+func TestFileTriggerEncoding(t *testing.T) {
+	cwd := t.TempDir()
+	// The null byte sits past the first 8 KB, so this still counts as text
+	lateNull := append([]byte(strings.Repeat("a", 9000)), 0)
+	files := map[string][]byte{
+		"logo.png":  {0x89, 'P', 'N', 'G', 0x00, 0x1a},
+		"main.go":   []byte("package main\n"),
+		"big.txt":   lateNull,
+		"empty.txt": {},
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(cwd, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		path     string
+		content  string
+		want     bool
+	}{
+		{"unset fires for binary", "", "logo.png", "", true},
+		{"unset fires for text", "", "main.go", "", true},
+		{"text skips binary", schema.FileEncodingText, "logo.png", "", false},
+		{"text fires for text", schema.FileEncodingText, "main.go", "", true},
+		{"binary fires for binary", schema.FileEncodingBinary, "logo.png", "", true},
+		{"binary skips text", schema.FileEncodingBinary, "main.go", "", false},
+		{"null byte past 8 KB", schema.FileEncodingText, "big.txt", "", true},
+		{"empty file is text", schema.FileEncodingBinary, "empty.txt", "", false},
+		{"disk wins over content", schema.FileEncodingText, "logo.png", "text", false},
+		{"new file uses content", schema.FileEncodingText, "new.bin", "a\x00b", false},
+		{"new text file uses content", schema.FileEncodingText, "new.txt", "hello", true},
+		{"unknown encoding skips check", schema.FileEncodingBinary, "new.txt", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					File: &schema.FileTrigger{Encoding: tt.encoding},
+				},
+			}
+			event := &schema.Event{
+				Cwd:  cwd,
+				File: &schema.FileEvent{Path: tt.path, Action: "create", Content: tt.content},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileTriggerSymlinks(t *testing.T) {
+	cwd := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cwd, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(cwd, "src", "main.go"), filepath.Join(outside, "shared.go")} {
+		if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"link.go":     filepath.Join(cwd, "src", "main.go"),
+		"external.go": filepath.Join(outside, "shared.go"),
+		"dangling.go": filepath.Join(cwd, "missing.go"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(cwd, name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		symlinks string
+		paths    []string
+		path     string
+		want     bool
+	}{
+		{"regular file fires by default", "", []string{"src/**"}, "src/main.go", true},
+		{"follow matches the resolved path", "", []string{"src/**"}, "link.go", true},
+		{"follow ignores the link path", schema.FileSymlinksFollow, []string{"link.go"}, "link.go", false},
+		{"follow skips targets outside the patterns", "", []string{"*.go"}, "external.go", false},
+		{"dangling link keeps its own path", "", []string{"*.go"}, "dangling.go", true},
+		{"ignore skips symlinks", schema.FileSymlinksIgnore, []string{"src/**"}, "link.go", false},
+		{"ignore fires for regular files", schema.FileSymlinksIgnore, []string{"src/**"}, "src/main.go", true},
+		{"only fires for symlinks", schema.FileSymlinksOnly, []string{"src/**"}, "link.go", true},
+		{"only skips regular files", schema.FileSymlinksOnly, []string{"src/**"}, "src/main.go", false},
+		{"only skips missing files", schema.FileSymlinksOnly, nil, "new.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					File: &schema.FileTrigger{Symlinks: tt.symlinks, Paths: tt.paths},
+				},
+			}
+			event := &schema.Event{
+				Cwd:  cwd,
+				File: &schema.FileEvent{Path: tt.path, Action: "edit"},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAll(t *testing.T) {
+	workflow := &schema.Workflow{
+		Name: "sources",
+		On: schema.OnConfig{
+			File: &schema.FileTrigger{Paths: []string{"src/**"}, Actions: []string{"create", "edit"}},
+		},
+	}
+	editSource := &schema.Event{File: &schema.FileEvent{Path: "src/app.go", Action: "edit"}}
+	createSource := &schema.Event{File: &schema.FileEvent{Path: "src/app.go", Action: "create"}}
+	editDocs := &schema.Event{File: &schema.FileEvent{Path: "docs/readme.md", Action: "edit"}}
+	toolOnly := &schema.Event{Tool: &schema.ToolEvent{Name: "view"}}
+
+	m := NewMatcher(workflow)
+	got := m.MatchAll([]*schema.Event{editSource, editDocs, nil, toolOnly, createSource})
+	if len(got) != 2 || got[0] != editSource || got[1] != createSource {
+		t.Errorf("MatchAll() = %v, want the two source events in order", got)
+	}
+
+	if got := m.MatchAll(nil); len(got) != 0 {
+		t.Errorf("MatchAll(nil) = %v, want none", got)
+	}
+	if got := m.MatchAll([]*schema.Event{editDocs, toolOnly}); got != nil {
+		t.Errorf("MatchAll() = %v, want nil when nothing matches", got)
+	}
+
+	// Match agrees with MatchAll on a single event
+	for _, event := range []*schema.Event{editSource, editDocs, toolOnly, createSource} {
+		if single := m.MatchAll([]*schema.Event{event}); m.Match(event) != (len(single) == 1) {
+			t.Errorf("Match() = %v but MatchAll() = %v for %+v", m.Match(event), single, event)
+		}
+	}
+}
+
+// TestCommitTriggerAuthor tests the author glob filter on commit triggers
+func TestCommitTriggerAuthor(t *testing.T) {
+	tests := []struct {
+		name   string
+		author string
+		paths  []string
+		want   bool
+	}{
+		{"no filter", "", nil, true},
+		{"exact match", "copilot-bot@github.com", nil, true},
+		{"exact mismatch", "someone@github.com", nil, false},
+		{"domain wildcard", "*@github.com", nil, true},
+		{"domain wildcard mismatch", "*@example.com", nil, false},
+		{"author and paths match", "*@github.com", []string{"src/**"}, true},
+		{"author matches but paths do not", "*@github.com", []string{"docs/**"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					Commit: &schema.CommitTrigger{Author: tt.author, Paths: tt.paths},
+				},
+			}
+			event := &schema.Event{
+				Commit: &schema.CommitEvent{
+					SHA:    "abc123",
+					Author: "copilot-bot@github.com",
+					Files:  []schema.FileStatus{{Path: "src/main.go", Status: "modified"}},
+				},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCommitTriggerBranch tests the branch filters on commit triggers
+func TestCommitTriggerBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger schema.CommitTrigger
+		branch  string
+		want    bool
+	}{
+		{"no filter", schema.CommitTrigger{}, "feature/login", true},
+		{"exact branch", schema.CommitTrigger{Branch: "main"}, "main", true},
+		{"other branch", schema.CommitTrigger{Branch: "main"}, "feature/login", false},
+		{"single segment glob", schema.CommitTrigger{Branch: "release/*"}, "release/1.2", true},
+		{"single segment glob does not cross /", schema.CommitTrigger{Branch: "release/*"}, "release/1.2/hotfix", false},
+		{"any depth glob", schema.CommitTrigger{Branch: "feature/**"}, "feature/team/login", true},
+		{"unknown branch skips the filter", schema.CommitTrigger{Branch: "main"}, "", true},
+		{"branch and paths match", schema.CommitTrigger{Branch: "main", Paths: []string{"src/**"}}, "main", true},
+		{"branch matches but paths do not", schema.CommitTrigger{Branch: "main", Paths: []string{"docs/**"}}, "main", false},
+		{"branch and author must both match", schema.CommitTrigger{Branch: "main", Author: "*@example.com"}, "main", false},
+		{"branches list", schema.CommitTrigger{Branches: []string{"main", "release/**"}}, "release/1.2", true},
+		{"branches list mismatch", schema.CommitTrigger{Branches: []string{"main"}}, "develop", false},
+		{"branches negation", schema.CommitTrigger{Branches: []string{"feature/**", "!feature/wip"}}, "feature/wip", false},
+		{"branches-ignore", schema.CommitTrigger{BranchesIgnore: []string{"wip/**"}}, "wip/spike", false},
+		{"branch with branches-ignore", schema.CommitTrigger{Branch: "feature/**", BranchesIgnore: []string{"feature/wip"}}, "feature/login", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := tt.trigger
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{Commit: &trigger},
+			}
+			event := &schema.Event{
+				Commit: &schema.CommitEvent{
+					SHA:    "abc123",
+					Author: "copilot-bot@github.com",
+					Branch: tt.branch,
+					Files:  []schema.FileStatus{{Path: "src/main.go", Status: "modified"}},
+				},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCommitTriggerStatusFilter tests the file status filter on commit triggers
+func TestCommitTriggerStatusFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger schema.CommitTrigger
+		files   []schema.FileStatus
+		want    bool
+	}{
+		{"no filter", schema.CommitTrigger{}, []schema.FileStatus{{Path: "src/main.go", Status: "modified"}}, true},
+		{"added file matches", schema.CommitTrigger{StatusFilter: []string{"added"}}, []schema.FileStatus{{Path: "src/new.go", Status: "added"}}, true},
+		{"modified only does not match", schema.CommitTrigger{StatusFilter: []string{"added"}}, []schema.FileStatus{{Path: "src/main.go", Status: "modified"}}, false},
+		{"several statuses", schema.CommitTrigger{StatusFilter: []string{"added", "deleted"}}, []schema.FileStatus{{Path: "src/old.go", Status: "deleted"}}, true},
+		{"copied file matches", schema.CommitTrigger{StatusFilter: []string{"copied"}}, []schema.FileStatus{{Path: "src/copy.go", Status: "copied"}}, true},
+		{"added file in paths", schema.CommitTrigger{StatusFilter: []string{"added"}, Paths: []string{"migrations/**"}}, []schema.FileStatus{
+			{Path: "src/main.go", Status: "added"},
+			{Path: "migrations/002.sql", Status: "added"},
+		}, true},
+		{"modified file in paths is filtered out", schema.CommitTrigger{StatusFilter: []string{"added"}, Paths: []string{"migrations/**"}}, []schema.FileStatus{
+			{Path: "src/main.go", Status: "added"},
+			{Path: "migrations/001.sql", Status: "modified"},
+		}, false},
+		{"paths-ignore applies to filtered files", schema.CommitTrigger{StatusFilter: []string{"added"}, PathsIgnore: []string{"**/*.md"}}, []schema.FileStatus{
+			{Path: "README.md", Status: "added"},
+			{Path: "src/main.go", Status: "modified"},
+		}, false},
+		{"no files", schema.CommitTrigger{StatusFilter: []string{"added"}}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := tt.trigger
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{Commit: &trigger},
+			}
+			event := &schema.Event{
+				Commit: &schema.CommitEvent{SHA: "abc123", Files: tt.files},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFileTriggerMinChangedLines tests the changed-lines threshold on file triggers
+func TestFileTriggerMinChangedLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     int
+		content string
+		changed int
+		want    bool
+	}{
+		{"no threshold", 0, "", 0, true},
+		{"content below threshold", 3, "one\ntwo", 0, false},
+		{"content meets threshold", 3, "one\ntwo\nthree", 0, true},
+		{"no content or count", 1, "", 0, false},
+		{"changed lines preferred over content", 5, "one", 6, true},
+		{"changed lines below threshold", 5, "1\n2\n3\n4\n5\n6", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					File: &schema.FileTrigger{MinChangedLines: tt.min},
+				},
+			}
+			event := &schema.Event{
+				File: &schema.FileEvent{
+					Path:         "src/main.go",
+					Action:       "edit",
+					Content:      tt.content,
+					ChangedLines: tt.changed,
+				},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestCommitTriggerNegationInPaths tests commit trigger handles negation patterns in paths
 func TestCommitTriggerNegationInPaths(t *testing.T) {
 	trigger := &schema.CommitTrigger{
@@ -1172,3 +1770,243 @@ func TestPushTriggerBranchesIgnoreWithNoMatchingBranch(t *testing.T) {
 		t.Error("Expected non-ignored branch to match")
 	}
 }
+
+func TestMatchScheduleTrigger(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			Schedule: &schema.ScheduleTrigger{Cron: "0 2 * * *"},
+		},
+	}
+	matcher := NewMatcher(workflow)
+
+	tests := []struct {
+		name  string
+		event *schema.Event
+		want  bool
+	}{
+		{"same cron", &schema.Event{Schedule: &schema.ScheduleEvent{Cron: "0 2 * * *"}}, true},
+		{"same cron extra spacing", &schema.Event{Schedule: &schema.ScheduleEvent{Cron: "0  2 * * *"}}, true},
+		{"different cron", &schema.Event{Schedule: &schema.ScheduleEvent{Cron: "0 3 * * *"}}, false},
+		{"schedule now", &schema.Event{Schedule: &schema.ScheduleEvent{}}, true},
+		{"non-schedule event", &schema.Event{Tool: &schema.ToolEvent{Name: "edit"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matcher.Match(tt.event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLifecycleWildcard(t *testing.T) {
+	tests := []struct {
+		name      string
+		trigger   string
+		lifecycle string
+		want      bool
+	}{
+		{"default matches pre", "", "pre", true},
+		{"default rejects post", "", "post", false},
+		{"wildcard matches pre", "*", "pre", true},
+		{"wildcard matches post", "*", "post", true},
+		{"wildcard matches custom", "*", "background", true},
+		{"any matches post", "any", "post", true},
+		{"any matches custom", "any", "background", true},
+		{"custom matches itself", "background", "background", true},
+		{"custom rejects post", "background", "post", false},
+		{"post rejects default event", "post", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					Tool:   &schema.ToolTrigger{Name: "edit", Lifecycle: tt.trigger},
+					File:   &schema.FileTrigger{Lifecycle: tt.trigger},
+					Commit: &schema.CommitTrigger{Lifecycle: tt.trigger},
+					Push:   &schema.PushTrigger{Lifecycle: tt.trigger},
+				},
+			}
+			matcher := NewMatcher(workflow)
+			toolsMatcher := NewMatcher(&schema.Workflow{
+				On: schema.OnConfig{Tools: []schema.ToolTrigger{{Name: "edit", Lifecycle: tt.trigger}}},
+			})
+
+			events := map[string]*schema.Event{
+				"tool":   {Tool: &schema.ToolEvent{Name: "edit"}, Lifecycle: tt.lifecycle},
+				"file":   {File: &schema.FileEvent{Path: "a.txt", Action: "edit"}, Lifecycle: tt.lifecycle},
+				"commit": {Commit: &schema.CommitEvent{Message: "msg"}, Lifecycle: tt.lifecycle},
+				"push":   {Push: &schema.PushEvent{Ref: "refs/heads/main"}, Lifecycle: tt.lifecycle},
+			}
+			for kind, event := range events {
+				if got := matcher.Match(event); got != tt.want {
+					t.Errorf("%s Match() = %v, want %v", kind, got, tt.want)
+				}
+			}
+			if got := toolsMatcher.Match(events["tool"]); got != tt.want {
+				t.Errorf("tools Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPushTriggerPaths tests paths and paths-ignore against the files in the push diff
+func TestPushTriggerPaths(t *testing.T) {
+	goFiles := []schema.FileStatus{{Path: "src/main.go", Status: "modified"}, {Path: "README.md", Status: "modified"}}
+	docsOnly := []schema.FileStatus{{Path: "docs/guide.md", Status: "added"}}
+
+	tests := []struct {
+		name        string
+		paths       []string
+		pathsIgnore []string
+		before      string
+		files       []schema.FileStatus
+		want        bool
+	}{
+		{"no filters", nil, nil, "abc", goFiles, true},
+		{"paths match", []string{"src/**"}, nil, "abc", goFiles, true},
+		{"paths miss", []string{"src/**"}, nil, "abc", docsOnly, false},
+		{"negation patterns are not includes", []string{"!src/**"}, nil, "abc", goFiles, false},
+		{"paths-ignore some files", nil, []string{"*.md"}, "abc", goFiles, true},
+		{"paths-ignore every file", nil, []string{"docs/**"}, "abc", docsOnly, false},
+		{"empty diff with paths", []string{"src/**"}, nil, "abc", nil, false},
+		{"unknown diff skips paths", []string{"src/**"}, nil, "", nil, true},
+		{"unknown diff skips paths-ignore", nil, []string{"docs/**"}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					Push: &schema.PushTrigger{Paths: tt.paths, PathsIgnore: tt.pathsIgnore},
+				},
+			}
+			event := &schema.Event{
+				Push: &schema.PushEvent{Ref: "refs/heads/main", Before: tt.before, After: "def", Files: tt.files},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			File: &schema.FileTrigger{
+				Paths:       []string{"src/**/*.go", "!src/vendor/**"},
+				PathsIgnore: []string{"!literal"},
+			},
+			Push: &schema.PushTrigger{
+				Branches:   []string{"release/**", "!release/old/**"},
+				TagsIgnore: []string{"v0.*"},
+			},
+		},
+	}
+	c := Compile(workflow)
+
+	if len(c.filePaths) != 2 || c.filePaths[0].negate || !c.filePaths[1].negate {
+		t.Fatalf("Expected the second path to be negated, got %+v", c.filePaths)
+	}
+	if g := c.filePaths[0]; !g.double || g.leading || g.prefix != "src" || g.suffix != "*.go" {
+		t.Errorf("Expected src/**/*.go to split into src and *.go, got %+v", g)
+	}
+	// Ignore lists do not support negation, so "!" stays part of the pattern
+	if g := c.filePathsIgnore[0]; g.negate || g.pattern != "!literal" {
+		t.Errorf("Expected a literal !literal ignore pattern, got %+v", g)
+	}
+	if r := c.pushBranches[1]; !r.negate || strings.Join(r.segments, "|") != "release|old|**" {
+		t.Errorf("Expected a negated release/old/** pattern, got %+v", r)
+	}
+	if len(c.commitPaths) != 0 || c.commitBranch != nil {
+		t.Errorf("Expected nothing compiled for the missing commit trigger, got %+v", c)
+	}
+}
+
+func TestMatcherReusesCompiledPatterns(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			File: &schema.FileTrigger{Paths: []string{"src/**/*.go", "!src/vendor/**"}},
+			Push: &schema.PushTrigger{Branches: []string{"release/**", "!release/old/**"}},
+		},
+	}
+	events := []struct {
+		event *schema.Event
+		want  bool
+	}{
+		{&schema.Event{File: &schema.FileEvent{Path: "src/pkg/main.go", Action: "edit"}}, true},
+		{&schema.Event{File: &schema.FileEvent{Path: "src/vendor/lib/x.go", Action: "edit"}}, false},
+		{&schema.Event{File: &schema.FileEvent{Path: "docs/readme.md", Action: "edit"}}, false},
+		{&schema.Event{Push: &schema.PushEvent{Ref: "refs/heads/release/1.0"}}, true},
+		{&schema.Event{Push: &schema.PushEvent{Ref: "refs/heads/release/old/0.9"}}, false},
+	}
+
+	// One matcher gives the same answers for every event, including from
+	// several goroutines at once
+	m := NewMatcher(workflow)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, tt := range events {
+				if got := m.Match(tt.event); got != tt.want {
+					t.Errorf("Match(%+v) = %v, want %v", tt.event, got, tt.want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMatcherFor(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{File: &schema.FileTrigger{Paths: []string{"**/*.go"}}},
+	}
+
+	m := MatcherFor(workflow)
+	if again := MatcherFor(workflow); again != m {
+		t.Error("Expected the cached matcher to be reused")
+	}
+	if !m.Match(&schema.Event{File: &schema.FileEvent{Path: "cmd/main.go", Action: "edit"}}) {
+		t.Error("Expected the cached matcher to match")
+	}
+
+	// A copy has its own triggers, so it must not reuse the original's matcher
+	copied := *workflow
+	copied.On.File = &schema.FileTrigger{Paths: []string{"**/*.md"}}
+	if MatcherFor(&copied) == m {
+		t.Error("Expected a copied workflow to get its own matcher")
+	}
+	if !MatcherFor(&copied).Match(&schema.Event{File: &schema.FileEvent{Path: "README.md", Action: "edit"}}) {
+		t.Error("Expected the copy's matcher to use the copy's triggers")
+	}
+}
+
+func BenchmarkMatchCommitFiles(b *testing.B) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			Commit: &schema.CommitTrigger{
+				Paths:       []string{"src/**/*.go", "cmd/**/*.go", "internal/**/testdata/**"},
+				PathsIgnore: []string{"**/*_test.go", "docs/**"},
+			},
+		},
+	}
+	files := make([]schema.FileStatus, 200)
+	for i := range files {
+		files[i] = schema.FileStatus{Path: fmt.Sprintf("docs/section%d/page.md", i), Status: "modified"}
+	}
+	files[len(files)-1].Path = "src/pkg/main.go"
+	event := &schema.Event{Commit: &schema.CommitEvent{Files: files}}
+
+	m := NewMatcher(workflow)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !m.Match(event) {
+			b.Fatal("Expected the commit to match")
+		}
+	}
+}